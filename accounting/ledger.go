@@ -0,0 +1,326 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+// Package accounting keeps a ledger of what the validator spends and recovers in
+// each challenge: the gas of every transaction it makes, the mini-stakes it locks
+// in edges and gets refunded, and the bonds it posts on assertions. Entries are
+// attributed to the challenged assertion, challenge level and edge they were made
+// for, and can be persisted so that the costs of past disputes survive restarts.
+package accounting
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"os"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/pkg/errors"
+)
+
+var (
+	srvlog               = log.New("service", "accounting")
+	recordedTxsCounter   = metrics.NewRegisteredCounter("arb/validator/accounting/transactions", nil)
+	gasUsedCounter       = metrics.NewRegisteredCounter("arb/validator/accounting/gas_used", nil)
+	stakesLockedCounter  = metrics.NewRegisteredCounter("arb/validator/accounting/mini_stakes_locked", nil)
+	stakesRefundCounter  = metrics.NewRegisteredCounter("arb/validator/accounting/mini_stakes_refunded", nil)
+	bondsPostedCounter   = metrics.NewRegisteredCounter("arb/validator/accounting/bonds_posted", nil)
+	persistErrorsCounter = metrics.NewRegisteredCounter("arb/validator/accounting/persist_errors", nil)
+)
+
+func init() {
+	srvlog.SetHandler(log.StreamHandler(os.Stdout, log.LogfmtFormat()))
+}
+
+// Kind of a ledger entry.
+type Kind string
+
+const (
+	// Gas paid for a transaction, in wei.
+	GasSpent Kind = "gas_spent"
+	// Mini-stake locked by creating a level zero edge, in units of the stake token.
+	MiniStakeLocked Kind = "mini_stake_locked"
+	// Mini-stake refunded for an edge we locked it in, in units of the stake token.
+	MiniStakeRefunded Kind = "mini_stake_refunded"
+	// Bond posted by staking on a new assertion, in units of the stake token.
+	BondPosted Kind = "bond_posted"
+)
+
+// Entry is a single cost or recovery in a challenge.
+type Entry struct {
+	Kind Kind `json:"kind"`
+	// The challenged assertion, under which the challenge is namespaced. Bonds are
+	// attributed to the parent of the assertion staked on, which its rivals, if any,
+	// challenge.
+	AssertionHash  common.Hash `json:"assertionHash"`
+	ChallengeLevel uint8       `json:"challengeLevel"`
+	// The edge acted on, if any.
+	EdgeId      common.Hash `json:"edgeId"`
+	Method      string      `json:"method,omitempty"`
+	TxHash      common.Hash `json:"txHash"`
+	BlockNumber uint64      `json:"blockNumber"`
+	GasUsed     uint64      `json:"gasUsed,omitempty"`
+	Amount      *big.Int    `json:"amount"`
+}
+
+// ChallengeCosts are the totals of the entries of a challenge.
+type ChallengeCosts struct {
+	AssertionHash      common.Hash `json:"assertionHash"`
+	Transactions       uint64      `json:"transactions"`
+	GasUsed            uint64      `json:"gasUsed"`
+	GasCost            *big.Int    `json:"gasCost"`
+	MiniStakesLocked   *big.Int    `json:"miniStakesLocked"`
+	MiniStakesRefunded *big.Int    `json:"miniStakesRefunded"`
+	BondsPosted        *big.Int    `json:"bondsPosted"`
+	Entries            []*Entry    `json:"entries,omitempty"`
+}
+
+func newChallengeCosts(assertionHash common.Hash) *ChallengeCosts {
+	return &ChallengeCosts{
+		AssertionHash:      assertionHash,
+		GasCost:            new(big.Int),
+		MiniStakesLocked:   new(big.Int),
+		MiniStakesRefunded: new(big.Int),
+		BondsPosted:        new(big.Int),
+	}
+}
+
+func (c *ChallengeCosts) add(e *Entry) {
+	switch e.Kind {
+	case GasSpent:
+		c.Transactions++
+		c.GasUsed += e.GasUsed
+		c.GasCost.Add(c.GasCost, e.Amount)
+	case MiniStakeLocked:
+		c.MiniStakesLocked.Add(c.MiniStakesLocked, e.Amount)
+	case MiniStakeRefunded:
+		c.MiniStakesRefunded.Add(c.MiniStakesRefunded, e.Amount)
+	case BondPosted:
+		c.BondsPosted.Add(c.BondsPosted, e.Amount)
+	}
+}
+
+func (c *ChallengeCosts) copy(withEntries bool) *ChallengeCosts {
+	copied := &ChallengeCosts{
+		AssertionHash:      c.AssertionHash,
+		Transactions:       c.Transactions,
+		GasUsed:            c.GasUsed,
+		GasCost:            new(big.Int).Set(c.GasCost),
+		MiniStakesLocked:   new(big.Int).Set(c.MiniStakesLocked),
+		MiniStakesRefunded: new(big.Int).Set(c.MiniStakesRefunded),
+		BondsPosted:        new(big.Int).Set(c.BondsPosted),
+	}
+	if withEntries {
+		copied.Entries = make([]*Entry, len(c.Entries))
+		copy(copied.Entries, c.Entries)
+	}
+	return copied
+}
+
+// Identifies an entry, so that the same movement observed twice, such as when
+// rescanning events, is only recorded once.
+type entryKey struct {
+	kind   Kind
+	txHash common.Hash
+	edgeId common.Hash
+}
+
+type Opt func(l *Ledger)
+
+// WithFile persists the ledger to a file at the given path, one JSON entry per line.
+// Entries already in the file are loaded when the ledger is created.
+func WithFile(path string) Opt {
+	return func(l *Ledger) {
+		l.path = path
+	}
+}
+
+// Ledger records the costs and recoveries of challenges. It is safe for concurrent use.
+type Ledger struct {
+	lock       sync.RWMutex
+	path       string
+	challenges map[common.Hash]*ChallengeCosts
+	// Challenges in the order their first entry was recorded.
+	order []common.Hash
+	seen  map[entryKey]bool
+	// Mini-stakes locked by edge id, which refunds are matched against.
+	lockedStakes map[common.Hash]*Entry
+}
+
+// NewLedger creates a ledger, loading the entries persisted by a previous run if
+// it is backed by a file.
+func NewLedger(opts ...Opt) (*Ledger, error) {
+	l := &Ledger{
+		challenges:   make(map[common.Hash]*ChallengeCosts),
+		seen:         make(map[entryKey]bool),
+		lockedStakes: make(map[common.Hash]*Entry),
+	}
+	for _, o := range opts {
+		o(l)
+	}
+	if l.path == "" {
+		return l, nil
+	}
+	data, err := os.ReadFile(l.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return l, nil
+		}
+		return nil, err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for scanner.Scan() {
+		e := &Entry{}
+		if err := json.Unmarshal(scanner.Bytes(), e); err != nil {
+			return nil, errors.Wrap(err, "could not decode ledger entry")
+		}
+		l.addLocked(e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Record an entry in the ledger, persisting it if the ledger is backed by a file.
+// Entries already recorded are ignored. The entry is kept in memory even if it could
+// not be persisted, as the movement it records happened regardless.
+func (l *Ledger) Record(e *Entry) {
+	if e.Amount == nil {
+		e.Amount = new(big.Int)
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if !l.addLocked(e) {
+		return
+	}
+	switch e.Kind {
+	case GasSpent:
+		recordedTxsCounter.Inc(1)
+		gasUsedCounter.Inc(int64(e.GasUsed))
+	case MiniStakeLocked:
+		stakesLockedCounter.Inc(1)
+	case MiniStakeRefunded:
+		stakesRefundCounter.Inc(1)
+	case BondPosted:
+		bondsPostedCounter.Inc(1)
+	}
+	if err := l.persistLocked(e); err != nil {
+		persistErrorsCounter.Inc(1)
+		srvlog.Error("Could not persist ledger entry", log.Ctx{
+			"kind":          e.Kind,
+			"assertionHash": e.AssertionHash,
+			"txHash":        e.TxHash,
+			"err":           err,
+		})
+	}
+}
+
+// RecordRefund records the refund of the mini-stake of an edge, attributed to the
+// challenge the stake was locked in. Refunds of edges we did not lock a stake in
+// are ignored.
+func (l *Ledger) RecordRefund(edgeId common.Hash, amount *big.Int, txHash common.Hash, blockNumber uint64) {
+	l.lock.RLock()
+	locked, ok := l.lockedStakes[edgeId]
+	l.lock.RUnlock()
+	if !ok {
+		return
+	}
+	l.Record(&Entry{
+		Kind:           MiniStakeRefunded,
+		AssertionHash:  locked.AssertionHash,
+		ChallengeLevel: locked.ChallengeLevel,
+		EdgeId:         edgeId,
+		TxHash:         txHash,
+		BlockNumber:    blockNumber,
+		Amount:         amount,
+	})
+}
+
+// ChallengeCosts returns the totals of every challenge with entries, in the order
+// they were first recorded, without their entries.
+func (l *Ledger) ChallengeCosts() []*ChallengeCosts {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	costs := make([]*ChallengeCosts, 0, len(l.order))
+	for _, assertionHash := range l.order {
+		costs = append(costs, l.challenges[assertionHash].copy(false))
+	}
+	return costs
+}
+
+// ChallengeCost returns the totals and entries of the challenge of an assertion.
+func (l *Ledger) ChallengeCost(assertionHash common.Hash) (*ChallengeCosts, bool) {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	c, ok := l.challenges[assertionHash]
+	if !ok {
+		return nil, false
+	}
+	return c.copy(true), true
+}
+
+// Totals returns the totals across all challenges.
+func (l *Ledger) Totals() *ChallengeCosts {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	totals := newChallengeCosts(common.Hash{})
+	for _, c := range l.challenges {
+		totals.Transactions += c.Transactions
+		totals.GasUsed += c.GasUsed
+		totals.GasCost.Add(totals.GasCost, c.GasCost)
+		totals.MiniStakesLocked.Add(totals.MiniStakesLocked, c.MiniStakesLocked)
+		totals.MiniStakesRefunded.Add(totals.MiniStakesRefunded, c.MiniStakesRefunded)
+		totals.BondsPosted.Add(totals.BondsPosted, c.BondsPosted)
+	}
+	return totals
+}
+
+// Adds an entry to the totals of its challenge, returning false if it was already recorded.
+func (l *Ledger) addLocked(e *Entry) bool {
+	key := entryKey{kind: e.Kind, txHash: e.TxHash, edgeId: e.EdgeId}
+	if l.seen[key] {
+		return false
+	}
+	l.seen[key] = true
+	c, ok := l.challenges[e.AssertionHash]
+	if !ok {
+		c = newChallengeCosts(e.AssertionHash)
+		l.challenges[e.AssertionHash] = c
+		l.order = append(l.order, e.AssertionHash)
+	}
+	c.add(e)
+	c.Entries = append(c.Entries, e)
+	if e.Kind == MiniStakeLocked {
+		l.lockedStakes[e.EdgeId] = e
+	}
+	return true
+}
+
+func (l *Ledger) persistLocked(e *Entry) error {
+	if l.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(append(data, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,72 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package accounting
+
+import (
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLedger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger.jsonl")
+	ledger, err := NewLedger(WithFile(path))
+	require.NoError(t, err)
+
+	challenge := common.BytesToHash([]byte("challenge"))
+	other := common.BytesToHash([]byte("other"))
+	edgeId := common.BytesToHash([]byte("edge"))
+	createTx := common.BytesToHash([]byte("create"))
+	ledger.Record(&Entry{Kind: GasSpent, AssertionHash: challenge, ChallengeLevel: 1, EdgeId: edgeId, TxHash: createTx, GasUsed: 10, Amount: big.NewInt(100)})
+	ledger.Record(&Entry{Kind: MiniStakeLocked, AssertionHash: challenge, ChallengeLevel: 1, EdgeId: edgeId, TxHash: createTx, Amount: big.NewInt(7)})
+	ledger.Record(&Entry{Kind: GasSpent, AssertionHash: other, TxHash: common.BytesToHash([]byte("stake")), GasUsed: 20, Amount: big.NewInt(200)})
+	ledger.Record(&Entry{Kind: BondPosted, AssertionHash: other, TxHash: common.BytesToHash([]byte("stake")), Amount: big.NewInt(1000)})
+
+	// The same transaction observed again is only recorded once.
+	ledger.Record(&Entry{Kind: GasSpent, AssertionHash: challenge, ChallengeLevel: 1, EdgeId: edgeId, TxHash: createTx, GasUsed: 10, Amount: big.NewInt(100)})
+
+	// Refunds are attributed to the challenge the stake was locked in, and refunds of
+	// stakes we did not lock are ignored.
+	refundTx := common.BytesToHash([]byte("refund"))
+	ledger.RecordRefund(edgeId, big.NewInt(7), refundTx, 50)
+	ledger.RecordRefund(edgeId, big.NewInt(7), refundTx, 50)
+	ledger.RecordRefund(common.BytesToHash([]byte("rival")), big.NewInt(7), refundTx, 50)
+
+	costs := ledger.ChallengeCosts()
+	require.Len(t, costs, 2)
+	require.Equal(t, challenge, costs[0].AssertionHash)
+	require.Equal(t, other, costs[1].AssertionHash)
+	require.Equal(t, uint64(1), costs[0].Transactions)
+	require.Equal(t, int64(100), costs[0].GasCost.Int64())
+	require.Equal(t, int64(7), costs[0].MiniStakesLocked.Int64())
+	require.Equal(t, int64(7), costs[0].MiniStakesRefunded.Int64())
+	require.Empty(t, costs[0].Entries)
+
+	chal, ok := ledger.ChallengeCost(challenge)
+	require.True(t, ok)
+	require.Len(t, chal.Entries, 3)
+	require.Equal(t, uint8(1), chal.Entries[2].ChallengeLevel)
+	require.Equal(t, uint64(50), chal.Entries[2].BlockNumber)
+	_, ok = ledger.ChallengeCost(common.BytesToHash([]byte("unknown")))
+	require.False(t, ok)
+
+	totals := ledger.Totals()
+	require.Equal(t, uint64(2), totals.Transactions)
+	require.Equal(t, uint64(30), totals.GasUsed)
+	require.Equal(t, int64(300), totals.GasCost.Int64())
+	require.Equal(t, int64(1000), totals.BondsPosted.Int64())
+
+	// Entries are loaded from the file on restart.
+	reloaded, err := NewLedger(WithFile(path))
+	require.NoError(t, err)
+	require.Equal(t, ledger.ChallengeCosts(), reloaded.ChallengeCosts())
+	require.Equal(t, totals, reloaded.Totals())
+	reloaded.RecordRefund(edgeId, big.NewInt(7), refundTx, 50)
+	chal, ok = reloaded.ChallengeCost(challenge)
+	require.True(t, ok)
+	require.Len(t, chal.Entries, 3)
+}
@@ -0,0 +1,253 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+// Package alerts defines a typed alerting subsystem for BOLD validators. Components of
+// the challenge manager raise events, such as an invalid assertion being observed or an
+// evil edge being confirmed, and a dispatcher deduplicates and rate limits them before
+// delivering them to a set of pluggable notification sinks. Delivery happens in the
+// background, so raising an event never waits on a slow sink.
+package alerts
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	utilTime "github.com/OffchainLabs/bold/time"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	srvlog              = log.New("service", "alerts")
+	sentCounter         = metrics.NewRegisteredCounter("arb/validator/alerts/sent", nil)
+	deduplicatedCounter = metrics.NewRegisteredCounter("arb/validator/alerts/deduplicated", nil)
+	rateLimitedCounter  = metrics.NewRegisteredCounter("arb/validator/alerts/rate_limited", nil)
+	sinkErrorCounter    = metrics.NewRegisteredCounter("arb/validator/alerts/sink_errors", nil)
+	droppedCounter      = metrics.NewRegisteredCounter("arb/validator/alerts/dropped", nil)
+)
+
+const defaultQueueSize = 100
+
+func init() {
+	srvlog.SetHandler(log.StreamHandler(os.Stdout, log.LogfmtFormat()))
+}
+
+// Sink delivers alert events to an external destination.
+type Sink interface {
+	Name() string
+	Send(ctx context.Context, ev *Event) error
+}
+
+type Opt func(d *Dispatcher)
+
+// WithSink adds a notification sink to the dispatcher.
+func WithSink(s Sink) Opt {
+	return func(d *Dispatcher) {
+		d.sinks = append(d.sinks, s)
+	}
+}
+
+// WithDedupWindow sets the duration during which events with the same kind and key
+// are only delivered once. The default is ten minutes.
+func WithDedupWindow(window time.Duration) Opt {
+	return func(d *Dispatcher) {
+		d.dedupWindow = window
+	}
+}
+
+// WithRateLimit sets the maximum number of events of a single kind delivered within
+// an interval. The default is ten events per minute.
+func WithRateLimit(maxEvents uint64, interval time.Duration) Opt {
+	return func(d *Dispatcher) {
+		d.rateLimit = maxEvents
+		d.rateInterval = interval
+	}
+}
+
+// WithQueueSize sets the number of events waiting for delivery to the sinks beyond
+// which new events are dropped. The default is 100.
+func WithQueueSize(size int) Opt {
+	return func(d *Dispatcher) {
+		d.queueSize = size
+	}
+}
+
+// WithValidatorName tags every event with the name of the running validator.
+func WithValidatorName(name string) Opt {
+	return func(d *Dispatcher) {
+		d.validatorName = name
+	}
+}
+
+// WithTimeReference allows setting the time reference used for deduplication and rate
+// limiting. This is useful for testing with a fake time reference.
+func WithTimeReference(ref utilTime.Reference) Opt {
+	return func(d *Dispatcher) {
+		d.timeRef = ref
+	}
+}
+
+type rateWindow struct {
+	start time.Time
+	count uint64
+}
+
+// Dispatcher deduplicates and rate limits alert events before delivering them to
+// its sinks. A nil dispatcher is valid and drops every event, so components can hold
+// an optional dispatcher without checking for it at every call site.
+type Dispatcher struct {
+	sinks         []Sink
+	dedupWindow   time.Duration
+	rateLimit     uint64
+	rateInterval  time.Duration
+	validatorName string
+	timeRef       utilTime.Reference
+	queueSize     int
+	queue         chan queuedEvent
+
+	lock        sync.Mutex
+	lastSent    map[string]time.Time
+	sentOrder   []sentEvent
+	rateWindows map[Kind]*rateWindow
+}
+
+// An event delivered at a time, kept in the order of delivery so that dedup entries
+// can be expired from the oldest without scanning all of them.
+type sentEvent struct {
+	key string
+	at  time.Time
+}
+
+// An event waiting for delivery, or a request to be told once all events queued before
+// it were delivered.
+type queuedEvent struct {
+	ev      *Event
+	flushed chan struct{}
+}
+
+// NewDispatcher creates an alert dispatcher from the given options, and starts
+// delivering the events it is notified of to its sinks.
+func NewDispatcher(opts ...Opt) *Dispatcher {
+	d := &Dispatcher{
+		sinks:        make([]Sink, 0),
+		dedupWindow:  time.Minute * 10,
+		rateLimit:    10,
+		rateInterval: time.Minute,
+		timeRef:      utilTime.NewRealTimeReference(),
+		lastSent:     make(map[string]time.Time),
+		rateWindows:  make(map[Kind]*rateWindow),
+		queueSize:    defaultQueueSize,
+	}
+	for _, o := range opts {
+		o(d)
+	}
+	d.queue = make(chan queuedEvent, d.queueSize)
+	go d.deliver()
+	return d
+}
+
+// Notify queues an event for delivery to all sinks unless it is a duplicate of an event
+// sent within the dedup window or its kind exceeded the rate limit. Events are dropped
+// if the queue is full, and sink errors are logged rather than returned, as alerting
+// should never interrupt the caller's protocol duties.
+func (d *Dispatcher) Notify(_ context.Context, ev *Event) {
+	if d == nil || ev == nil {
+		return
+	}
+	now := d.timeRef.Get()
+	if !d.shouldSend(ev, now) {
+		return
+	}
+	if ev.Timestamp.IsZero() {
+		ev.Timestamp = now
+	}
+	if ev.Validator == "" {
+		ev.Validator = d.validatorName
+	}
+	select {
+	case d.queue <- queuedEvent{ev: ev}:
+	default:
+		droppedCounter.Inc(1)
+		srvlog.Warn("Alert queue full, dropping alert", log.Ctx{
+			"kind": ev.Kind,
+			"key":  ev.Key,
+		})
+	}
+}
+
+// Flush waits until all events queued so far were delivered to the sinks.
+func (d *Dispatcher) Flush() {
+	if d == nil {
+		return
+	}
+	flushed := make(chan struct{})
+	d.queue <- queuedEvent{flushed: flushed}
+	<-flushed
+}
+
+// Delivers queued events one at a time for the lifetime of the dispatcher. Events are
+// sent with a context of their own, as the caller which raised one may be long gone.
+func (d *Dispatcher) deliver() {
+	for q := range d.queue {
+		if q.flushed != nil {
+			close(q.flushed)
+			continue
+		}
+		sentCounter.Inc(1)
+		for _, s := range d.sinks {
+			if err := s.Send(context.Background(), q.ev); err != nil {
+				sinkErrorCounter.Inc(1)
+				srvlog.Error("Could not send alert", log.Ctx{
+					"sink": s.Name(),
+					"kind": q.ev.Kind,
+					"key":  q.ev.Key,
+					"err":  err,
+				})
+			}
+		}
+	}
+}
+
+func (d *Dispatcher) shouldSend(ev *Event, now time.Time) bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	key := ev.dedupKey()
+	if last, ok := d.lastSent[key]; ok && now.Sub(last) < d.dedupWindow {
+		deduplicatedCounter.Inc(1)
+		return false
+	}
+	window, ok := d.rateWindows[ev.Kind]
+	if !ok || now.Sub(window.start) >= d.rateInterval {
+		window = &rateWindow{start: now}
+		d.rateWindows[ev.Kind] = window
+	}
+	if window.count >= d.rateLimit {
+		rateLimitedCounter.Inc(1)
+		return false
+	}
+	window.count++
+	d.pruneLocked(now)
+	d.lastSent[key] = now
+	d.sentOrder = append(d.sentOrder, sentEvent{key: key, at: now})
+	return true
+}
+
+// Removes dedup entries that have fallen out of the dedup window so the map does
+// not grow without bound over the lifetime of the process. Events are delivered in
+// time order, so only the oldest entries need to be checked.
+func (d *Dispatcher) pruneLocked(now time.Time) {
+	expired := 0
+	for _, sent := range d.sentOrder {
+		if now.Sub(sent.at) < d.dedupWindow {
+			break
+		}
+		// The key may have been delivered again since, in which case it is still live.
+		if last, ok := d.lastSent[sent.key]; ok && last.Equal(sent.at) {
+			delete(d.lastSent, sent.key)
+		}
+		expired++
+	}
+	d.sentOrder = d.sentOrder[expired:]
+}
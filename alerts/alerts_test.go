@@ -0,0 +1,167 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package alerts
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	utilTime "github.com/OffchainLabs/bold/time"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingSink struct {
+	events []*Event
+	err    error
+}
+
+func (s *recordingSink) Name() string {
+	return "recording"
+}
+
+func (s *recordingSink) Send(_ context.Context, ev *Event) error {
+	s.events = append(s.events, ev)
+	return s.err
+}
+
+func TestDispatcher_Deduplication(t *testing.T) {
+	ctx := context.Background()
+	timeRef := utilTime.NewArtificialTimeReference()
+	sink := &recordingSink{}
+	d := NewDispatcher(
+		WithSink(sink),
+		WithDedupWindow(time.Minute),
+		WithTimeReference(timeRef),
+		WithValidatorName("alice"),
+	)
+	edgeId := common.BytesToHash([]byte("foo"))
+
+	d.Notify(ctx, NewStakeAtRiskEvent(edgeId, common.Hash{}, "block"))
+	d.Notify(ctx, NewStakeAtRiskEvent(edgeId, common.Hash{}, "block"))
+	d.Flush()
+	require.Equal(t, 1, len(sink.events))
+	require.Equal(t, "alice", sink.events[0].Validator)
+	require.Equal(t, timeRef.Get(), sink.events[0].Timestamp)
+
+	// A different kind with the same key is not a duplicate.
+	d.Notify(ctx, NewEvilEdgeConfirmedEvent(edgeId, common.Hash{}, "block"))
+	d.Flush()
+	require.Equal(t, 2, len(sink.events))
+
+	// Once the dedup window passes, the event is sent again.
+	timeRef.Add(time.Minute)
+	d.Notify(ctx, NewStakeAtRiskEvent(edgeId, common.Hash{}, "block"))
+	d.Flush()
+	require.Equal(t, 3, len(sink.events))
+}
+
+func TestDispatcher_RateLimit(t *testing.T) {
+	ctx := context.Background()
+	timeRef := utilTime.NewArtificialTimeReference()
+	sink := &recordingSink{}
+	d := NewDispatcher(
+		WithSink(sink),
+		WithRateLimit(2, time.Minute),
+		WithTimeReference(timeRef),
+	)
+	for i := 0; i < 5; i++ {
+		d.Notify(ctx, NewInvalidAssertionEvent(common.BytesToHash([]byte{byte(i)}), common.Hash{}, 1))
+	}
+	d.Flush()
+	require.Equal(t, 2, len(sink.events))
+
+	// Other kinds have their own budget.
+	d.Notify(ctx, NewChallengeOpenedEvent(common.Hash{}, common.Hash{}))
+	d.Flush()
+	require.Equal(t, 3, len(sink.events))
+
+	timeRef.Add(time.Minute)
+	d.Notify(ctx, NewInvalidAssertionEvent(common.BytesToHash([]byte{9}), common.Hash{}, 1))
+	d.Flush()
+	require.Equal(t, 4, len(sink.events))
+}
+
+func TestDispatcher_SinkErrorsDoNotStopDelivery(t *testing.T) {
+	failing := &recordingSink{err: errors.New("bad")}
+	ok := &recordingSink{}
+	d := NewDispatcher(WithSink(failing), WithSink(ok))
+	d.Notify(context.Background(), NewChallengeOpenedEvent(common.Hash{}, common.Hash{}))
+	d.Flush()
+	require.Equal(t, 1, len(failing.events))
+	require.Equal(t, 1, len(ok.events))
+}
+
+func TestDispatcher_NilIsNoop(t *testing.T) {
+	var d *Dispatcher
+	d.Notify(context.Background(), NewChallengeOpenedEvent(common.Hash{}, common.Hash{}))
+	d.Flush()
+}
+
+// A sink which blocks until released, like a webhook whose endpoint does not respond.
+type blockingSink struct {
+	recordingSink
+	release chan struct{}
+}
+
+func (s *blockingSink) Send(ctx context.Context, ev *Event) error {
+	<-s.release
+	return s.recordingSink.Send(ctx, ev)
+}
+
+func TestDispatcher_DoesNotWaitOnSinks(t *testing.T) {
+	ctx := context.Background()
+	sink := &blockingSink{release: make(chan struct{})}
+	d := NewDispatcher(WithSink(sink), WithQueueSize(1), WithRateLimit(100, time.Minute))
+
+	// The first event is being delivered and the second one is queued, so the third
+	// one is dropped rather than blocking the caller.
+	for i := 0; i < 3; i++ {
+		d.Notify(ctx, NewStakeAtRiskEvent(common.BytesToHash([]byte{byte(i)}), common.Hash{}, "block"))
+		if i == 0 {
+			require.Eventually(t, func() bool { return len(d.queue) == 0 }, time.Second, time.Millisecond)
+		}
+	}
+	require.Len(t, d.queue, 1)
+
+	close(sink.release)
+	d.Flush()
+	require.Equal(t, 2, len(sink.events))
+	require.Equal(t, NewStakeAtRiskEvent(common.BytesToHash([]byte{1}), common.Hash{}, "block").Key, sink.events[1].Key)
+}
+
+func TestDispatcher_ExpiresDedupEntries(t *testing.T) {
+	ctx := context.Background()
+	timeRef := utilTime.NewArtificialTimeReference()
+	d := NewDispatcher(
+		WithSink(&recordingSink{}),
+		WithDedupWindow(time.Minute),
+		WithRateLimit(100, time.Minute),
+		WithTimeReference(timeRef),
+	)
+	first := common.BytesToHash([]byte("first"))
+	second := common.BytesToHash([]byte("second"))
+	d.Notify(ctx, NewStakeAtRiskEvent(first, common.Hash{}, "block"))
+	timeRef.Add(time.Second * 30)
+	d.Notify(ctx, NewStakeAtRiskEvent(second, common.Hash{}, "block"))
+	require.Len(t, d.lastSent, 2)
+
+	// Only the entry that fell out of the window is removed.
+	timeRef.Add(time.Second * 30)
+	d.Notify(ctx, NewStakeAtRiskEvent(common.BytesToHash([]byte("third")), common.Hash{}, "block"))
+	require.Len(t, d.lastSent, 2)
+	require.Len(t, d.sentOrder, 2)
+	_, ok := d.lastSent[NewStakeAtRiskEvent(first, common.Hash{}, "block").dedupKey()]
+	require.False(t, ok)
+
+	// An entry delivered again is kept until its latest delivery expires.
+	timeRef.Add(time.Second * 30)
+	d.Notify(ctx, NewStakeAtRiskEvent(second, common.Hash{}, "block"))
+	timeRef.Add(time.Second * 45)
+	d.Notify(ctx, NewStakeAtRiskEvent(first, common.Hash{}, "block"))
+	_, ok = d.lastSent[NewStakeAtRiskEvent(second, common.Hash{}, "block").dedupKey()]
+	require.True(t, ok)
+}
@@ -0,0 +1,158 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package alerts
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Kind of an alert event.
+type Kind string
+
+const (
+	// InvalidAssertion is raised when an assertion we disagree with is observed onchain.
+	InvalidAssertion Kind = "invalid_assertion"
+	// ChallengeOpened is raised when a new challenge is observed for an assertion.
+	ChallengeOpened Kind = "challenge_opened"
+	// HonestTimerNearExpiry is raised when the cumulative path timer of an honest edge
+	// is close to the challenge period, meaning the edge must be confirmed soon.
+	HonestTimerNearExpiry Kind = "honest_timer_near_expiry"
+	// EvilEdgeConfirmed is raised when an edge we do not agree with is confirmed onchain.
+	EvilEdgeConfirmed Kind = "evil_edge_confirmed"
+	// StakeAtRisk is raised when one of our honest edges has a confirmed rival, meaning
+	// the stake on it can no longer be recovered by winning the challenge.
+	StakeAtRisk Kind = "stake_at_risk"
+	// LowBalance is raised when the staker's balance drops below a configured threshold.
+	LowBalance Kind = "low_balance"
+)
+
+// Severity of an alert event.
+type Severity string
+
+const (
+	Info     Severity = "info"
+	Warning  Severity = "warning"
+	Critical Severity = "critical"
+)
+
+// Event is a single alert delivered to every configured sink. The key identifies
+// the subject of the event, such as an assertion hash or an edge id, and is used
+// together with the kind to deduplicate repeated notifications.
+type Event struct {
+	Kind      Kind              `json:"kind"`
+	Severity  Severity          `json:"severity"`
+	Key       string            `json:"key"`
+	Message   string            `json:"message"`
+	Validator string            `json:"validator,omitempty"`
+	Fields    map[string]string `json:"fields,omitempty"`
+	Timestamp time.Time         `json:"timestamp"`
+}
+
+func (e *Event) dedupKey() string {
+	return string(e.Kind) + "/" + e.Key
+}
+
+// NewInvalidAssertionEvent for an assertion whose claimed execution state we disagree with.
+func NewInvalidAssertionEvent(assertionHash, parentAssertionHash common.Hash, batchCount uint64) *Event {
+	return &Event{
+		Kind:     InvalidAssertion,
+		Severity: Critical,
+		Key:      assertionHash.Hex(),
+		Message:  fmt.Sprintf("Detected invalid assertion %#x", assertionHash),
+		Fields: map[string]string{
+			"assertionHash":       assertionHash.Hex(),
+			"parentAssertionHash": parentAssertionHash.Hex(),
+			"batchCount":          fmt.Sprintf("%d", batchCount),
+		},
+	}
+}
+
+// NewChallengeOpenedEvent for a challenge on the children of the given assertion.
+func NewChallengeOpenedEvent(challengedAssertionHash, edgeId common.Hash) *Event {
+	return &Event{
+		Kind:     ChallengeOpened,
+		Severity: Warning,
+		Key:      challengedAssertionHash.Hex(),
+		Message:  fmt.Sprintf("Challenge opened on assertion %#x", challengedAssertionHash),
+		Fields: map[string]string{
+			"challengedAssertionHash": challengedAssertionHash.Hex(),
+			"edgeId":                  edgeId.Hex(),
+		},
+	}
+}
+
+// NewHonestTimerNearExpiryEvent for an honest edge whose path timer is close to the challenge period.
+func NewHonestTimerNearExpiryEvent(
+	edgeId, assertionHash common.Hash,
+	challengeLevel string,
+	pathTimer, challengePeriodBlocks uint64,
+) *Event {
+	return &Event{
+		Kind:     HonestTimerNearExpiry,
+		Severity: Warning,
+		Key:      edgeId.Hex(),
+		Message: fmt.Sprintf(
+			"Honest edge %#x has path timer %d of %d challenge period blocks",
+			edgeId,
+			pathTimer,
+			challengePeriodBlocks,
+		),
+		Fields: map[string]string{
+			"edgeId":                edgeId.Hex(),
+			"assertionHash":         assertionHash.Hex(),
+			"challengeLevel":        challengeLevel,
+			"pathTimer":             fmt.Sprintf("%d", pathTimer),
+			"challengePeriodBlocks": fmt.Sprintf("%d", challengePeriodBlocks),
+		},
+	}
+}
+
+// NewEvilEdgeConfirmedEvent for a confirmed edge we do not agree with.
+func NewEvilEdgeConfirmedEvent(edgeId, assertionHash common.Hash, challengeLevel string) *Event {
+	return &Event{
+		Kind:     EvilEdgeConfirmed,
+		Severity: Critical,
+		Key:      edgeId.Hex(),
+		Message:  fmt.Sprintf("Edge %#x we disagree with was confirmed", edgeId),
+		Fields: map[string]string{
+			"edgeId":         edgeId.Hex(),
+			"assertionHash":  assertionHash.Hex(),
+			"challengeLevel": challengeLevel,
+		},
+	}
+}
+
+// NewStakeAtRiskEvent for an honest edge that has a confirmed rival.
+func NewStakeAtRiskEvent(edgeId, assertionHash common.Hash, challengeLevel string) *Event {
+	return &Event{
+		Kind:     StakeAtRisk,
+		Severity: Critical,
+		Key:      edgeId.Hex(),
+		Message:  fmt.Sprintf("Honest edge %#x has a confirmed rival", edgeId),
+		Fields: map[string]string{
+			"edgeId":         edgeId.Hex(),
+			"assertionHash":  assertionHash.Hex(),
+			"challengeLevel": challengeLevel,
+		},
+	}
+}
+
+// NewLowBalanceEvent for a staker address whose balance is below the given threshold.
+func NewLowBalanceEvent(addr common.Address, balance, threshold *big.Int) *Event {
+	return &Event{
+		Kind:     LowBalance,
+		Severity: Warning,
+		Key:      addr.Hex(),
+		Message:  fmt.Sprintf("Balance of %s is %s wei, below threshold of %s wei", addr.Hex(), balance, threshold),
+		Fields: map[string]string{
+			"address":   addr.Hex(),
+			"balance":   balance.String(),
+			"threshold": threshold.String(),
+		},
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package alerts
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookSink(t *testing.T) {
+	received := make(chan *Event, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "secret", r.Header.Get("Authorization"))
+		ev := &Event{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(ev))
+		received <- ev
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	sink, err := NewWebhookSink(srv.URL, map[string]string{"Authorization": "secret"}, time.Second)
+	require.NoError(t, err)
+	ev := NewChallengeOpenedEvent(common.BytesToHash([]byte("foo")), common.Hash{})
+	require.NoError(t, sink.Send(context.Background(), ev))
+	got := <-received
+	require.Equal(t, ev.Kind, got.Kind)
+	require.Equal(t, ev.Key, got.Key)
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	sink, err = NewWebhookSink(failing.URL, nil, time.Second)
+	require.NoError(t, err)
+	require.ErrorContains(t, sink.Send(context.Background(), ev), "status 500")
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alerts.jsonl")
+	sink, err := NewFileSink(path)
+	require.NoError(t, err)
+	require.NoError(t, sink.Send(context.Background(), NewChallengeOpenedEvent(common.Hash{}, common.Hash{})))
+	require.NoError(t, sink.Send(context.Background(), NewStakeAtRiskEvent(common.Hash{}, common.Hash{}, "block")))
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	kinds := make([]Kind, 0)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		ev := &Event{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), ev))
+		kinds = append(kinds, ev.Kind)
+	}
+	require.Equal(t, []Kind{ChallengeOpened, StakeAtRisk}, kinds)
+}
+
+func TestWriterSink(t *testing.T) {
+	buf := new(bytes.Buffer)
+	sink := NewWriterSink(buf)
+	require.NoError(t, sink.Send(context.Background(), NewLowBalanceEvent(common.Address{}, common.Big1, common.Big2)))
+	ev := &Event{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), ev))
+	require.Equal(t, LowBalance, ev.Kind)
+	require.Equal(t, "1", ev.Fields["balance"])
+}
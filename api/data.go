@@ -2,10 +2,16 @@ package api
 
 import (
 	"context"
+	"time"
+
 	"github.com/ethereum/go-ethereum/common"
 
+	"github.com/OffchainLabs/bold/accounting"
+	"github.com/OffchainLabs/bold/assertions"
 	protocol "github.com/OffchainLabs/bold/chain-abstraction"
 	challengetree "github.com/OffchainLabs/bold/challenge-manager/challenge-tree"
+	edgetracker "github.com/OffchainLabs/bold/challenge-manager/edge-tracker"
+	"github.com/OffchainLabs/bold/challenge-manager/types"
 )
 
 type EdgesProvider interface {
@@ -21,3 +27,39 @@ type AssertionsProvider interface {
 	ReadAssertionCreationInfo(context.Context, protocol.AssertionHash) (*protocol.AssertionCreatedInfo, error)
 	LatestCreatedAssertionHashes(ctx context.Context) ([]protocol.AssertionHash, error)
 }
+
+type DivergencesProvider interface {
+	DivergenceReports() []*assertions.DivergenceReport
+	DivergenceReport(assertionHash common.Hash) (*assertions.DivergenceReport, bool)
+}
+
+type TrackersProvider interface {
+	TrackerSnapshots() []*edgetracker.Snapshot
+	TrackerSnapshot(edgeId common.Hash) (*edgetracker.Snapshot, bool)
+}
+
+// AccountingProvider reports what each challenge cost and what was recovered.
+type AccountingProvider interface {
+	ChallengeCosts() []*accounting.ChallengeCosts
+	ChallengeCost(assertionHash common.Hash) (*accounting.ChallengeCosts, bool)
+	Totals() *accounting.ChallengeCosts
+}
+
+// AdminProvider changes the settings of a challenge manager at runtime.
+type AdminProvider interface {
+	AdminStatus() *AdminStatus
+	SetMode(mode types.Mode) error
+	SetPostingPaused(paused bool)
+	SetMovesPaused(paused bool)
+	SetPostingInterval(d time.Duration) error
+	ForceTrackEdge(ctx context.Context, edgeId common.Hash) error
+	Rescan(fromBlock uint64)
+}
+
+// AdminStatus of the settings operators can change at runtime.
+type AdminStatus struct {
+	Mode            string `json:"mode"`
+	PostingPaused   bool   `json:"postingPaused"`
+	MovesPaused     bool   `json:"movesPaused"`
+	PostingInterval string `json:"postingInterval"`
+}
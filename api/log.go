@@ -1,7 +1,17 @@
 package api
 
-import gethLog "github.com/ethereum/go-ethereum/log"
+import (
+	"os"
+
+	gethLog "github.com/ethereum/go-ethereum/log"
+)
 
 var (
 	log = gethLog.New("service", "api")
+	// Audit trail of the actions taken through the admin endpoints.
+	auditLog = gethLog.New("service", "admin-audit")
 )
+
+func init() {
+	auditLog.SetHandler(gethLog.StreamHandler(os.Stdout, gethLog.LogfmtFormat()))
+}
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func (s *Server) accountingTotalsHandler(w http.ResponseWriter, r *http.Request) {
+	if err := writeJSONResponse(w, 200, s.accounting.Totals()); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+}
+
+func (s *Server) listChallengeCostsHandler(w http.ResponseWriter, r *http.Request) {
+	if err := writeJSONResponse(w, 200, s.accounting.ChallengeCosts()); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+}
+
+func (s *Server) getChallengeCostHandler(w http.ResponseWriter, r *http.Request) {
+	assertionHash := mux.Vars(r)["id"]
+	costs, ok := s.accounting.ChallengeCost(common.HexToHash(assertionHash))
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no costs recorded for challenge of assertion %s", assertionHash))
+		return
+	}
+	if err := writeJSONResponse(w, 200, costs); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+}
@@ -0,0 +1,81 @@
+package api_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/OffchainLabs/bold/accounting"
+	"github.com/OffchainLabs/bold/api"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestAccounting(t *testing.T) {
+	ledger, err := accounting.NewLedger()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertionHash := common.BytesToHash([]byte("foo"))
+	ledger.Record(&accounting.Entry{
+		Kind:          accounting.GasSpent,
+		AssertionHash: assertionHash,
+		Method:        "createLayerZeroEdge",
+		TxHash:        common.BytesToHash([]byte("tx")),
+		GasUsed:       100,
+		Amount:        big.NewInt(1000),
+	})
+	ledger.Record(&accounting.Entry{
+		Kind:          accounting.MiniStakeLocked,
+		AssertionHash: assertionHash,
+		TxHash:        common.BytesToHash([]byte("tx")),
+		Amount:        big.NewInt(5),
+	})
+	s, err := api.NewServer(&api.Config{
+		EdgesProvider:      &FakeEdgesProvider{},
+		AssertionsProvider: &FakeAssertionProvider{},
+		AccountingProvider: ledger,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func(path string, code int) *httptest.ResponseRecorder {
+		t.Helper()
+		req, err := http.NewRequest("GET", path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		s.Router().ServeHTTP(rr, req)
+		if rr.Code != code {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, code)
+		}
+		return rr
+	}
+
+	var list []*accounting.ChallengeCosts
+	if err = json.Unmarshal(get("/accounting/challenges", http.StatusOK).Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].AssertionHash != assertionHash || list[0].GasCost.Int64() != 1000 || len(list[0].Entries) != 0 {
+		t.Errorf("Unexpected response: %+v", list)
+	}
+
+	var costs accounting.ChallengeCosts
+	if err = json.Unmarshal(get("/accounting/challenges/"+assertionHash.Hex(), http.StatusOK).Body.Bytes(), &costs); err != nil {
+		t.Fatal(err)
+	}
+	if costs.MiniStakesLocked.Int64() != 5 || len(costs.Entries) != 2 {
+		t.Errorf("Unexpected response: %+v", costs)
+	}
+	get("/accounting/challenges/"+common.BytesToHash([]byte("bar")).Hex(), http.StatusNotFound)
+
+	var totals accounting.ChallengeCosts
+	if err = json.Unmarshal(get("/accounting/totals", http.StatusOK).Body.Bytes(), &totals); err != nil {
+		t.Fatal(err)
+	}
+	if totals.Transactions != 1 || totals.GasUsed != 100 {
+		t.Errorf("Unexpected response: %+v", totals)
+	}
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/OffchainLabs/bold/challenge-manager/types"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Only accepts requests bearing the admin token as a bearer token in the Authorization
+// header. Rejected requests are audit logged too.
+func (s *Server) authenticateAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		token := strings.TrimPrefix(header, "Bearer ")
+		if !strings.HasPrefix(header, "Bearer ") || subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) != 1 {
+			auditLog.Warn("Rejected unauthenticated admin request", "path", r.URL.Path, "remoteAddr", r.RemoteAddr)
+			writeError(w, http.StatusUnauthorized, fmt.Errorf("invalid admin token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Audit logs an admin action and responds with the resulting admin status.
+func (s *Server) completeAdminAction(w http.ResponseWriter, r *http.Request, action string, err error, params ...any) {
+	ctx := append([]any{"action", action, "remoteAddr", r.RemoteAddr}, params...)
+	if err != nil {
+		auditLog.Warn("Admin action failed", append(ctx, "err", err)...)
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	auditLog.Info("Admin action succeeded", ctx...)
+	s.adminStatusHandler(w, r)
+}
+
+func (s *Server) adminStatusHandler(w http.ResponseWriter, r *http.Request) {
+	if err := writeJSONResponse(w, 200, s.admin.AdminStatus()); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+}
+
+type setModeRequest struct {
+	Mode string `json:"mode"`
+}
+
+func (s *Server) setModeHandler(w http.ResponseWriter, r *http.Request) {
+	var req setModeRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err == nil {
+		var mode types.Mode
+		if mode, err = types.ParseMode(req.Mode); err == nil {
+			err = s.admin.SetMode(mode)
+		}
+	}
+	s.completeAdminAction(w, r, "set_mode", err, "mode", req.Mode)
+}
+
+func (s *Server) setPostingPausedHandler(w http.ResponseWriter, r *http.Request) {
+	action := mux.Vars(r)["action"]
+	s.admin.SetPostingPaused(action == "pause")
+	s.completeAdminAction(w, r, action+"_posting", nil)
+}
+
+type setPostingIntervalRequest struct {
+	Interval string `json:"interval"`
+}
+
+func (s *Server) setPostingIntervalHandler(w http.ResponseWriter, r *http.Request) {
+	var req setPostingIntervalRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err == nil {
+		var interval time.Duration
+		if interval, err = time.ParseDuration(req.Interval); err == nil {
+			err = s.admin.SetPostingInterval(interval)
+		}
+	}
+	s.completeAdminAction(w, r, "set_posting_interval", err, "interval", req.Interval)
+}
+
+func (s *Server) setMovesPausedHandler(w http.ResponseWriter, r *http.Request) {
+	action := mux.Vars(r)["action"]
+	s.admin.SetMovesPaused(action == "pause")
+	s.completeAdminAction(w, r, action+"_moves", nil)
+}
+
+func (s *Server) forceTrackEdgeHandler(w http.ResponseWriter, r *http.Request) {
+	edgeId := mux.Vars(r)["id"]
+	err := s.admin.ForceTrackEdge(r.Context(), common.HexToHash(edgeId))
+	s.completeAdminAction(w, r, "force_track_edge", err, "edgeId", edgeId)
+}
+
+type rescanRequest struct {
+	FromBlock uint64 `json:"fromBlock"`
+}
+
+func (s *Server) rescanHandler(w http.ResponseWriter, r *http.Request) {
+	var req rescanRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err == nil {
+		s.admin.Rescan(req.FromBlock)
+	}
+	s.completeAdminAction(w, r, "rescan", err, "fromBlock", req.FromBlock)
+}
@@ -0,0 +1,154 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OffchainLabs/bold/api"
+	"github.com/OffchainLabs/bold/challenge-manager/types"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type FakeAdminProvider struct {
+	Mode            types.Mode
+	PostingPaused   bool
+	MovesPaused     bool
+	PostingInterval time.Duration
+	TrackedEdges    []common.Hash
+	RescanFrom      uint64
+}
+
+func (f *FakeAdminProvider) AdminStatus() *api.AdminStatus {
+	return &api.AdminStatus{
+		Mode:            f.Mode.String(),
+		PostingPaused:   f.PostingPaused,
+		MovesPaused:     f.MovesPaused,
+		PostingInterval: f.PostingInterval.String(),
+	}
+}
+
+func (f *FakeAdminProvider) SetMode(mode types.Mode) error {
+	f.Mode = mode
+	return nil
+}
+
+func (f *FakeAdminProvider) SetPostingPaused(paused bool) {
+	f.PostingPaused = paused
+}
+
+func (f *FakeAdminProvider) SetMovesPaused(paused bool) {
+	f.MovesPaused = paused
+}
+
+func (f *FakeAdminProvider) SetPostingInterval(d time.Duration) error {
+	f.PostingInterval = d
+	return nil
+}
+
+func (f *FakeAdminProvider) ForceTrackEdge(_ context.Context, edgeId common.Hash) error {
+	f.TrackedEdges = append(f.TrackedEdges, edgeId)
+	return nil
+}
+
+func (f *FakeAdminProvider) Rescan(fromBlock uint64) {
+	f.RescanFrom = fromBlock
+}
+
+func TestAdmin(t *testing.T) {
+	_, err := api.NewServer(&api.Config{
+		EdgesProvider:      &FakeEdgesProvider{},
+		AssertionsProvider: &FakeAssertionProvider{},
+		AdminProvider:      &FakeAdminProvider{},
+	})
+	if !errors.Is(err, api.ErrNoAdminToken) {
+		t.Fatalf("expected error %v, got %v", api.ErrNoAdminToken, err)
+	}
+
+	admin := &FakeAdminProvider{Mode: types.DefensiveMode, PostingInterval: time.Hour}
+	s, err := api.NewServer(&api.Config{
+		EdgesProvider:      &FakeEdgesProvider{},
+		AssertionsProvider: &FakeAssertionProvider{},
+		AdminProvider:      admin,
+		AdminToken:         "secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	doWithAuthorization := func(method, path, authorization, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req, err := http.NewRequest(method, path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rr := httptest.NewRecorder()
+		s.Router().ServeHTTP(rr, req)
+		return rr
+	}
+	do := func(method, path, token, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		if token == "" {
+			return doWithAuthorization(method, path, "", body)
+		}
+		return doWithAuthorization(method, path, "Bearer "+token, body)
+	}
+
+	// Requests without the admin token as a bearer token are rejected.
+	for _, authorization := range []string{"", "Bearer wrong", "secret", "Basic secret", "bearer secret"} {
+		if rr := doWithAuthorization("PUT", "/admin/mode", authorization, `{"mode":"make"}`); rr.Code != http.StatusUnauthorized {
+			t.Fatalf("%q: handler returned wrong status code: got %v want %v", authorization, rr.Code, http.StatusUnauthorized)
+		}
+	}
+	if admin.Mode != types.DefensiveMode {
+		t.Fatalf("mode changed by unauthenticated request: %s", admin.Mode)
+	}
+
+	for _, tc := range []struct {
+		method string
+		path   string
+		body   string
+		code   int
+	}{
+		{"PUT", "/admin/mode", `{"mode":"confirmer"}`, http.StatusOK},
+		{"PUT", "/admin/mode", `{"mode":"make"}`, http.StatusOK},
+		{"PUT", "/admin/mode", `{"mode":"unknown"}`, http.StatusBadRequest},
+		{"POST", "/admin/posting/pause", "", http.StatusOK},
+		{"POST", "/admin/moves/pause", "", http.StatusOK},
+		{"POST", "/admin/moves/resume", "", http.StatusOK},
+		{"PUT", "/admin/posting/interval", `{"interval":"30s"}`, http.StatusOK},
+		{"PUT", "/admin/posting/interval", `{"interval":"soon"}`, http.StatusBadRequest},
+		{"POST", "/admin/edges/0x01/track", "", http.StatusOK},
+		{"POST", "/admin/rescan", `{"fromBlock":42}`, http.StatusOK},
+	} {
+		if rr := do(tc.method, tc.path, "secret", tc.body); rr.Code != tc.code {
+			t.Fatalf("%s %s %s: handler returned wrong status code: got %v want %v", tc.method, tc.path, tc.body, rr.Code, tc.code)
+		}
+	}
+
+	rr := do("GET", "/admin/status", "secret", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	var status api.AdminStatus
+	if err = json.Unmarshal(rr.Body.Bytes(), &status); err != nil {
+		t.Fatal(err)
+	}
+	want := api.AdminStatus{Mode: "make", PostingPaused: true, MovesPaused: false, PostingInterval: "30s"}
+	if status != want {
+		t.Errorf("Unexpected status: got %+v want %+v", status, want)
+	}
+	if len(admin.TrackedEdges) != 1 || admin.TrackedEdges[0] != common.HexToHash("0x01") {
+		t.Errorf("Unexpected tracked edges: %v", admin.TrackedEdges)
+	}
+	if admin.RescanFrom != 42 {
+		t.Errorf("Unexpected rescan block: %d", admin.RescanFrom)
+	}
+}
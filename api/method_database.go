@@ -14,6 +14,7 @@ import (
 	"github.com/jmoiron/sqlx"
 
 	protocol "github.com/OffchainLabs/bold/chain-abstraction"
+	"github.com/OffchainLabs/bold/events"
 )
 
 type Database struct {
@@ -22,6 +23,7 @@ type Database struct {
 	currentTableVersion int
 	updateInterval      time.Duration
 	edges               EdgesProvider
+	events              *events.Bus
 	lastUpdated         time.Time
 
 	versionMutex *sync.RWMutex
@@ -35,7 +37,9 @@ type DBConfig struct {
 	DBUpdateInterval time.Duration
 }
 
-func NewDatabase(config *DBConfig, edges EdgesProvider) (*Database, error) {
+// NewDatabase creates a database of the edges of the provider. If an event bus is given,
+// the database is also updated as soon as edges are added, bisected or confirmed.
+func NewDatabase(config *DBConfig, edges EdgesProvider, bus *events.Bus) (*Database, error) {
 	if _, err := os.Stat(config.DBPath); err != nil {
 		_, err = os.Create(config.DBPath)
 		if err != nil {
@@ -52,20 +56,57 @@ func NewDatabase(config *DBConfig, edges EdgesProvider) (*Database, error) {
 		currentTableVersion: -1,
 		updateInterval:      config.DBUpdateInterval,
 		edges:               edges,
+		events:              bus,
+		versionMutex:        &sync.RWMutex{},
+		updateMutex:         &sync.Mutex{},
 	}, nil
 }
 
 // Start starts the database update loop.
-// Update the database every updateInterval.
+// Update the database every updateInterval, or as soon as an edge event is published.
 // Create a new table version after fetching the edges.
 // Drops the previous table version after updating.
+// Stops once the context is done.
 func (d *Database) Start(ctx context.Context) {
+	var edgeEvents <-chan events.Event
+	if d.events != nil {
+		sub := d.events.Subscribe(
+			"api-database",
+			events.WithKinds(events.KindEdgeAdded, events.KindEdgeBisected, events.KindEdgeConfirmed),
+		)
+		defer sub.Unsubscribe()
+		edgeEvents = sub.Events()
+	}
 	for {
 		err := d.Update(ctx)
 		if err != nil {
 			log.Error("failed to update database", "err", err)
 		}
-		time.Sleep(d.updateInterval)
+		select {
+		case <-time.After(d.updateInterval):
+		case _, ok := <-edgeEvents:
+			if !ok {
+				edgeEvents = nil
+				continue
+			}
+			// Events published in a burst are written in a single update.
+			drainEvents(edgeEvents)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func drainEvents(ch <-chan events.Event) {
+	for {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				return
+			}
+		default:
+			return
+		}
 	}
 }
 
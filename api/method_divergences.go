@@ -0,0 +1,30 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func (s *Server) listDivergencesHandler(w http.ResponseWriter, r *http.Request) {
+	if err := writeJSONResponse(w, 200, s.divergences.DivergenceReports()); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+}
+
+func (s *Server) getDivergenceHandler(w http.ResponseWriter, r *http.Request) {
+	assertionHash := mux.Vars(r)["id"]
+	report, ok := s.divergences.DivergenceReport(common.HexToHash(assertionHash))
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no divergence report for assertion %s", assertionHash))
+		return
+	}
+	if err := writeJSONResponse(w, 200, report); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+}
@@ -0,0 +1,89 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/OffchainLabs/bold/api"
+	"github.com/OffchainLabs/bold/assertions"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type FakeDivergencesProvider struct {
+	Reports []*assertions.DivergenceReport
+}
+
+func (f *FakeDivergencesProvider) DivergenceReports() []*assertions.DivergenceReport {
+	return f.Reports
+}
+
+func (f *FakeDivergencesProvider) DivergenceReport(assertionHash common.Hash) (*assertions.DivergenceReport, bool) {
+	for _, r := range f.Reports {
+		if r.AssertionHash == assertionHash {
+			return r, true
+		}
+	}
+	return nil, false
+}
+
+func TestDivergences(t *testing.T) {
+	batch := uint64(3)
+	divergences := &FakeDivergencesProvider{
+		Reports: []*assertions.DivergenceReport{
+			{
+				AssertionHash: common.BytesToHash([]byte("foo")),
+				DivergentFields: []assertions.FieldDivergence{
+					{Field: "BlockHash", Claimed: "0x01", Local: "0x02"},
+				},
+				FirstDivergentBatch: &batch,
+			},
+		},
+	}
+	s, err := api.NewServer(&api.Config{
+		EdgesProvider:       &FakeEdgesProvider{},
+		AssertionsProvider:  &FakeAssertionProvider{},
+		DivergencesProvider: divergences,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest("GET", "/divergences", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	s.Router().ServeHTTP(rr, req)
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	var resp []*assertions.DivergenceReport
+	if err = json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp) != 1 || resp[0].DivergentFields[0].Field != "BlockHash" || *resp[0].FirstDivergentBatch != batch {
+		t.Errorf("Unexpected response: %+v", resp)
+	}
+
+	req, err = http.NewRequest("GET", "/divergences/"+common.BytesToHash([]byte("foo")).Hex(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr = httptest.NewRecorder()
+	s.Router().ServeHTTP(rr, req)
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	req, err = http.NewRequest("GET", "/divergences/"+common.BytesToHash([]byte("bar")).Hex(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr = httptest.NewRecorder()
+	s.Router().ServeHTTP(rr, req)
+	if status := rr.Code; status != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNotFound)
+	}
+}
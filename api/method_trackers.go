@@ -0,0 +1,30 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func (s *Server) listTrackersHandler(w http.ResponseWriter, r *http.Request) {
+	if err := writeJSONResponse(w, 200, s.trackers.TrackerSnapshots()); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+}
+
+func (s *Server) getTrackerHandler(w http.ResponseWriter, r *http.Request) {
+	edgeId := mux.Vars(r)["id"]
+	snapshot, ok := s.trackers.TrackerSnapshot(common.HexToHash(edgeId))
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no tracker running for edge %s", edgeId))
+		return
+	}
+	if err := writeJSONResponse(w, 200, snapshot); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+}
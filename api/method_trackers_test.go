@@ -0,0 +1,99 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/OffchainLabs/bold/api"
+	edgetracker "github.com/OffchainLabs/bold/challenge-manager/edge-tracker"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type FakeTrackersProvider struct {
+	Snapshots []*edgetracker.Snapshot
+}
+
+func (f *FakeTrackersProvider) TrackerSnapshots() []*edgetracker.Snapshot {
+	return f.Snapshots
+}
+
+func (f *FakeTrackersProvider) TrackerSnapshot(edgeId common.Hash) (*edgetracker.Snapshot, bool) {
+	for _, s := range f.Snapshots {
+		if s.EdgeId == edgeId {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+func TestTrackers(t *testing.T) {
+	failed := edgetracker.Transition{
+		Time: time.Unix(1, 0).UTC(),
+		From: "bisecting",
+		To:   "bisecting",
+		Err:  "could not bisect",
+	}
+	trackers := &FakeTrackersProvider{
+		Snapshots: []*edgetracker.Snapshot{
+			{
+				EdgeId:         common.BytesToHash([]byte("foo")),
+				ChallengeLevel: "block",
+				EndHeight:      32,
+				State:          "bisecting",
+				Transitions: []edgetracker.Transition{
+					{Time: time.Unix(0, 0).UTC(), From: "start", To: "bisecting", Event: "edgeBisect"},
+					failed,
+				},
+				LastAction: &failed,
+			},
+		},
+	}
+	s, err := api.NewServer(&api.Config{
+		EdgesProvider:      &FakeEdgesProvider{},
+		AssertionsProvider: &FakeAssertionProvider{},
+		TrackersProvider:   trackers,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest("GET", "/trackers", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	s.Router().ServeHTTP(rr, req)
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	var resp []*edgetracker.Snapshot
+	if err = json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp) != 1 || resp[0].State != "bisecting" || len(resp[0].Transitions) != 2 || resp[0].LastAction.Err != failed.Err {
+		t.Errorf("Unexpected response: %+v", resp)
+	}
+
+	req, err = http.NewRequest("GET", "/trackers/"+common.BytesToHash([]byte("foo")).Hex(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr = httptest.NewRecorder()
+	s.Router().ServeHTTP(rr, req)
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	req, err = http.NewRequest("GET", "/trackers/"+common.BytesToHash([]byte("bar")).Hex(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr = httptest.NewRecorder()
+	s.Router().ServeHTTP(rr, req)
+	if status := rr.Code; status != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNotFound)
+	}
+}
@@ -11,6 +11,8 @@ import (
 	"time"
 
 	"github.com/gorilla/mux"
+
+	"github.com/OffchainLabs/bold/events"
 )
 
 var (
@@ -18,6 +20,7 @@ var (
 	ErrNoEdgesProvider          = errors.New("no edges provider")
 	ErrNoAssertionsProvider     = errors.New("no assertions provider")
 	ErrAlreadyRegisteredMethods = errors.New("already registered methods")
+	ErrNoAdminToken             = errors.New("admin endpoints require a token")
 )
 
 type Config struct {
@@ -25,14 +28,31 @@ type Config struct {
 	EdgesProvider      EdgesProvider
 	AssertionsProvider AssertionsProvider
 	DBConfig           *DBConfig
+	// Optional, updates the database as soon as edge events are published.
+	EventBus *events.Bus
+	// Optional, enables the divergence report endpoints.
+	DivergencesProvider DivergencesProvider
+	// Optional, enables the edge tracker introspection endpoints.
+	TrackersProvider TrackersProvider
+	// Optional, enables the challenge cost accounting endpoints.
+	AccountingProvider AccountingProvider
+	// Optional, enables the admin endpoints, which are authenticated with the
+	// admin token as a bearer token.
+	AdminProvider AdminProvider
+	AdminToken    string
 }
 
 type Server struct {
 	srv *http.Server
 
-	edges      EdgesProvider
-	assertions AssertionsProvider
-	database   *Database
+	edges       EdgesProvider
+	assertions  AssertionsProvider
+	divergences DivergencesProvider
+	trackers    TrackersProvider
+	accounting  AccountingProvider
+	admin       AdminProvider
+	adminToken  string
+	database    *Database
 
 	router *mux.Router
 
@@ -54,6 +74,9 @@ func NewServer(cfg *Config) (*Server, error) {
 	if cfg.AssertionsProvider == nil {
 		return nil, ErrNoAssertionsProvider
 	}
+	if cfg.AdminProvider != nil && cfg.AdminToken == "" {
+		return nil, ErrNoAdminToken
+	}
 
 	r := mux.NewRouter()
 
@@ -65,12 +88,17 @@ func NewServer(cfg *Config) (*Server, error) {
 			ReadTimeout:       15 * time.Second,
 			ReadHeaderTimeout: 15 * time.Second,
 		},
-		edges:      cfg.EdgesProvider,
-		assertions: cfg.AssertionsProvider,
-		router:     r,
+		edges:       cfg.EdgesProvider,
+		assertions:  cfg.AssertionsProvider,
+		divergences: cfg.DivergencesProvider,
+		trackers:    cfg.TrackersProvider,
+		accounting:  cfg.AccountingProvider,
+		admin:       cfg.AdminProvider,
+		adminToken:  cfg.AdminToken,
+		router:      r,
 	}
 	if cfg.DBConfig != nil && cfg.DBConfig.Enable {
-		database, err := NewDatabase(cfg.DBConfig, cfg.EdgesProvider)
+		database, err := NewDatabase(cfg.DBConfig, cfg.EdgesProvider, cfg.EventBus)
 		if err != nil {
 			return nil, err
 		}
@@ -85,13 +113,29 @@ func NewServer(cfg *Config) (*Server, error) {
 }
 
 func (s *Server) Start(ctx context.Context) error {
-	go s.database.Start(ctx)
+	if s.database != nil {
+		go s.database.Start(ctx)
+	}
 	return s.srv.ListenAndServe()
 }
 
+// Bounds the final flush of the database when the server stops.
+const databaseFlushTimeout = 10 * time.Second
+
+// Stop shuts down the server, waiting for in-flight requests until the context is done,
+// and then flushes the latest edges to the database before closing it. The flush has its
+// own timeout, as the shutdown may have used up the context's deadline.
 func (s *Server) Stop(ctx context.Context) error {
-	s.database.close()
-	return s.srv.Shutdown(ctx)
+	err := s.srv.Shutdown(ctx)
+	if s.database != nil {
+		flushCtx, cancel := context.WithTimeout(context.Background(), databaseFlushTimeout)
+		defer cancel()
+		if flushErr := s.database.Update(flushCtx); flushErr != nil {
+			log.Error("failed to flush database", "err", flushErr)
+		}
+		s.database.close()
+	}
+	return err
 }
 
 func (s *Server) registerMethods() error {
@@ -115,6 +159,38 @@ func (s *Server) registerMethods() error {
 	// Stakes
 	s.router.HandleFunc("/mini-stakes", s.listMiniStakesHandler).Methods("GET")
 
+	// Divergence reports
+	if s.divergences != nil {
+		s.router.HandleFunc("/divergences", s.listDivergencesHandler).Methods("GET")
+		s.router.HandleFunc("/divergences/{id}", s.getDivergenceHandler).Methods("GET")
+	}
+
+	// Edge trackers
+	if s.trackers != nil {
+		s.router.HandleFunc("/trackers", s.listTrackersHandler).Methods("GET")
+		s.router.HandleFunc("/trackers/{id}", s.getTrackerHandler).Methods("GET")
+	}
+
+	// Challenge costs
+	if s.accounting != nil {
+		s.router.HandleFunc("/accounting/totals", s.accountingTotalsHandler).Methods("GET")
+		s.router.HandleFunc("/accounting/challenges", s.listChallengeCostsHandler).Methods("GET")
+		s.router.HandleFunc("/accounting/challenges/{id}", s.getChallengeCostHandler).Methods("GET")
+	}
+
+	// Admin
+	if s.admin != nil {
+		admin := s.router.PathPrefix("/admin").Subrouter()
+		admin.Use(s.authenticateAdmin)
+		admin.HandleFunc("/status", s.adminStatusHandler).Methods("GET")
+		admin.HandleFunc("/mode", s.setModeHandler).Methods("PUT")
+		admin.HandleFunc("/posting/{action:pause|resume}", s.setPostingPausedHandler).Methods("POST")
+		admin.HandleFunc("/posting/interval", s.setPostingIntervalHandler).Methods("PUT")
+		admin.HandleFunc("/moves/{action:pause|resume}", s.setMovesPausedHandler).Methods("POST")
+		admin.HandleFunc("/edges/{id}/track", s.forceTrackEdgeHandler).Methods("POST")
+		admin.HandleFunc("/rescan", s.rescanHandler).Methods("POST")
+	}
+
 	// Database query
 	if s.database != nil {
 		s.router.HandleFunc("/query-database/{query}", s.queryDatabaseHandler).Methods("GET")
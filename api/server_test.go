@@ -1,9 +1,13 @@
 package api_test
 
 import (
+	"context"
+	"path/filepath"
 	"testing"
 
 	"github.com/OffchainLabs/bold/api"
+	protocol "github.com/OffchainLabs/bold/chain-abstraction"
+	_ "github.com/mattn/go-sqlite3"
 )
 
 func NewTestServer(t *testing.T) (*api.Server, *FakeEdgesProvider, *FakeAssertionProvider) {
@@ -26,3 +30,42 @@ func NewTestServer(t *testing.T) (*api.Server, *FakeEdgesProvider, *FakeAssertio
 
 	return s, edges, assertions
 }
+
+// Fails to provide edges once the context is done.
+type contextEdgesProvider struct {
+	FakeEdgesProvider
+	flushed bool
+}
+
+func (p *contextEdgesProvider) GetEdges(ctx context.Context) ([]protocol.SpecEdge, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	p.flushed = true
+	return p.Edges, nil
+}
+
+func TestServer_StopFlushesAfterDeadline(t *testing.T) {
+	edges := &contextEdgesProvider{}
+	s, err := api.NewServer(&api.Config{
+		EdgesProvider:      edges,
+		AssertionsProvider: &FakeAssertionProvider{},
+		DBConfig: &api.DBConfig{
+			Enable:    true,
+			DBPath:    filepath.Join(t.TempDir(), "edges.db"),
+			TableName: "edges",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The shutdown used up the caller's deadline.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := s.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !edges.flushed {
+		t.Fatal("database was not flushed on stop")
+	}
+}
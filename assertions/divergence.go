@@ -0,0 +1,304 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package assertions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	protocol "github.com/OffchainLabs/bold/chain-abstraction"
+	"github.com/OffchainLabs/bold/containers/option"
+	"github.com/OffchainLabs/bold/containers/threadsafe"
+	l2stateprovider "github.com/OffchainLabs/bold/layer2-state-provider"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/pkg/errors"
+)
+
+// HistorySource is a view of an L2 execution history which can be compared
+// against another in order to find the point where the two diverge.
+type HistorySource interface {
+	ExecutionStateAfterBatchCount(ctx context.Context, batchCount uint64) (*protocol.ExecutionState, error)
+	l2stateprovider.L2MessageStateCollector
+}
+
+// FieldDivergence describes a single field of an execution state on which the
+// claimed and local states disagree.
+type FieldDivergence struct {
+	Field   string `json:"field"`
+	Claimed string `json:"claimed"`
+	Local   string `json:"local"`
+}
+
+// DivergenceReport is a structured diagnosis of an assertion we disagree with,
+// meant to give incident response a concrete starting point.
+type DivergenceReport struct {
+	AssertionHash       common.Hash              `json:"assertionHash"`
+	ParentAssertionHash common.Hash              `json:"parentAssertionHash"`
+	ClaimedState        *protocol.ExecutionState `json:"claimedState"`
+	LocalState          *protocol.ExecutionState `json:"localState,omitempty"`
+	DivergentFields     []FieldDivergence        `json:"divergentFields"`
+	FromBatch           uint64                   `json:"fromBatch"`
+	ToBatch             uint64                   `json:"toBatch"`
+	// The first batch count after which the local and reference histories
+	// disagree. Only set if a reference history is configured.
+	FirstDivergentBatch *uint64 `json:"firstDivergentBatch,omitempty"`
+	// The height of the first divergent L2 message state within the first
+	// divergent batch, where height 0 is the state at the end of the previous batch.
+	FirstDivergentMessage *uint64   `json:"firstDivergentMessage,omitempty"`
+	Errors                []string  `json:"errors,omitempty"`
+	CreatedAt             time.Time `json:"createdAt"`
+}
+
+// Diagnoser produces divergence reports for assertions whose claimed state
+// we disagree with, keeping them in memory and optionally persisting them to disk.
+type Diagnoser struct {
+	local     HistorySource
+	reference HistorySource
+	dir       string
+	reports   *threadsafe.Map[common.Hash, *DivergenceReport]
+}
+
+type DiagnoserOpt func(d *Diagnoser)
+
+// WithDivergenceReference sets a history source following the claimed chain,
+// such as a replica node, which is used to binary-search for the first
+// divergent batch and L2 message. Without it, only the claimed and local
+// execution states are compared.
+func WithDivergenceReference(ref HistorySource) DiagnoserOpt {
+	return func(d *Diagnoser) {
+		d.reference = ref
+	}
+}
+
+// WithReportsDir persists reports as JSON files in the given directory.
+// Reports already present in the directory are loaded on creation.
+func WithReportsDir(dir string) DiagnoserOpt {
+	return func(d *Diagnoser) {
+		d.dir = dir
+	}
+}
+
+// NewDiagnoser creates a diagnoser which compares claims against a local history.
+func NewDiagnoser(local HistorySource, opts ...DiagnoserOpt) (*Diagnoser, error) {
+	if local == nil {
+		return nil, errors.New("local history source cannot be nil")
+	}
+	d := &Diagnoser{
+		local:   local,
+		reports: threadsafe.NewMap[common.Hash, *DivergenceReport](),
+	}
+	for _, o := range opts {
+		o(d)
+	}
+	if d.dir != "" {
+		if err := d.loadReports(); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// Diagnose compares the state claimed by an assertion with our local history
+// and stores the resulting report. Failures to read either history are
+// recorded in the report rather than aborting the diagnosis.
+func (d *Diagnoser) Diagnose(
+	ctx context.Context,
+	creationInfo *protocol.AssertionCreatedInfo,
+) (*DivergenceReport, error) {
+	claimed := protocol.GoExecutionStateFromSolidity(creationInfo.AfterState)
+	before := protocol.GoExecutionStateFromSolidity(creationInfo.BeforeState)
+	report := &DivergenceReport{
+		AssertionHash:       creationInfo.AssertionHash,
+		ParentAssertionHash: creationInfo.ParentAssertionHash,
+		ClaimedState:        claimed,
+		DivergentFields:     make([]FieldDivergence, 0),
+		FromBatch:           before.GlobalState.Batch,
+		ToBatch:             claimed.GlobalState.Batch,
+		CreatedAt:           time.Now(),
+	}
+	local, err := d.local.ExecutionStateAfterBatchCount(ctx, report.ToBatch)
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("could not read local state at batch %d: %v", report.ToBatch, err))
+	} else {
+		report.LocalState = local
+		report.DivergentFields = compareExecutionStates(claimed, local)
+	}
+	if d.reference != nil {
+		if err := d.locateDivergence(ctx, report); err != nil {
+			report.Errors = append(report.Errors, err.Error())
+		}
+	}
+	if err := d.save(report); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+// DivergenceReports returns all stored reports, newest first.
+func (d *Diagnoser) DivergenceReports() []*DivergenceReport {
+	reports := make([]*DivergenceReport, 0, d.reports.NumItems())
+	_ = d.reports.ForEach(func(_ common.Hash, r *DivergenceReport) error {
+		reports = append(reports, r)
+		return nil
+	})
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].CreatedAt.After(reports[j].CreatedAt)
+	})
+	return reports
+}
+
+// DivergenceReport returns the stored report for an assertion, if any.
+func (d *Diagnoser) DivergenceReport(assertionHash common.Hash) (*DivergenceReport, bool) {
+	return d.reports.TryGet(assertionHash)
+}
+
+// Binary-searches the batches of the assertion for the first one after which
+// the local and reference histories disagree, and then the L2 message states
+// within that batch. Diverged histories never reconverge, as every state
+// commits to the one before it, so the predicate is monotonic.
+func (d *Diagnoser) locateDivergence(ctx context.Context, report *DivergenceReport) error {
+	if report.ToBatch <= report.FromBatch {
+		return nil
+	}
+	batch, found, err := firstDivergence(report.FromBatch+1, report.ToBatch, func(b uint64) (bool, error) {
+		local, err := d.local.ExecutionStateAfterBatchCount(ctx, b)
+		if err != nil {
+			return false, errors.Wrapf(err, "could not read local state at batch %d", b)
+		}
+		ref, err := d.reference.ExecutionStateAfterBatchCount(ctx, b)
+		if err != nil {
+			return false, errors.Wrapf(err, "could not read reference state at batch %d", b)
+		}
+		return !local.Equals(ref), nil
+	})
+	if err != nil || !found {
+		return err
+	}
+	report.FirstDivergentBatch = &batch
+
+	fromBatch, toBatch := l2stateprovider.Batch(batch-1), l2stateprovider.Batch(batch)
+	local, err := d.local.L2MessageStatesUpTo(ctx, 0, option.None[l2stateprovider.Height](), fromBatch, toBatch)
+	if err != nil {
+		return errors.Wrapf(err, "could not read local message states in batch %d", batch)
+	}
+	ref, err := d.reference.L2MessageStatesUpTo(ctx, 0, option.None[l2stateprovider.Height](), fromBatch, toBatch)
+	if err != nil {
+		return errors.Wrapf(err, "could not read reference message states in batch %d", batch)
+	}
+	n := uint64(len(local))
+	if uint64(len(ref)) < n {
+		n = uint64(len(ref))
+	}
+	if n == 0 {
+		return nil
+	}
+	msg, found, _ := firstDivergence(0, n-1, func(i uint64) (bool, error) {
+		return local[i] != ref[i], nil
+	})
+	if found {
+		report.FirstDivergentMessage = &msg
+	}
+	return nil
+}
+
+// Finds the smallest index in [lo, hi] for which diverged returns true,
+// assuming that once true, it stays true for all larger indices.
+func firstDivergence(lo, hi uint64, diverged func(uint64) (bool, error)) (uint64, bool, error) {
+	found := false
+	result := hi
+	for lo <= hi {
+		mid := lo + (hi-lo)/2
+		ok, err := diverged(mid)
+		if err != nil {
+			return 0, false, err
+		}
+		if ok {
+			found = true
+			result = mid
+			if mid == 0 {
+				break
+			}
+			hi = mid - 1
+		} else {
+			lo = mid + 1
+		}
+	}
+	return result, found, nil
+}
+
+func compareExecutionStates(claimed, local *protocol.ExecutionState) []FieldDivergence {
+	diffs := make([]FieldDivergence, 0)
+	add := func(field string, c, l any) {
+		diffs = append(diffs, FieldDivergence{
+			Field:   field,
+			Claimed: fmt.Sprintf("%v", c),
+			Local:   fmt.Sprintf("%v", l),
+		})
+	}
+	if claimed.GlobalState.BlockHash != local.GlobalState.BlockHash {
+		add("BlockHash", claimed.GlobalState.BlockHash.Hex(), local.GlobalState.BlockHash.Hex())
+	}
+	if claimed.GlobalState.SendRoot != local.GlobalState.SendRoot {
+		add("SendRoot", claimed.GlobalState.SendRoot.Hex(), local.GlobalState.SendRoot.Hex())
+	}
+	if claimed.GlobalState.Batch != local.GlobalState.Batch {
+		add("Batch", claimed.GlobalState.Batch, local.GlobalState.Batch)
+	}
+	if claimed.GlobalState.PosInBatch != local.GlobalState.PosInBatch {
+		add("PosInBatch", claimed.GlobalState.PosInBatch, local.GlobalState.PosInBatch)
+	}
+	if claimed.MachineStatus != local.MachineStatus {
+		add("MachineStatus", claimed.MachineStatus, local.MachineStatus)
+	}
+	return diffs
+}
+
+func (d *Diagnoser) save(report *DivergenceReport) error {
+	d.reports.Put(report.AssertionHash, report)
+	if d.dir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(d.dir, 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(d.dir, report.AssertionHash.Hex()+".json")
+	return os.WriteFile(path, data, 0o644)
+}
+
+func (d *Diagnoser) loadReports() error {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(d.dir, e.Name()))
+		if err != nil {
+			return err
+		}
+		report := &DivergenceReport{}
+		if err := json.Unmarshal(data, report); err != nil {
+			srvlog.Warn("Skipping unreadable divergence report", log.Ctx{"file": e.Name(), "err": err})
+			continue
+		}
+		d.reports.Put(report.AssertionHash, report)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package assertions_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/OffchainLabs/bold/assertions"
+	protocol "github.com/OffchainLabs/bold/chain-abstraction"
+	statemanager "github.com/OffchainLabs/bold/testing/mocks/state-provider"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiagnoser_Diagnose(t *testing.T) {
+	ctx := context.Background()
+	honest, err := statemanager.NewForSimpleMachine(statemanager.WithNumBatchesRead(5))
+	require.NoError(t, err)
+	evil, err := statemanager.NewForSimpleMachine(
+		statemanager.WithNumBatchesRead(5),
+		statemanager.WithBlockDivergenceHeight(36), // Batch 4 in the mock.
+		statemanager.WithMachineDivergenceStep(1),
+	)
+	require.NoError(t, err)
+
+	beforeState, err := honest.ExecutionStateAfterBatchCount(ctx, 0)
+	require.NoError(t, err)
+	claimedState, err := evil.ExecutionStateAfterBatchCount(ctx, 5)
+	require.NoError(t, err)
+	creationInfo := &protocol.AssertionCreatedInfo{
+		AssertionHash:       common.BytesToHash([]byte("evil")),
+		ParentAssertionHash: common.BytesToHash([]byte("genesis")),
+		BeforeState:         beforeState.AsSolidityStruct(),
+		AfterState:          claimedState.AsSolidityStruct(),
+	}
+
+	t.Run("compares fields without a reference", func(t *testing.T) {
+		d, err := assertions.NewDiagnoser(honest)
+		require.NoError(t, err)
+		report, err := d.Diagnose(ctx, creationInfo)
+		require.NoError(t, err)
+		require.Empty(t, report.Errors)
+		require.Equal(t, 1, len(report.DivergentFields))
+		require.Equal(t, "BlockHash", report.DivergentFields[0].Field)
+		require.Nil(t, report.FirstDivergentBatch)
+
+		stored, ok := d.DivergenceReport(creationInfo.AssertionHash)
+		require.True(t, ok)
+		require.Equal(t, report, stored)
+	})
+	t.Run("locates first divergent batch and message with a reference", func(t *testing.T) {
+		d, err := assertions.NewDiagnoser(honest, assertions.WithDivergenceReference(evil))
+		require.NoError(t, err)
+		report, err := d.Diagnose(ctx, creationInfo)
+		require.NoError(t, err)
+		require.Empty(t, report.Errors)
+		require.NotNil(t, report.FirstDivergentBatch)
+		require.Equal(t, uint64(4), *report.FirstDivergentBatch)
+		require.NotNil(t, report.FirstDivergentMessage)
+		// The block divergence height of 36 lands seven messages into batch 4 in the mock.
+		require.Equal(t, uint64(7), *report.FirstDivergentMessage)
+	})
+	t.Run("persists reports to disk", func(t *testing.T) {
+		dir := t.TempDir()
+		d, err := assertions.NewDiagnoser(honest, assertions.WithReportsDir(dir))
+		require.NoError(t, err)
+		_, err = d.Diagnose(ctx, creationInfo)
+		require.NoError(t, err)
+
+		reloaded, err := assertions.NewDiagnoser(honest, assertions.WithReportsDir(dir))
+		require.NoError(t, err)
+		reports := reloaded.DivergenceReports()
+		require.Equal(t, 1, len(reports))
+		require.Equal(t, creationInfo.AssertionHash, reports[0].AssertionHash)
+	})
+}
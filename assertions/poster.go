@@ -10,6 +10,7 @@ import (
 
 	protocol "github.com/OffchainLabs/bold/chain-abstraction"
 	solimpl "github.com/OffchainLabs/bold/chain-abstraction/sol-implementation"
+	"github.com/OffchainLabs/bold/challenge-manager/stakers"
 	"github.com/OffchainLabs/bold/challenge-manager/types"
 	"github.com/OffchainLabs/bold/containers"
 	"github.com/OffchainLabs/bold/containers/option"
@@ -19,31 +20,77 @@ import (
 )
 
 func (m *Manager) postAssertionRoutine(ctx context.Context) {
-	if m.challengeReader.Mode() < types.MakeMode {
-		srvlog.Warn("Staker strategy not configured to stake on latest assertions")
-		return
+	if m.challengeReader.Mode() != types.MakeMode {
+		srvlog.Warn("Staker strategy not configured to stake on latest assertions, until switched to make mode")
+	} else if !m.challengeReader.IsLeader() {
+		srvlog.Info("Not the leader instance, deferring assertion posting")
 	}
-	if _, err := m.PostAssertion(ctx); err != nil {
-		if !errors.Is(err, solimpl.ErrAlreadyExists) {
-			srvlog.Error("Could not submit latest assertion to L1", log.Ctx{"err": err})
-		}
-	}
-	ticker := time.NewTicker(m.postInterval)
+	m.tryPostAssertion(ctx)
+	ticker := time.NewTicker(m.PostingInterval())
 	defer ticker.Stop()
 	for {
 		select {
 		case <-ticker.C:
-			if _, err := m.PostAssertion(ctx); err != nil {
-				if !errors.Is(err, solimpl.ErrAlreadyExists) {
-					srvlog.Error("Could not submit latest assertion to L1", log.Ctx{"err": err})
-				}
-			}
+			m.tryPostAssertion(ctx)
+		case d := <-m.postIntervalUpdates:
+			ticker.Reset(d)
 		case <-ctx.Done():
 			return
 		}
 	}
 }
 
+// Posts the latest assertion, unless the mode was switched away from make mode,
+// posting is paused, or this instance is not the leader.
+func (m *Manager) tryPostAssertion(ctx context.Context) {
+	if m.challengeReader.Mode() != types.MakeMode || m.postingPaused.Load() {
+		return
+	}
+	if !m.challengeReader.IsLeader() {
+		return
+	}
+	if _, err := m.PostAssertion(ctx); err != nil {
+		if !errors.Is(err, solimpl.ErrAlreadyExists) {
+			srvlog.Error("Could not submit latest assertion to L1", log.Ctx{"err": err})
+		}
+	}
+}
+
+// SetPostingPaused pauses or resumes posting new assertions.
+func (m *Manager) SetPostingPaused(paused bool) {
+	m.postingPaused.Store(paused)
+}
+
+// PostingPaused returns true if posting new assertions is paused.
+func (m *Manager) PostingPaused() bool {
+	return m.postingPaused.Load()
+}
+
+// SetPostingInterval changes how often new assertions are posted, taking effect
+// from the next posting attempt.
+func (m *Manager) SetPostingInterval(d time.Duration) error {
+	if d <= 0 {
+		return errors.New("assertion posting interval must be greater than 0")
+	}
+	m.postIntervalLock.Lock()
+	defer m.postIntervalLock.Unlock()
+	m.postInterval = d
+	// Only the latest update matters to the posting routine.
+	select {
+	case <-m.postIntervalUpdates:
+	default:
+	}
+	m.postIntervalUpdates <- d
+	return nil
+}
+
+// PostingInterval returns how often new assertions are posted.
+func (m *Manager) PostingInterval() time.Duration {
+	m.postIntervalLock.Lock()
+	defer m.postIntervalLock.Unlock()
+	return m.postInterval
+}
+
 // PostAssertion differs depending on whether or not the validator is currently staked.
 func (m *Manager) PostAssertion(ctx context.Context) (option.Option[protocol.Assertion], error) {
 	// Ensure that we only build on a valid parent from this validator's perspective.
@@ -57,7 +104,27 @@ func (m *Manager) PostAssertion(ctx context.Context) (option.Option[protocol.Ass
 	if err != nil {
 		return option.None[protocol.Assertion](), err
 	}
-	staked, err := m.chain.IsStaked(ctx)
+	return m.stakeOnNewAssertion(ctx, parentAssertionCreationInfo, stakers.AssertionPoster)
+}
+
+// Posts an assertion following a parent, staking with the identity selected for
+// the given role if a staker pool is configured, or with the chain's key otherwise.
+func (m *Manager) stakeOnNewAssertion(
+	ctx context.Context,
+	parentCreationInfo *protocol.AssertionCreatedInfo,
+	role stakers.Role,
+) (option.Option[protocol.Assertion], error) {
+	chain := m.chain
+	var staker *stakers.Selection
+	if m.stakers != nil {
+		var err error
+		staker, err = m.stakers.Select(ctx, role)
+		if err != nil {
+			return option.None[protocol.Assertion](), err
+		}
+		chain = staker
+	}
+	staked, err := chain.IsStaked(ctx)
 	if err != nil {
 		return option.None[protocol.Assertion](), err
 	}
@@ -66,12 +133,12 @@ func (m *Manager) PostAssertion(ctx context.Context) (option.Option[protocol.Ass
 	var postErr error
 	if staked {
 		assertionOpt, postErr = m.PostAssertionBasedOnParent(
-			ctx, parentAssertionCreationInfo, m.chain.StakeOnNewAssertion,
+			ctx, parentCreationInfo, chain.StakeOnNewAssertion,
 		)
 	} else {
 		// Otherwise, we post a new assertion and place a new stake on it.
 		assertionOpt, postErr = m.PostAssertionBasedOnParent(
-			ctx, parentAssertionCreationInfo, m.chain.NewStakeOnNewAssertion,
+			ctx, parentCreationInfo, chain.NewStakeOnNewAssertion,
 		)
 	}
 	if postErr != nil {
@@ -79,6 +146,9 @@ func (m *Manager) PostAssertion(ctx context.Context) (option.Option[protocol.Ass
 	}
 	if assertionOpt.IsSome() {
 		m.submittedAssertions.Insert(assertionOpt.Unwrap().Id().Hash)
+		if staker != nil {
+			staker.Used()
+		}
 	}
 	return assertionOpt, nil
 }
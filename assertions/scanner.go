@@ -13,13 +13,18 @@ import (
 	"math/big"
 	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/OffchainLabs/bold/alerts"
 	protocol "github.com/OffchainLabs/bold/chain-abstraction"
+	"github.com/OffchainLabs/bold/challenge-manager/stakers"
 	"github.com/OffchainLabs/bold/challenge-manager/types"
 	"github.com/OffchainLabs/bold/containers"
 	"github.com/OffchainLabs/bold/containers/option"
 	"github.com/OffchainLabs/bold/containers/threadsafe"
+	"github.com/OffchainLabs/bold/events"
 	l2stateprovider "github.com/OffchainLabs/bold/layer2-state-provider"
 	retry "github.com/OffchainLabs/bold/runtime"
 	"github.com/OffchainLabs/bold/solgen/go/rollupgen"
@@ -32,8 +37,13 @@ import (
 
 var (
 	srvlog = log.New("service", "assertions")
+
+	errNotLeader = errors.New("not the leader instance, deferring response to invalid assertion")
 )
 
+// Bounds the extra chain and state provider calls made to diagnose a divergence.
+const divergenceDiagnosisTimeout = 5 * time.Minute
+
 func init() {
 	srvlog.SetHandler(log.StreamHandler(os.Stdout, log.LogfmtFormat()))
 }
@@ -60,7 +70,49 @@ type Manager struct {
 	assertionsProcessedCount    uint64
 	stateManager                l2stateprovider.ExecutionProvider
 	postInterval                time.Duration
+	postIntervalLock            sync.Mutex
+	postIntervalUpdates         chan time.Duration
+	postingPaused               atomic.Bool
+	rescanFrom                  atomic.Pointer[uint64]
 	submittedAssertions         *threadsafe.Set[common.Hash]
+	alerts                      *alerts.Dispatcher
+	diagnoser                   *Diagnoser
+	diagnosing                  *threadsafe.Set[common.Hash]
+	confirming                  *threadsafe.Set[protocol.AssertionHash]
+	stakers                     *stakers.Pool
+	events                      *events.LogPublisher
+}
+
+type Opt func(m *Manager)
+
+// WithAlerts sets the dispatcher used to notify operators about invalid assertions.
+func WithAlerts(d *alerts.Dispatcher) Opt {
+	return func(m *Manager) {
+		m.alerts = d
+	}
+}
+
+// WithDivergenceDiagnoser sets the diagnoser used to produce a divergence report
+// for every assertion whose claimed state we disagree with.
+func WithDivergenceDiagnoser(d *Diagnoser) Opt {
+	return func(m *Manager) {
+		m.diagnoser = d
+	}
+}
+
+// WithStakerPool stakes new assertions and rivals with identities selected from a
+// pool of staker keys, instead of the single key of the assertion chain.
+func WithStakerPool(p *stakers.Pool) Opt {
+	return func(m *Manager) {
+		m.stakers = p
+	}
+}
+
+// WithEventBus sets the bus on which assertion creations and confirmations are published.
+func WithEventBus(bus *events.Bus) Opt {
+	return func(m *Manager) {
+		m.events = events.NewLogPublisher(bus)
+	}
 }
 
 // NewManager creates a manager from the required dependencies.
@@ -76,6 +128,7 @@ func NewManager(
 	stateManager l2stateprovider.ExecutionProvider,
 	postInterval time.Duration,
 	averageTimeForBlockCreation time.Duration,
+	opts ...Opt,
 ) (*Manager, error) {
 	if pollInterval == 0 {
 		return nil, errors.New("assertion scanning interval must be greater than 0")
@@ -83,7 +136,7 @@ func NewManager(
 	if assertionConfirmationAttemptInterval == 0 {
 		return nil, errors.New("assertion confirmation attempt interval must be greater than 0")
 	}
-	return &Manager{
+	m := &Manager{
 		chain:                       chain,
 		backend:                     backend,
 		stateProvider:               stateProvider,
@@ -98,9 +151,16 @@ func NewManager(
 		assertionsProcessedCount:    0,
 		stateManager:                stateManager,
 		postInterval:                postInterval,
+		postIntervalUpdates:         make(chan time.Duration, 1),
 		submittedAssertions:         threadsafe.NewSet[common.Hash](),
+		diagnosing:                  threadsafe.NewSet[common.Hash](),
+		confirming:                  threadsafe.NewSet[protocol.AssertionHash](),
 		averageTimeForBlockCreation: averageTimeForBlockCreation,
-	}, nil
+	}
+	for _, o := range opts {
+		o(m)
+	}
+	return m, nil
 }
 
 // The Start function begins two main tasks:
@@ -139,6 +199,13 @@ func (m *Manager) Start(ctx context.Context) {
 		srvlog.Error("Could not check for assertion added event")
 		return
 	}
+	_, err = retry.UntilSucceeds(ctx, func() (bool, error) {
+		return true, m.checkForAssertionConfirmed(ctx, filterer, filterOpts)
+	})
+	if err != nil {
+		srvlog.Error("Could not check for assertion confirmed event")
+		return
+	}
 
 	ticker := time.NewTicker(m.pollInterval)
 	defer ticker.Stop()
@@ -155,6 +222,10 @@ func (m *Manager) Start(ctx context.Context) {
 				continue
 			}
 			toBlock := latestBlock.Number.Uint64()
+			if rescanFrom := m.rescanFrom.Swap(nil); rescanFrom != nil && *rescanFrom < fromBlock {
+				srvlog.Info("Rescanning assertions", log.Ctx{"fromBlock": *rescanFrom})
+				fromBlock = *rescanFrom
+			}
 			if fromBlock == toBlock {
 				continue
 			}
@@ -170,13 +241,28 @@ func (m *Manager) Start(ctx context.Context) {
 				srvlog.Error("Could not check for assertion added", log.Ctx{"err": err})
 				return
 			}
+			_, err = retry.UntilSucceeds(ctx, func() (bool, error) {
+				return true, m.checkForAssertionConfirmed(ctx, filterer, filterOpts)
+			})
+			if err != nil {
+				srvlog.Error("Could not check for assertion confirmed", log.Ctx{"err": err})
+				return
+			}
 			fromBlock = toBlock
+			m.events.Prune(fromBlock)
 		case <-ctx.Done():
 			return
 		}
 	}
 }
 
+// Rescan assertions from the given block at the next poll, processing the assertions
+// created since again, such as after an RPC provider missed events. Has no effect if
+// the block has not been scanned yet.
+func (m *Manager) Rescan(fromBlock uint64) {
+	m.rescanFrom.Store(&fromBlock)
+}
+
 func (m *Manager) ForksDetected() uint64 {
 	return m.forksDetectedCount
 }
@@ -214,13 +300,18 @@ func (m *Manager) checkForAssertionAdded(
 	for it.Next() {
 		if it.Error() != nil {
 			return errors.Wrapf(
-				err,
+				it.Error(),
 				"got iterator error when scanning assertion creations from block %d to %d",
 				filterOpts.Start,
 				*filterOpts.End,
 			)
 		}
 		assertionHash := protocol.AssertionHash{Hash: it.Event.AssertionHash}
+		m.events.Publish(ctx, it.Event.Raw, &events.AssertionCreated{
+			AssertionHash:       assertionHash,
+			ParentAssertionHash: protocol.AssertionHash{Hash: it.Event.ParentAssertionHash},
+			Block:               it.Event.Raw.BlockNumber,
+		})
 
 		// Try to confirm the assertion in the background.
 		go m.keepTryingAssertionConfirmation(ctx, assertionHash)
@@ -242,6 +333,42 @@ func (m *Manager) checkForAssertionAdded(
 	return nil
 }
 
+// Filters for assertion confirmed events within a range and publishes them.
+func (m *Manager) checkForAssertionConfirmed(
+	ctx context.Context,
+	filterer *rollupgen.RollupUserLogicFilterer,
+	filterOpts *bind.FilterOpts,
+) error {
+	if m.events == nil {
+		return nil
+	}
+	it, err := filterer.FilterAssertionConfirmed(filterOpts, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err = it.Close(); err != nil {
+			srvlog.Error("Could not close filter iterator", log.Ctx{"err": err})
+		}
+	}()
+	for it.Next() {
+		if it.Error() != nil {
+			return errors.Wrapf(
+				it.Error(),
+				"got iterator error when scanning assertion confirmations from block %d",
+				filterOpts.Start,
+			)
+		}
+		m.events.Publish(ctx, it.Event.Raw, &events.AssertionConfirmed{
+			AssertionHash: protocol.AssertionHash{Hash: it.Event.AssertionHash},
+			BlockHash:     it.Event.BlockHash,
+			SendRoot:      it.Event.SendRoot,
+			Block:         it.Event.Raw.BlockNumber,
+		})
+	}
+	return nil
+}
+
 // ProcessAssertionCreationEvent by checking if we agree with its claimed state.
 // If we do not, we attempt to post a rival assertion along the fork and initiate a challenge
 // if we are configured to do so. If we have not yet caught up to the claimed state,
@@ -272,7 +399,11 @@ func (m *Manager) ProcessAssertionCreationEvent(
 	case errors.Is(err, l2stateprovider.ErrNoExecutionState):
 		// If we disagree with the execution state, we should try to post the rival
 		// assertion that we believe is correct and initiate a challenge if possible.
-		if postRivalErr := m.postRivalAssertionAndChallenge(ctx, creationInfo); postRivalErr != nil {
+		postRivalErr := m.postRivalAssertionAndChallenge(ctx, creationInfo)
+		// The diagnosis makes further calls to the chain and the state provider, so it
+		// runs in the background rather than delaying the rival or its retries.
+		go m.diagnoseDivergence(ctx, creationInfo)
+		if postRivalErr != nil {
 			return postRivalErr
 		}
 		m.assertionsProcessedCount++
@@ -302,6 +433,40 @@ func (m *Manager) ProcessAssertionCreationEvent(
 	return nil
 }
 
+// Produces a divergence report for an assertion we disagree with, if a diagnoser
+// is configured. Processing may be retried, so assertions which already have a
+// report, or are being diagnosed, are skipped.
+func (m *Manager) diagnoseDivergence(ctx context.Context, creationInfo *protocol.AssertionCreatedInfo) {
+	if m.diagnoser == nil {
+		return
+	}
+	if _, ok := m.diagnoser.DivergenceReport(creationInfo.AssertionHash); ok {
+		return
+	}
+	if !m.diagnosing.InsertIfAbsent(creationInfo.AssertionHash) {
+		return
+	}
+	defer m.diagnosing.Delete(creationInfo.AssertionHash)
+	ctx, cancel := context.WithTimeout(ctx, divergenceDiagnosisTimeout)
+	defer cancel()
+	report, err := m.diagnoser.Diagnose(ctx, creationInfo)
+	if err != nil {
+		srvlog.Error("Could not save divergence report", log.Ctx{"err": err, "assertionHash": creationInfo.AssertionHash})
+	}
+	if report == nil {
+		return
+	}
+	fields := make([]string, len(report.DivergentFields))
+	for i, f := range report.DivergentFields {
+		fields[i] = f.Field
+	}
+	srvlog.Warn("Diagnosed divergence from claimed assertion", log.Ctx{
+		"validatorName":   m.validatorName,
+		"assertionHash":   creationInfo.AssertionHash,
+		"divergentFields": strings.Join(fields, ","),
+	})
+}
+
 // Attempts to post a rival assertion to a given assertion and then attempts to
 // open a challenge on that fork in the chain if configured to do so.
 func (m *Manager) postRivalAssertionAndChallenge(
@@ -320,10 +485,21 @@ func (m *Manager) postRivalAssertionAndChallenge(
 		"batchCount":            batchCount,
 		"claimedExecutionState": fmt.Sprintf("%+v", claimedState),
 	}
+	m.alerts.Notify(ctx, alerts.NewInvalidAssertionEvent(
+		creationInfo.AssertionHash,
+		creationInfo.ParentAssertionHash,
+		batchCount,
+	))
 	if !m.canPostRivalAssertion() {
 		srvlog.Warn("Detected invalid assertion, but not configured to post a rival stake", logFields)
 		return nil
 	}
+	if !m.challengeReader.IsLeader() {
+		// Returning an error makes the caller retry, so that this instance
+		// responds to the assertion if it becomes the leader in the meantime.
+		// Posting a rival and challenging are idempotent if the leader already did so.
+		return errNotLeader
+	}
 
 	srvlog.Info("Disagreed with execution state from observed assertion", logFields)
 
@@ -407,30 +583,7 @@ func (m *Manager) maybePostRivalAssertion(
 		return option.None[protocol.Assertion](), err
 	}
 	// Post what we believe is the correct assertion that follows the ancestor we agree with.
-	staked, err := m.chain.IsStaked(ctx)
-	if err != nil {
-		return option.None[protocol.Assertion](), err
-	}
-	// If the validator is already staked, we post an assertion and move existing stake to it.
-	var assertionOpt option.Option[protocol.Assertion]
-	var postErr error
-	if staked {
-		assertionOpt, postErr = m.PostAssertionBasedOnParent(
-			ctx, latestAgreedWithAncestor, m.chain.StakeOnNewAssertion,
-		)
-	} else {
-		// Otherwise, we post a new assertion and place a new stake on it.
-		assertionOpt, postErr = m.PostAssertionBasedOnParent(
-			ctx, latestAgreedWithAncestor, m.chain.NewStakeOnNewAssertion,
-		)
-	}
-	if postErr != nil {
-		return option.None[protocol.Assertion](), postErr
-	}
-	if assertionOpt.IsSome() {
-		m.submittedAssertions.Insert(assertionOpt.Unwrap().Id().Hash)
-	}
-	return assertionOpt, nil
+	return m.stakeOnNewAssertion(ctx, latestAgreedWithAncestor, stakers.RivalStaker)
 }
 
 // Look back until we find the ancestor we agree with for the given assertion.
@@ -472,10 +625,16 @@ func (m *Manager) findLastAgreedWithAncestor(
 }
 
 func (m *Manager) keepTryingAssertionConfirmation(ctx context.Context, assertionHash protocol.AssertionHash) {
-	// Only resolve mode strategies or higher should be confirming assertions.
-	if m.challengeReader.Mode() < types.ResolveMode {
+	// Only resolve mode strategies or higher should be confirming assertions. Confirmer
+	// mode confirms assertions within its own gas budget instead.
+	if mode := m.challengeReader.Mode(); mode < types.ResolveMode || mode == types.ConfirmerMode {
+		return
+	}
+	// Assertions seen again, such as during a rescan, are already being confirmed.
+	if !m.confirming.InsertIfAbsent(assertionHash) {
 		return
 	}
+	defer m.confirming.Delete(assertionHash)
 	for {
 		ticker := time.NewTicker(m.confirmationAttemptInterval)
 		defer ticker.Stop()
@@ -483,6 +642,9 @@ func (m *Manager) keepTryingAssertionConfirmation(ctx context.Context, assertion
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
+			if !m.challengeReader.IsLeader() {
+				continue
+			}
 			if m.assertionConfirmed(ctx, assertionHash) {
 				return
 			}
@@ -555,11 +717,13 @@ func (m *Manager) assertionConfirmed(ctx context.Context, assertionHash protocol
 
 // Returns true if the manager can respond to an assertion with a challenge.
 func (m *Manager) canPostRivalAssertion() bool {
-	return m.challengeReader.Mode() >= types.DefensiveMode
+	mode := m.challengeReader.Mode()
+	return mode >= types.DefensiveMode && mode != types.ConfirmerMode
 }
 
 func (m *Manager) canPostChallenge() bool {
-	return m.challengeReader.Mode() >= types.DefensiveMode
+	mode := m.challengeReader.Mode()
+	return mode >= types.DefensiveMode && mode != types.ConfirmerMode
 }
 
 func randUint64(max uint64) (uint64, error) {
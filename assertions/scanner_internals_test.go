@@ -3,8 +3,11 @@ package assertions
 import (
 	"context"
 	"testing"
+	"time"
 
 	protocol "github.com/OffchainLabs/bold/chain-abstraction"
+	"github.com/OffchainLabs/bold/challenge-manager/types"
+	"github.com/OffchainLabs/bold/containers/threadsafe"
 	l2stateprovider "github.com/OffchainLabs/bold/layer2-state-provider"
 	"github.com/OffchainLabs/bold/solgen/go/rollupgen"
 	"github.com/OffchainLabs/bold/testing/mocks"
@@ -120,3 +123,33 @@ func TestFindLastAgreedWithAncestor(t *testing.T) {
 		assert.ErrorContains(t, err, "errored")
 	})
 }
+
+type resolveModeReader struct{}
+
+func (resolveModeReader) Mode() types.Mode     { return types.ResolveMode }
+func (resolveModeReader) MaxDelaySeconds() int { return 0 }
+func (resolveModeReader) IsLeader() bool       { return true }
+
+func TestKeepTryingAssertionConfirmation_Deduplicates(t *testing.T) {
+	ctx := context.Background()
+	assertionHash := protocol.AssertionHash{Hash: common.BytesToHash([]byte("assertion"))}
+	chain := &mocks.MockProtocol{}
+	chain.On("AssertionStatus", ctx, assertionHash).Return(protocol.AssertionConfirmed, nil)
+	manager := &Manager{
+		chain:                       chain,
+		challengeReader:             resolveModeReader{},
+		confirmationAttemptInterval: time.Millisecond,
+		confirming:                  threadsafe.NewSet[protocol.AssertionHash](),
+	}
+
+	// An assertion already being confirmed, such as when seen again during a
+	// rescan, is not confirmed twice.
+	manager.confirming.Insert(assertionHash)
+	manager.keepTryingAssertionConfirmation(ctx, assertionHash)
+	chain.AssertNotCalled(t, "AssertionStatus", ctx, assertionHash)
+
+	manager.confirming.Delete(assertionHash)
+	manager.keepTryingAssertionConfirmation(ctx, assertionHash)
+	chain.AssertNumberOfCalls(t, "AssertionStatus", 1)
+	assert.False(t, manager.confirming.Has(assertionHash))
+}
@@ -4,12 +4,15 @@
 package assertions_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"math/big"
 	"testing"
 	"time"
 
+	"github.com/OffchainLabs/bold/alerts"
 	"github.com/OffchainLabs/bold/assertions"
 	protocol "github.com/OffchainLabs/bold/chain-abstraction"
 	challengemanager "github.com/OffchainLabs/bold/challenge-manager"
@@ -318,6 +321,120 @@ func TestScanner_ProcessAssertionCreation(t *testing.T) {
 		require.Equal(t, uint64(1), scanner.AssertionsProcessed())
 		require.Equal(t, uint64(1), scanner.ChallengesSubmitted())
 	})
+	t.Run("watchtower validator alerts on invalid assertion", func(t *testing.T) {
+		ctx := context.Background()
+		createdData, err := setup.CreateTwoValidatorFork(ctx, &setup.CreateForkConfig{
+			DivergeBlockHeight: 5,
+		}, setup.WithMockOneStepProver())
+		require.NoError(t, err)
+
+		manager, err := challengemanager.New(
+			ctx,
+			createdData.Chains[1],
+			createdData.Backend,
+			createdData.HonestStateManager,
+			createdData.Addrs.Rollup,
+			challengemanager.WithMode(types.WatchTowerMode),
+			challengemanager.WithEdgeTrackerWakeInterval(100*time.Millisecond),
+		)
+		require.NoError(t, err)
+
+		buf := new(bytes.Buffer)
+		dispatcher := alerts.NewDispatcher(alerts.WithSink(alerts.NewWriterSink(buf)))
+		scanner, err := assertions.NewManager(
+			createdData.Chains[1],
+			createdData.HonestStateManager,
+			createdData.Backend,
+			manager,
+			createdData.Addrs.Rollup,
+			"",
+			time.Second,
+			time.Second,
+			createdData.HonestStateManager,
+			time.Second,
+			time.Second,
+			assertions.WithAlerts(dispatcher),
+		)
+		require.NoError(t, err)
+
+		err = scanner.ProcessAssertionCreationEvent(ctx, createdData.Leaf2.Id())
+		require.NoError(t, err)
+		require.Equal(t, uint64(0), scanner.ChallengesSubmitted())
+
+		dispatcher.Flush()
+		ev := &alerts.Event{}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), ev))
+		require.Equal(t, alerts.InvalidAssertion, ev.Kind)
+		require.Equal(t, createdData.Leaf2.Id().Hash.Hex(), ev.Key)
+	})
+}
+
+// Blocks reading local states until released.
+type blockingHistory struct {
+	assertions.HistorySource
+	release chan struct{}
+}
+
+func (h *blockingHistory) ExecutionStateAfterBatchCount(ctx context.Context, batchCount uint64) (*protocol.ExecutionState, error) {
+	select {
+	case <-h.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return h.HistorySource.ExecutionStateAfterBatchCount(ctx, batchCount)
+}
+
+func TestScanner_DiagnosisDoesNotDelayRival(t *testing.T) {
+	ctx := context.Background()
+	createdData, err := setup.CreateTwoValidatorFork(ctx, &setup.CreateForkConfig{
+		DivergeBlockHeight: 5,
+	}, setup.WithMockOneStepProver())
+	require.NoError(t, err)
+
+	manager, err := challengemanager.New(
+		ctx,
+		createdData.Chains[1],
+		createdData.Backend,
+		createdData.HonestStateManager,
+		createdData.Addrs.Rollup,
+		challengemanager.WithMode(types.MakeMode),
+		challengemanager.WithEdgeTrackerWakeInterval(100*time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	history, ok := createdData.HonestStateManager.(assertions.HistorySource)
+	require.True(t, ok)
+	slowHistory := &blockingHistory{HistorySource: history, release: make(chan struct{})}
+	diagnoser, err := assertions.NewDiagnoser(slowHistory)
+	require.NoError(t, err)
+	scanner, err := assertions.NewManager(
+		createdData.Chains[1],
+		createdData.HonestStateManager,
+		createdData.Backend,
+		manager,
+		createdData.Addrs.Rollup,
+		"",
+		time.Second,
+		time.Second,
+		createdData.HonestStateManager,
+		time.Second,
+		time.Second,
+		assertions.WithDivergenceDiagnoser(diagnoser),
+	)
+	require.NoError(t, err)
+
+	// The rival is posted while the diagnosis is still blocked.
+	err = scanner.ProcessAssertionCreationEvent(ctx, createdData.Leaf2.Id())
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), scanner.ChallengesSubmitted())
+	_, ok = diagnoser.DivergenceReport(createdData.Leaf2.Id().Hash)
+	require.False(t, ok)
+
+	close(slowHistory.release)
+	require.Eventually(t, func() bool {
+		_, ok := diagnoser.DivergenceReport(createdData.Leaf2.Id().Hash)
+		return ok
+	}, time.Second*5, time.Millisecond*10)
 }
 
 func setupChallengeManager(t *testing.T) (*challengemanager.Manager, *mocks.MockProtocol, *mocks.MockStateManager, *setup.ChainSetup) {
@@ -48,6 +48,14 @@ type Protocol interface {
 	AssertionChain
 }
 
+// MultiStakerChain is an assertion chain controlling several staker keys,
+// which can act on behalf of any of them.
+type MultiStakerChain interface {
+	AssertionChain
+	StakerAddresses() []common.Address
+	ForStaker(addr common.Address) (AssertionChain, error)
+}
+
 type AssertionStatus uint8
 
 const (
@@ -66,6 +74,9 @@ type Assertion interface {
 	PrevId(ctx context.Context) (AssertionHash, error)
 	HasSecondChild() (bool, error)
 	CreatedAtBlock() (uint64, error)
+	// FirstChildCreationBlock returns the block at which the first child of the
+	// assertion was created, or zero if it has no children.
+	FirstChildCreationBlock() (uint64, error)
 }
 
 // AssertionCreatedInfo from an event creation.
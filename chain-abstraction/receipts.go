@@ -0,0 +1,27 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package protocol
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+type receiptObserverKey struct{}
+
+// WithReceiptObserver returns a context under which the receipt of every transaction
+// mined by a protocol implementation is passed to the observer, including receipts
+// of transactions that reverted.
+func WithReceiptObserver(ctx context.Context, observe func(*types.Receipt)) context.Context {
+	return context.WithValue(ctx, receiptObserverKey{}, observe)
+}
+
+// ObserveReceipt passes the receipt of a mined transaction to the observer of the
+// context, if any.
+func ObserveReceipt(ctx context.Context, receipt *types.Receipt) {
+	if observe, ok := ctx.Value(receiptObserverKey{}).(func(*types.Receipt)); ok && receipt != nil {
+		observe(receipt)
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package solimpl
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/OffchainLabs/bold/accounting"
+	protocol "github.com/OffchainLabs/bold/chain-abstraction"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// WithLedger records the gas of the transactions made by the assertion chain and its
+// challenge manager, the mini-stakes locked in edges, and the bonds posted on
+// assertions in the given ledger.
+func WithLedger(ledger *accounting.Ledger) Opt {
+	return func(a *AssertionChain) {
+		a.ledger = ledger
+	}
+}
+
+// The challenge a transaction was made in.
+type txAttribution struct {
+	method         string
+	assertionHash  common.Hash
+	challengeLevel protocol.ChallengeLevel
+	edgeId         common.Hash
+}
+
+// Records the gas paid for a mined transaction, if a ledger is kept.
+func (a *AssertionChain) recordGas(receipt *types.Receipt, attr txAttribution) {
+	if a.ledger == nil || receipt == nil {
+		return
+	}
+	cost := new(big.Int)
+	if receipt.EffectiveGasPrice != nil {
+		cost.Mul(receipt.EffectiveGasPrice, new(big.Int).SetUint64(receipt.GasUsed))
+	}
+	a.ledger.Record(a.newEntry(accounting.GasSpent, receipt, attr, cost))
+}
+
+// Records the funds moved by a mined transaction, if a ledger is kept.
+func (a *AssertionChain) recordFunds(kind accounting.Kind, receipt *types.Receipt, attr txAttribution, amount *big.Int) {
+	if a.ledger == nil || receipt == nil || amount == nil || amount.Sign() == 0 {
+		return
+	}
+	a.ledger.Record(a.newEntry(kind, receipt, attr, new(big.Int).Set(amount)))
+}
+
+func (a *AssertionChain) newEntry(kind accounting.Kind, receipt *types.Receipt, attr txAttribution, amount *big.Int) *accounting.Entry {
+	e := &accounting.Entry{
+		Kind:           kind,
+		AssertionHash:  attr.assertionHash,
+		ChallengeLevel: attr.challengeLevel.Uint8(),
+		EdgeId:         attr.edgeId,
+		Method:         attr.method,
+		TxHash:         receipt.TxHash,
+		Amount:         amount,
+	}
+	if kind == accounting.GasSpent {
+		e.GasUsed = receipt.GasUsed
+	}
+	if receipt.BlockNumber != nil {
+		e.BlockNumber = receipt.BlockNumber.Uint64()
+	}
+	return e
+}
+
+// Records the gas paid for a transaction acting on an edge, attributed to the
+// challenge the edge is in, if a ledger is kept.
+func (e *specEdge) recordGas(ctx context.Context, receipt *types.Receipt, method string) {
+	if e.manager.assertionChain.ledger == nil || receipt == nil {
+		return
+	}
+	// An edge's challenge is only looked up once the transaction is mined, and the
+	// gas is recorded even if the lookup fails, just not attributed to a challenge.
+	assertionHash, _ := e.AssertionHash(ctx)
+	e.manager.assertionChain.recordGas(receipt, txAttribution{
+		method:         method,
+		assertionHash:  assertionHash.Hash,
+		challengeLevel: e.GetChallengeLevel(),
+		edgeId:         e.id,
+	})
+}
+
+// Records the gas paid to create a level zero edge and the mini-stake it locked, if
+// a ledger is kept. No stake is locked if staking is disabled in the challenge manager,
+// or if the transaction reverted.
+func (cm *specChallengeManager) recordLevelZeroEdge(ctx context.Context, receipt *types.Receipt, attr txAttribution, reverted bool) {
+	chain := cm.assertionChain
+	if chain.ledger == nil {
+		return
+	}
+	chain.recordGas(receipt, attr)
+	if reverted {
+		return
+	}
+	opts := &bind.CallOpts{Context: ctx}
+	stakeToken, err := cm.caller.StakeToken(opts)
+	if err != nil || stakeToken == (common.Address{}) {
+		return
+	}
+	stakeAmount, err := cm.caller.StakeAmount(opts)
+	if err != nil {
+		return
+	}
+	chain.recordFunds(accounting.MiniStakeLocked, receipt, attr, stakeAmount)
+}
+
+// Records the bond posted by staking on a new assertion, which is the increase of
+// our stake in the rollup, if a ledger is kept.
+func (a *AssertionChain) recordBond(receipt *types.Receipt, attr txAttribution) {
+	if a.ledger == nil || receipt == nil {
+		return
+	}
+	for _, l := range receipt.Logs {
+		stakeUpdated, err := a.rollup.ParseUserStakeUpdated(*l)
+		if err != nil || stakeUpdated.User != a.txOpts.From {
+			continue
+		}
+		a.recordFunds(accounting.BondPosted, receipt, attr, new(big.Int).Sub(stakeUpdated.FinalBalance, stakeUpdated.InitialBalance))
+	}
+}
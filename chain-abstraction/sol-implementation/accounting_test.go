@@ -0,0 +1,162 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package solimpl_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/OffchainLabs/bold/accounting"
+	protocol "github.com/OffchainLabs/bold/chain-abstraction"
+	solimpl "github.com/OffchainLabs/bold/chain-abstraction/sol-implementation"
+	"github.com/OffchainLabs/bold/containers/option"
+	l2stateprovider "github.com/OffchainLabs/bold/layer2-state-provider"
+	challenge_testing "github.com/OffchainLabs/bold/testing"
+	"github.com/OffchainLabs/bold/testing/setup"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssertionChain_WithLedger(t *testing.T) {
+	ctx := context.Background()
+	createdData, err := setup.CreateTwoValidatorFork(ctx, &setup.CreateForkConfig{}, setup.WithMockOneStepProver())
+	require.NoError(t, err)
+	genesisHash, err := createdData.Chains[0].GenesisAssertionHash(ctx)
+	require.NoError(t, err)
+
+	ledger, err := accounting.NewLedger()
+	require.NoError(t, err)
+	chain, err := solimpl.NewAssertionChain(
+		ctx,
+		createdData.Addrs.Rollup,
+		createdData.Accounts[1].TxOpts,
+		createdData.Backend,
+		solimpl.WithLedger(ledger),
+	)
+	require.NoError(t, err)
+	challengeManager, err := chain.SpecChallengeManager(ctx)
+	require.NoError(t, err)
+
+	// Creating a level zero edge costs gas and locks a mini-stake.
+	req := &l2stateprovider.HistoryCommitmentRequest{
+		WasmModuleRoot:              common.Hash{},
+		FromBatch:                   0,
+		ToBatch:                     1,
+		UpperChallengeOriginHeights: []l2stateprovider.Height{},
+		FromHeight:                  0,
+		UpToHeight:                  option.Some(l2stateprovider.Height(0)),
+	}
+	start, err := createdData.HonestStateManager.HistoryCommitment(ctx, req)
+	require.NoError(t, err)
+	req.UpToHeight = option.Some(l2stateprovider.Height(challenge_testing.LevelZeroBlockEdgeHeight))
+	end, err := createdData.HonestStateManager.HistoryCommitment(ctx, req)
+	require.NoError(t, err)
+	prefixProof, err := createdData.HonestStateManager.PrefixProof(ctx, req, l2stateprovider.Height(0))
+	require.NoError(t, err)
+	edge, err := challengeManager.AddBlockChallengeLevelZeroEdge(ctx, createdData.Leaf1, start, end, prefixProof)
+	require.NoError(t, err)
+
+	// Bisecting it once rivaled costs gas, attributed to the challenge the edge is in.
+	evilChallengeManager, err := createdData.Chains[1].SpecChallengeManager(ctx)
+	require.NoError(t, err)
+	req.UpToHeight = option.Some(l2stateprovider.Height(0))
+	evilStart, err := createdData.EvilStateManager.HistoryCommitment(ctx, req)
+	require.NoError(t, err)
+	req.UpToHeight = option.Some(l2stateprovider.Height(challenge_testing.LevelZeroBlockEdgeHeight))
+	evilEnd, err := createdData.EvilStateManager.HistoryCommitment(ctx, req)
+	require.NoError(t, err)
+	evilPrefixProof, err := createdData.EvilStateManager.PrefixProof(ctx, req, l2stateprovider.Height(0))
+	require.NoError(t, err)
+	_, err = evilChallengeManager.AddBlockChallengeLevelZeroEdge(ctx, createdData.Leaf2, evilStart, evilEnd, evilPrefixProof)
+	require.NoError(t, err)
+	req.UpToHeight = option.Some(l2stateprovider.Height(challenge_testing.LevelZeroBlockEdgeHeight / 2))
+	bisectCommit, err := createdData.HonestStateManager.HistoryCommitment(ctx, req)
+	require.NoError(t, err)
+	req.UpToHeight = option.Some(l2stateprovider.Height(challenge_testing.LevelZeroBlockEdgeHeight))
+	bisectProof, err := createdData.HonestStateManager.PrefixProof(ctx, req, challenge_testing.LevelZeroBlockEdgeHeight/2)
+	require.NoError(t, err)
+	_, _, err = edge.Bisect(ctx, bisectCommit.Merkle, bisectProof)
+	require.NoError(t, err)
+
+	costs, ok := ledger.ChallengeCost(genesisHash)
+	require.True(t, ok)
+	require.Len(t, costs.Entries, 3)
+	for _, e := range costs.Entries {
+		require.Equal(t, edge.Id().Hash, e.EdgeId)
+		require.Equal(t, protocol.NewBlockChallengeLevel().Uint8(), e.ChallengeLevel)
+	}
+	require.Equal(t, accounting.MiniStakeLocked, costs.Entries[1].Kind)
+	require.Equal(t, "bisectEdge", costs.Entries[2].Method)
+	require.Equal(t, uint64(2), costs.Transactions)
+	require.NotZero(t, costs.GasUsed)
+	require.Equal(t, 1, costs.GasCost.Sign())
+	require.Equal(t, int64(1), costs.MiniStakesLocked.Int64())
+
+	// Staking on a new assertion posts a bond, attributed to its parent.
+	staker, err := solimpl.NewAssertionChain(
+		ctx,
+		createdData.Addrs.Rollup,
+		createdData.Accounts[3].TxOpts,
+		createdData.Backend,
+		solimpl.WithLedger(ledger),
+	)
+	require.NoError(t, err)
+	genesisInfo, err := staker.ReadAssertionCreationInfo(ctx, protocol.AssertionHash{Hash: genesisHash})
+	require.NoError(t, err)
+	_, err = staker.NewStakeOnNewAssertion(ctx, genesisInfo, &protocol.ExecutionState{
+		GlobalState: protocol.GoGlobalState{
+			BlockHash: common.BytesToHash([]byte("another hash")),
+			Batch:     1,
+		},
+		MachineStatus: protocol.MachineStatusFinished,
+	})
+	require.NoError(t, err)
+	costs, ok = ledger.ChallengeCost(genesisHash)
+	require.True(t, ok)
+	require.Equal(t, uint64(3), costs.Transactions)
+	require.Equal(t, genesisInfo.RequiredStake, costs.BondsPosted)
+}
+
+// Sends transactions without estimating their gas, so that ones that revert are mined.
+type unestimatedBackend struct {
+	*backends.SimulatedBackend
+}
+
+func (*unestimatedBackend) EstimateGas(context.Context, ethereum.CallMsg) (uint64, error) {
+	return 1_000_000, nil
+}
+
+func TestAssertionChain_WithLedger_RecordsRevertedTransactions(t *testing.T) {
+	ctx := context.Background()
+	createdData, err := setup.CreateTwoValidatorFork(ctx, &setup.CreateForkConfig{}, setup.WithMockOneStepProver())
+	require.NoError(t, err)
+	genesisHash, err := createdData.Chains[0].GenesisAssertionHash(ctx)
+	require.NoError(t, err)
+
+	ledger, err := accounting.NewLedger()
+	require.NoError(t, err)
+	chain, err := solimpl.NewAssertionChain(
+		ctx,
+		createdData.Addrs.Rollup,
+		createdData.Accounts[1].TxOpts,
+		&unestimatedBackend{createdData.Backend},
+		solimpl.WithLedger(ledger),
+	)
+	require.NoError(t, err)
+
+	// The assertion cannot be confirmed before its confirm period has passed, yet the
+	// gas spent on trying is still recorded.
+	err = chain.ConfirmAssertionByTime(ctx, createdData.Leaf1.Id())
+	require.ErrorContains(t, err, "transaction errored")
+	costs, ok := ledger.ChallengeCost(genesisHash)
+	require.True(t, ok)
+	require.Len(t, costs.Entries, 1)
+	require.Equal(t, accounting.GasSpent, costs.Entries[0].Kind)
+	require.Equal(t, "confirmAssertion", costs.Entries[0].Method)
+	require.NotEqual(t, common.Hash{}, costs.Entries[0].TxHash)
+	require.Equal(t, uint64(1), costs.Transactions)
+	require.NotZero(t, costs.GasUsed)
+}
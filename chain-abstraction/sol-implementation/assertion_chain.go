@@ -13,6 +13,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/OffchainLabs/bold/accounting"
 	protocol "github.com/OffchainLabs/bold/chain-abstraction"
 	"github.com/OffchainLabs/bold/containers/threadsafe"
 	"github.com/OffchainLabs/bold/solgen/go/bridgegen"
@@ -63,8 +64,10 @@ type AssertionChain struct {
 	rollup                                   *rollupgen.RollupCore
 	userLogic                                *rollupgen.RollupUserLogic
 	txOpts                                   *bind.TransactOpts
+	stakers                                  []*bind.TransactOpts
 	rollupAddr                               common.Address
 	confirmedChallengesByParentAssertionHash *threadsafe.Set[protocol.AssertionHash] // TODO: Use an LRU cache instead.
+	ledger                                   *accounting.Ledger
 }
 
 type Opt func(*AssertionChain)
@@ -75,6 +78,16 @@ func WithTrackedContractBackend() Opt {
 	}
 }
 
+// WithStakerIdentities gives the assertion chain additional staker keys besides the
+// one it is created with, which can be acted on behalf of via ForStaker.
+func WithStakerIdentities(txOpts ...*bind.TransactOpts) Opt {
+	return func(a *AssertionChain) {
+		for _, opts := range txOpts {
+			a.stakers = append(a.stakers, copyTxOpts(opts))
+		}
+	}
+}
+
 // NewAssertionChain instantiates an assertion chain
 // instance from a chain backend and provided options.
 func NewAssertionChain(
@@ -90,6 +103,7 @@ func NewAssertionChain(
 	chain := &AssertionChain{
 		backend:                                  backend,
 		txOpts:                                   copiedOpts,
+		stakers:                                  []*bind.TransactOpts{copiedOpts},
 		rollupAddr:                               rollupAddr,
 		confirmedChallengesByParentAssertionHash: threadsafe.NewSet[protocol.AssertionHash](),
 	}
@@ -113,6 +127,34 @@ func NewAssertionChain(
 	return chain, nil
 }
 
+// StakerAddress returns the address of the key this chain sends transactions from.
+func (a *AssertionChain) StakerAddress() common.Address {
+	return a.txOpts.From
+}
+
+// StakerAddresses returns the addresses of all staker keys of the chain, starting
+// with the key it was created with.
+func (a *AssertionChain) StakerAddresses() []common.Address {
+	addrs := make([]common.Address, len(a.stakers))
+	for i, opts := range a.stakers {
+		addrs[i] = opts.From
+	}
+	return addrs
+}
+
+// ForStaker returns a view of the assertion chain which sends all transactions,
+// including those of its challenge manager, from the given staker key.
+func (a *AssertionChain) ForStaker(addr common.Address) (protocol.AssertionChain, error) {
+	for _, opts := range a.stakers {
+		if opts.From == addr {
+			view := *a
+			view.txOpts = opts
+			return &view, nil
+		}
+	}
+	return nil, fmt.Errorf("no staker key for address %#x", addr)
+}
+
 func (a *AssertionChain) Backend() protocol.ChainBackend {
 	return a.backend
 }
@@ -299,6 +341,11 @@ func (a *AssertionChain) createAndStakeOnAssertion(
 			computedHash,
 		)
 	})
+	attr := txAttribution{
+		method:        "stakeOnNewAssertion",
+		assertionHash: parentAssertionCreationInfo.AssertionHash,
+	}
+	a.recordGas(receipt, attr)
 	if createErr := handleCreateAssertionError(err, postState.GlobalState.BlockHash); createErr != nil {
 		return nil, fmt.Errorf("could not create assertion: %w", createErr)
 	}
@@ -318,6 +365,7 @@ func (a *AssertionChain) createAndStakeOnAssertion(
 	if !found {
 		return nil, errors.New("could not find assertion created event in logs")
 	}
+	a.recordBond(receipt, attr)
 	return a.GetAssertion(ctx, protocol.AssertionHash{Hash: assertionCreated.AssertionHash})
 }
 
@@ -388,6 +436,11 @@ func (a *AssertionChain) ConfirmAssertionByChallengeWinner(
 			creationInfo.AfterInboxBatchAcc,
 		)
 	})
+	a.recordGas(receipt, txAttribution{
+		method:        "confirmAssertion",
+		assertionHash: creationInfo.ParentAssertionHash,
+		edgeId:        winningEdgeId.Hash,
+	})
 	if err != nil {
 		return err
 	}
@@ -706,3 +706,52 @@ func submitBatch(
 		"message count after posting to bridge stub did not increase",
 	)
 }
+
+func TestAssertionChain_ForStaker(t *testing.T) {
+	ctx := context.Background()
+	cfg, err := setup.ChainsWithEdgeChallengeManager()
+	require.NoError(t, err)
+	primary := cfg.Accounts[1].TxOpts
+	secondary := cfg.Accounts[3].TxOpts
+	chain, err := solimpl.NewAssertionChain(
+		ctx,
+		cfg.Addrs.Rollup,
+		primary,
+		cfg.Backend,
+		solimpl.WithStakerIdentities(secondary),
+	)
+	require.NoError(t, err)
+	require.Equal(t, []common.Address{primary.From, secondary.From}, chain.StakerAddresses())
+
+	_, err = chain.ForStaker(cfg.Accounts[2].TxOpts.From)
+	require.ErrorContains(t, err, "no staker key")
+
+	genesisHash, err := chain.GenesisAssertionHash(ctx)
+	require.NoError(t, err)
+	genesisInfo, err := chain.ReadAssertionCreationInfo(ctx, protocol.AssertionHash{Hash: genesisHash})
+	require.NoError(t, err)
+	for i := uint64(0); i < 100; i++ {
+		cfg.Backend.Commit()
+	}
+
+	// Staking through the secondary view stakes with the secondary key only.
+	view, err := chain.ForStaker(secondary.From)
+	require.NoError(t, err)
+	require.Equal(t, secondary.From, view.(*solimpl.AssertionChain).StakerAddress())
+	postState := &protocol.ExecutionState{
+		GlobalState: protocol.GoGlobalState{
+			BlockHash: common.BytesToHash([]byte("foo")),
+			Batch:     1,
+		},
+		MachineStatus: protocol.MachineStatusFinished,
+	}
+	_, err = view.NewStakeOnNewAssertion(ctx, genesisInfo, postState)
+	require.NoError(t, err)
+
+	staked, err := view.IsStaked(ctx)
+	require.NoError(t, err)
+	require.True(t, staked)
+	staked, err = chain.IsStaked(ctx)
+	require.NoError(t, err)
+	require.False(t, staked)
+}
@@ -217,9 +217,10 @@ func (e *specEdge) Bisect(
 		return lower, upper, nil
 	}
 
-	_, err = e.manager.assertionChain.transact(ctx, e.manager.backend, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+	receipt, err := e.manager.assertionChain.transact(ctx, e.manager.backend, func(opts *bind.TransactOpts) (*types.Transaction, error) {
 		return e.manager.writer.BisectEdge(opts, e.id, prefixHistoryRoot, prefixProof)
 	})
+	e.recordGas(ctx, receipt, "bisectEdge")
 	if err != nil {
 		return nil, nil, err
 	}
@@ -291,7 +292,7 @@ func (e *specEdge) ConfirmByTimer(ctx context.Context, ancestorIds []protocol.Ed
 	for i, r := range ancestorIds {
 		ancestors[i] = r.Hash
 	}
-	_, err = e.manager.assertionChain.transact(ctx, e.manager.backend, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+	receipt, err := e.manager.assertionChain.transact(ctx, e.manager.backend, func(opts *bind.TransactOpts) (*types.Transaction, error) {
 		return e.manager.writer.ConfirmEdgeByTime(opts, e.id, ancestors, challengeV2gen.ExecutionStateData{
 			ExecutionState: challengeV2gen.ExecutionState{
 				GlobalState:   challengeV2gen.GlobalState(assertionCreation.AfterState.GlobalState),
@@ -301,6 +302,7 @@ func (e *specEdge) ConfirmByTimer(ctx context.Context, ancestorIds []protocol.Ed
 			InboxAcc:          assertionCreation.AfterInboxBatchAcc,
 		})
 	})
+	e.recordGas(ctx, receipt, "confirmEdgeByTime")
 	ancestorStrings := make([]string, len(ancestorIds))
 	for i, r := range ancestorIds {
 		ancestorStrings[i] = containers.Trunc(r.Hash[:])
@@ -323,9 +325,10 @@ func (e *specEdge) ConfirmByChildren(ctx context.Context) error {
 		return nil
 	}
 
-	_, err = e.manager.assertionChain.transact(ctx, e.manager.backend, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+	receipt, err := e.manager.assertionChain.transact(ctx, e.manager.backend, func(opts *bind.TransactOpts) (*types.Transaction, error) {
 		return e.manager.writer.ConfirmEdgeByChildren(opts, e.id)
 	})
+	e.recordGas(ctx, receipt, "confirmEdgeByChildren")
 	return err
 }
 
@@ -338,9 +341,10 @@ func (e *specEdge) ConfirmByClaim(ctx context.Context, claimId protocol.ClaimId)
 		return nil
 	}
 
-	_, err = e.manager.assertionChain.transact(ctx, e.manager.backend, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+	receipt, err := e.manager.assertionChain.transact(ctx, e.manager.backend, func(opts *bind.TransactOpts) (*types.Transaction, error) {
 		return e.manager.writer.ConfirmEdgeByClaim(opts, e.id, claimId)
 	})
+	e.recordGas(ctx, receipt, "confirmEdgeByClaim")
 	return err
 }
 
@@ -634,7 +638,7 @@ func (cm *specChallengeManager) ConfirmEdgeByOneStepProof(
 			result,
 		)
 	}
-	if _, err = cm.assertionChain.transact(
+	receipt, err := cm.assertionChain.transact(
 		ctx,
 		cm.assertionChain.backend,
 		func(opts *bind.TransactOpts) (*types.Transaction, error) {
@@ -655,7 +659,14 @@ func (cm *specChallengeManager) ConfirmEdgeByOneStepProof(
 				pre,
 				post,
 			)
-		}); err != nil {
+		})
+	cm.assertionChain.recordGas(receipt, txAttribution{
+		method:         "confirmEdgeByOneStepProof",
+		assertionHash:  assertionHash.Hash,
+		challengeLevel: edge.Unwrap().GetChallengeLevel(),
+		edgeId:         tentativeWinnerId.Hash,
+	})
+	if err != nil {
 		return errors.Wrapf(
 			err,
 			"could not confirm one step proof at machine step %d: before hash %#x, computed after hash %#x, actual expected after hash %#x",
@@ -665,8 +676,7 @@ func (cm *specChallengeManager) ConfirmEdgeByOneStepProof(
 			result,
 		)
 	}
-
-	return err
+	return nil
 }
 
 // Like abi.NewType but panics if it errors for use in constants
@@ -826,6 +836,11 @@ func (cm *specChallengeManager) AddBlockChallengeLevelZeroEdge(
 		)
 	})
 	if err != nil {
+		cm.recordLevelZeroEdge(ctx, receipt, txAttribution{
+			method:         "createLayerZeroEdge",
+			assertionHash:  assertionCreation.ParentAssertionHash,
+			challengeLevel: protocol.NewBlockChallengeLevel(),
+		}, true)
 		return nil, fmt.Errorf("could not create root block challenge edge: %w", err)
 	}
 	if len(receipt.Logs) == 0 {
@@ -844,6 +859,12 @@ func (cm *specChallengeManager) AddBlockChallengeLevelZeroEdge(
 	if !found {
 		return nil, errors.New("could not find edge added event in logs")
 	}
+	cm.recordLevelZeroEdge(ctx, receipt, txAttribution{
+		method:         "createLayerZeroEdge",
+		assertionHash:  assertionCreation.ParentAssertionHash,
+		challengeLevel: protocol.NewBlockChallengeLevel(),
+		edgeId:         edgeAdded.EdgeId,
+	}, false)
 	someLevelZeroEdge, err = cm.GetEdge(ctx, protocol.EdgeId{Hash: edgeAdded.EdgeId})
 	if err != nil {
 		return nil, errors.Wrapf(err, "could not get created edge by id: %#x", edgeAdded.EdgeId)
@@ -921,7 +942,7 @@ func (cm *specChallengeManager) AddSubChallengeLevelZeroEdge(
 	if err != nil {
 		return nil, err
 	}
-	_, err = cm.assertionChain.transact(ctx, cm.backend, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+	receipt, err := cm.assertionChain.transact(ctx, cm.backend, func(opts *bind.TransactOpts) (*types.Transaction, error) {
 		return cm.writer.CreateLayerZeroEdge(
 			opts,
 			challengeV2gen.CreateEdgeArgs{
@@ -934,6 +955,17 @@ func (cm *specChallengeManager) AddSubChallengeLevelZeroEdge(
 			},
 		)
 	})
+	if cm.assertionChain.ledger != nil && receipt != nil {
+		// The gas and stake are recorded even if the challenge cannot be looked up,
+		// just not attributed to it.
+		assertionHash, _ := challengedEdge.AssertionHash(ctx)
+		cm.recordLevelZeroEdge(ctx, receipt, txAttribution{
+			method:         "createLayerZeroEdge",
+			assertionHash:  assertionHash.Hash,
+			challengeLevel: subChalTyp,
+			edgeId:         edgeId.Hash,
+		}, err != nil)
+	}
 	if err != nil {
 		return nil, err
 	}
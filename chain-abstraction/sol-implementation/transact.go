@@ -7,6 +7,7 @@ import (
 	"context"
 	"math/big"
 
+	protocol "github.com/OffchainLabs/bold/chain-abstraction"
 	"github.com/OffchainLabs/bold/containers"
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
@@ -27,7 +28,8 @@ type ChainCommitter interface {
 // returning. This function additionally waits for the transaction to complete and returns
 // an optional transaction receipt. It returns an error if the
 // transaction had a non-successful status on-chain, or if the execution of the callback
-// errored directly.
+// errored directly. The receipt of a transaction that was mined but reverted is returned
+// along with the error, so that the gas it spent can still be accounted for.
 func (a *AssertionChain) transact(
 	ctx context.Context,
 	backend ChainBackend,
@@ -75,6 +77,7 @@ func (a *AssertionChain) transact(
 	if err != nil {
 		return nil, err
 	}
+	protocol.ObserveReceipt(ctx, receipt)
 	if receipt.Status != types.ReceiptStatusSuccessful {
 		callMsg := ethereum.CallMsg{
 			From:       opts.From,
@@ -86,7 +89,7 @@ func (a *AssertionChain) transact(
 			AccessList: tx.AccessList(),
 		}
 		if _, err := backend.CallContract(ctx, callMsg, nil); err != nil {
-			return nil, errors.Wrap(err, "transaction errored")
+			return receipt, errors.Wrap(err, "transaction errored")
 		}
 	}
 	return receipt, nil
@@ -88,6 +88,14 @@ func (a *Assertion) CreatedAtBlock() (uint64, error) {
 	return inner.CreatedAtBlock, nil
 }
 
+func (a *Assertion) FirstChildCreationBlock() (uint64, error) {
+	inner, err := a.inner()
+	if err != nil {
+		return 0, err
+	}
+	return inner.FirstChildBlock, nil
+}
+
 type honestEdge struct {
 	protocol.SpecEdge
 }
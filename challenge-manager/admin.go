@@ -0,0 +1,148 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package challengemanager
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/OffchainLabs/bold/api"
+	protocol "github.com/OffchainLabs/bold/chain-abstraction"
+	"github.com/OffchainLabs/bold/challenge-manager/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/pkg/errors"
+)
+
+// ErrMovesPaused is returned when a challenge is requested of a challenge manager
+// whose moves were paused by an operator.
+var ErrMovesPaused = errors.New("challenge moves are paused")
+
+// WithAdminToken enables the admin endpoints of the API, authenticated with the given
+// bearer token, which operators use to change the challenge manager at runtime.
+func WithAdminToken(token string) Opt {
+	return func(val *Manager) {
+		val.adminToken = token
+	}
+}
+
+// SetMode switches the mode of the challenge manager at runtime, keeping all of its
+// in-memory challenge state. Switching to a mode which monitors challenges starts
+// watching them if it was not already. Switching to confirmer mode starts confirming
+// honest edges and the assertions created from then on, and leaving it stops doing so.
+func (m *Manager) SetMode(mode types.Mode) error {
+	current := m.Mode()
+	if mode > types.ConfirmerMode {
+		return fmt.Errorf("unknown mode %d", mode)
+	}
+	if mode == types.ConfirmerMode {
+		m.lifecycleLock.Lock()
+		err := m.initConfirmer()
+		m.lifecycleLock.Unlock()
+		if err != nil {
+			return errors.Wrap(err, "could not create confirmer")
+		}
+	}
+	m.mode.Store(uint32(mode))
+	srvlog.Info("Switched mode", log.Ctx{
+		"validatorName": m.name,
+		"from":          current,
+		"to":            mode,
+	})
+	if monitorsChallenges(mode) {
+		m.startChallengeRoutines()
+	} else {
+		m.syncConfirmer()
+	}
+	return nil
+}
+
+// SetMovesPaused pauses or resumes moves in challenges, including opening them.
+// Edge trackers keep running while paused, so that they act as soon as resumed.
+func (m *Manager) SetMovesPaused(paused bool) {
+	m.movesPaused.Store(paused)
+}
+
+// MovesAllowed returns false while moves are paused, or in watchtower or confirmer mode.
+func (m *Manager) MovesAllowed() bool {
+	mode := m.Mode()
+	return !m.movesPaused.Load() && mode != types.WatchTowerMode && mode != types.ConfirmerMode
+}
+
+// SetPostingPaused pauses or resumes posting new assertions.
+func (m *Manager) SetPostingPaused(paused bool) {
+	m.assertionManager.SetPostingPaused(paused)
+}
+
+// SetPostingInterval changes how often new assertions are posted.
+func (m *Manager) SetPostingInterval(d time.Duration) error {
+	return m.assertionManager.SetPostingInterval(d)
+}
+
+// Rescan assertions and edge events from the given block, such as after an RPC
+// provider missed events.
+func (m *Manager) Rescan(fromBlock uint64) {
+	m.assertionManager.Rescan(fromBlock)
+	m.watcher.Rescan(fromBlock)
+}
+
+// ForceTrackEdge spawns an edge tracker for an honest edge, even if the edge was
+// tracked before and its tracker exited. It is a no-op if a tracker is running.
+func (m *Manager) ForceTrackEdge(ctx context.Context, edgeId common.Hash) error {
+	if m.stopping.Load() {
+		return ErrShuttingDown
+	}
+	if m.Mode() == types.ConfirmerMode {
+		return errors.New("edges are not tracked in confirmer mode")
+	}
+	id := protocol.EdgeId{Hash: edgeId}
+	if m.activeTrackers.Has(id) {
+		return nil
+	}
+	chalManager, err := m.chain.SpecChallengeManager(ctx)
+	if err != nil {
+		return err
+	}
+	edgeOpt, err := chalManager.GetEdge(ctx, id)
+	if err != nil {
+		return err
+	}
+	if edgeOpt.IsNone() {
+		return fmt.Errorf("no edge found with id %#x", edgeId)
+	}
+	edge := edgeOpt.Unwrap()
+	// Adding an edge the watcher missed also determines whether it is honest.
+	if err := m.watcher.AddEdge(ctx, edge); err != nil {
+		return errors.Wrap(err, "could not add edge to watcher")
+	}
+	if !m.isHonestEdge(id) {
+		return fmt.Errorf("edge %#x is not honest", edgeId)
+	}
+	// A tracker spawned for the edge in the meantime, such as by the watcher or
+	// another call, is kept rather than replaced.
+	if !m.untrackExitedEdge(id) {
+		return nil
+	}
+	return m.TrackEdge(ctx, edge)
+}
+
+func (m *Manager) isHonestEdge(id protocol.EdgeId) bool {
+	for _, edge := range m.watcher.GetHonestEdges() {
+		if edge.Id() == id {
+			return true
+		}
+	}
+	return false
+}
+
+// AdminStatus returns the settings operators can change at runtime.
+func (m *Manager) AdminStatus() *api.AdminStatus {
+	return &api.AdminStatus{
+		Mode:            m.Mode().String(),
+		PostingPaused:   m.assertionManager.PostingPaused(),
+		MovesPaused:     m.movesPaused.Load(),
+		PostingInterval: m.assertionManager.PostingInterval().String(),
+	}
+}
@@ -0,0 +1,325 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package watcher
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"sync"
+
+	protocol "github.com/OffchainLabs/bold/chain-abstraction"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/pkg/errors"
+)
+
+// ChallengeSummary is what remains of a challenge once it is complete and the
+// watcher evicts it from memory.
+type ChallengeSummary struct {
+	// The challenged assertion, which the challenge is namespaced under.
+	AssertionHash common.Hash `json:"assertionHash"`
+	// The assertion claimed by the honest block challenge root edge, if it was known.
+	ClaimedAssertionHash common.Hash   `json:"claimedAssertionHash"`
+	HonestEdgeIds        []common.Hash `json:"honestEdgeIds"`
+	// Level zero edges confirmed in the challenge, by the id of the edge they claim.
+	ConfirmedLevelZeroEdges map[common.Hash]common.Hash `json:"confirmedLevelZeroEdges"`
+	// The latest block whose edge events were processed when the challenge was evicted.
+	EvictedAtBlock uint64 `json:"evictedAtBlock"`
+}
+
+// ChallengeArchive persists the summaries of evicted challenges.
+type ChallengeArchive interface {
+	Archive(ctx context.Context, summary *ChallengeSummary) error
+	// Summary returns the archived summary of the challenge of an assertion, or nil if
+	// there is none, so that challenges evicted before a restart are not tracked again.
+	Summary(assertionHash common.Hash) (*ChallengeSummary, error)
+}
+
+// A challenge evicted from memory, remembered so that its edges seen again are ignored.
+type tombstone struct {
+	// The latest block whose edge events were processed when the tombstone was added.
+	atBlock uint64
+	// The assertion claimed by the honest block challenge root edge, if it was known.
+	claimedAssertionHash common.Hash
+}
+
+// ChallengeEvictor releases the state kept for a challenge outside of the watcher,
+// such as its edge trackers, once the watcher evicts it.
+type ChallengeEvictor interface {
+	EvictChallenge(summary *ChallengeSummary)
+}
+
+// FileArchive is a challenge archive appending summaries to a file, one JSON
+// object per line.
+type FileArchive struct {
+	lock sync.Mutex
+	path string
+}
+
+// NewFileArchive creates an archive appending summaries to the file at the given
+// path, which is created if it does not exist.
+func NewFileArchive(path string) *FileArchive {
+	return &FileArchive{path: path}
+}
+
+// Archive appends a summary to the file and syncs it to disk.
+func (a *FileArchive) Archive(_ context.Context, summary *ChallengeSummary) error {
+	data, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	f, err := os.OpenFile(a.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(append(data, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Summary reads archived summaries until the one of the given assertion's challenge.
+func (a *FileArchive) Summary(assertionHash common.Hash) (*ChallengeSummary, error) {
+	var found *ChallengeSummary
+	err := a.scan(func(summary *ChallengeSummary) bool {
+		if summary.AssertionHash == assertionHash {
+			found = summary
+			return false
+		}
+		return true
+	})
+	return found, err
+}
+
+// Summaries reads all archived summaries, in the order they were archived.
+func (a *FileArchive) Summaries() ([]*ChallengeSummary, error) {
+	summaries := make([]*ChallengeSummary, 0)
+	err := a.scan(func(summary *ChallengeSummary) bool {
+		summaries = append(summaries, summary)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return summaries, nil
+}
+
+// Streams the archived summaries to a callback, one line at a time, until it returns
+// false.
+func (a *FileArchive) scan(fn func(summary *ChallengeSummary) bool) error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	f, err := os.Open(a.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			summary := &ChallengeSummary{}
+			if decodeErr := json.Unmarshal(line, summary); decodeErr != nil {
+				return errors.Wrap(decodeErr, "could not decode archived challenge summary")
+			}
+			if !fn(summary) {
+				return nil
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Evicts the challenges which are complete, archiving their summaries first. A challenge
+// whose summary could not be archived is kept until the next attempt.
+func (w *Watcher) pruneCompletedChallenges(ctx context.Context) {
+	assertionHashes := make([]protocol.AssertionHash, 0, w.challenges.NumItems())
+	_ = w.challenges.ForEach(func(assertionHash protocol.AssertionHash, _ *trackedChallenge) error {
+		assertionHashes = append(assertionHashes, assertionHash)
+		return nil
+	})
+	for _, assertionHash := range assertionHashes {
+		complete, err := w.chain.IsChallengeComplete(ctx, assertionHash)
+		if err != nil {
+			srvlog.Error("Could not check if challenge is complete", log.Ctx{
+				"assertionHash": assertionHash.Hash,
+				"err":           err,
+			})
+			continue
+		}
+		if !complete {
+			continue
+		}
+		if err := w.evictChallenge(ctx, assertionHash); err != nil {
+			srvlog.Error("Could not evict completed challenge", log.Ctx{
+				"assertionHash": assertionHash.Hash,
+				"err":           err,
+			})
+		}
+	}
+	w.pruneTombstones(ctx)
+	w.updateRetentionMetrics()
+}
+
+func (w *Watcher) evictChallenge(ctx context.Context, assertionHash protocol.AssertionHash) error {
+	chal, ok := w.challenges.TryGet(assertionHash)
+	if !ok {
+		return nil
+	}
+	summary := &ChallengeSummary{
+		AssertionHash:           assertionHash.Hash,
+		HonestEdgeIds:           make([]common.Hash, 0, chal.honestEdgeTree.GetEdges().NumItems()),
+		ConfirmedLevelZeroEdges: make(map[common.Hash]common.Hash),
+		EvictedAtBlock:          w.syncedBlock.Load(),
+	}
+	if root, err := chal.honestEdgeTree.HonestBlockChallengeRootEdge(); err == nil && root.ClaimId().IsSome() {
+		summary.ClaimedAssertionHash = common.Hash(root.ClaimId().Unwrap())
+	}
+	_ = chal.honestEdgeTree.GetEdges().ForEach(func(edgeId protocol.EdgeId, _ protocol.SpecEdge) error {
+		summary.HonestEdgeIds = append(summary.HonestEdgeIds, edgeId.Hash)
+		return nil
+	})
+	sort.Slice(summary.HonestEdgeIds, func(i, j int) bool {
+		return bytes.Compare(summary.HonestEdgeIds[i].Bytes(), summary.HonestEdgeIds[j].Bytes()) < 0
+	})
+	_ = chal.confirmedLevelZeroEdgeClaimIds.ForEach(func(claimId protocol.ClaimId, edgeId protocol.EdgeId) error {
+		summary.ConfirmedLevelZeroEdges[common.Hash(claimId)] = edgeId.Hash
+		return nil
+	})
+	if w.archive != nil {
+		if err := w.archive.Archive(ctx, summary); err != nil {
+			return errors.Wrap(err, "could not archive challenge summary")
+		}
+	}
+	// Edges of the challenge seen again, such as during a rescan, are ignored
+	// rather than tracking the challenge anew.
+	w.evicted.Put(assertionHash, tombstone{
+		atBlock:              w.syncedBlock.Load(),
+		claimedAssertionHash: summary.ClaimedAssertionHash,
+	})
+	w.challenges.Delete(assertionHash)
+	if w.evictor != nil {
+		w.evictor.EvictChallenge(summary)
+	}
+	evictedChallengesCounter.Inc(1)
+	srvlog.Info("Evicted completed challenge", log.Ctx{
+		"validatorName": w.validatorName,
+		"assertionHash": assertionHash.Hash,
+		"honestEdges":   len(summary.HonestEdgeIds),
+	})
+	return nil
+}
+
+// Forgets the challenges evicted from memory once their edges can no longer matter:
+// once the assertion claimed in the challenge is confirmed and no longer the latest
+// confirmed assertion, or once a challenge period has passed since the tombstone was
+// added. Edges of the challenge seen again afterwards, such as during a rescan, are
+// still ignored, as the challenge is found to be complete onchain.
+func (w *Watcher) pruneTombstones(ctx context.Context) {
+	if w.evicted.NumItems() == 0 {
+		return
+	}
+	chalManager, err := w.chain.SpecChallengeManager(ctx)
+	if err != nil {
+		srvlog.Error("Could not get challenge manager", log.Ctx{"err": err})
+		return
+	}
+	horizon, err := chalManager.ChallengePeriodBlocks(ctx)
+	if err != nil {
+		srvlog.Error("Could not get challenge period blocks", log.Ctx{"err": err})
+		return
+	}
+	latestConfirmed, err := w.chain.LatestConfirmed(ctx)
+	if err != nil {
+		srvlog.Error("Could not get latest confirmed assertion", log.Ctx{"err": err})
+		return
+	}
+	syncedBlock := w.syncedBlock.Load()
+	tombstones := make(map[protocol.AssertionHash]tombstone, w.evicted.NumItems())
+	_ = w.evicted.ForEach(func(assertionHash protocol.AssertionHash, t tombstone) error {
+		tombstones[assertionHash] = t
+		return nil
+	})
+	for assertionHash, t := range tombstones {
+		if syncedBlock >= t.atBlock+horizon {
+			w.evicted.Delete(assertionHash)
+			continue
+		}
+		claimed := protocol.AssertionHash{Hash: t.claimedAssertionHash}
+		if claimed.Hash == (common.Hash{}) || claimed == latestConfirmed.Id() {
+			continue
+		}
+		status, err := w.chain.AssertionStatus(ctx, claimed)
+		if err != nil {
+			srvlog.Error("Could not get assertion status", log.Ctx{
+				"assertionHash": claimed.Hash,
+				"err":           err,
+			})
+			continue
+		}
+		if status == protocol.AssertionConfirmed {
+			w.evicted.Delete(assertionHash)
+		}
+	}
+}
+
+// IsChallengeEvicted returns true if the challenge of an assertion was complete and
+// evicted from memory, after which its edges are no longer tracked. Challenges evicted
+// before a restart are looked up in the archive the first time they are checked.
+func (w *Watcher) IsChallengeEvicted(assertionHash protocol.AssertionHash) bool {
+	if w.evicted.Has(assertionHash) {
+		return true
+	}
+	if w.archive == nil || w.challenges.Has(assertionHash) {
+		return false
+	}
+	summary, err := w.archive.Summary(assertionHash.Hash)
+	if err != nil {
+		srvlog.Error("Could not read archived challenge summary", log.Ctx{
+			"assertionHash": assertionHash.Hash,
+			"err":           err,
+		})
+		return false
+	}
+	if summary == nil {
+		return false
+	}
+	w.evicted.Put(assertionHash, tombstone{
+		atBlock:              w.syncedBlock.Load(),
+		claimedAssertionHash: summary.ClaimedAssertionHash,
+	})
+	return true
+}
+
+func (w *Watcher) updateRetentionMetrics() {
+	retainedEdges := uint64(0)
+	_ = w.challenges.ForEach(func(_ protocol.AssertionHash, chal *trackedChallenge) error {
+		retainedEdges += chal.honestEdgeTree.GetEdges().NumItems()
+		return nil
+	})
+	retainedChallengesGauge.Update(int64(w.challenges.NumItems()))
+	retainedEdgesGauge.Update(int64(retainedEdges))
+}
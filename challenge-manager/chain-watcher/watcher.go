@@ -12,14 +12,18 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/OffchainLabs/bold/accounting"
+	"github.com/OffchainLabs/bold/alerts"
 	protocol "github.com/OffchainLabs/bold/chain-abstraction"
 	challengetree "github.com/OffchainLabs/bold/challenge-manager/challenge-tree"
 	edgetracker "github.com/OffchainLabs/bold/challenge-manager/edge-tracker"
 	"github.com/OffchainLabs/bold/containers"
 	"github.com/OffchainLabs/bold/containers/threadsafe"
+	"github.com/OffchainLabs/bold/events"
 	l2stateprovider "github.com/OffchainLabs/bold/layer2-state-provider"
 	retry "github.com/OffchainLabs/bold/runtime"
 	"github.com/OffchainLabs/bold/solgen/go/challengeV2gen"
@@ -38,6 +42,11 @@ var (
 	edgeConfirmedByTimeCounter     = metrics.NewRegisteredCounter("arb/validator/watcher/confirmed_by_time", nil)
 	edgeConfirmedByOSPCounter      = metrics.NewRegisteredCounter("arb/validator/watcher/confirmed_by_osp", nil)
 	edgeConfirmedByClaimCounter    = metrics.NewRegisteredCounter("arb/validator/watcher/confirmed_by_claim", nil)
+	edgeBisectedCounter            = metrics.NewRegisteredCounter("arb/validator/watcher/edge_bisected", nil)
+	evictedChallengesCounter       = metrics.NewRegisteredCounter("arb/validator/watcher/evicted_challenges", nil)
+	retainedChallengesGauge        = metrics.NewRegisteredGauge("arb/validator/watcher/retained_challenges", nil)
+	retainedEdgesGauge             = metrics.NewRegisteredGauge("arb/validator/watcher/retained_honest_edges", nil)
+	droppedPendingEdgesCounter     = metrics.NewRegisteredCounter("arb/validator/watcher/dropped_pending_edges", nil)
 )
 
 const (
@@ -51,11 +60,17 @@ func init() {
 	srvlog.SetHandler(log.StreamHandler(os.Stdout, log.LogfmtFormat()))
 }
 
-// EdgeManager provides a method to track edges, via edge tracker goroutines.
+// EdgeManager provides a method to track edges, via edge trackers.
 type EdgeManager interface {
 	TrackEdge(ctx context.Context, edge protocol.SpecEdge) error
 }
 
+// TrackerWaker is notified of edges being added or confirmed in a challenge,
+// so that the trackers of the challenge can act on them right away.
+type TrackerWaker interface {
+	WakeChallenge(topLevelAssertionHash protocol.AssertionHash)
+}
+
 // ConfirmationMetadataChecker defines a struct which can retrieve information about
 // an edge to determine if it can be confirmed via different means. For example,
 // checking if a confirmed edge exists that claims a specified edge id as its claim id,
@@ -87,6 +102,37 @@ type ConfirmationMetadataChecker interface {
 type trackedChallenge struct {
 	honestEdgeTree                 *challengetree.HonestChallengeTree
 	confirmedLevelZeroEdgeClaimIds *threadsafe.Map[protocol.ClaimId, protocol.EdgeId]
+	// Edges seen before the honest block challenge root edge, which cannot be
+	// added to the tree until it is known. Rivals may bisect and open subchallenges
+	// among themselves before the honest edge is posted.
+	pendingLock  sync.Mutex
+	pendingEdges []protocol.SpecEdge
+}
+
+// Defers an edge unless the challenge already has the maximum number of pending
+// edges, returning false if it does. Edges that are already pending are not
+// deferred twice, such as when they are seen again during a rescan.
+func (c *trackedChallenge) deferEdge(edge protocol.SpecEdge, maxPending int) bool {
+	c.pendingLock.Lock()
+	defer c.pendingLock.Unlock()
+	for _, pending := range c.pendingEdges {
+		if pending.Id() == edge.Id() {
+			return true
+		}
+	}
+	if len(c.pendingEdges) >= maxPending {
+		return false
+	}
+	c.pendingEdges = append(c.pendingEdges, edge)
+	return true
+}
+
+func (c *trackedChallenge) takePendingEdges() []protocol.SpecEdge {
+	c.pendingLock.Lock()
+	defer c.pendingLock.Unlock()
+	pending := c.pendingEdges
+	c.pendingEdges = nil
+	return pending
 }
 
 // The Watcher implements a service in the validator runtime
@@ -106,6 +152,80 @@ type Watcher struct {
 	validatorName        string
 	numBigStepLevels     uint8
 	initialSyncCompleted atomic.Bool
+	// Latest block up to which edge events have been processed, if the watcher
+	// is scanning the chain.
+	syncedBlock atomic.Uint64
+	rescanFrom  atomic.Pointer[uint64]
+	alerts      *alerts.Dispatcher
+	waker       TrackerWaker
+	events      *events.LogPublisher
+	ledger      *accounting.Ledger
+	archive     ChallengeArchive
+	evictor     ChallengeEvictor
+	// Challenges evicted from memory once complete, until their edges can no longer matter.
+	evicted *threadsafe.Map[protocol.AssertionHash, tombstone]
+	// How often completed challenges are evicted from memory.
+	pruneInterval time.Duration
+	// Maximum number of edges deferred per challenge until the honest block
+	// challenge root edge is known.
+	maxPendingEdges int
+}
+
+type Opt func(w *Watcher)
+
+// WithAlerts sets the dispatcher used to notify operators about newly opened
+// challenges and confirmed edges we disagree with.
+func WithAlerts(d *alerts.Dispatcher) Opt {
+	return func(w *Watcher) {
+		w.alerts = d
+	}
+}
+
+// WithTrackerWaker sets the waker notified of edge events in tracked challenges.
+func WithTrackerWaker(waker TrackerWaker) Opt {
+	return func(w *Watcher) {
+		w.waker = waker
+	}
+}
+
+// WithEventBus sets the bus on which edge events are published once processed.
+func WithEventBus(bus *events.Bus) Opt {
+	return func(w *Watcher) {
+		w.events = events.NewLogPublisher(bus)
+	}
+}
+
+// WithLedger records the refunds of the mini-stakes locked in the ledger as they are
+// observed onchain.
+func WithLedger(ledger *accounting.Ledger) Opt {
+	return func(w *Watcher) {
+		w.ledger = ledger
+	}
+}
+
+// WithChallengeArchive sets the archive the summaries of completed challenges are
+// persisted to before they are evicted from memory. Without an archive, the summaries
+// of evicted challenges are lost, and after a restart, challenges are only known to
+// be complete from the chain.
+func WithChallengeArchive(archive ChallengeArchive) Opt {
+	return func(w *Watcher) {
+		w.archive = archive
+	}
+}
+
+// WithChallengeEvictor sets the evictor notified when a completed challenge is
+// evicted from memory.
+func WithChallengeEvictor(evictor ChallengeEvictor) Opt {
+	return func(w *Watcher) {
+		w.evictor = evictor
+	}
+}
+
+// WithPruneInterval sets how often completed challenges are evicted from memory.
+func WithPruneInterval(d time.Duration) Opt {
+	return func(w *Watcher) {
+		w.pruneInterval = d
+	}
 }
 
 // New initializes a watcher service for frequently scanning the chain
@@ -118,11 +238,12 @@ func New(
 	interval time.Duration,
 	numBigStepLevels uint8,
 	validatorName string,
+	opts ...Opt,
 ) (*Watcher, error) {
 	if interval == 0 {
 		return nil, errors.New("chain watcher polling interval must be greater than 0")
 	}
-	return &Watcher{
+	w := &Watcher{
 		chain:              chain,
 		edgeManager:        edgeManager,
 		pollEventsInterval: interval,
@@ -131,7 +252,20 @@ func New(
 		histChecker:        histChecker,
 		numBigStepLevels:   numBigStepLevels,
 		validatorName:      validatorName,
-	}, nil
+		pruneInterval:      time.Minute,
+		maxPendingEdges:    1024,
+		evicted:            threadsafe.NewMap[protocol.AssertionHash, tombstone](),
+	}
+	for _, o := range opts {
+		o(w)
+	}
+	if w.pruneInterval == 0 {
+		return nil, errors.New("chain watcher prune interval must be greater than 0")
+	}
+	if w.maxPendingEdges <= 0 {
+		return nil, errors.New("chain watcher max pending edges must be greater than 0")
+	}
+	return w, nil
 }
 
 // HonestBlockChallengeRootEdge gets the honest block challenge root edge for a given challenge
@@ -177,6 +311,12 @@ func (w *Watcher) ComputeHonestPathTimer(
 		return 0, nil, nil, errors.New("latest block header number is not a uint64")
 	}
 	blockNumber := header.Number.Uint64()
+	// Rivals created after the latest block the watcher has processed are not yet known,
+	// so edges would appear unrivaled past that block. Computing timers as of that block
+	// prevents edges from appearing confirmable before they actually are.
+	if synced := w.syncedBlock.Load(); synced != 0 && synced < blockNumber {
+		blockNumber = synced
+	}
 	return w.ComputeHonestPathTimerByBlockNumber(ctx, topLevelAssertionHash, edgeId, blockNumber)
 }
 
@@ -266,6 +406,13 @@ func (w *Watcher) Start(ctx context.Context) {
 		srvlog.Error("Could not check for edge added", log.Ctx{"err": err})
 		return
 	}
+	_, err = retry.UntilSucceeds(ctx, func() (bool, error) {
+		return true, w.checkForEdgeBisected(ctx, filterer, filterOpts)
+	})
+	if err != nil {
+		srvlog.Error("Could not check for edge bisected", log.Ctx{"err": err})
+		return
+	}
 	_, err = retry.UntilSucceeds(ctx, func() (bool, error) {
 		return true, w.checkForEdgeConfirmedByOneStepProof(ctx, filterer, filterOpts)
 	})
@@ -294,14 +441,26 @@ func (w *Watcher) Start(ctx context.Context) {
 		srvlog.Error("Could not check for edge confirmed by time", log.Ctx{"err": err})
 		return
 	}
+	_, err = retry.UntilSucceeds(ctx, func() (bool, error) {
+		return true, w.checkForEdgeRefunded(ctx, filterer, filterOpts)
+	})
+	if err != nil {
+		srvlog.Error("Could not check for edge refunded", log.Ctx{"err": err})
+		return
+	}
 
+	w.syncedBlock.Store(toBlock)
 	w.initialSyncCompleted.Store(true)
 
 	fromBlock = toBlock
 	ticker := time.NewTicker(w.pollEventsInterval)
 	defer ticker.Stop()
+	pruneTicker := time.NewTicker(w.pruneInterval)
+	defer pruneTicker.Stop()
 	for {
 		select {
+		case <-pruneTicker.C:
+			w.pruneCompletedChallenges(ctx)
 		case <-ticker.C:
 			latestBlock, err := w.backend.HeaderByNumber(ctx, nil)
 			if err != nil {
@@ -313,6 +472,10 @@ func (w *Watcher) Start(ctx context.Context) {
 				continue
 			}
 			toBlock := latestBlock.Number.Uint64()
+			if rescanFrom := w.rescanFrom.Swap(nil); rescanFrom != nil && *rescanFrom < fromBlock {
+				srvlog.Info("Rescanning edges", log.Ctx{"fromBlock": *rescanFrom})
+				fromBlock = *rescanFrom
+			}
 			if fromBlock == toBlock {
 				continue
 			}
@@ -340,6 +503,10 @@ func (w *Watcher) Start(ctx context.Context) {
 				srvlog.Error("Could not check for edge added", log.Ctx{"err": err})
 				continue
 			}
+			if err = w.checkForEdgeBisected(ctx, filterer, filterOpts); err != nil {
+				srvlog.Error("Could not check for edge bisected", log.Ctx{"err": err})
+				continue
+			}
 			if err = w.checkForEdgeConfirmedByOneStepProof(ctx, filterer, filterOpts); err != nil {
 				srvlog.Error("Could not check for edge confirmed by osp", log.Ctx{"err": err})
 				continue
@@ -356,13 +523,26 @@ func (w *Watcher) Start(ctx context.Context) {
 				srvlog.Error("Could not check for edge confirmed by claim", log.Ctx{"err": err})
 				continue
 			}
+			if err = w.checkForEdgeRefunded(ctx, filterer, filterOpts); err != nil {
+				srvlog.Error("Could not check for edge refunded", log.Ctx{"err": err})
+				continue
+			}
+			w.syncedBlock.Store(toBlock)
 			fromBlock = toBlock
+			w.events.Prune(fromBlock)
 		case <-ctx.Done():
 			return
 		}
 	}
 }
 
+// Rescan edge events from the given block at the next poll, processing the events
+// since again, such as after an RPC provider missed events. Has no effect if the
+// block has not been scanned yet.
+func (w *Watcher) Rescan(fromBlock uint64) {
+	w.rescanFrom.Store(&fromBlock)
+}
+
 func (w *Watcher) GetEdge(ctx context.Context, edgeId common.Hash) (protocol.SpecEdge, error) {
 	challengeManager, err := w.chain.SpecChallengeManager(ctx)
 	if err != nil {
@@ -430,7 +610,7 @@ func (w *Watcher) getAllEdges(
 	for it.Next() {
 		if it.Error() != nil {
 			return nil, errors.Wrapf(
-				err,
+				it.Error(),
 				"got iterator error when scanning edge creations from block %d to %d",
 				filterOpts.Start,
 				*filterOpts.End,
@@ -601,6 +781,9 @@ func (w *Watcher) AddVerifiedHonestEdge(ctx context.Context, edge protocol.Verif
 	if err != nil {
 		return err
 	}
+	if w.IsChallengeEvicted(assertionHash) {
+		return nil
+	}
 	// If a challenge is not yet being tracked locally by the watcher
 	// for the edge's assertion hash, it adds an entry to the map.
 	chal, ok := w.challenges.TryGet(assertionHash)
@@ -617,13 +800,14 @@ func (w *Watcher) AddVerifiedHonestEdge(ctx context.Context, edge protocol.Verif
 			confirmedLevelZeroEdgeClaimIds: threadsafe.NewMap[protocol.ClaimId, protocol.EdgeId](),
 		}
 		w.challenges.Put(assertionHash, chal)
+		retainedChallengesGauge.Update(int64(w.challenges.NumItems()))
 	}
 	// Add the edge to a local challenge tree of honest edges and, if needed,
 	// we also spawn a tracker for the edge.
 	if err := chal.honestEdgeTree.AddHonestEdge(edge); err != nil {
 		return errors.Wrap(err, "could not add honest edge to challenge tree")
 	}
-	return nil
+	return w.addPendingEdges(ctx, chal)
 }
 
 // Filters for all edge added events within a range and processes them.
@@ -644,7 +828,7 @@ func (w *Watcher) checkForEdgeAdded(
 	for it.Next() {
 		if it.Error() != nil {
 			return errors.Wrapf(
-				err,
+				it.Error(),
 				"got iterator error when scanning edge creations from block %d to %d",
 				filterOpts.Start,
 				*filterOpts.End,
@@ -657,26 +841,90 @@ func (w *Watcher) checkForEdgeAdded(
 			return processErr
 		}
 		edgeAddedCounter.Inc(1)
+		w.events.Publish(ctx, it.Event.Raw, &events.EdgeAdded{
+			EdgeId:      protocol.EdgeId{Hash: it.Event.EdgeId},
+			MutualId:    protocol.MutualId(it.Event.MutualId),
+			OriginId:    protocol.OriginId(it.Event.OriginId),
+			ClaimId:     protocol.ClaimId(it.Event.ClaimId),
+			Level:       it.Event.Level,
+			Length:      it.Event.Length.Uint64(),
+			HasRival:    it.Event.HasRival,
+			IsLayerZero: it.Event.IsLayerZero,
+			Block:       it.Event.Raw.BlockNumber,
+		})
 	}
 	return nil
 }
 
 // AddEdge to watcher. If it is honest, it will be tracked.
 func (w *Watcher) AddEdge(ctx context.Context, edge protocol.SpecEdge) error {
+	chal, err := w.addEdge(ctx, edge)
+	if err != nil || chal == nil {
+		return err
+	}
+	return w.addPendingEdges(ctx, chal)
+}
+
+// Adds edges deferred until the honest block challenge root edge was known,
+// once it is. Edges that still cannot be added are deferred again.
+func (w *Watcher) addPendingEdges(ctx context.Context, chal *trackedChallenge) error {
+	if _, err := chal.honestEdgeTree.HonestBlockChallengeRootEdge(); err != nil {
+		return nil
+	}
+	pending := chal.takePendingEdges()
+	for i, edge := range pending {
+		if _, err := w.addEdge(ctx, edge); err != nil {
+			for _, rest := range pending[i:] {
+				w.deferEdge(chal, rest)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// Defers an edge until the honest block challenge root edge of its challenge is
+// known. An honest validator posts its root edge as soon as it sees a rival, so
+// edges beyond the bound are dropped rather than letting an adversary grow the
+// pending edges without limit. Dropped edges are only added if seen again, such
+// as during a rescan.
+func (w *Watcher) deferEdge(chal *trackedChallenge, edge protocol.SpecEdge) {
+	if chal.deferEdge(edge, w.maxPendingEdges) {
+		return
+	}
+	droppedPendingEdgesCounter.Inc(1)
+	srvlog.Warn("Dropping edge seen before the honest block challenge root edge, too many are pending", log.Ctx{
+		"edgeId":          edge.Id(),
+		"validatorName":   w.validatorName,
+		"maxPendingEdges": w.maxPendingEdges,
+	})
+}
+
+// Adds an edge to the tree of the challenge it belongs to, returning the
+// challenge unless it is already complete.
+func (w *Watcher) addEdge(ctx context.Context, edge protocol.SpecEdge) (*trackedChallenge, error) {
 	challengeParentAssertionHash, err := edge.AssertionHash(ctx)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if w.IsChallengeEvicted(challengeParentAssertionHash) {
+		return nil, nil
 	}
 	challengeComplete, err := w.chain.IsChallengeComplete(ctx, challengeParentAssertionHash)
 	if err != nil {
-		return errors.Wrapf(
+		return nil, errors.Wrapf(
 			err,
 			"could not check if edge with parent assertion hash %#x is part of a completed challenge",
 			challengeParentAssertionHash.Hash,
 		)
 	}
 	if challengeComplete {
-		return nil
+		// Remembered like an evicted challenge, so that its other edges are neither
+		// looked up in the archive nor checked onchain again.
+		if !w.challenges.Has(challengeParentAssertionHash) {
+			w.evicted.Put(challengeParentAssertionHash, tombstone{atBlock: w.syncedBlock.Load()})
+		}
+		return nil, nil
 	}
 	chal, ok := w.challenges.TryGet(challengeParentAssertionHash)
 	if !ok {
@@ -692,21 +940,36 @@ func (w *Watcher) AddEdge(ctx context.Context, edge protocol.SpecEdge) error {
 			confirmedLevelZeroEdgeClaimIds: threadsafe.NewMap[protocol.ClaimId, protocol.EdgeId](),
 		}
 		w.challenges.Put(challengeParentAssertionHash, chal)
+		retainedChallengesGauge.Update(int64(w.challenges.NumItems()))
+	}
+	// Challenges seen during the initial sync were opened in the past, and alerting on
+	// them would notify operators again on every restart.
+	if !ok && w.initialSyncCompleted.Load() {
+		w.alerts.Notify(ctx, alerts.NewChallengeOpenedEvent(challengeParentAssertionHash.Hash, edge.Id().Hash))
 	}
 	// Add the edge to a local challenge tree of tracked edges. If it is honest,
 	// we also spawn a tracker for the edge.
 	agreement, err := chal.honestEdgeTree.AddEdge(ctx, edge)
 	if err != nil {
+		if errors.Is(err, challengetree.ErrNoHonestRootEdge) {
+			// The edge is added once we know the honest root edge.
+			w.deferEdge(chal, edge)
+			return chal, nil
+		}
 		if !errors.Is(err, challengetree.ErrAlreadyBeingTracked) {
-			return errors.Wrap(err, "could not add edge to challenge tree")
+			return nil, errors.Wrap(err, "could not add edge to challenge tree")
 		}
 		// If the error is that we are already tracking the edge, we exit early.
-		return nil
+		return chal, nil
+	}
+	// A new rival or child edge may require the challenge's trackers to move.
+	if w.waker != nil {
+		w.waker.WakeChallenge(challengeParentAssertionHash)
 	}
 	if agreement.IsHonestEdge {
-		return w.edgeManager.TrackEdge(ctx, edge)
+		return chal, w.edgeManager.TrackEdge(ctx, edge)
 	}
-	return nil
+	return chal, nil
 }
 
 // Processes an edge added event by adding it to the honest challenge tree if it is honest.
@@ -728,6 +991,46 @@ func (w *Watcher) processEdgeAddedEvent(
 	return w.AddEdge(ctx, edgeOpt.Unwrap())
 }
 
+// Filters for edge bisected events within a range and publishes them. Bisections
+// need no processing, as the children they add are processed as added edges.
+func (w *Watcher) checkForEdgeBisected(
+	ctx context.Context,
+	filterer *challengeV2gen.EdgeChallengeManagerFilterer,
+	filterOpts *bind.FilterOpts,
+) error {
+	if w.events == nil {
+		return nil
+	}
+	it, err := filterer.FilterEdgeBisected(filterOpts, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err = it.Close(); err != nil {
+			srvlog.Error("Could not close filter iterator", log.Ctx{"err": err})
+		}
+	}()
+	for it.Next() {
+		if it.Error() != nil {
+			return errors.Wrapf(
+				it.Error(),
+				"got iterator error when scanning edge bisections from block %d to %d",
+				filterOpts.Start,
+				*filterOpts.End,
+			)
+		}
+		edgeBisectedCounter.Inc(1)
+		w.events.Publish(ctx, it.Event.Raw, &events.EdgeBisected{
+			EdgeId:                  protocol.EdgeId{Hash: it.Event.EdgeId},
+			LowerChildId:            protocol.EdgeId{Hash: it.Event.LowerChildId},
+			UpperChildId:            protocol.EdgeId{Hash: it.Event.UpperChildId},
+			LowerChildAlreadyExists: it.Event.LowerChildAlreadyExists,
+			Block:                   it.Event.Raw.BlockNumber,
+		})
+	}
+	return nil
+}
+
 // Filters for edge confirmed by one step proof events within a range.
 // and processes any events found.
 func (w *Watcher) checkForEdgeConfirmedByOneStepProof(
@@ -747,7 +1050,7 @@ func (w *Watcher) checkForEdgeConfirmedByOneStepProof(
 	for it.Next() {
 		if it.Error() != nil {
 			return errors.Wrapf(
-				err,
+				it.Error(),
 				"got iterator error when scanning edge creations from block %d to %d",
 				filterOpts.Start,
 				*filterOpts.End,
@@ -762,6 +1065,12 @@ func (w *Watcher) checkForEdgeConfirmedByOneStepProof(
 			return processErr
 		}
 		edgeConfirmedByOSPCounter.Inc(1)
+		w.events.Publish(ctx, it.Event.Raw, &events.EdgeConfirmed{
+			EdgeId:   protocol.EdgeId{Hash: it.Event.EdgeId},
+			MutualId: protocol.MutualId(it.Event.MutualId),
+			By:       events.ByOneStepProof,
+			Block:    it.Event.Raw.BlockNumber,
+		})
 	}
 	return nil
 }
@@ -785,7 +1094,7 @@ func (w *Watcher) checkForEdgeConfirmedByTime(
 	for it.Next() {
 		if it.Error() != nil {
 			return errors.Wrapf(
-				err,
+				it.Error(),
 				"got iterator error when scanning edge creations from block %d to %d",
 				filterOpts.Start,
 				*filterOpts.End,
@@ -800,6 +1109,12 @@ func (w *Watcher) checkForEdgeConfirmedByTime(
 			return processErr
 		}
 		edgeConfirmedByTimeCounter.Inc(1)
+		w.events.Publish(ctx, it.Event.Raw, &events.EdgeConfirmed{
+			EdgeId:   protocol.EdgeId{Hash: it.Event.EdgeId},
+			MutualId: protocol.MutualId(it.Event.MutualId),
+			By:       events.ByTime,
+			Block:    it.Event.Raw.BlockNumber,
+		})
 	}
 	return nil
 }
@@ -823,7 +1138,7 @@ func (w *Watcher) checkForEdgeConfirmedByChildren(
 	for it.Next() {
 		if it.Error() != nil {
 			return errors.Wrapf(
-				err,
+				it.Error(),
 				"got iterator error when scanning edge creations from block %d to %d",
 				filterOpts.Start,
 				*filterOpts.End,
@@ -838,6 +1153,45 @@ func (w *Watcher) checkForEdgeConfirmedByChildren(
 			return processErr
 		}
 		edgeConfirmedByChildrenCounter.Inc(1)
+		w.events.Publish(ctx, it.Event.Raw, &events.EdgeConfirmed{
+			EdgeId:   protocol.EdgeId{Hash: it.Event.EdgeId},
+			MutualId: protocol.MutualId(it.Event.MutualId),
+			By:       events.ByChildren,
+			Block:    it.Event.Raw.BlockNumber,
+		})
+	}
+	return nil
+}
+
+// Filters for edge refunds within a range, recording those of the mini-stakes
+// we locked in the ledger, if one is kept.
+func (w *Watcher) checkForEdgeRefunded(
+	_ context.Context,
+	filterer *challengeV2gen.EdgeChallengeManagerFilterer,
+	filterOpts *bind.FilterOpts,
+) error {
+	if w.ledger == nil {
+		return nil
+	}
+	it, err := filterer.FilterEdgeRefunded(filterOpts, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err = it.Close(); err != nil {
+			srvlog.Error("Could not close filter iterator", log.Ctx{"err": err})
+		}
+	}()
+	for it.Next() {
+		if it.Error() != nil {
+			return errors.Wrapf(
+				it.Error(),
+				"got iterator error when scanning edge refunds from block %d to %d",
+				filterOpts.Start,
+				*filterOpts.End,
+			)
+		}
+		w.ledger.RecordRefund(it.Event.EdgeId, it.Event.StakeAmount, it.Event.Raw.TxHash, it.Event.Raw.BlockNumber)
 	}
 	return nil
 }
@@ -861,7 +1215,7 @@ func (w *Watcher) checkForEdgeConfirmedByClaim(
 	for it.Next() {
 		if it.Error() != nil {
 			return errors.Wrapf(
-				err,
+				it.Error(),
 				"got iterator error when scanning edge creations from block %d to %d",
 				filterOpts.Start,
 				*filterOpts.End,
@@ -876,6 +1230,13 @@ func (w *Watcher) checkForEdgeConfirmedByClaim(
 			return processErr
 		}
 		edgeConfirmedByClaimCounter.Inc(1)
+		w.events.Publish(ctx, it.Event.Raw, &events.EdgeConfirmed{
+			EdgeId:         protocol.EdgeId{Hash: it.Event.EdgeId},
+			MutualId:       protocol.MutualId(it.Event.MutualId),
+			By:             events.ByClaim,
+			ClaimingEdgeId: protocol.EdgeId{Hash: it.Event.ClaimingEdgeId},
+			Block:          it.Event.Raw.BlockNumber,
+		})
 	}
 	return nil
 }
@@ -903,6 +1264,23 @@ func (w *Watcher) processEdgeConfirmation(
 	if err != nil {
 		return err
 	}
+	// Confirmations may allow ancestors to be confirmed, or trackers to despawn.
+	if w.waker != nil {
+		w.waker.WakeChallenge(challengeParentAssertionHash)
+	}
+
+	// A confirmed edge that is not part of our honest tree for a tracked challenge
+	// is an edge we disagree with, which operators must be alerted about.
+	if tracked, ok := w.challenges.TryGet(challengeParentAssertionHash); ok && w.alerts != nil {
+		if !tracked.honestEdgeTree.GetEdges().Has(edgeId) {
+			challengeLevel := edge.GetChallengeLevel()
+			w.alerts.Notify(ctx, alerts.NewEvilEdgeConfirmedEvent(
+				edgeId.Hash,
+				challengeParentAssertionHash.Hash,
+				challengeLevel.String(),
+			))
+		}
+	}
 
 	// If an edge does not have a claim ID, it is not a level zero edge, and thus we can return early,
 	// as the following operations only operate on level zero edges.
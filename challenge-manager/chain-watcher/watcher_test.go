@@ -5,9 +5,13 @@ package watcher
 
 import (
 	"context"
+	"errors"
 	"math/big"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/OffchainLabs/bold/alerts"
 	protocol "github.com/OffchainLabs/bold/chain-abstraction"
 	challengetree "github.com/OffchainLabs/bold/challenge-manager/challenge-tree"
 	"github.com/OffchainLabs/bold/containers/option"
@@ -51,6 +55,7 @@ func TestWatcher_processEdgeConfirmation(t *testing.T) {
 
 	watcher := &Watcher{
 		challenges: threadsafe.NewMap[protocol.AssertionHash, *trackedChallenge](),
+		evicted:    threadsafe.NewMap[protocol.AssertionHash, tombstone](),
 		chain:      mockChain,
 	}
 	watcher.challenges.Put(assertionHash, &trackedChallenge{
@@ -76,7 +81,7 @@ func TestWatcher_processEdgeAddedEvent(t *testing.T) {
 	).Return(mockChallengeManager, nil)
 
 	assertionHash := protocol.AssertionHash{Hash: common.BytesToHash([]byte("foo"))}
-	parentAssertionHash := protocol.AssertionHash{Hash: common.BytesToHash([]byte("parent foo"))}
+	claimedAssertionHash := protocol.AssertionHash{Hash: common.BytesToHash([]byte("claimed foo"))}
 	edgeId := protocol.EdgeId{Hash: common.BytesToHash([]byte("bar"))}
 	originId := protocol.OriginId(common.BytesToHash([]byte("origin bar")))
 	edge := &mocks.MockSpecEdge{}
@@ -94,12 +99,12 @@ func TestWatcher_processEdgeAddedEvent(t *testing.T) {
 
 	info := &protocol.AssertionCreatedInfo{
 		InboxMaxCount:       big.NewInt(1),
-		ParentAssertionHash: parentAssertionHash.Hash,
+		ParentAssertionHash: assertionHash.Hash,
 	}
 	mockChain.On(
 		"ReadAssertionCreationInfo",
 		ctx,
-		assertionHash,
+		claimedAssertionHash,
 	).Return(info, nil)
 	parentInfo := &protocol.AssertionCreatedInfo{
 		InboxMaxCount: big.NewInt(1),
@@ -107,7 +112,7 @@ func TestWatcher_processEdgeAddedEvent(t *testing.T) {
 	mockChain.On(
 		"ReadAssertionCreationInfo",
 		ctx,
-		parentAssertionHash,
+		assertionHash,
 	).Return(parentInfo, nil)
 	heights := protocol.OriginHeights{}
 	mockChain.On(
@@ -130,7 +135,7 @@ func TestWatcher_processEdgeAddedEvent(t *testing.T) {
 	edge.On("Id").Return(edgeId)
 	edge.On("OriginId").Return(originId)
 	edge.On("CreatedAtBlock").Return(uint64(0), nil)
-	edge.On("ClaimId").Return(option.Some(protocol.ClaimId(assertionHash.Hash)))
+	edge.On("ClaimId").Return(option.Some(protocol.ClaimId(claimedAssertionHash.Hash)))
 	edge.On("MutualId").Return(protocol.MutualId{})
 	edge.On("GetChallengeLevel").Return(protocol.NewBlockChallengeLevel(), nil)
 	edge.On("GetReversedChallengeLevel").Return(protocol.ChallengeLevel(2), nil)
@@ -184,6 +189,7 @@ func TestWatcher_processEdgeAddedEvent(t *testing.T) {
 
 	watcher := &Watcher{
 		challenges:  threadsafe.NewMap[protocol.AssertionHash, *trackedChallenge](),
+		evicted:     threadsafe.NewMap[protocol.AssertionHash, tombstone](),
 		histChecker: mockStateManager,
 		chain:       mockChain,
 		edgeManager: mockManager,
@@ -250,6 +256,7 @@ func TestWatcher_AddVerifiedHonestEdge(t *testing.T) {
 
 	watcher := &Watcher{
 		challenges:  threadsafe.NewMap[protocol.AssertionHash, *trackedChallenge](),
+		evicted:     threadsafe.NewMap[protocol.AssertionHash, tombstone](),
 		histChecker: mockStateManager,
 		chain:       mockChain,
 		edgeManager: mockManager,
@@ -266,3 +273,277 @@ func TestWatcher_AddVerifiedHonestEdge(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, blockNum-createdAt+assertionUnrivaledBlocks, uint64(pathTimer))
 }
+
+func TestWatcher_AddEdgeBeforeHonestRootEdge(t *testing.T) {
+	ctx := context.Background()
+	mockChain := &mocks.MockProtocol{}
+	mockStateManager := &mocks.MockStateManager{}
+	mockManager := &mocks.MockEdgeTracker{}
+
+	assertionHash := protocol.AssertionHash{Hash: common.BytesToHash([]byte("foo"))}
+	mockChain.On("IsChallengeComplete", ctx, assertionHash).Return(false, nil)
+	mockChain.On("AssertionUnrivaledBlocks", ctx, assertionHash).Return(uint64(1), nil)
+	claimedAssertionHash := protocol.AssertionHash{Hash: common.BytesToHash([]byte("claimed"))}
+	mockChain.On("ReadAssertionCreationInfo", ctx, claimedAssertionHash).Return(&protocol.AssertionCreatedInfo{
+		InboxMaxCount:       big.NewInt(1),
+		ParentAssertionHash: assertionHash.Hash,
+	}, nil)
+	mockChain.On("ReadAssertionCreationInfo", ctx, assertionHash).Return(&protocol.AssertionCreatedInfo{
+		InboxMaxCount: big.NewInt(1),
+	}, nil)
+
+	// Children of an evil edge, added to the chain before the honest root edge.
+	evilStartCommit := common.BytesToHash([]byte("start"))
+	evilEndCommit := common.BytesToHash([]byte("evil end"))
+	newEvilEdge := func(name string) *mocks.MockSpecEdge {
+		evilEdgeId := protocol.EdgeId{Hash: common.BytesToHash([]byte(name))}
+		evilEdge := &mocks.MockSpecEdge{}
+		evilEdge.On("AssertionHash", ctx).Return(assertionHash, nil)
+		evilEdge.On("Id").Return(evilEdgeId)
+		evilEdge.On("CreatedAtBlock").Return(uint64(3), nil)
+		evilEdge.On("ClaimId").Return(option.None[protocol.ClaimId]())
+		evilEdge.On("MutualId").Return(protocol.MutualId(common.BytesToHash([]byte("evil mutual"))))
+		evilEdge.On("GetChallengeLevel").Return(protocol.NewBlockChallengeLevel(), nil)
+		evilEdge.On("StartCommitment").Return(protocol.Height(0), evilStartCommit)
+		evilEdge.On("EndCommitment").Return(protocol.Height(16), evilEndCommit)
+		mockChain.On("TopLevelAssertion", ctx, evilEdgeId).Return(assertionHash, nil)
+		mockChain.On("TopLevelClaimHeights", ctx, evilEdgeId).Return(protocol.OriginHeights{}, nil)
+		return evilEdge
+	}
+	evilEdge := newEvilEdge("evil")
+
+	request := &l2stateprovider.HistoryCommitmentRequest{
+		WasmModuleRoot:              common.Hash{},
+		FromBatch:                   0,
+		ToBatch:                     0,
+		UpperChallengeOriginHeights: []l2stateprovider.Height{},
+		FromHeight:                  0,
+		UpToHeight:                  option.Some[l2stateprovider.Height](16),
+	}
+	mockStateManager.On(
+		"AgreesWithHistoryCommitment",
+		ctx,
+		protocol.NewBlockChallengeLevel(),
+		request,
+		l2stateprovider.History{Height: 16, MerkleRoot: evilEndCommit},
+	).Return(false, nil)
+	mockStateManager.On(
+		"AgreesWithHistoryCommitment",
+		ctx,
+		protocol.NewBlockChallengeLevel(),
+		request,
+		l2stateprovider.History{Height: 0, MerkleRoot: evilStartCommit},
+	).Return(true, nil)
+
+	watcher := &Watcher{
+		challenges:       threadsafe.NewMap[protocol.AssertionHash, *trackedChallenge](),
+		evicted:          threadsafe.NewMap[protocol.AssertionHash, tombstone](),
+		histChecker:      mockStateManager,
+		chain:            mockChain,
+		edgeManager:      mockManager,
+		numBigStepLevels: 1,
+		maxPendingEdges:  1,
+	}
+
+	// The edge cannot be checked against our history without the honest root
+	// edge, so it is deferred rather than failing.
+	require.NoError(t, watcher.AddEdge(ctx, evilEdge))
+	chal, ok := watcher.challenges.TryGet(assertionHash)
+	require.Equal(t, true, ok)
+	require.Len(t, chal.pendingEdges, 1)
+
+	// Edges seen again are not deferred twice, and edges beyond the bound are dropped.
+	require.NoError(t, watcher.AddEdge(ctx, evilEdge))
+	require.NoError(t, watcher.AddEdge(ctx, newEvilEdge("other evil")))
+	require.Len(t, chal.pendingEdges, 1)
+	require.Equal(t, evilEdge.Id(), chal.pendingEdges[0].Id())
+	mockStateManager.AssertNotCalled(t, "AgreesWithHistoryCommitment")
+
+	honestEdgeId := protocol.EdgeId{Hash: common.BytesToHash([]byte("honest"))}
+	edge := &mocks.MockSpecEdge{}
+	edge.On("AssertionHash", ctx).Return(assertionHash, nil)
+	edge.On("Id").Return(honestEdgeId)
+	edge.On("CreatedAtBlock").Return(uint64(5), nil)
+	edge.On("ClaimId").Return(option.Some(protocol.ClaimId(claimedAssertionHash.Hash)))
+	edge.On("MutualId").Return(protocol.MutualId{})
+	edge.On("GetChallengeLevel").Return(protocol.NewBlockChallengeLevel(), nil)
+	edge.On("GetReversedChallengeLevel").Return(protocol.ChallengeLevel(2), nil)
+	honest := &mockHonestEdge{edge}
+
+	// Once the honest root edge is known, the deferred edge is added.
+	require.NoError(t, watcher.AddVerifiedHonestEdge(ctx, honest))
+	require.Len(t, chal.pendingEdges, 0)
+	mockStateManager.AssertExpectations(t)
+}
+
+type recordingEvictor struct {
+	summaries []*ChallengeSummary
+}
+
+func (r *recordingEvictor) EvictChallenge(summary *ChallengeSummary) {
+	r.summaries = append(r.summaries, summary)
+}
+
+type failingArchive struct{}
+
+func (failingArchive) Archive(context.Context, *ChallengeSummary) error {
+	return errors.New("disk full")
+}
+
+func (failingArchive) Summary(common.Hash) (*ChallengeSummary, error) {
+	return nil, nil
+}
+
+func TestWatcher_pruneCompletedChallenges(t *testing.T) {
+	ctx := context.Background()
+	mockChain := &mocks.MockProtocol{}
+	completed := protocol.AssertionHash{Hash: common.BytesToHash([]byte("completed"))}
+	ongoing := protocol.AssertionHash{Hash: common.BytesToHash([]byte("ongoing"))}
+	mockChain.On("IsChallengeComplete", ctx, completed).Return(true, nil)
+	mockChain.On("IsChallengeComplete", ctx, ongoing).Return(false, nil)
+	chalManager := &mocks.MockSpecChallengeManager{}
+	chalManager.On("ChallengePeriodBlocks", ctx).Return(uint64(50), nil)
+	mockChain.On("SpecChallengeManager", ctx).Return(chalManager, nil)
+	latestConfirmed := protocol.AssertionHash{Hash: common.BytesToHash([]byte("latest"))}
+	mockChain.On("LatestConfirmed", ctx).Return(&mocks.MockAssertion{MockId: latestConfirmed}, nil)
+
+	evictor := &recordingEvictor{}
+	watcher := &Watcher{
+		challenges: threadsafe.NewMap[protocol.AssertionHash, *trackedChallenge](),
+		evicted:    threadsafe.NewMap[protocol.AssertionHash, tombstone](),
+		chain:      mockChain,
+		archive:    failingArchive{},
+		evictor:    evictor,
+	}
+	claimId := protocol.ClaimId(common.BytesToHash([]byte("claim")))
+	confirmedEdgeId := protocol.EdgeId{Hash: common.BytesToHash([]byte("confirmed"))}
+	for _, assertionHash := range []protocol.AssertionHash{completed, ongoing} {
+		chal := &trackedChallenge{
+			honestEdgeTree:                 challengetree.New(assertionHash, mockChain, &mocks.MockStateManager{}, 1, "alice"),
+			confirmedLevelZeroEdgeClaimIds: threadsafe.NewMap[protocol.ClaimId, protocol.EdgeId](),
+		}
+		chal.confirmedLevelZeroEdgeClaimIds.Put(claimId, confirmedEdgeId)
+		watcher.challenges.Put(assertionHash, chal)
+	}
+	watcher.syncedBlock.Store(100)
+
+	// A challenge whose summary could not be archived is kept in memory.
+	watcher.pruneCompletedChallenges(ctx)
+	require.Equal(t, uint64(2), watcher.challenges.NumItems())
+	require.Empty(t, evictor.summaries)
+
+	archive := NewFileArchive(filepath.Join(t.TempDir(), "challenges.jsonl"))
+	watcher.archive = archive
+	watcher.pruneCompletedChallenges(ctx)
+	require.Equal(t, uint64(1), watcher.challenges.NumItems())
+	require.True(t, watcher.challenges.Has(ongoing))
+
+	want := &ChallengeSummary{
+		AssertionHash:           completed.Hash,
+		HonestEdgeIds:           []common.Hash{},
+		ConfirmedLevelZeroEdges: map[common.Hash]common.Hash{common.Hash(claimId): confirmedEdgeId.Hash},
+		EvictedAtBlock:          100,
+	}
+	require.Equal(t, []*ChallengeSummary{want}, evictor.summaries)
+	archived, err := archive.Summaries()
+	require.NoError(t, err)
+	require.Equal(t, []*ChallengeSummary{want}, archived)
+
+	// Evicted challenges are not archived again.
+	watcher.pruneCompletedChallenges(ctx)
+	archived, err = archive.Summaries()
+	require.NoError(t, err)
+	require.Len(t, archived, 1)
+
+	// Edges of an evicted challenge seen again, such as during a rescan, do not track it anew.
+	edge := &mocks.MockSpecEdge{}
+	edge.On("AssertionHash", ctx).Return(completed, nil)
+	numCalls := len(mockChain.Calls)
+	require.NoError(t, watcher.AddEdge(ctx, edge))
+	require.False(t, watcher.challenges.Has(completed))
+	require.Len(t, evictor.summaries, 1)
+	require.Len(t, mockChain.Calls, numCalls)
+
+	// Nor do they after a restart, as evicted challenges are read back from the archive.
+	restarted, err := New(mockChain, nil, nil, nil, time.Second, 1, "alice", WithChallengeArchive(archive))
+	require.NoError(t, err)
+	require.True(t, restarted.IsChallengeEvicted(completed))
+	require.False(t, restarted.IsChallengeEvicted(ongoing))
+	require.NoError(t, restarted.AddEdge(ctx, edge))
+	require.False(t, restarted.challenges.Has(completed))
+
+	// Tombstones are kept for a challenge period, after which edges of the challenge
+	// are found to be complete onchain.
+	watcher.syncedBlock.Store(149)
+	watcher.pruneCompletedChallenges(ctx)
+	require.True(t, watcher.evicted.Has(completed))
+	watcher.syncedBlock.Store(150)
+	watcher.pruneCompletedChallenges(ctx)
+	require.False(t, watcher.evicted.Has(completed))
+	require.NoError(t, watcher.AddEdge(ctx, edge))
+	require.False(t, watcher.challenges.Has(completed))
+	mockChain.AssertCalled(t, "IsChallengeComplete", ctx, completed)
+	require.True(t, watcher.evicted.Has(completed))
+
+	// Or until the assertion claimed in the challenge is confirmed, and no longer the
+	// latest confirmed assertion.
+	claimingLatest := protocol.AssertionHash{Hash: common.BytesToHash([]byte("claiming latest"))}
+	claimedConfirmed := protocol.AssertionHash{Hash: common.BytesToHash([]byte("claimed confirmed"))}
+	claimedPending := protocol.AssertionHash{Hash: common.BytesToHash([]byte("claimed pending"))}
+	mockChain.On("AssertionStatus", ctx, claimedConfirmed).Return(protocol.AssertionConfirmed, nil)
+	mockChain.On("AssertionStatus", ctx, claimedPending).Return(protocol.AssertionPending, nil)
+	watcher.evicted.Put(claimingLatest, tombstone{atBlock: 150, claimedAssertionHash: latestConfirmed.Hash})
+	watcher.evicted.Put(claimedConfirmed, tombstone{atBlock: 150, claimedAssertionHash: claimedConfirmed.Hash})
+	watcher.evicted.Put(claimedPending, tombstone{atBlock: 150, claimedAssertionHash: claimedPending.Hash})
+	watcher.pruneCompletedChallenges(ctx)
+	require.True(t, watcher.evicted.Has(claimingLatest))
+	require.False(t, watcher.evicted.Has(claimedConfirmed))
+	require.True(t, watcher.evicted.Has(claimedPending))
+}
+
+type recordingAlertSink struct {
+	events []*alerts.Event
+}
+
+func (*recordingAlertSink) Name() string {
+	return "recording"
+}
+
+func (s *recordingAlertSink) Send(_ context.Context, ev *alerts.Event) error {
+	s.events = append(s.events, ev)
+	return nil
+}
+
+func TestWatcher_ChallengeOpenedAlertsAfterInitialSync(t *testing.T) {
+	ctx := context.Background()
+	mockChain := &mocks.MockProtocol{}
+	sink := &recordingAlertSink{}
+	watcher := &Watcher{
+		challenges:       threadsafe.NewMap[protocol.AssertionHash, *trackedChallenge](),
+		evicted:          threadsafe.NewMap[protocol.AssertionHash, tombstone](),
+		chain:            mockChain,
+		numBigStepLevels: 1,
+		alerts:           alerts.NewDispatcher(alerts.WithSink(sink)),
+	}
+	newEdge := func(name string) *mocks.MockSpecEdge {
+		assertionHash := protocol.AssertionHash{Hash: common.BytesToHash([]byte(name))}
+		edgeId := protocol.EdgeId{Hash: common.BytesToHash([]byte(name + " edge"))}
+		edge := &mocks.MockSpecEdge{}
+		edge.On("AssertionHash", ctx).Return(assertionHash, nil)
+		edge.On("Id").Return(edgeId)
+		mockChain.On("IsChallengeComplete", ctx, assertionHash).Return(false, nil)
+		mockChain.On("TopLevelAssertion", ctx, edgeId).Return(protocol.AssertionHash{}, errors.New("bad request"))
+		return edge
+	}
+
+	// Challenges replayed during the initial sync were opened in the past.
+	require.Error(t, watcher.AddEdge(ctx, newEdge("old")))
+	watcher.alerts.Flush()
+	require.Len(t, sink.events, 0)
+
+	watcher.initialSyncCompleted.Store(true)
+	require.Error(t, watcher.AddEdge(ctx, newEdge("new")))
+	watcher.alerts.Flush()
+	require.Len(t, sink.events, 1)
+	require.Equal(t, alerts.ChallengeOpened, sink.events[0].Kind)
+}
@@ -106,7 +106,8 @@ func (ht *HonestChallengeTree) ComputeHonestPathTimer(
 
 // ComputeAncestorsWithTimers computes the ancestors of the given edge and their respective path timers, even
 // across challenge levels. Ancestor lists are linked through challenge levels via claimed edges. It is generalized
-// to any number of challenge levels in the protocol.
+// to any number of challenge levels in the protocol. Ancestors and the inputs to their local timers are memoised,
+// so computing them again as blocks advance does not walk the tree.
 func (ht *HonestChallengeTree) ComputeAncestorsWithTimers(
 	ctx context.Context,
 	edgeId protocol.EdgeId,
@@ -117,6 +118,38 @@ func (ht *HonestChallengeTree) ComputeAncestorsWithTimers(
 	if !ok {
 		return nil, errNotFound(edgeId)
 	}
+	ancestry, err := ht.honestAncestors(ctx, startEdge)
+	if err != nil {
+		return nil, err
+	}
+	localTimers := make([]EdgeLocalTimer, len(ancestry))
+	for i, ancestorId := range ancestry {
+		ancestor, found := ht.edges.TryGet(ancestorId)
+		if !found {
+			return nil, errNotFound(ancestorId)
+		}
+		timer, timerErr := ht.localTimer(ancestor, blockNumber)
+		if timerErr != nil {
+			return nil, timerErr
+		}
+		localTimers[i] = EdgeLocalTimer(timer)
+	}
+	return &AncestorsQueryResponse{
+		AncestorLocalTimers: localTimers,
+		AncestorEdgeIds:     ancestry,
+	}, nil
+}
+
+// Gets the honest ancestors of an edge from the timer cache, or computes and
+// caches them if they are not yet known.
+func (ht *HonestChallengeTree) honestAncestors(
+	ctx context.Context,
+	startEdge protocol.SpecEdge,
+) (HonestAncestors, error) {
+	if ancestry, ok := ht.timers.getAncestors(startEdge.Id()); ok {
+		// Callers own the returned slice.
+		return append(make(HonestAncestors, 0, len(ancestry)), ancestry...), nil
+	}
 	currentChallengeLevel := startEdge.GetReversedChallengeLevel()
 
 	// Set a cursor at the edge we start from. We will update this cursor
@@ -124,7 +157,6 @@ func (ht *HonestChallengeTree) ComputeAncestorsWithTimers(
 	currentEdge := startEdge
 
 	ancestry := make([]protocol.EdgeId, 0)
-	localTimers := make([]EdgeLocalTimer, 0)
 
 	// Challenge levels go from lowest to highest, where lowest is the smallest challenge level
 	// (where challenges are over individual, WASM opcodes). If we have 3 challenge levels,
@@ -138,17 +170,15 @@ func (ht *HonestChallengeTree) ComputeAncestorsWithTimers(
 		}
 
 		// Compute the ancestors for the current edge in the current challenge level.
-		ancestorLocalTimers, ancestorsAtLevel, err := ht.findHonestAncestorsWithinChallengeLevel(ctx, rootEdge, currentEdge, blockNumber)
+		ancestorsAtLevel, err := ht.findHonestAncestorsWithinChallengeLevel(ctx, rootEdge, currentEdge)
 		if err != nil {
 			return nil, err
 		}
 
-		// Expand the total ancestry and timers slices. We want ancestors from
+		// Expand the total ancestry slice. We want ancestors from
 		// the bottom-up, so we must reverse the output slice from the find function.
-		containers.Reverse(ancestorLocalTimers)
 		containers.Reverse(ancestorsAtLevel)
 		ancestry = append(ancestry, ancestorsAtLevel...)
-		localTimers = append(localTimers, ancestorLocalTimers...)
 
 		// Advance the challenge level.
 		currentChallengeLevel += 1
@@ -163,31 +193,15 @@ func (ht *HonestChallengeTree) ComputeAncestorsWithTimers(
 		if err != nil {
 			return nil, err
 		}
-		claimEdgeLocalTimer, err := ht.localTimer(nextLevelClaimedEdge, blockNumber)
-		if err != nil {
-			return nil, err
-		}
 
 		// Update the cursor to be the claimed edge at the next challenge level.
 		currentEdge = nextLevelClaimedEdge
 
 		// Include the next level claimed edge in the ancestry list.
 		ancestry = append(ancestry, nextLevelClaimedEdge.Id())
-		localTimers = append(localTimers, EdgeLocalTimer(claimEdgeLocalTimer))
-	}
-
-	// If the ancestry is empty, we just return an empty response.
-	if len(ancestry) == 0 {
-		return &AncestorsQueryResponse{
-			AncestorLocalTimers: make([]EdgeLocalTimer, 0),
-			AncestorEdgeIds:     ancestry,
-		}, nil
 	}
-
-	return &AncestorsQueryResponse{
-		AncestorLocalTimers: localTimers,
-		AncestorEdgeIds:     ancestry,
-	}, nil
+	ht.timers.putAncestors(startEdge.Id(), ancestry)
+	return append(make(HonestAncestors, 0, len(ancestry)), ancestry...), nil
 }
 
 // Computes the list of ancestors in a challenge level from a root edge down
@@ -197,63 +211,65 @@ func (ht *HonestChallengeTree) findHonestAncestorsWithinChallengeLevel(
 	ctx context.Context,
 	rootEdge protocol.ReadOnlyEdge,
 	queryingFor protocol.ReadOnlyEdge,
-	blockNumber uint64,
-) ([]EdgeLocalTimer, []protocol.EdgeId, error) {
+) ([]protocol.EdgeId, error) {
 	found := false
 	cursor := rootEdge
 	ancestry := make([]protocol.EdgeId, 0)
-	localTimers := make([]EdgeLocalTimer, 0)
 	wantedEdgeStart, _ := queryingFor.StartCommitment()
 
 	for {
 		if ctx.Err() != nil {
-			return nil, nil, ctx.Err()
+			return nil, ctx.Err()
 		}
 		if cursor.Id() == queryingFor.Id() {
 			found = true
 			break
 		}
-		// We expand the ancestry and timers' slices using the cursor edge.
+		// We expand the ancestry slice using the cursor edge.
 		ancestry = append(ancestry, cursor.Id())
-		timer, err := ht.localTimer(cursor, blockNumber)
-		if err != nil {
-			return nil, nil, err
-		}
-		localTimers = append(localTimers, EdgeLocalTimer(timer))
 
 		currStart, _ := cursor.StartCommitment()
 		currEnd, _ := cursor.EndCommitment()
 		bisectTo, err := bisection.Bisect(uint64(currStart), uint64(currEnd))
 		if err != nil {
-			return nil, nil, errors.Wrapf(err, "could not bisect start=%d, end=%d", currStart, currEnd)
+			return nil, errors.Wrapf(err, "could not bisect start=%d, end=%d", currStart, currEnd)
 		}
 		// If the wanted edge's start commitment is less than the bisection height of the current
 		// edge in the loop, it means it is part of its lower children.
 		if uint64(wantedEdgeStart) < bisectTo {
 			lowerChild, lowerErr := cursor.LowerChild(ctx)
 			if lowerErr != nil {
-				return nil, nil, errors.Wrapf(lowerErr, "could not get lower child for edge %#x", cursor.Id())
+				return nil, errors.Wrapf(lowerErr, "could not get lower child for edge %#x", cursor.Id())
 			}
 			if lowerChild.IsNone() {
-				return nil, nil, errors.Wrapf(ErrNoLowerChildYet, "edge id %#x", cursor.Id())
+				return nil, errors.Wrapf(ErrNoLowerChildYet, "edge id %#x", cursor.Id())
+			}
+			child, ok := ht.edges.TryGet(lowerChild.Unwrap())
+			if !ok {
+				// The child was made onchain, but not yet added to the tree.
+				return nil, errors.Wrapf(ErrNoLowerChildYet, "lower child %#x of edge %#x not in honest challenge tree", lowerChild.Unwrap(), cursor.Id())
 			}
-			cursor = ht.edges.Get(lowerChild.Unwrap())
+			cursor = child
 		} else {
 			// Else, it is part of the upper children.
 			upperChild, upperErr := cursor.UpperChild(ctx)
 			if upperErr != nil {
-				return nil, nil, errors.Wrapf(upperErr, "could not get upper child for edge %#x", cursor.Id())
+				return nil, errors.Wrapf(upperErr, "could not get upper child for edge %#x", cursor.Id())
 			}
 			if upperChild.IsNone() {
-				return nil, nil, fmt.Errorf("edge %#x had no upper child", cursor.Id())
+				return nil, fmt.Errorf("edge %#x had no upper child", cursor.Id())
+			}
+			child, ok := ht.edges.TryGet(upperChild.Unwrap())
+			if !ok {
+				return nil, fmt.Errorf("upper child %#x of edge %#x not in honest challenge tree", upperChild.Unwrap(), cursor.Id())
 			}
-			cursor = ht.edges.Get(upperChild.Unwrap())
+			cursor = child
 		}
 	}
 	if !found {
-		return nil, nil, errNotFound(queryingFor.Id())
+		return nil, errNotFound(queryingFor.Id())
 	}
-	return localTimers, ancestry, nil
+	return ancestry, nil
 }
 
 // Computes the root edge for a given child edge at a challenge level.
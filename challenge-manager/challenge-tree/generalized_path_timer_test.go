@@ -173,6 +173,21 @@ func TestComputeAncestorsWithTimers(t *testing.T) {
 		}
 		require.Equal(t, wanted, resp.AncestorEdgeIds)
 	})
+	t.Run("child not yet added to the tree errored", func(t *testing.T) {
+		root := tree.edges.Get(id("blk-0.a-16.a"))
+		queryingFor := tree.edges.Get(id("blk-4.a-5.a"))
+		lowerChild := tree.edges.Get(id("blk-0.a-8.a"))
+		tree.edges.Delete(lowerChild.Id())
+		_, err := tree.findHonestAncestorsWithinChallengeLevel(ctx, root, queryingFor)
+		require.ErrorIs(t, err, ErrNoLowerChildYet)
+		tree.edges.Put(lowerChild.Id(), lowerChild)
+
+		upperChild := tree.edges.Get(id("blk-4.a-8.a"))
+		tree.edges.Delete(upperChild.Id())
+		_, err = tree.findHonestAncestorsWithinChallengeLevel(ctx, root, queryingFor)
+		require.ErrorContains(t, err, "not in honest challenge tree")
+		tree.edges.Put(upperChild.Id(), upperChild)
+	})
 }
 
 func TestHasConfirmableAncestor(t *testing.T) {
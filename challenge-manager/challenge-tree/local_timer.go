@@ -14,30 +14,25 @@ import (
 // Gets the local timer of an edge at a block number, T. If T is earlier than the edge's creation,
 // this function will return 0.
 func (ht *HonestChallengeTree) localTimer(e protocol.ReadOnlyEdge, blockNum uint64) (uint64, error) {
-	createdAtBlock, err := e.CreatedAtBlock()
+	r, err := ht.rivalry(e)
 	if err != nil {
 		return 0, err
 	}
-	if blockNum <= createdAtBlock {
+	if blockNum <= r.createdAt {
 		return 0, nil
 	}
 	// If no rival at a block num, then the local timer is defined
 	// as t - t_creation(e).
-	unrivaled, err := ht.unrivaledAtBlockNum(e, blockNum)
-	if err != nil {
-		return 0, err
-	}
-	if unrivaled {
-		return blockNum - createdAtBlock, nil
+	if r.unrivaledAt(blockNum) {
+		return blockNum - r.createdAt, nil
 	}
 	// Else we return the earliest created rival's block number: t_rival - t_creation(e).
 	// This unwrap is safe because the edge has rivals at this point due to the check above.
-	earliest := ht.earliestCreatedRivalBlockNumber(e)
-	tRival := earliest.Unwrap()
-	if createdAtBlock >= tRival {
+	tRival := r.earliestRival.Unwrap()
+	if r.createdAt >= tRival {
 		return 0, nil
 	}
-	return tRival - createdAtBlock, nil
+	return tRival - r.createdAt, nil
 }
 
 // Gets the minimum creation block number across all of an edge's rivals. If an edge
@@ -60,29 +55,23 @@ func (ht *HonestChallengeTree) earliestCreatedRivalBlockNumber(e protocol.ReadOn
 // Determines if an edge was unrivaled at a block num T. If any rival existed
 // for the edge at T, this function will return false.
 func (ht *HonestChallengeTree) unrivaledAtBlockNum(e protocol.ReadOnlyEdge, blockNum uint64) (bool, error) {
-	createdAtBlock, err := e.CreatedAtBlock()
+	r, err := ht.rivalry(e)
 	if err != nil {
 		return false, err
 	}
-	if blockNum < createdAtBlock {
+	if blockNum < r.createdAt {
 		return false, fmt.Errorf(
 			"edge creation block %d less than specified %d",
-			createdAtBlock,
+			r.createdAt,
 			blockNum,
 		)
 	}
-	rivals := ht.rivalsWithCreationTimes(e)
-	if len(rivals) == 0 {
-		return true, nil
-	}
-	for _, r := range rivals {
-		// If a rival existed before or at the time of the edge's
-		// creation, we then return false.
-		if uint64(r.createdAtBlock) <= blockNum {
-			return false, nil
-		}
-	}
-	return true, nil
+	return r.unrivaledAt(blockNum), nil
+}
+
+// If a rival existed before or at a block num T, the edge was rivaled at T.
+func (r rivalry) unrivaledAt(blockNum uint64) bool {
+	return r.earliestRival.IsNone() || r.earliestRival.Unwrap() > blockNum
 }
 
 // Contains a rival edge's id and its creation block number.
@@ -0,0 +1,95 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package challengetree
+
+import (
+	"sync"
+
+	protocol "github.com/OffchainLabs/bold/chain-abstraction"
+	"github.com/OffchainLabs/bold/containers/option"
+)
+
+// Edge trackers compute the path timers of their edges on every tick, which requires
+// walking an edge's honest ancestors across challenge levels, reading their children,
+// and scanning the rivals of each ancestor. The timer cache memoises the parts of that
+// computation which only change when edges are added to the tree:
+//
+//   - The honest ancestors of an edge never change once computed, as an edge's children
+//     and claim can only be set once.
+//   - The local timer of an edge only depends on its creation block and the creation
+//     block of its earliest rival. These are recomputed once the number of edges sharing
+//     its mutual id changes, that is, once a rival arrives.
+//
+// As blocks advance, path timers are then computed from the memoised values without
+// any chain reads or scans over rivals.
+type timerCache struct {
+	lock      sync.RWMutex
+	ancestors map[protocol.EdgeId]HonestAncestors
+	rivalries map[protocol.EdgeId]rivalry
+}
+
+// The inputs needed to compute an edge's local timer at any block number.
+type rivalry struct {
+	createdAt     uint64
+	earliestRival option.Option[uint64]
+	// The number of edges sharing the edge's mutual id when computed, including itself.
+	numMutuals uint64
+}
+
+func (c *timerCache) getAncestors(edgeId protocol.EdgeId) (HonestAncestors, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	ancestors, ok := c.ancestors[edgeId]
+	return ancestors, ok
+}
+
+func (c *timerCache) putAncestors(edgeId protocol.EdgeId, ancestors HonestAncestors) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.ancestors == nil {
+		c.ancestors = make(map[protocol.EdgeId]HonestAncestors)
+	}
+	c.ancestors[edgeId] = ancestors
+}
+
+func (c *timerCache) getRivalry(edgeId protocol.EdgeId) (rivalry, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	r, ok := c.rivalries[edgeId]
+	return r, ok
+}
+
+func (c *timerCache) putRivalry(edgeId protocol.EdgeId, r rivalry) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.rivalries == nil {
+		c.rivalries = make(map[protocol.EdgeId]rivalry)
+	}
+	c.rivalries[edgeId] = r
+}
+
+// Gets the memoised creation block and earliest rival creation block of an edge,
+// recomputing them if rivals arrived since they were last computed.
+func (ht *HonestChallengeTree) rivalry(e protocol.ReadOnlyEdge) (rivalry, error) {
+	// The number of mutuals is read before scanning rivals, so a rival arriving
+	// concurrently can only cause an extra recomputation later on.
+	numMutuals := uint64(0)
+	if mutuals, ok := ht.mutualIds.TryGet(e.MutualId()); ok && mutuals != nil {
+		numMutuals = mutuals.NumItems()
+	}
+	if r, ok := ht.timers.getRivalry(e.Id()); ok && r.numMutuals == numMutuals {
+		return r, nil
+	}
+	createdAt, err := e.CreatedAtBlock()
+	if err != nil {
+		return rivalry{}, err
+	}
+	r := rivalry{
+		createdAt:     createdAt,
+		earliestRival: ht.earliestCreatedRivalBlockNumber(e),
+		numMutuals:    numMutuals,
+	}
+	ht.timers.putRivalry(e.Id(), r)
+	return r, nil
+}
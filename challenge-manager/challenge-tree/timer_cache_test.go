@@ -0,0 +1,194 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package challengetree
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	protocol "github.com/OffchainLabs/bold/chain-abstraction"
+	"github.com/OffchainLabs/bold/challenge-manager/challenge-tree/mock"
+	"github.com/OffchainLabs/bold/containers/threadsafe"
+	bisection "github.com/OffchainLabs/bold/math"
+	"github.com/stretchr/testify/require"
+)
+
+// Forgets everything memoised, so path timers are computed from scratch.
+func (c *timerCache) reset() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.ancestors = nil
+	c.rivalries = nil
+}
+
+// A challenge across all three challenge levels in which the honest party bisected
+// down to a one-step edge at every level, with each honest edge along the way rivaled
+// one block after its creation.
+type deepChallenge struct {
+	tree *HonestChallengeTree
+	// The honest root edges at each level, from the block challenge level down.
+	roots []*mock.Edge
+	// The honest one-step edge at the small step level, which has the deepest ancestry.
+	leaf *mock.Edge
+	// The block at which the leaf was created.
+	lastBlock uint64
+}
+
+func setupDeepChallenge(tb testing.TB, heightPerLevel uint64) *deepChallenge {
+	tb.Helper()
+	ht := &HonestChallengeTree{
+		edges:                  threadsafe.NewMap[protocol.EdgeId, protocol.SpecEdge](),
+		mutualIds:              threadsafe.NewMap[protocol.MutualId, *threadsafe.Map[protocol.EdgeId, creationTime]](),
+		metadataReader:         &mockMetadataReader{unrivaledAssertionBlocks: 1},
+		totalChallengeLevels:   3,
+		honestRootEdgesByLevel: threadsafe.NewMap[protocol.ChallengeLevel, *threadsafe.Slice[protocol.ReadOnlyEdge]](),
+	}
+	c := &deepChallenge{tree: ht}
+	block := uint64(1)
+	addEdge := func(e *mock.Edge) {
+		ht.edges.Put(e.Id(), e)
+		mutuals, ok := ht.mutualIds.TryGet(e.MutualId())
+		if !ok {
+			mutuals = threadsafe.NewMap[protocol.EdgeId, creationTime]()
+			ht.mutualIds.Put(e.MutualId(), mutuals)
+		}
+		mutuals.Put(e.Id(), creationTime(e.CreationBlock))
+	}
+	newLevelEdge := func(level protocol.ChallengeLevel, prefix string, origin mock.OriginId, start, end uint64, commit string) *mock.Edge {
+		return &mock.Edge{
+			ID:                   mock.EdgeId(fmt.Sprintf("%s-%d.a-%d.%s", prefix, start, end, commit)),
+			EdgeType:             level,
+			StartHeight:          start,
+			StartCommit:          "a",
+			EndHeight:            end,
+			EndCommit:            mock.Commit(commit),
+			OriginID:             origin,
+			CreationBlock:        block,
+			TotalChallengeLevels: 3,
+		}
+	}
+	var claimed *mock.Edge
+	for level, prefix := range []string{"blk", "big", "smol"} {
+		var origin mock.OriginId
+		claimId := "assertion"
+		if claimed != nil {
+			origin = mock.OriginId(claimed.ComputeMutualId())
+			claimId = string(claimed.ID)
+		}
+		challengeLevel := protocol.ChallengeLevel(level)
+		cursor := newLevelEdge(challengeLevel, prefix, origin, 0, heightPerLevel, "a")
+		cursor.ClaimID = claimId
+		addEdge(cursor)
+		rootEdges := threadsafe.NewSlice[protocol.ReadOnlyEdge]()
+		rootEdges.Push(cursor)
+		ht.honestRootEdgesByLevel.Put(cursor.GetReversedChallengeLevel(), rootEdges)
+		c.roots = append(c.roots, cursor)
+		for cursor.EndHeight-cursor.StartHeight > 1 {
+			block++
+			addEdge(newLevelEdge(challengeLevel, prefix, origin, cursor.StartHeight, cursor.EndHeight, "b"))
+			block++
+			mid, err := bisection.Bisect(cursor.StartHeight, cursor.EndHeight)
+			require.NoError(tb, err)
+			lower := newLevelEdge(challengeLevel, prefix, origin, cursor.StartHeight, mid, "a")
+			upper := newLevelEdge(challengeLevel, prefix, origin, mid, cursor.EndHeight, "a")
+			addEdge(lower)
+			addEdge(upper)
+			cursor.LowerChildID = lower.ID
+			cursor.UpperChildID = upper.ID
+			cursor = lower
+		}
+		claimed = cursor
+	}
+	c.leaf = claimed
+	c.lastBlock = block
+	return c
+}
+
+func TestComputeAncestorsWithTimers_Memoised(t *testing.T) {
+	ctx := context.Background()
+	c := setupDeepChallenge(t, 1<<8)
+	ht := c.tree
+	leafId := c.leaf.Id()
+
+	// Memoised timers match those computed from scratch as blocks advance.
+	for block := c.lastBlock; block < c.lastBlock+10; block++ {
+		memoised, err := ht.ComputeAncestorsWithTimers(ctx, leafId, block)
+		require.NoError(t, err)
+		ht.timers.reset()
+		fresh, err := ht.ComputeAncestorsWithTimers(ctx, leafId, block)
+		require.NoError(t, err)
+		require.Equal(t, fresh, memoised)
+		require.Len(t, memoised.AncestorEdgeIds, 3*8+2)
+	}
+
+	// Ancestors are not computed again, so an edge's children are not read again.
+	lowerChild := c.roots[0].LowerChildID
+	c.roots[0].LowerChildID = ""
+	_, err := ht.ComputeAncestorsWithTimers(ctx, leafId, c.lastBlock)
+	require.NoError(t, err)
+	c.roots[0].LowerChildID = lowerChild
+
+	// The unrivaled leaf's local timer grows with every block.
+	timer, err := ht.localTimer(c.leaf, c.lastBlock+5)
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), timer)
+
+	// Once a rival arrives, the leaf's timer stops growing.
+	rival := *c.leaf
+	rival.ID = "smol-0.a-1.b"
+	rival.EndCommit = "b"
+	rival.CreationBlock = c.lastBlock + 3
+	ht.mutualIds.Get(c.leaf.MutualId()).Put(rival.Id(), creationTime(rival.CreationBlock))
+	for block := c.lastBlock + 3; block < c.lastBlock+10; block++ {
+		timer, err = ht.localTimer(c.leaf, block)
+		require.NoError(t, err)
+		require.Equal(t, uint64(3), timer)
+	}
+	before, err := ht.ComputeAncestorsWithTimers(ctx, leafId, c.lastBlock+10)
+	require.NoError(t, err)
+	pathTimer, err := ht.ComputeHonestPathTimer(ctx, leafId, before.AncestorLocalTimers, c.lastBlock+10)
+	require.NoError(t, err)
+	ht.timers.reset()
+	after, err := ht.ComputeAncestorsWithTimers(ctx, leafId, c.lastBlock+10)
+	require.NoError(t, err)
+	freshPathTimer, err := ht.ComputeHonestPathTimer(ctx, leafId, after.AncestorLocalTimers, c.lastBlock+10)
+	require.NoError(t, err)
+	require.Equal(t, after, before)
+	require.Equal(t, freshPathTimer, pathTimer)
+}
+
+// Compares computing path timers of the deepest edge in a challenge from scratch, as
+// was done on every tick before path timers were memoised, with computing them as
+// blocks advance.
+func BenchmarkComputeHonestPathTimer(b *testing.B) {
+	ctx := context.Background()
+	for _, bisections := range []uint64{8, 16, 32} {
+		c := setupDeepChallenge(b, 1<<bisections)
+		ht := c.tree
+		leafId := c.leaf.Id()
+		compute := func(b *testing.B, block uint64) {
+			resp, err := ht.ComputeAncestorsWithTimers(ctx, leafId, block)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, err = ht.ComputeHonestPathTimer(ctx, leafId, resp.AncestorLocalTimers, block); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.Run(fmt.Sprintf("bisections=%d/uncached", bisections), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				ht.timers.reset()
+				compute(b, c.lastBlock+uint64(i))
+			}
+		})
+		b.Run(fmt.Sprintf("bisections=%d/new_block", bisections), func(b *testing.B) {
+			compute(b, c.lastBlock)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				compute(b, c.lastBlock+uint64(i))
+			}
+		})
+	}
+}
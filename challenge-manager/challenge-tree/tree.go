@@ -42,6 +42,7 @@ type HonestChallengeTree struct {
 	validatorName          string
 	totalChallengeLevels   uint8
 	honestRootEdgesByLevel *threadsafe.Map[protocol.ChallengeLevel, *threadsafe.Slice[protocol.ReadOnlyEdge]]
+	timers                 timerCache
 }
 
 func New(
@@ -64,6 +65,39 @@ func New(
 	}
 }
 
+// Returns the module root the machines executing the claimed assertion are of, which
+// is the root in the config of the challenged assertion, as the claimed assertion is its
+// child. It differs from the root in the claimed assertion's own config after an upgrade
+// of the chain.
+func (ht *HonestChallengeTree) claimWasmModuleRoot(
+	ctx context.Context,
+	claimedAssertionHash protocol.AssertionHash,
+	claimedCreationInfo *protocol.AssertionCreatedInfo,
+) (common.Hash, error) {
+	if claimedCreationInfo.ParentAssertionHash != ht.topLevelAssertionHash.Hash {
+		return common.Hash{}, errors.Wrapf(
+			ErrMismatchedWasmModuleRoot,
+			"claimed assertion %#x is a child of %#x, not of challenged assertion %#x",
+			claimedAssertionHash.Hash,
+			claimedCreationInfo.ParentAssertionHash,
+			ht.topLevelAssertionHash.Hash,
+		)
+	}
+	challengedCreationInfo, err := ht.metadataReader.ReadAssertionCreationInfo(ctx, ht.topLevelAssertionHash)
+	if err != nil {
+		return common.Hash{}, errors.Wrapf(err, "could not read config of challenged assertion %#x", ht.topLevelAssertionHash.Hash)
+	}
+	if challengedCreationInfo.AssertionHash != (common.Hash{}) && challengedCreationInfo.AssertionHash != ht.topLevelAssertionHash.Hash {
+		return common.Hash{}, errors.Wrapf(
+			ErrMismatchedWasmModuleRoot,
+			"read config of assertion %#x for challenged assertion %#x",
+			challengedCreationInfo.AssertionHash,
+			ht.topLevelAssertionHash.Hash,
+		)
+	}
+	return challengedCreationInfo.WasmModuleRoot, nil
+}
+
 // HonestBlockChallengeRootEdge gets the honest, root challenge block edge for the top level assertion
 // being challenged.
 func (ht *HonestChallengeTree) HonestBlockChallengeRootEdge() (protocol.ReadOnlyEdge, error) {
@@ -79,12 +113,14 @@ func (ht *HonestChallengeTree) HonestBlockChallengeRootEdge() (protocol.ReadOnly
 		}
 		return rootEdges.Get(0).Unwrap(), nil
 	}
-	return nil, fmt.Errorf("no honest root edges for block challenge level for assertion %#x", ht.topLevelAssertionHash)
+	return nil, errors.Wrapf(ErrNoHonestRootEdge, "assertion %#x", ht.topLevelAssertionHash)
 }
 
 var (
+	ErrNoHonestRootEdge                 = errors.New("no honest root edges for block challenge level")
 	ErrAlreadyBeingTracked              = errors.New("edge already being tracked")
 	ErrMismatchedChallengeAssertionHash = errors.New("edge challenged assertion hash is not the expected one for the challenge")
+	ErrMismatchedWasmModuleRoot         = errors.New("claimed assertion is not executed by the module root of the challenged assertion")
 )
 
 // AddEdge to the honest challenge tree. Only honest edges are tracked, but we also keep track
@@ -129,6 +165,11 @@ func (ht *HonestChallengeTree) AddEdge(ctx context.Context, eg protocol.SpecEdge
 	fromBatch := l2stateprovider.Batch(protocol.GoGlobalStateFromSolidity(creationInfo.BeforeState.GlobalState).Batch)
 	toBatch := l2stateprovider.Batch(protocol.GoGlobalStateFromSolidity(creationInfo.AfterState.GlobalState).Batch)
 
+	wasmModuleRoot, err := ht.claimWasmModuleRoot(ctx, claimedAssertionHash, creationInfo)
+	if err != nil {
+		return protocol.Agreement{}, err
+	}
+
 	// We only track edges we fully agree with (honest edges).
 	startHeight, startCommit := eg.StartCommitment()
 	endHeight, endCommit := eg.EndCommitment()
@@ -146,7 +187,7 @@ func (ht *HonestChallengeTree) AddEdge(ctx context.Context, eg protocol.SpecEdge
 	var agreesWithStart bool
 	if challengeLevel == protocol.NewBlockChallengeLevel() {
 		request := &l2stateprovider.HistoryCommitmentRequest{
-			WasmModuleRoot:              creationInfo.WasmModuleRoot,
+			WasmModuleRoot:              wasmModuleRoot,
 			FromBatch:                   fromBatch,
 			ToBatch:                     toBatch,
 			FromHeight:                  0,
@@ -182,7 +223,7 @@ func (ht *HonestChallengeTree) AddEdge(ctx context.Context, eg protocol.SpecEdge
 			return protocol.Agreement{}, errors.New("start height cannot be zero")
 		}
 		request := &l2stateprovider.HistoryCommitmentRequest{
-			WasmModuleRoot:              creationInfo.WasmModuleRoot,
+			WasmModuleRoot:              wasmModuleRoot,
 			FromBatch:                   fromBatch,
 			ToBatch:                     toBatch,
 			FromHeight:                  l2stateprovider.Height(0),
@@ -105,6 +105,86 @@ func TestAddEdge(t *testing.T) {
 		_, err := ht.AddEdge(ctx, edge)
 		require.ErrorContains(t, err, "could not check if agrees with")
 	})
+	t.Run("checks agreement under the module root of the parent assertion", func(t *testing.T) {
+		// The chain's module root was upgraded between the parent and the claimed assertion.
+		claimedAssertionHash := common.BytesToHash([]byte("claimed"))
+		parentAssertionHash := ht.topLevelAssertionHash.Hash
+		oldRoot, newRoot := common.Hash{1}, common.Hash{2}
+		edge := newEdge(&newCfg{t: t, edgeId: "blk-0.a-16.c", createdAt: 1, claimId: "claimed"})
+		ht.metadataReader = &mockMetadataReader{
+			assertionErr:  nil,
+			assertionHash: ht.topLevelAssertionHash,
+			creationInfos: map[common.Hash]*protocol.AssertionCreatedInfo{
+				claimedAssertionHash: {
+					ParentAssertionHash: parentAssertionHash,
+					WasmModuleRoot:      newRoot,
+					InboxMaxCount:       big.NewInt(1),
+				},
+				parentAssertionHash: {
+					WasmModuleRoot: oldRoot,
+					InboxMaxCount:  big.NewInt(1),
+				},
+			},
+		}
+		end, endCommit := edge.EndCommitment()
+		mockStateManager := &mocks.MockStateManager{}
+		mockStateManager.On(
+			"AgreesWithHistoryCommitment",
+			ctx,
+			protocol.NewBlockChallengeLevel(),
+			&l2stateprovider.HistoryCommitmentRequest{
+				WasmModuleRoot:              oldRoot,
+				FromBatch:                   0,
+				ToBatch:                     0,
+				UpperChallengeOriginHeights: []l2stateprovider.Height{},
+				FromHeight:                  0,
+				UpToHeight:                  option.Some[l2stateprovider.Height](l2stateprovider.Height(end)),
+			},
+			l2stateprovider.History{
+				Height:     uint64(end),
+				MerkleRoot: endCommit,
+			},
+		).Return(false, l2stateprovider.ErrUnknownWasmModuleRoot)
+		ht.histChecker = mockStateManager
+		_, err := ht.AddEdge(ctx, edge)
+		require.ErrorIs(t, err, l2stateprovider.ErrUnknownWasmModuleRoot)
+		mockStateManager.AssertExpectations(t)
+	})
+	t.Run("rejects claims not executed by the module root of the challenged assertion", func(t *testing.T) {
+		claimedAssertionHash := common.BytesToHash([]byte("claimed"))
+		edge := newEdge(&newCfg{t: t, edgeId: "blk-0.a-16.c", createdAt: 1, claimId: "claimed"})
+		mockStateManager := &mocks.MockStateManager{}
+		ht.histChecker = mockStateManager
+
+		// The claimed assertion is not a child of the challenged one, so it is executed by
+		// the module root in the config of another assertion.
+		ht.metadataReader = &mockMetadataReader{
+			assertionHash: ht.topLevelAssertionHash,
+			creationInfos: map[common.Hash]*protocol.AssertionCreatedInfo{
+				claimedAssertionHash: {
+					ParentAssertionHash: common.BytesToHash([]byte("other")),
+					InboxMaxCount:       big.NewInt(1),
+				},
+			},
+		}
+		_, err := ht.AddEdge(ctx, edge)
+		require.ErrorIs(t, err, ErrMismatchedWasmModuleRoot)
+
+		// The config read for the challenged assertion is that of another assertion.
+		ht.metadataReader = &mockMetadataReader{
+			assertionHash: ht.topLevelAssertionHash,
+			creationInfos: map[common.Hash]*protocol.AssertionCreatedInfo{
+				ht.topLevelAssertionHash.Hash: {
+					AssertionHash:  common.BytesToHash([]byte("other")),
+					WasmModuleRoot: common.Hash{1},
+					InboxMaxCount:  big.NewInt(1),
+				},
+			},
+		}
+		_, err = ht.AddEdge(ctx, edge)
+		require.ErrorIs(t, err, ErrMismatchedWasmModuleRoot)
+		mockStateManager.AssertNotCalled(t, "AgreesWithHistoryCommitment")
+	})
 	t.Run("fully disagrees with edge", func(t *testing.T) {
 		ht.metadataReader = &mockMetadataReader{
 			assertionErr:  nil,
@@ -398,6 +478,8 @@ type mockMetadataReader struct {
 	claimHeights             protocol.OriginHeights
 	claimHeightsErr          error
 	unrivaledAssertionBlocks uint64
+	// Optional, the creation info of assertions by hash.
+	creationInfos map[common.Hash]*protocol.AssertionCreatedInfo
 }
 
 func (m *mockMetadataReader) TopLevelAssertion(
@@ -422,9 +504,13 @@ func (m *mockMetadataReader) SpecChallengeManager(_ context.Context) (protocol.S
 	return nil, nil
 }
 func (m *mockMetadataReader) ReadAssertionCreationInfo(
-	_ context.Context, _ protocol.AssertionHash,
+	_ context.Context, assertionHash protocol.AssertionHash,
 ) (*protocol.AssertionCreatedInfo, error) {
-	return &protocol.AssertionCreatedInfo{InboxMaxCount: big.NewInt(1)}, nil
+	if info, ok := m.creationInfos[assertionHash.Hash]; ok {
+		return info, nil
+	}
+	// Assertions are children of the challenged one by default.
+	return &protocol.AssertionCreatedInfo{ParentAssertionHash: m.assertionHash.Hash, InboxMaxCount: big.NewInt(1)}, nil
 }
 
 type newCfg struct {
@@ -12,7 +12,9 @@ import (
 
 	protocol "github.com/OffchainLabs/bold/chain-abstraction"
 	edgetracker "github.com/OffchainLabs/bold/challenge-manager/edge-tracker"
+	"github.com/OffchainLabs/bold/challenge-manager/stakers"
 	"github.com/OffchainLabs/bold/containers"
+	"github.com/OffchainLabs/bold/events"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/pkg/errors"
 )
@@ -21,6 +23,12 @@ import (
 // and starting a challenge transaction. If the challenge creation is successful, we add a leaf
 // with an associated history commitment to it and spawn a challenge tracker in the background.
 func (m *Manager) ChallengeAssertion(ctx context.Context, id protocol.AssertionHash) error {
+	if m.stopping.Load() {
+		return ErrShuttingDown
+	}
+	if !m.MovesAllowed() {
+		return ErrMovesPaused
+	}
 	assertion, err := m.chain.GetAssertion(ctx, id)
 	if err != nil {
 		return errors.Wrapf(err, "could not get assertion to challenge with id %#x", id)
@@ -51,6 +59,12 @@ func (m *Manager) ChallengeAssertion(ctx context.Context, id protocol.AssertionH
 		srvlog.Info("Root level edge for challenged assertion already exists, skipping move", log.Ctx{"assertionHash": id.Hash})
 		return nil
 	}
+	m.events.Publish(ctx, &events.MoveSubmitted{
+		EdgeId:              levelZeroEdge.Id(),
+		ChallengedAssertion: parentAssertionHash,
+		Move:                "open_challenge",
+		Validator:           m.name,
+	})
 	if verifiedErr := m.watcher.AddVerifiedHonestEdge(ctx, levelZeroEdge); verifiedErr != nil {
 		fields := log.Ctx{
 			"edgeId": levelZeroEdge.Id(),
@@ -67,14 +81,12 @@ func (m *Manager) ChallengeAssertion(ctx context.Context, id protocol.AssertionH
 		m.watcher,
 		m,
 		edgeTrackerAssertionInfo,
-		edgetracker.WithActInterval(m.edgeTrackerWakeInterval),
-		edgetracker.WithTimeReference(m.timeRef),
-		edgetracker.WithValidatorName(m.name),
+		m.edgeTrackerOpts()...,
 	)
 	if err != nil {
 		return err
 	}
-	go tracker.Spawn(ctx)
+	tracker.Spawn(ctx)
 
 	srvlog.Info("Successfully created level zero edge for block challenge", log.Ctx{
 		"name":          m.name,
@@ -164,10 +176,26 @@ func (m *Manager) addBlockChallengeLevelZeroEdge(
 	if err != nil {
 		return nil, nil, false, err
 	}
+	var staker *stakers.Selection
+	if m.stakers != nil {
+		// Fund the mini-stake of the edge from the identity selected for it.
+		var selectErr error
+		staker, selectErr = m.stakers.Select(ctx, stakers.MiniStaker)
+		if selectErr != nil {
+			return nil, nil, false, selectErr
+		}
+		manager, err = staker.SpecChallengeManager(ctx)
+		if err != nil {
+			return nil, nil, false, err
+		}
+	}
 	edge, err := manager.AddBlockChallengeLevelZeroEdge(ctx, assertion, startCommit, endCommit, startEndPrefixProof)
 	if err != nil {
 		return nil, nil, false, errors.Wrap(err, "could not post block challenge root edge")
 	}
+	if staker != nil {
+		staker.Used()
+	}
 	return edge, &edgetracker.AssociatedAssertionMetadata{
 		FromBatch:      fromBatch,
 		ToBatch:        toBatch,
@@ -0,0 +1,518 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+// Package confirmer confirms every confirmable honest edge and assertion on behalf of
+// other validators. It backs challenge managers run as confirmers, which do not stake,
+// but keep challenges progressing even if the stakers that opened them are offline.
+// Transactions are capped by a gas budget.
+//
+// Confirmations are not batched into fewer transactions, as neither the challenge
+// manager nor the rollup contract has an entrypoint confirming more than one edge or
+// assertion at once. Instead, no transaction is spent on an edge confirmable by time
+// whose ancestor is also confirmable by time, as confirming the ancestor settles the
+// subchallenges below it at once.
+package confirmer
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	protocol "github.com/OffchainLabs/bold/chain-abstraction"
+	watcher "github.com/OffchainLabs/bold/challenge-manager/chain-watcher"
+	challengetree "github.com/OffchainLabs/bold/challenge-manager/challenge-tree"
+	edgetracker "github.com/OffchainLabs/bold/challenge-manager/edge-tracker"
+	"github.com/OffchainLabs/bold/challenge-manager/types"
+	"github.com/OffchainLabs/bold/containers"
+	"github.com/OffchainLabs/bold/events"
+	"github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/pkg/errors"
+)
+
+var (
+	srvlog                      = log.New("service", "confirmer")
+	edgesConfirmedCounter       = metrics.NewRegisteredCounter("arb/validator/confirmer/edges_confirmed", nil)
+	assertionsConfirmedCounter  = metrics.NewRegisteredCounter("arb/validator/confirmer/assertions_confirmed", nil)
+	confirmationErrorsCounter   = metrics.NewRegisteredCounter("arb/validator/confirmer/confirmation_errors", nil)
+	gasSpentGweiGauge           = metrics.NewRegisteredGauge("arb/validator/confirmer/gas_spent_gwei", nil)
+	errGasBudgetExhausted       = errors.New("gas budget exhausted")
+	gwei                        = big.NewInt(1_000_000_000)
+	defaultConfirmationInterval = time.Second * 10
+)
+
+func init() {
+	srvlog.SetHandler(log.StreamHandler(os.Stdout, log.LogfmtFormat()))
+}
+
+// EdgeSource provides the honest edges which can be confirmed, along with the
+// information needed to confirm them. It is implemented by the chain watcher.
+type EdgeSource interface {
+	GetHonestConfirmableEdges(ctx context.Context) (map[string][]protocol.SpecEdge, error)
+	ConfirmedEdgeWithClaimExists(
+		topLevelAssertionHash protocol.AssertionHash,
+		claimId protocol.ClaimId,
+	) (protocol.EdgeId, bool)
+	ComputeHonestPathTimer(
+		ctx context.Context,
+		topLevelAssertionHash protocol.AssertionHash,
+		edgeId protocol.EdgeId,
+	) (challengetree.PathTimer, challengetree.HonestAncestors, []challengetree.EdgeLocalTimer, error)
+}
+
+// OneStepProver confirms honest edges of length one at the lowest challenge level
+// by one step proof, which requires the machine state of the challenged assertion.
+type OneStepProver interface {
+	ProveOneStep(ctx context.Context, edge protocol.SpecEdge) error
+}
+
+type Opt func(c *Confirmer)
+
+// WithInterval specifies how often the confirmer looks for confirmable edges and
+// assertions. The default is ten seconds.
+func WithInterval(d time.Duration) Opt {
+	return func(c *Confirmer) {
+		c.interval = d
+	}
+}
+
+// WithGasBudget caps the wei spent by the account sending confirmations. Spending is
+// measured from the receipts of the confirmations, as the gas used times the effective
+// gas price, so reverted confirmations count towards the budget too. Once the budget
+// is exhausted, no more confirmations are sent.
+func WithGasBudget(account common.Address, budget *big.Int) Opt {
+	return func(c *Confirmer) {
+		c.account = account
+		c.budget = budget
+	}
+}
+
+// WithEventBus learns of new assertions from the events published on the bus, and
+// publishes the edge confirmations made by the confirmer.
+func WithEventBus(bus *events.Bus) Opt {
+	return func(c *Confirmer) {
+		c.events = bus
+	}
+}
+
+// WithOneStepProver enables confirming edges by one step proof.
+func WithOneStepProver(p OneStepProver) Opt {
+	return func(c *Confirmer) {
+		c.prover = p
+	}
+}
+
+// WithValidatorName is a human-readable identifier for the validator in logs and events.
+func WithValidatorName(name string) Opt {
+	return func(c *Confirmer) {
+		c.validatorName = name
+	}
+}
+
+// Confirmer confirms honest edges by one step proof, children, claim or time, and
+// unrivaled assertions by time, regardless of who staked on them.
+type Confirmer struct {
+	chain        protocol.Protocol
+	edges        EdgeSource
+	reader       types.ChallengeReader
+	interval     time.Duration
+	events       *events.Bus
+	subscription *events.Subscription
+	// Held while the confirmer runs, as it can be stopped and started again.
+	runLock       sync.Mutex
+	prover        OneStepProver
+	validatorName string
+	// Gas budget
+	account       common.Address
+	budget        *big.Int
+	spentLock     sync.Mutex
+	spent         *big.Int
+	budgetWarning sync.Once
+	// Assertions not yet known to be confirmed, by hash.
+	pendingLock sync.Mutex
+	pending     map[protocol.AssertionHash]uint64
+}
+
+// New creates a confirmer of the edges provided by the source. The challenge reader
+// tells whether this instance is the leader which should act onchain.
+func New(
+	chain protocol.Protocol,
+	edges EdgeSource,
+	reader types.ChallengeReader,
+	opts ...Opt,
+) (*Confirmer, error) {
+	c := &Confirmer{
+		chain:    chain,
+		edges:    edges,
+		reader:   reader,
+		interval: defaultConfirmationInterval,
+		spent:    new(big.Int),
+		pending:  make(map[protocol.AssertionHash]uint64),
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	if c.budget != nil {
+		if c.account == (common.Address{}) {
+			return nil, errors.New("gas budget requires the account sending confirmations")
+		}
+	}
+	if c.events != nil {
+		// Subscribe right away, so that no assertion created before Start is missed.
+		c.subscribe()
+	}
+	return c, nil
+}
+
+// Every assertion event must be seen, so publishers wait for the confirmer, whose
+// events are handled apart from confirmations so they never wait long.
+func (c *Confirmer) subscribe() {
+	c.subscription = c.events.Subscribe(
+		"confirmer",
+		events.WithKinds(events.KindAssertionCreated, events.KindAssertionConfirmed),
+		events.WithBackpressure(events.Block),
+	)
+}
+
+// Start confirming edges and assertions until the context is done. A stopped confirmer
+// can be started again, and learns of the assertions created from then on.
+func (c *Confirmer) Start(ctx context.Context) {
+	c.runLock.Lock()
+	defer c.runLock.Unlock()
+	srvlog.Info("Started confirmer", log.Ctx{"validatorName": c.validatorName})
+	if c.events != nil {
+		if c.subscription == nil {
+			c.subscribe()
+		}
+		// Confirmations send transactions, which may take a while and publish events
+		// themselves, so events are handled separately to not hold up publishers.
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.handleEvents(ctx)
+		}()
+		defer func() {
+			// Publishers must not wait for a confirmer which is not running.
+			c.subscription.Unsubscribe()
+			wg.Wait()
+			c.subscription = nil
+		}()
+	}
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if !c.reader.IsLeader() {
+				continue
+			}
+			if _, err := c.Confirm(ctx); err != nil {
+				srvlog.Error("Could not confirm edges and assertions", log.Ctx{"err": err})
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Handles the events of the confirmer's subscription until it is unsubscribed or the
+// context is done. Handling an event must not block, as publishers wait for it.
+func (c *Confirmer) handleEvents(ctx context.Context) {
+	evs := c.subscription.Events()
+	for {
+		select {
+		case ev, ok := <-evs:
+			if !ok {
+				return
+			}
+			c.handleEvent(ev)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *Confirmer) handleEvent(ev events.Event) {
+	switch ev := ev.(type) {
+	case *events.AssertionCreated:
+		c.TrackAssertion(ev.AssertionHash, ev.Block)
+	case *events.AssertionConfirmed:
+		c.pendingLock.Lock()
+		delete(c.pending, ev.AssertionHash)
+		c.pendingLock.Unlock()
+	}
+}
+
+// TrackAssertion adds an assertion created at a block to those confirmed once their
+// confirmation period has passed. Assertions are learned from the event bus, if any.
+func (c *Confirmer) TrackAssertion(assertionHash protocol.AssertionHash, createdAtBlock uint64) {
+	c.pendingLock.Lock()
+	defer c.pendingLock.Unlock()
+	c.pending[assertionHash] = createdAtBlock
+}
+
+// Spent returns the wei spent on confirmations so far.
+func (c *Confirmer) Spent() *big.Int {
+	c.spentLock.Lock()
+	defer c.spentLock.Unlock()
+	return new(big.Int).Set(c.spent)
+}
+
+// Confirm everything currently confirmable, returning the number of confirmations made.
+// Edges are confirmed in passes until no more can be confirmed, as confirming an edge
+// may make its parent confirmable by children, or the edge it claims confirmable by
+// claim. Of the edges confirmable by time, only those without an ancestor confirmable by
+// time are confirmed, as confirming the ancestor settles their subchallenges at once.
+func (c *Confirmer) Confirm(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		n, err := c.confirmEdges(ctx)
+		total += n
+		if errors.Is(err, errGasBudgetExhausted) {
+			return total, nil
+		}
+		if err != nil {
+			return total, err
+		}
+		if n == 0 {
+			break
+		}
+	}
+	n, err := c.confirmAssertions(ctx)
+	return total + n, err
+}
+
+// Makes a single pass over the confirmable honest edges.
+func (c *Confirmer) confirmEdges(ctx context.Context) (int, error) {
+	confirmable, err := c.edges.GetHonestConfirmableEdges(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "could not get confirmable edges")
+	}
+	confirmed := 0
+	for _, edge := range confirmable[watcher.ConfirmableByOSP] {
+		if c.prover == nil {
+			break
+		}
+		ok, err := c.send(ctx, edge, "one step proof", func(ctx context.Context) error {
+			return c.prover.ProveOneStep(ctx, edge)
+		})
+		if err != nil {
+			return confirmed, err
+		}
+		if ok {
+			confirmed++
+		}
+	}
+	for _, edge := range confirmable[watcher.ConfirmableByChildren] {
+		ok, err := c.send(ctx, edge, "children", func(ctx context.Context) error {
+			return edge.ConfirmByChildren(ctx)
+		})
+		if err != nil {
+			return confirmed, err
+		}
+		if ok {
+			confirmed++
+			c.publishConfirmation(ctx, edge)
+		}
+	}
+	for _, edge := range confirmable[watcher.ConfirmableByClaim] {
+		assertionHash, err := edge.AssertionHash(ctx)
+		if err != nil {
+			return confirmed, errors.Wrap(err, "could not get prev assertion hash")
+		}
+		claimingEdge, found := c.edges.ConfirmedEdgeWithClaimExists(assertionHash, protocol.ClaimId(edge.Id().Hash))
+		if !found {
+			continue
+		}
+		ok, err := c.send(ctx, edge, "claim", func(ctx context.Context) error {
+			return edge.ConfirmByClaim(ctx, protocol.ClaimId(claimingEdge.Hash))
+		})
+		if err != nil {
+			return confirmed, err
+		}
+		if ok {
+			confirmed++
+			c.publishConfirmation(ctx, edge)
+		}
+	}
+	n, err := c.confirmByTime(ctx, confirmable[watcher.ConfirmableByTimer])
+	return confirmed + n, err
+}
+
+func (c *Confirmer) confirmByTime(ctx context.Context, edges []protocol.SpecEdge) (int, error) {
+	byTime := make(map[protocol.EdgeId]bool, len(edges))
+	for _, edge := range edges {
+		byTime[edge.Id()] = true
+	}
+	confirmed := 0
+	for _, edge := range edges {
+		assertionHash, err := edge.AssertionHash(ctx)
+		if err != nil {
+			return confirmed, errors.Wrap(err, "could not get prev assertion hash")
+		}
+		_, ancestors, _, err := c.edges.ComputeHonestPathTimer(ctx, assertionHash, edge.Id())
+		if err != nil {
+			if errors.Is(err, challengetree.ErrNoLowerChildYet) {
+				continue
+			}
+			return confirmed, errors.Wrap(err, "could not compute honest path timer")
+		}
+		if hasAncestorIn(ancestors, byTime) {
+			continue
+		}
+		ok, err := c.send(ctx, edge, "time", func(ctx context.Context) error {
+			return edge.ConfirmByTimer(ctx, ancestors)
+		})
+		if err != nil {
+			return confirmed, err
+		}
+		if ok {
+			confirmed++
+			c.publishConfirmation(ctx, edge)
+		}
+	}
+	return confirmed, nil
+}
+
+func hasAncestorIn(ancestors challengetree.HonestAncestors, edges map[protocol.EdgeId]bool) bool {
+	for _, id := range ancestors {
+		if edges[id] {
+			return true
+		}
+	}
+	return false
+}
+
+// Confirms pending assertions by time in creation order, stopping at the first one
+// whose confirmation period has not yet passed, as later ones cannot have passed theirs.
+// Rivaled assertions cannot be confirmed by time, and are confirmed by the chain
+// watcher once their challenge is won instead.
+func (c *Confirmer) confirmAssertions(ctx context.Context) (int, error) {
+	type pendingAssertion struct {
+		hash  protocol.AssertionHash
+		block uint64
+	}
+	c.pendingLock.Lock()
+	pending := make([]pendingAssertion, 0, len(c.pending))
+	for hash, block := range c.pending {
+		pending = append(pending, pendingAssertion{hash: hash, block: block})
+	}
+	c.pendingLock.Unlock()
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].block < pending[j].block
+	})
+
+	confirmed := 0
+	for _, p := range pending {
+		status, err := c.chain.AssertionStatus(ctx, p.hash)
+		if err != nil {
+			return confirmed, errors.Wrapf(err, "could not get status of assertion %#x", p.hash.Hash)
+		}
+		if status != protocol.AssertionPending {
+			c.pendingLock.Lock()
+			delete(c.pending, p.hash)
+			c.pendingLock.Unlock()
+			continue
+		}
+		if err := c.spend(ctx, func(ctx context.Context) error {
+			return c.chain.ConfirmAssertionByTime(ctx, p.hash)
+		}); err != nil {
+			if errors.Is(err, errGasBudgetExhausted) {
+				return confirmed, nil
+			}
+			if strings.Contains(err.Error(), protocol.BeforeDeadlineAssertionConfirmationError) {
+				return confirmed, nil
+			}
+			srvlog.Debug("Could not confirm assertion by time", log.Ctx{
+				"assertionHash": containers.Trunc(p.hash.Bytes()),
+				"err":           err,
+			})
+			continue
+		}
+		c.pendingLock.Lock()
+		delete(c.pending, p.hash)
+		c.pendingLock.Unlock()
+		confirmed++
+		assertionsConfirmedCounter.Inc(1)
+		srvlog.Info("Confirmed assertion by time", log.Ctx{
+			"validatorName": c.validatorName,
+			"assertionHash": containers.Trunc(p.hash.Bytes()),
+		})
+	}
+	return confirmed, nil
+}
+
+// Sends a confirmation of an edge, returning whether it succeeded. Failed confirmations
+// are logged rather than returned, as other validators may have confirmed the edge
+// first. Only an exhausted gas budget stops the current pass.
+func (c *Confirmer) send(ctx context.Context, edge protocol.SpecEdge, by string, confirm func(ctx context.Context) error) (bool, error) {
+	fields := log.Ctx{
+		"validatorName": c.validatorName,
+		"edgeId":        containers.Trunc(edge.Id().Bytes()),
+		"by":            by,
+	}
+	if err := c.spend(ctx, confirm); err != nil {
+		if errors.Is(err, errGasBudgetExhausted) {
+			return false, err
+		}
+		confirmationErrorsCounter.Inc(1)
+		fields["err"] = err
+		srvlog.Warn("Could not confirm edge", fields)
+		return false, nil
+	}
+	edgesConfirmedCounter.Inc(1)
+	srvlog.Info("Confirmed edge", fields)
+	return true, nil
+}
+
+// Sends a transaction if the gas budget allows it, accounting for the wei it spent.
+func (c *Confirmer) spend(ctx context.Context, transact func(ctx context.Context) error) error {
+	if c.budget == nil {
+		return transact(ctx)
+	}
+	if spent := c.Spent(); spent.Cmp(c.budget) >= 0 {
+		c.budgetWarning.Do(func() {
+			srvlog.Warn("Gas budget exhausted, no longer sending confirmations", log.Ctx{
+				"validatorName": c.validatorName,
+				"budget":        c.budget.String(),
+				"spent":         spent.String(),
+			})
+		})
+		return errGasBudgetExhausted
+	}
+	return transact(protocol.WithReceiptObserver(ctx, func(receipt *gethtypes.Receipt) {
+		if receipt.EffectiveGasPrice == nil {
+			return
+		}
+		cost := new(big.Int).Mul(receipt.EffectiveGasPrice, new(big.Int).SetUint64(receipt.GasUsed))
+		c.spentLock.Lock()
+		c.spent.Add(c.spent, cost)
+		gasSpentGweiGauge.Update(new(big.Int).Div(c.spent, gwei).Int64())
+		c.spentLock.Unlock()
+	}))
+}
+
+// Publishes a confirmation made by the confirmer as a move on the event bus.
+func (c *Confirmer) publishConfirmation(ctx context.Context, edge protocol.SpecEdge) {
+	if c.events == nil {
+		return
+	}
+	assertionHash, err := edge.AssertionHash(ctx)
+	if err != nil {
+		srvlog.Error("Could not get assertion hash to publish confirmation", log.Ctx{"err": err})
+		return
+	}
+	c.events.Publish(ctx, &events.MoveSubmitted{
+		EdgeId:              edge.Id(),
+		ChallengedAssertion: assertionHash,
+		Move:                edgetracker.Confirm.String(),
+		Validator:           c.validatorName,
+	})
+}
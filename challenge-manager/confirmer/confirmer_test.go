@@ -0,0 +1,258 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package confirmer
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	protocol "github.com/OffchainLabs/bold/chain-abstraction"
+	watcher "github.com/OffchainLabs/bold/challenge-manager/chain-watcher"
+	challengetree "github.com/OffchainLabs/bold/challenge-manager/challenge-tree"
+	"github.com/OffchainLabs/bold/challenge-manager/types"
+	"github.com/OffchainLabs/bold/events"
+	"github.com/OffchainLabs/bold/testing/mocks"
+	"github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var challengedAssertion = protocol.AssertionHash{Hash: common.Hash{0xaa}}
+
+// Returns the confirmable edges of each pass in turn, and nothing afterwards.
+type fakeEdgeSource struct {
+	passes    []map[string][]protocol.SpecEdge
+	claims    map[protocol.ClaimId]protocol.EdgeId
+	ancestors map[protocol.EdgeId]challengetree.HonestAncestors
+}
+
+func (f *fakeEdgeSource) GetHonestConfirmableEdges(context.Context) (map[string][]protocol.SpecEdge, error) {
+	if len(f.passes) == 0 {
+		return map[string][]protocol.SpecEdge{}, nil
+	}
+	pass := f.passes[0]
+	f.passes = f.passes[1:]
+	return pass, nil
+}
+
+func (f *fakeEdgeSource) ConfirmedEdgeWithClaimExists(
+	_ protocol.AssertionHash,
+	claimId protocol.ClaimId,
+) (protocol.EdgeId, bool) {
+	id, ok := f.claims[claimId]
+	return id, ok
+}
+
+func (f *fakeEdgeSource) ComputeHonestPathTimer(
+	_ context.Context,
+	_ protocol.AssertionHash,
+	edgeId protocol.EdgeId,
+) (challengetree.PathTimer, challengetree.HonestAncestors, []challengetree.EdgeLocalTimer, error) {
+	return 0, f.ancestors[edgeId], nil, nil
+}
+
+type fakeReader struct{}
+
+func (fakeReader) Mode() types.Mode     { return types.ConfirmerMode }
+func (fakeReader) MaxDelaySeconds() int { return 0 }
+func (fakeReader) IsLeader() bool       { return true }
+
+func newEdge(id byte) *mocks.MockSpecEdge {
+	edge := &mocks.MockSpecEdge{}
+	edge.On("Id").Return(protocol.EdgeId{Hash: common.Hash{id}})
+	edge.On("AssertionHash", mock.Anything).Return(challengedAssertion, nil)
+	return edge
+}
+
+func TestConfirmer_ConfirmsEdges(t *testing.T) {
+	ctx := context.Background()
+	byChildren := newEdge(1)
+	byChildren.On("ConfirmByChildren", ctx).Return(nil)
+	byClaim := newEdge(2)
+	claimingEdge := protocol.EdgeId{Hash: common.Hash{3}}
+	byClaim.On("ConfirmByClaim", ctx, protocol.ClaimId(claimingEdge.Hash)).Return(nil)
+	lostRace := newEdge(4)
+	lostRace.On("ConfirmByChildren", ctx).Return(errors.New("edge already confirmed"))
+
+	// Both the parent and its child are confirmable by time, but only the parent
+	// needs to be confirmed.
+	parent := newEdge(5)
+	parentAncestors := challengetree.HonestAncestors{{Hash: common.Hash{6}}}
+	parent.On("ConfirmByTimer", ctx, []protocol.EdgeId(parentAncestors)).Return(nil)
+	child := newEdge(7)
+	source := &fakeEdgeSource{
+		passes: []map[string][]protocol.SpecEdge{
+			{
+				watcher.ConfirmableByChildren: {lostRace},
+				watcher.ConfirmableByClaim:    {byClaim},
+				watcher.ConfirmableByTimer:    {child, parent},
+			},
+			// Confirmations in the first pass made another edge confirmable.
+			{watcher.ConfirmableByChildren: {byChildren}},
+		},
+		claims: map[protocol.ClaimId]protocol.EdgeId{
+			protocol.ClaimId(byClaim.Id().Hash): claimingEdge,
+		},
+		ancestors: map[protocol.EdgeId]challengetree.HonestAncestors{
+			parent.Id(): parentAncestors,
+			child.Id():  {parent.Id(), {Hash: common.Hash{6}}},
+		},
+	}
+	bus := events.NewBus()
+	moves := bus.Subscribe("test", events.WithKinds(events.KindMoveSubmitted))
+	defer moves.Unsubscribe()
+	c, err := New(&mocks.MockProtocol{}, source, fakeReader{}, WithEventBus(bus), WithValidatorName("carol"))
+	require.NoError(t, err)
+
+	confirmed, err := c.Confirm(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 3, confirmed)
+	byChildren.AssertExpectations(t)
+	byClaim.AssertExpectations(t)
+	parent.AssertExpectations(t)
+	child.AssertNotCalled(t, "ConfirmByTimer", mock.Anything, mock.Anything)
+
+	for i := 0; i < confirmed; i++ {
+		move, ok := (<-moves.Events()).(*events.MoveSubmitted)
+		require.True(t, ok)
+		require.Equal(t, "confirm", move.Move)
+		require.Equal(t, "carol", move.Validator)
+		require.Equal(t, challengedAssertion, move.ChallengedAssertion)
+	}
+}
+
+func TestConfirmer_ConfirmsAssertions(t *testing.T) {
+	ctx := context.Background()
+	first := protocol.AssertionHash{Hash: common.Hash{1}}
+	second := protocol.AssertionHash{Hash: common.Hash{2}}
+	third := protocol.AssertionHash{Hash: common.Hash{3}}
+	chain := &mocks.MockProtocol{}
+	chain.On("AssertionStatus", mock.Anything, first).Return(protocol.AssertionConfirmed, nil)
+	chain.On("AssertionStatus", mock.Anything, second).Return(protocol.AssertionPending, nil)
+	chain.On("AssertionStatus", mock.Anything, third).Return(protocol.AssertionPending, nil)
+	confirmedSecond := make(chan struct{})
+	chain.On("ConfirmAssertionByTime", mock.Anything, second).Return(nil).Once().Run(func(mock.Arguments) {
+		close(confirmedSecond)
+	})
+	chain.On("ConfirmAssertionByTime", mock.Anything, third).Return(errors.New(protocol.BeforeDeadlineAssertionConfirmationError))
+
+	bus := events.NewBus()
+	c, err := New(chain, &fakeEdgeSource{}, fakeReader{}, WithEventBus(bus), WithInterval(10*time.Millisecond))
+	require.NoError(t, err)
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go c.Start(runCtx)
+
+	// Assertions are learned from the bus, and confirmed in creation order.
+	bus.Publish(ctx, &events.AssertionCreated{AssertionHash: third, Block: 3})
+	bus.Publish(ctx, &events.AssertionCreated{AssertionHash: first, Block: 1})
+	bus.Publish(ctx, &events.AssertionCreated{AssertionHash: second, Block: 2})
+	select {
+	case <-confirmedSecond:
+	case <-time.After(time.Second):
+		t.Fatal("assertion was not confirmed")
+	}
+	require.Eventually(t, func() bool {
+		c.pendingLock.Lock()
+		defer c.pendingLock.Unlock()
+		_, ok := c.pending[third]
+		return len(c.pending) == 1 && ok
+	}, time.Second, 10*time.Millisecond)
+	chain.AssertNotCalled(t, "ConfirmAssertionByTime", mock.Anything, first)
+
+	// Assertions confirmed by others are no longer confirmed.
+	bus.Publish(ctx, &events.AssertionConfirmed{AssertionHash: third})
+	require.Eventually(t, func() bool {
+		c.pendingLock.Lock()
+		defer c.pendingLock.Unlock()
+		return len(c.pending) == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+// Blocks while getting confirmable edges until released.
+type blockingEdgeSource struct {
+	fakeEdgeSource
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingEdgeSource) GetHonestConfirmableEdges(ctx context.Context) (map[string][]protocol.SpecEdge, error) {
+	select {
+	case b.started <- struct{}{}:
+	default:
+	}
+	<-b.release
+	return b.fakeEdgeSource.GetHonestConfirmableEdges(ctx)
+}
+
+func TestConfirmer_HandlesEventsWhileConfirming(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	source := &blockingEdgeSource{started: make(chan struct{}), release: make(chan struct{})}
+	defer close(source.release)
+	chain := &mocks.MockProtocol{}
+	chain.On("AssertionStatus", mock.Anything, mock.Anything).Return(protocol.AssertionConfirmed, nil)
+	bus := events.NewBus()
+	c, err := New(chain, source, fakeReader{}, WithEventBus(bus), WithInterval(10*time.Millisecond))
+	require.NoError(t, err)
+	go c.Start(ctx)
+	<-source.started
+
+	// Publishers wait for the confirmer to see every assertion, which it does even
+	// while a confirmation is in progress, beyond what its buffer holds.
+	publishCtx, cancelPublish := context.WithTimeout(ctx, 5*time.Second)
+	defer cancelPublish()
+	numAssertions := 1000
+	for i := 0; i < numAssertions; i++ {
+		bus.Publish(publishCtx, &events.AssertionCreated{
+			AssertionHash: protocol.AssertionHash{Hash: common.BigToHash(big.NewInt(int64(i)))},
+			Block:         uint64(i),
+		})
+	}
+	require.NoError(t, publishCtx.Err())
+	require.Eventually(t, func() bool {
+		c.pendingLock.Lock()
+		defer c.pendingLock.Unlock()
+		return len(c.pending) == numAssertions
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestConfirmer_GasBudget(t *testing.T) {
+	ctx := context.Background()
+	chain := &mocks.MockProtocol{}
+	edges := make([]protocol.SpecEdge, 3)
+	for i := range edges {
+		edge := newEdge(byte(i + 1))
+		// Each confirmation uses 5 gas at an effective price of 2 wei.
+		edge.On("ConfirmByChildren", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+			protocol.ObserveReceipt(args.Get(0).(context.Context), &gethtypes.Receipt{
+				GasUsed:           5,
+				EffectiveGasPrice: big.NewInt(2),
+			})
+		})
+		edges[i] = edge
+	}
+	source := &fakeEdgeSource{
+		passes: []map[string][]protocol.SpecEdge{{watcher.ConfirmableByChildren: edges}},
+	}
+
+	_, err := New(chain, source, fakeReader{}, WithGasBudget(common.Address{}, big.NewInt(15)))
+	require.ErrorContains(t, err, "requires the account")
+
+	account := common.Address{1}
+	c, err := New(chain, source, fakeReader{}, WithGasBudget(account, big.NewInt(15)))
+	require.NoError(t, err)
+
+	// Confirmations are sent until the budget is spent, overshooting it by at most
+	// one transaction.
+	confirmed, err := c.Confirm(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, confirmed)
+	require.Equal(t, big.NewInt(20), c.Spent())
+	edges[2].(*mocks.MockSpecEdge).AssertNotCalled(t, "ConfirmByChildren", mock.Anything)
+}
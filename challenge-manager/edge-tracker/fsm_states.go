@@ -72,6 +72,13 @@ type edgeAwaitConfirmation struct{}
 
 type edgeConfirm struct{}
 
+// A move which failed, leading the tracker along the transition of the action it
+// wraps. The transition is recorded with the error of the move.
+type failedMove struct {
+	edgeTrackerAction
+	err error
+}
+
 func (edgeBackToStart) String() string {
 	return "back_to_start"
 }
@@ -0,0 +1,101 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package edgetracker
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Number of recent transitions kept by each tracker for introspection.
+const transitionHistorySize = 32
+
+// Transition of a tracker's state machine made by one of its actions, or the
+// error an action ran into.
+type Transition struct {
+	Time  time.Time `json:"time"`
+	From  string    `json:"from"`
+	To    string    `json:"to"`
+	Event string    `json:"event,omitempty"`
+	Err   string    `json:"err,omitempty"`
+}
+
+// Snapshot of a tracker's state, for debugging edges that are not progressing.
+type Snapshot struct {
+	EdgeId         common.Hash `json:"edgeId"`
+	ChallengeLevel string      `json:"challengeLevel"`
+	StartHeight    uint64      `json:"startHeight"`
+	EndHeight      uint64      `json:"endHeight"`
+	State          string      `json:"state"`
+	// Recent transitions that changed the tracker's state or ran into an error,
+	// oldest first.
+	Transitions []Transition `json:"transitions"`
+	// Outcome of the tracker's last action, whether or not it changed its state.
+	LastAction *Transition `json:"lastAction,omitempty"`
+}
+
+// Keeps a bounded history of a tracker's transitions in a ring buffer.
+type transitionHistory struct {
+	lock       sync.RWMutex
+	ring       []Transition
+	next       int
+	full       bool
+	lastAction *Transition
+}
+
+func newTransitionHistory(size int) *transitionHistory {
+	return &transitionHistory{ring: make([]Transition, size)}
+}
+
+func (h *transitionHistory) record(tr Transition) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.lastAction = &tr
+	// Trackers waiting on the chain go back to the state they are in on every
+	// wake up, which would crowd out the transitions worth looking at.
+	if tr.From == tr.To && tr.Err == "" {
+		return
+	}
+	h.ring[h.next] = tr
+	h.next = (h.next + 1) % len(h.ring)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+func (h *transitionHistory) transitions() []Transition {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	if !h.full {
+		return append([]Transition{}, h.ring[:h.next]...)
+	}
+	return append(append([]Transition{}, h.ring[h.next:]...), h.ring[:h.next]...)
+}
+
+func (h *transitionHistory) last() *Transition {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	if h.lastAction == nil {
+		return nil
+	}
+	tr := *h.lastAction
+	return &tr
+}
+
+// Snapshot of the tracker's current state and recent transitions.
+func (et *Tracker) Snapshot() *Snapshot {
+	startHeight, _ := et.edge.StartCommitment()
+	endHeight, _ := et.edge.EndCommitment()
+	return &Snapshot{
+		EdgeId:         et.edge.Id().Hash,
+		ChallengeLevel: et.edge.GetChallengeLevel().String(),
+		StartHeight:    uint64(startHeight),
+		EndHeight:      uint64(endHeight),
+		State:          et.CurrentState().String(),
+		Transitions:    et.history.transitions(),
+		LastAction:     et.history.last(),
+	}
+}
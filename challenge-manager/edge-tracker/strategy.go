@@ -0,0 +1,297 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package edgetracker
+
+import (
+	"context"
+	"fmt"
+
+	protocol "github.com/OffchainLabs/bold/chain-abstraction"
+	"github.com/OffchainLabs/bold/containers/option"
+	"github.com/pkg/errors"
+)
+
+// Move is an action an edge tracker can take on its edge.
+type Move uint8
+
+const (
+	// Confirm attempts to confirm the edge by any available means.
+	Confirm Move = iota + 1
+	// OneStepProve submits a one step proof for the edge.
+	OneStepProve
+	// Bisect bisects the edge.
+	Bisect
+	// OpenSubchallengeLeaf adds a level zero edge in the subchallenge of the edge.
+	OpenSubchallengeLeaf
+)
+
+func (m Move) String() string {
+	switch m {
+	case Confirm:
+		return "confirm"
+	case OneStepProve:
+		return "one_step_prove"
+	case Bisect:
+		return "bisect"
+	case OpenSubchallengeLeaf:
+		return "open_subchallenge_leaf"
+	default:
+		return fmt.Sprintf("move(%d)", uint8(m))
+	}
+}
+
+// Strategy decides which moves an edge tracker makes. It is consulted in every
+// state of the tracker's state machine, except the terminal confirmed state.
+type Strategy interface {
+	// Moves returns the moves to attempt on the edge, in order. An attempt to
+	// confirm falls through to the next move if the edge cannot be confirmed yet,
+	// while any other move ends the attempt. If no move is made, the tracker waits
+	// until it is next woken up. Outside of the started state, the tracker only
+	// proceeds with the move of its current state if it is included.
+	Moves(ctx context.Context, s *Situation) ([]Move, error)
+}
+
+// StrategyFunc adapts a function to a strategy.
+type StrategyFunc func(ctx context.Context, s *Situation) ([]Move, error)
+
+func (f StrategyFunc) Moves(ctx context.Context, s *Situation) ([]Move, error) {
+	return f(ctx, s)
+}
+
+// Environment answers the questions a strategy may ask about an edge beyond the
+// edge itself, which depend on the rest of its challenge. Edge trackers answer
+// from the chain, while other consumers of strategies, such as offline replays,
+// may answer from a record of it.
+type Environment interface {
+	// ChallengePeriodBlocks returns the challenge period of the challenge manager.
+	ChallengePeriodBlocks(ctx context.Context) (uint64, error)
+	// Headroom returns the number of blocks left until the honest path timer of the
+	// edge reaches the challenge period.
+	Headroom(ctx context.Context, edge protocol.SpecEdge) (uint64, error)
+	// ChallengeBlocksElapsed returns the number of blocks since the first child of
+	// the assertion challenged by the edge was created.
+	ChallengeBlocksElapsed(ctx context.Context, edge protocol.SpecEdge) (uint64, error)
+}
+
+// Situation gives a strategy access to the state of an edge, querying the chain
+// lazily and at most once per consultation.
+type Situation struct {
+	edge              protocol.SpecEdge
+	env               Environment
+	state             State
+	canOneStepProve   option.Option[bool]
+	hasRival          option.Option[bool]
+	hasLengthOneRival option.Option[bool]
+	challengePeriod   option.Option[uint64]
+}
+
+// NewSituation of an edge in the given state of the tracker's state machine, for
+// consulting a strategy outside of an edge tracker.
+func NewSituation(edge protocol.SpecEdge, state State, env Environment) *Situation {
+	return &Situation{
+		edge:  edge,
+		env:   env,
+		state: state,
+	}
+}
+
+// State of the tracker's state machine.
+func (s *Situation) State() State {
+	return s.state
+}
+
+// Edge being tracked.
+func (s *Situation) Edge() protocol.SpecEdge {
+	return s.edge
+}
+
+// CanOneStepProve checks if the edge is at a one step fork in a small step challenge.
+func (s *Situation) CanOneStepProve(ctx context.Context) (bool, error) {
+	return cached(&s.canOneStepProve, func() (bool, error) {
+		return CanOneStepProve(ctx, s.edge)
+	})
+}
+
+// HasRival checks if the edge has a rival.
+func (s *Situation) HasRival(ctx context.Context) (bool, error) {
+	return cached(&s.hasRival, func() (bool, error) {
+		return s.edge.HasRival(ctx)
+	})
+}
+
+// HasLengthOneRival checks if the edge has a rival of length one, which is resolved
+// in a subchallenge, or by a one step proof at the lowest challenge level.
+func (s *Situation) HasLengthOneRival(ctx context.Context) (bool, error) {
+	return cached(&s.hasLengthOneRival, func() (bool, error) {
+		return s.edge.HasLengthOneRival(ctx)
+	})
+}
+
+// ChallengePeriodBlocks returns the challenge period of the challenge manager.
+func (s *Situation) ChallengePeriodBlocks(ctx context.Context) (uint64, error) {
+	return cached(&s.challengePeriod, func() (uint64, error) {
+		return s.env.ChallengePeriodBlocks(ctx)
+	})
+}
+
+// Headroom returns the number of blocks left until the honest path timer of the
+// edge reaches the challenge period.
+func (s *Situation) Headroom(ctx context.Context) (uint64, error) {
+	return s.env.Headroom(ctx, s.edge)
+}
+
+// ChallengeBlocksElapsed returns the number of blocks since the first child of the
+// challenged assertion was created. The unrivaled periods of an edge and its
+// ancestors all fall after that block, so no edge in the challenge, honest or not,
+// can have a path timer greater than this. It bounds how close any rival of the
+// edge is to being confirmable by time.
+func (s *Situation) ChallengeBlocksElapsed(ctx context.Context) (uint64, error) {
+	return s.env.ChallengeBlocksElapsed(ctx, s.edge)
+}
+
+// The environment of a tracker's edge, answered from the chain.
+type trackerEnvironment struct {
+	tracker *Tracker
+}
+
+func (e trackerEnvironment) ChallengePeriodBlocks(ctx context.Context) (uint64, error) {
+	chalManager, err := e.tracker.chain.SpecChallengeManager(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return chalManager.ChallengePeriodBlocks(ctx)
+}
+
+func (e trackerEnvironment) Headroom(ctx context.Context, _ protocol.SpecEdge) (uint64, error) {
+	return e.tracker.Headroom(ctx)
+}
+
+func (e trackerEnvironment) ChallengeBlocksElapsed(ctx context.Context, edge protocol.SpecEdge) (uint64, error) {
+	assertionHash, err := edge.AssertionHash(ctx)
+	if err != nil {
+		return 0, err
+	}
+	assertion, err := e.tracker.chain.GetAssertion(ctx, assertionHash)
+	if err != nil {
+		return 0, err
+	}
+	firstChildBlock, err := assertion.FirstChildCreationBlock()
+	if err != nil {
+		return 0, err
+	}
+	header, err := e.tracker.chain.Backend().HeaderByNumber(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	if !header.Number.IsUint64() {
+		return 0, errors.New("latest block number is not a uint64")
+	}
+	latest := header.Number.Uint64()
+	if latest <= firstChildBlock {
+		return 0, nil
+	}
+	return latest - firstChildBlock, nil
+}
+
+func cached[T any](value *option.Option[T], fetch func() (T, error)) (T, error) {
+	if value.IsSome() {
+		return value.Unwrap(), nil
+	}
+	v, err := fetch()
+	if err != nil {
+		return v, err
+	}
+	*value = option.Some(v)
+	return v, nil
+}
+
+// Honest returns the default strategy, which makes every move that advances the
+// honest edge: it confirms the edge if possible, and otherwise one step proves it,
+// or bisects or opens a subchallenge leaf if it is rivaled.
+func Honest() Strategy {
+	return StrategyFunc(honestMoves)
+}
+
+func honestMoves(ctx context.Context, s *Situation) ([]Move, error) {
+	switch s.State() {
+	case EdgeStarted:
+		canOsp, err := s.CanOneStepProve(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not check if edge can be one step proven")
+		}
+		if canOsp {
+			// An edge that cannot be proven, such as one without a rival, may
+			// still be confirmed by time.
+			return []Move{Confirm, OneStepProve}, nil
+		}
+		hasRival, err := s.HasRival(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not check presumptive")
+		}
+		if !hasRival {
+			return []Move{Confirm}, nil
+		}
+		atOneStepFork, err := s.HasLengthOneRival(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not check if edge has length one rival")
+		}
+		if atOneStepFork {
+			return []Move{Confirm, OpenSubchallengeLeaf}, nil
+		}
+		return []Move{Confirm, Bisect}, nil
+	case EdgeAtOneStepProof:
+		return []Move{OneStepProve}, nil
+	case EdgeAddingSubchallengeLeaf:
+		return []Move{OpenSubchallengeLeaf}, nil
+	case EdgeBisecting:
+		return []Move{Bisect}, nil
+	default:
+		return []Move{Confirm}, nil
+	}
+}
+
+// ConfirmOnly never makes challenge moves, only confirming edges once they can
+// be confirmed, for operators who do not want to spend on moves.
+func ConfirmOnly() Strategy {
+	return StrategyFunc(func(context.Context, *Situation) ([]Move, error) {
+		return []Move{Confirm}, nil
+	})
+}
+
+// LazyBisect makes the same moves as the honest strategy, except that it holds off
+// on bisecting or opening subchallenges on a rivaled edge until a rival could be
+// within the given number of blocks of being confirmable by time. As the bound
+// on rival timers is loose, the strategy acts early rather than late. The threshold
+// must leave enough time for the rest of the challenge to be played out.
+func LazyBisect(thresholdBlocks uint64) Strategy {
+	return StrategyFunc(func(ctx context.Context, s *Situation) ([]Move, error) {
+		moves, err := honestMoves(ctx, s)
+		if err != nil || s.State() != EdgeStarted || !containsMove(moves, Bisect, OpenSubchallengeLeaf) {
+			return moves, err
+		}
+		elapsed, err := s.ChallengeBlocksElapsed(ctx)
+		if err != nil {
+			return nil, err
+		}
+		period, err := s.ChallengePeriodBlocks(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if elapsed+thresholdBlocks < period {
+			return []Move{Confirm}, nil
+		}
+		return moves, nil
+	})
+}
+
+func containsMove(moves []Move, targets ...Move) bool {
+	for _, m := range moves {
+		for _, t := range targets {
+			if m == t {
+				return true
+			}
+		}
+	}
+	return false
+}
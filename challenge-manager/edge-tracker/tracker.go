@@ -11,15 +11,17 @@ import (
 	"os"
 	"time"
 
+	"github.com/OffchainLabs/bold/alerts"
 	protocol "github.com/OffchainLabs/bold/chain-abstraction"
 	challengetree "github.com/OffchainLabs/bold/challenge-manager/challenge-tree"
+	"github.com/OffchainLabs/bold/challenge-manager/stakers"
 	"github.com/OffchainLabs/bold/containers"
 	"github.com/OffchainLabs/bold/containers/fsm"
 	"github.com/OffchainLabs/bold/containers/option"
+	"github.com/OffchainLabs/bold/events"
 	l2stateprovider "github.com/OffchainLabs/bold/layer2-state-provider"
 	"github.com/OffchainLabs/bold/math"
 	commitments "github.com/OffchainLabs/bold/state-commitments/history"
-	utilTime "github.com/OffchainLabs/bold/time"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
@@ -36,6 +38,10 @@ var (
 	layerZeroLeafCounter = metrics.NewRegisteredCounter("arb/validator/tracker/layer_zero_leaves", nil)
 )
 
+// An alert is raised once an honest edge's path timer reaches this percentage of the
+// challenge period without the edge having been confirmed.
+const honestTimerAlertPercent = 90
+
 func init() {
 	srvlog.SetHandler(log.StreamHandler(os.Stdout, log.LogfmtFormat()))
 }
@@ -68,7 +74,15 @@ type ConfirmationMetadataChecker interface {
 
 type ChallengeTracker interface {
 	IsTrackingEdge(protocol.EdgeId) bool
-	MarkTrackedEdge(protocol.EdgeId)
+	// MarkTrackedEdge returns false if the edge is already tracked.
+	MarkTrackedEdge(protocol.EdgeId) bool
+	MarkTrackerExited(protocol.EdgeId)
+	IsLeader() bool
+	// MovesAllowed returns false while moves are paused by an operator.
+	MovesAllowed() bool
+	// ScheduleTracker hands a tracker over to be run by the challenge manager until
+	// it should despawn. Returns false if the tracker will not be run.
+	ScheduleTracker(topLevelAssertionHash protocol.AssertionHash, tracker *Tracker) bool
 }
 
 // AssociatedAssertionMetadata for the tracked edge.
@@ -78,30 +92,60 @@ type AssociatedAssertionMetadata struct {
 	WasmModuleRoot common.Hash
 }
 
+// Precomputer speculatively computes the history commitments and proofs the moves of
+// edges need before they are made.
+type Precomputer interface {
+	Warm(pathId common.Hash, edge *l2stateprovider.SpeculativeEdge)
+	Discard(pathId common.Hash)
+}
+
 type Opt func(et *Tracker)
 
-// WithActInterval sets the duration between actions. The default is one second.
-func WithActInterval(d time.Duration) Opt {
+// WithValidatorName associates a name to the running validator. This name is used only for logging
+// and is not exposed externally. This is particularly useful for debugging purposes.
+func WithValidatorName(name string) Opt {
 	return func(et *Tracker) {
-		et.actInterval = d
+		et.validatorName = name
 	}
 }
 
-// WithTimeReference allows setting the timer used by the tracker to determine that time
-// passed in accordance with the act interval set with [WithActInterval]. The default is
-// to use [github.com/offchainlabs/bold/time.NewRealTimeReference].
-// This is useful for testing with a fake time reference to avoid waiting for real time.
-func WithTimeReference(ref utilTime.Reference) Opt {
+// WithAlerts sets the dispatcher used to notify operators about honest edges whose
+// timers are close to expiry or whose stakes are at risk.
+func WithAlerts(d *alerts.Dispatcher) Opt {
 	return func(et *Tracker) {
-		et.timeRef = ref
+		et.alerts = d
 	}
 }
 
-// WithValidatorName associates a name to the running validator. This name is used only for logging
-// and is not exposed externally. This is particularly useful for debugging purposes.
-func WithValidatorName(name string) Opt {
+// WithEventBus sets the bus on which the moves the tracker and its children make are published.
+func WithEventBus(bus *events.Bus) Opt {
 	return func(et *Tracker) {
-		et.validatorName = name
+		et.events = bus
+	}
+}
+
+// WithStakerPool funds the mini-stakes of subchallenge edges with identities
+// selected from a pool of staker keys.
+func WithStakerPool(p *stakers.Pool) Opt {
+	return func(et *Tracker) {
+		et.stakers = p
+	}
+}
+
+// WithStrategy sets the strategy deciding which moves the tracker makes on its edge,
+// which is inherited by the trackers of its children. Defaults to the honest strategy.
+func WithStrategy(s Strategy) Opt {
+	return func(et *Tracker) {
+		et.strategy = s
+	}
+}
+
+// WithPrecomputer warms what the tracker's edge and its descendants need to bisect and
+// open subchallenges once the tracker is spawned, until the edge has made its move.
+// It is inherited by the trackers of its children.
+func WithPrecomputer(p Precomputer) Opt {
+	return func(et *Tracker) {
+		et.precomputer = p
 	}
 }
 
@@ -116,14 +160,18 @@ type Tracker struct {
 	edge                        protocol.SpecEdge
 	fsm                         *fsm.Fsm[edgeTrackerAction, State]
 	fsmOpts                     []fsm.Opt[edgeTrackerAction, State]
-	actInterval                 time.Duration
-	timeRef                     utilTime.Reference
 	validatorName               string
 	chain                       protocol.Protocol
 	stateProvider               l2stateprovider.Provider
 	chainWatcher                ConfirmationMetadataChecker
 	challengeManager            ChallengeTracker
 	associatedAssertionMetadata *AssociatedAssertionMetadata
+	alerts                      *alerts.Dispatcher
+	stakers                     *stakers.Pool
+	strategy                    Strategy
+	history                     *transitionHistory
+	events                      *events.Bus
+	precomputer                 Precomputer
 }
 
 func New(
@@ -143,15 +191,12 @@ func New(
 		chainWatcher:                chainWatcher,
 		challengeManager:            challengeManager,
 		associatedAssertionMetadata: assertionCreationInfo,
-		actInterval:                 time.Second,
-		timeRef:                     utilTime.NewRealTimeReference(),
+		strategy:                    Honest(),
+		history:                     newTransitionHistory(transitionHistorySize),
 	}
 	for _, o := range opts {
 		o(tr)
 	}
-	if tr.actInterval == 0 {
-		return nil, errors.New("edge tracker act interval must be greater than 0")
-	}
 	fsm, err := newEdgeTrackerFsm(
 		EdgeStarted,
 		tr.fsmOpts...,
@@ -179,109 +224,214 @@ func (et *Tracker) ChallengeManager() ChallengeTracker {
 	return et.challengeManager
 }
 
+// Spawn starts tracking the edge by handing the tracker over to the challenge
+// manager, which runs its steps until it should despawn.
 func (et *Tracker) Spawn(ctx context.Context) {
 	// No-op if we are already tracking this edge in our challenge manager.
 	if et.challengeManager.IsTrackingEdge(et.edge.Id()) {
 		return
 	}
 	fields := et.uniqueTrackerLogFields()
+	assertionHash, err := et.edge.AssertionHash(ctx)
+	if err != nil {
+		fields["err"] = err
+		srvlog.Error("Could not get assertion hash of edge to track", fields)
+		return
+	}
+	// Another tracker for the edge may have been spawned in the meantime.
+	if !et.challengeManager.MarkTrackedEdge(et.edge.Id()) {
+		return
+	}
+	if !et.challengeManager.ScheduleTracker(assertionHash, et) {
+		et.challengeManager.MarkTrackerExited(et.edge.Id())
+		return
+	}
 	srvlog.Info("Tracking edge", fields)
 	spawnedCounter.Inc(1)
-	et.challengeManager.MarkTrackedEdge(et.edge.Id())
-	t := et.timeRef.NewTicker(et.actInterval)
-	defer t.Stop()
-	for {
-		select {
-		case <-t.C():
-			if et.ShouldDespawn(ctx) {
-				srvlog.Info("Tracked edge received notice it should exit - now despawning", fields)
-				spawnedCounter.Dec(1)
-				return
-			}
-			if err := et.Act(ctx); err != nil {
-				fields["err"] = err
-				srvlog.Error("Could not act with edge tracker", fields)
-			}
-		case <-ctx.Done():
-			srvlog.Debug("Edge tracker goroutine exiting", fields)
-			spawnedCounter.Dec(1)
-			return
+	et.warm(ctx)
+}
+
+// Step acts on the edge until it has to wait for the chain to progress, and
+// returns true once the tracker should despawn.
+func (et *Tracker) Step(ctx context.Context) bool {
+	fields := et.uniqueTrackerLogFields()
+	if et.ShouldDespawn(ctx) {
+		srvlog.Info("Tracked edge received notice it should exit - now despawning", fields)
+		return true
+	}
+	// Followers keep the tracker alive so they can take over
+	// immediately, but only the leader acts.
+	if !et.challengeManager.IsLeader() {
+		return false
+	}
+	if !et.challengeManager.MovesAllowed() {
+		return false
+	}
+	// Keep acting while the edge moves on to states it has not yet been in
+	// during this step, which also stops retrying failed moves right away.
+	visited := map[State]bool{et.fsm.Current().State: true}
+	for ctx.Err() == nil {
+		if err := et.Act(ctx); err != nil {
+			fields["err"] = err
+			srvlog.Error("Could not act with edge tracker", fields)
+			return false
 		}
+		current := et.fsm.Current().State
+		if visited[current] {
+			return false
+		}
+		visited[current] = true
 	}
+	return false
+}
+
+// Headroom returns the number of blocks left until the honest path timer of the
+// edge reaches the challenge period, which is how urgently the edge must be acted on.
+func (et *Tracker) Headroom(ctx context.Context) (uint64, error) {
+	assertionHash, err := et.edge.AssertionHash(ctx)
+	if err != nil {
+		return 0, err
+	}
+	timer, _, _, err := et.chainWatcher.ComputeHonestPathTimer(ctx, assertionHash, et.edge.Id())
+	if err != nil {
+		return 0, err
+	}
+	chalManager, err := et.chain.SpecChallengeManager(ctx)
+	if err != nil {
+		return 0, err
+	}
+	challengePeriodBlocks, err := chalManager.ChallengePeriodBlocks(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if uint64(timer) >= challengePeriodBlocks {
+		return 0, nil
+	}
+	return challengePeriodBlocks - uint64(timer), nil
+}
+
+// Exit is called once the tracker is no longer run by the challenge manager.
+func (et *Tracker) Exit() {
+	srvlog.Debug("Edge tracker exiting", et.uniqueTrackerLogFields())
+	spawnedCounter.Dec(1)
+	et.discardPrecomputations()
+	et.challengeManager.MarkTrackerExited(et.edge.Id())
 }
 
 func (et *Tracker) CurrentState() State {
 	return et.fsm.Current().State
 }
 
+// Act makes the move of the tracker's current state, and records the resulting
+// transition in the tracker's history.
 func (et *Tracker) Act(ctx context.Context) error {
 	fields := et.uniqueTrackerLogFields()
+	from := et.fsm.Current().State
+	err := et.act(ctx, fields)
+	to := et.fsm.Current()
+	tr := Transition{
+		Time: time.Now(),
+		From: from.String(),
+		To:   to.State.String(),
+	}
+	// A failed transition is recorded over the error of the move leading to it.
+	if err != nil {
+		tr.Err = err.Error()
+	} else if to.SourceEvent != nil {
+		tr.Event = to.SourceEvent.String()
+		if failed, ok := to.SourceEvent.(failedMove); ok {
+			tr.Err = failed.err.Error()
+		}
+	}
+	et.history.record(tr)
+	return err
+}
+
+// Makes the move of the tracker's current state, returning the error of transitioning
+// the tracker's state machine. The error a move runs into is logged, and carried by
+// the event leading the tracker back to a waiting state.
+func (et *Tracker) act(ctx context.Context, fields log.Ctx) error {
 	current := et.fsm.Current()
+	if current.State == EdgeConfirmed {
+		srvlog.Info("Edge reached confirmed state", fields)
+		return et.fsm.Do(edgeConfirm{})
+	}
+	moves, err := et.strategy.Moves(ctx, NewSituation(et.edge, current.State, trackerEnvironment{tracker: et}))
+	if err != nil {
+		fields["err"] = err
+		srvlog.Error("Could not determine moves for edge", fields)
+		if current.State == EdgeConfirming {
+			return et.fsm.Do(withMoveErr(edgeAwaitConfirmation{}, err))
+		}
+		return et.fsm.Do(withMoveErr(edgeBackToStart{}, err))
+	}
 	switch current.State {
 	// Start state.
 	case EdgeStarted:
-		canOsp, err := CanOneStepProve(ctx, et.edge)
-		if err != nil {
-			fields["err"] = err
-			srvlog.Error("Could not check if edge can be one step proven", fields)
-			return et.fsm.Do(edgeBackToStart{})
-		}
-		if canOsp {
-			return et.fsm.Do(edgeHandleOneStepProof{})
-		}
-		wasConfirmed, err := et.tryToConfirm(ctx)
-		if err != nil {
-			if !errors.Is(err, errNotYetConfirmable) {
-				fields["err"] = err
-				srvlog.Error("Could not check if edge can be confirmed", fields)
+		// An edge that cannot be confirmed yet may still make its next move.
+		var confirmErr error
+		for _, move := range moves {
+			switch move {
+			case Confirm:
+				wasConfirmed, err := et.tryToConfirm(ctx)
+				if err != nil {
+					if !errors.Is(err, errNotYetConfirmable) {
+						fields["err"] = err
+						srvlog.Error("Could not check if edge can be confirmed", fields)
+						confirmErr = err
+					}
+				}
+				if wasConfirmed {
+					return et.fsm.Do(withMoveErr(edgeConfirm{}, confirmErr))
+				}
+			case OneStepProve:
+				return et.fsm.Do(withMoveErr(edgeHandleOneStepProof{}, confirmErr))
+			case OpenSubchallengeLeaf:
+				return et.fsm.Do(withMoveErr(edgeOpenSubchallengeLeaf{}, confirmErr))
+			case Bisect:
+				return et.fsm.Do(withMoveErr(edgeBisect{}, confirmErr))
+			default:
+				return fmt.Errorf("invalid move: %s", move)
 			}
 		}
-		if wasConfirmed {
-			return et.fsm.Do(edgeConfirm{})
-		}
-		hasRival, err := et.edge.HasRival(ctx)
-		if err != nil {
-			return errors.Wrap(err, "could not check presumptive")
-		}
-		if !hasRival {
-			return et.fsm.Do(edgeBackToStart{})
-		}
-		atOneStepFork, err := et.edge.HasLengthOneRival(ctx)
-		if err != nil {
-			fields["err"] = err
-			srvlog.Error("Could not check if edge has length one rival", fields)
-			return et.fsm.Do(edgeBackToStart{})
-		}
-		if atOneStepFork {
-			return et.fsm.Do(edgeOpenSubchallengeLeaf{})
-		}
-		return et.fsm.Do(edgeBisect{})
+		return et.fsm.Do(withMoveErr(edgeBackToStart{}, confirmErr))
 	// Edge is at a one-step-proof in a small-step challenge.
 	case EdgeAtOneStepProof:
-		if err := et.submitOneStepProof(ctx); err != nil {
+		if !containsMove(moves, OneStepProve) {
+			return et.fsm.Do(edgeBackToStart{})
+		}
+		if err := et.SubmitOneStepProof(ctx); err != nil {
 			fields["err"] = err
 			srvlog.Trace("Could not submit one step proof", fields)
-			return et.fsm.Do(edgeBackToStart{})
+			return et.fsm.Do(withMoveErr(edgeBackToStart{}, err))
 		}
 		return et.fsm.Do(edgeConfirm{})
 	// Edge tracker should add a subchallenge level zero leaf.
 	case EdgeAddingSubchallengeLeaf:
+		if !containsMove(moves, OpenSubchallengeLeaf) {
+			return et.fsm.Do(edgeBackToStart{})
+		}
 		if err := et.openSubchallengeLeaf(ctx); err != nil {
 			fields["err"] = err
 			srvlog.Error("Could not open subchallenge leaf", fields)
-			return et.fsm.Do(edgeBackToStart{})
+			return et.fsm.Do(withMoveErr(edgeBackToStart{}, err))
 		}
 		layerZeroLeafCounter.Inc(1)
+		et.discardPrecomputations()
 		return et.fsm.Do(edgeAwaitConfirmation{})
 	// Edge should bisect.
 	case EdgeBisecting:
+		if !containsMove(moves, Bisect) {
+			return et.fsm.Do(edgeBackToStart{})
+		}
 		lowerChild, upperChild, err := et.bisect(ctx)
 		if err != nil {
 			fields["err"] = err
 			srvlog.Error("Could not bisect", fields)
-			return et.fsm.Do(edgeBackToStart{})
+			return et.fsm.Do(withMoveErr(edgeBackToStart{}, err))
 		}
 		bisectedCounter.Inc(1)
+		et.publishMove(ctx, et.edge.Id(), Bisect)
 
 		firstTracker, err := New(
 			ctx,
@@ -291,15 +441,18 @@ func (et *Tracker) Act(ctx context.Context) error {
 			et.chainWatcher,
 			et.challengeManager,
 			et.associatedAssertionMetadata,
-			WithActInterval(et.actInterval),
-			WithTimeReference(et.timeRef),
 			WithValidatorName(et.validatorName),
 			WithFSMOpts(et.fsmOpts...),
+			WithAlerts(et.alerts),
+			WithStakerPool(et.stakers),
+			WithStrategy(et.strategy),
+			WithEventBus(et.events),
+			WithPrecomputer(et.precomputer),
 		)
 		if err != nil {
 			fields["err"] = err
 			srvlog.Error("Could not create new edge tracker", fields)
-			return et.fsm.Do(edgeBackToStart{})
+			return et.fsm.Do(withMoveErr(edgeBackToStart{}, err))
 		}
 		secondTracker, err := New(
 			ctx,
@@ -309,39 +462,55 @@ func (et *Tracker) Act(ctx context.Context) error {
 			et.chainWatcher,
 			et.challengeManager,
 			et.associatedAssertionMetadata,
-			WithActInterval(et.actInterval),
-			WithTimeReference(et.timeRef),
 			WithValidatorName(et.validatorName),
 			WithFSMOpts(et.fsmOpts...),
+			WithAlerts(et.alerts),
+			WithStakerPool(et.stakers),
+			WithStrategy(et.strategy),
+			WithEventBus(et.events),
+			WithPrecomputer(et.precomputer),
 		)
 		if err != nil {
 			fields["err"] = err
 			srvlog.Error("Could not create new edge tracker", fields)
-			return et.fsm.Do(edgeBackToStart{})
+			return et.fsm.Do(withMoveErr(edgeBackToStart{}, err))
 		}
-		go firstTracker.Spawn(ctx)
-		go secondTracker.Spawn(ctx)
+		firstTracker.Spawn(ctx)
+		secondTracker.Spawn(ctx)
+		// The children warm what they need once spawned, and only then are the
+		// precomputations discarded, so that those they share are kept.
+		et.discardPrecomputations()
 		return et.fsm.Do(edgeAwaitConfirmation{})
 	case EdgeConfirming:
+		if !containsMove(moves, Confirm) {
+			return et.fsm.Do(edgeAwaitConfirmation{})
+		}
 		wasConfirmed, err := et.tryToConfirm(ctx)
 		if err != nil {
-			if !errors.Is(err, errNotYetConfirmable) {
+			if errors.Is(err, errNotYetConfirmable) {
+				err = nil
+			} else {
 				fields["err"] = err
 				srvlog.Error("Could not check if edge can be confirmed", fields)
 			}
 		}
 		if !wasConfirmed {
-			return et.fsm.Do(edgeAwaitConfirmation{})
+			return et.fsm.Do(withMoveErr(edgeAwaitConfirmation{}, err))
 		}
-		return et.fsm.Do(edgeConfirm{})
-	case EdgeConfirmed:
-		srvlog.Info("Edge reached confirmed state", fields)
-		return et.fsm.Do(edgeConfirm{})
+		return et.fsm.Do(withMoveErr(edgeConfirm{}, err))
 	default:
 		return fmt.Errorf("invalid state: %s", current.State)
 	}
 }
 
+// Wraps the event of a transition with the error of the move leading to it, if any.
+func withMoveErr(action edgeTrackerAction, err error) edgeTrackerAction {
+	if err == nil {
+		return action
+	}
+	return failedMove{edgeTrackerAction: action, err: err}
+}
+
 // ShouldDespawn checks if an edge tracker should despawn and no longer act.
 // This is true if the edge's FSM state is the confirmed state or if
 // the edge has a confirmable ancestor by time.
@@ -359,6 +528,14 @@ func (et *Tracker) ShouldDespawn(ctx context.Context) bool {
 	if hasConfirmedRival {
 		// Cannot be confirmed if it has a confirmed rival edge. We should despawn the edge.
 		srvlog.Info("Edge has a confirmed rival, edge tracker will now despawn")
+		if assertionHash, hashErr := et.edge.AssertionHash(ctx); hashErr == nil {
+			challengeLevel := et.edge.GetChallengeLevel()
+			et.alerts.Notify(ctx, alerts.NewStakeAtRiskEvent(
+				et.edge.Id().Hash,
+				assertionHash.Hash,
+				challengeLevel.String(),
+			))
+		}
 		return true
 	}
 	assertionHash, err := et.edge.AssertionHash(ctx)
@@ -503,6 +680,7 @@ func (et *Tracker) tryToConfirm(ctx context.Context) (bool, error) {
 		}
 		srvlog.Info("Confirmed by children", et.uniqueTrackerLogFields())
 		confirmedCounter.Inc(1)
+		et.publishMove(ctx, et.edge.Id(), Confirm)
 		return true, nil
 	}
 
@@ -517,6 +695,7 @@ func (et *Tracker) tryToConfirm(ctx context.Context) (bool, error) {
 		}
 		srvlog.Info("Confirmed by claim", et.uniqueTrackerLogFields())
 		confirmedCounter.Inc(1)
+		et.publishMove(ctx, et.edge.Id(), Confirm)
 		return true, nil
 	}
 
@@ -542,11 +721,90 @@ func (et *Tracker) tryToConfirm(ctx context.Context) (bool, error) {
 		}
 		srvlog.Info("Confirmed by time", et.uniqueTrackerLogFields())
 		confirmedCounter.Inc(1)
+		et.publishMove(ctx, et.edge.Id(), Confirm)
 		return true, nil
 	}
+	if timer*100 >= challengetree.PathTimer(chalPeriod)*honestTimerAlertPercent {
+		challengeLevel := et.edge.GetChallengeLevel()
+		et.alerts.Notify(ctx, alerts.NewHonestTimerNearExpiryEvent(
+			et.edge.Id().Hash,
+			assertionHash.Hash,
+			challengeLevel.String(),
+			uint64(timer),
+			chalPeriod,
+		))
+	}
 	return false, errNotYetConfirmable
 }
 
+// Warms the precomputation of what the edge and its descendants need to bisect and
+// open subchallenges, if a precomputer is set.
+func (et *Tracker) warm(ctx context.Context) {
+	if et.precomputer == nil {
+		return
+	}
+	edge, err := et.speculativeEdge(ctx)
+	if err != nil {
+		fields := et.uniqueTrackerLogFields()
+		fields["err"] = err
+		srvlog.Debug("Could not warm precomputations for edge", fields)
+		return
+	}
+	et.precomputer.Warm(et.edge.Id().Hash, edge)
+}
+
+func (et *Tracker) discardPrecomputations() {
+	if et.precomputer != nil {
+		et.precomputer.Discard(et.edge.Id().Hash)
+	}
+}
+
+// Describes the edge for precomputation, with the same challenge origin heights as
+// the requests made to bisect it.
+func (et *Tracker) speculativeEdge(ctx context.Context) (*l2stateprovider.SpeculativeEdge, error) {
+	challengeLevel := et.edge.GetChallengeLevel()
+	upperHeights := make([]l2stateprovider.Height, 0)
+	if challengeLevel != protocol.NewBlockChallengeLevel() {
+		originHeights, err := et.edge.TopLevelClaimHeight(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, h := range originHeights.ChallengeOriginHeights {
+			upperHeights = append(upperHeights, l2stateprovider.Height(h))
+		}
+	}
+	chalManager, err := et.chain.SpecChallengeManager(ctx)
+	if err != nil {
+		return nil, err
+	}
+	numBigSteps, err := chalManager.NumBigSteps(ctx)
+	if err != nil {
+		return nil, err
+	}
+	heights, err := chalManager.LayerZeroHeights(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var subchallengeHeight uint64
+	switch next := uint8(challengeLevel) + 1; {
+	case next <= numBigSteps:
+		subchallengeHeight = heights.BigStepChallengeHeight
+	case next == numBigSteps+1:
+		subchallengeHeight = heights.SmallStepChallengeHeight
+	}
+	startHeight, _ := et.edge.StartCommitment()
+	endHeight, _ := et.edge.EndCommitment()
+	return &l2stateprovider.SpeculativeEdge{
+		WasmModuleRoot:              et.associatedAssertionMetadata.WasmModuleRoot,
+		FromBatch:                   et.associatedAssertionMetadata.FromBatch,
+		ToBatch:                     et.associatedAssertionMetadata.ToBatch,
+		UpperChallengeOriginHeights: upperHeights,
+		StartHeight:                 l2stateprovider.Height(startHeight),
+		EndHeight:                   l2stateprovider.Height(endHeight),
+		SubchallengeHeight:          l2stateprovider.Height(subchallengeHeight),
+	}, nil
+}
+
 // Determines the bisection point from parentHeight to toHeight and returns a history
 // commitment with a prefix proof for the action based on the challenge type.
 func (et *Tracker) DetermineBisectionHistoryWithProof(
@@ -864,7 +1122,16 @@ func (et *Tracker) openSubchallengeLeaf(ctx context.Context) error {
 	fields["parentEndHeight"] = endParentCommitment.Height
 	srvlog.Info("Creating subchallenge edge", fields)
 
-	manager, err := et.chain.SpecChallengeManager(ctx)
+	var chain protocol.AssertionChain = et.chain
+	var staker *stakers.Selection
+	if et.stakers != nil {
+		staker, err = et.stakers.Select(ctx, stakers.MiniStaker)
+		if err != nil {
+			return err
+		}
+		chain = staker
+	}
+	manager, err := chain.SpecChallengeManager(ctx)
 	if err != nil {
 		return err
 	}
@@ -880,11 +1147,15 @@ func (et *Tracker) openSubchallengeLeaf(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	if staker != nil {
+		staker.Used()
+	}
 	fields["firstLeaf"] = containers.Trunc(startHistory.FirstLeaf.Bytes())
 	fields["startCommitment"] = containers.Trunc(startHistory.Merkle.Bytes())
 	addedLeafChallengeLevel := addedLeaf.GetChallengeLevel()
 	fields["subChallengeType"] = addedLeafChallengeLevel
 	srvlog.Info("Created subchallenge edge", fields)
+	et.publishMove(ctx, addedLeaf.Id(), OpenSubchallengeLeaf)
 
 	if addVerifiedErr := et.chainWatcher.AddVerifiedHonestEdge(ctx, addedLeaf); addVerifiedErr != nil {
 		// We simply log an error, as if this errored, it will be added later on by the chain watcher
@@ -900,19 +1171,24 @@ func (et *Tracker) openSubchallengeLeaf(ctx context.Context) error {
 		et.chainWatcher,
 		et.challengeManager,
 		et.associatedAssertionMetadata,
-		WithActInterval(et.actInterval),
-		WithTimeReference(et.timeRef),
 		WithValidatorName(et.validatorName),
 		WithFSMOpts(et.fsmOpts...),
+		WithAlerts(et.alerts),
+		WithStakerPool(et.stakers),
+		WithStrategy(et.strategy),
+		WithEventBus(et.events),
+		WithPrecomputer(et.precomputer),
 	)
 	if err != nil {
 		return err
 	}
-	go tracker.Spawn(ctx)
+	tracker.Spawn(ctx)
 	return nil
 }
 
-func (et *Tracker) submitOneStepProof(ctx context.Context) error {
+// SubmitOneStepProof confirms the tracker's edge, of length one at the lowest challenge
+// level, by one step proof.
+func (et *Tracker) SubmitOneStepProof(ctx context.Context) error {
 	fields := et.uniqueTrackerLogFields()
 	srvlog.Info("Submitting one-step-proof to protocol", fields)
 	originHeights, err := et.edge.TopLevelClaimHeight(ctx)
@@ -959,9 +1235,28 @@ func (et *Tracker) submitOneStepProof(ctx context.Context) error {
 		return errors.Wrap(err, "could not confirm one step proof against protocol")
 	}
 	srvlog.Info("Succeeded one-step-proof for edge and confirmed it as winner", fields)
+	et.publishMove(ctx, et.edge.Id(), OneStepProve)
 	return nil
 }
 
+// Publishes a move accepted onchain, made on the tracker's edge or adding the given edge.
+func (et *Tracker) publishMove(ctx context.Context, edgeId protocol.EdgeId, move Move) {
+	if et.events == nil {
+		return
+	}
+	assertionHash, err := et.edge.AssertionHash(ctx)
+	if err != nil {
+		srvlog.Error("Could not get assertion hash to publish move", log.Ctx{"err": err, "move": move})
+		return
+	}
+	et.events.Publish(ctx, &events.MoveSubmitted{
+		EdgeId:              edgeId,
+		ChallengedAssertion: assertionHash,
+		Move:                move.String(),
+		Validator:           et.validatorName,
+	})
+}
+
 func CanOneStepProve(ctx context.Context, edge protocol.SpecEdge) (bool, error) {
 	start, _ := edge.StartCommitment()
 	end, _ := edge.EndCommitment()
@@ -0,0 +1,105 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package leader
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"time"
+
+	"github.com/gofrs/flock"
+	"github.com/pkg/errors"
+)
+
+type lease struct {
+	Holder    string    `json:"holder"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// FileLock is a lock backend storing the lease in a file, suitable for
+// instances running on the same host or sharing a filesystem that supports
+// advisory locks. Reads and writes of the lease are serialized with an
+// exclusive file lock.
+type FileLock struct {
+	path  string
+	guard *flock.Flock
+}
+
+// NewFileLock creates a lock backend storing the lease at the given path.
+func NewFileLock(path string) *FileLock {
+	return &FileLock{
+		path:  path,
+		guard: flock.New(path + ".lock"),
+	}
+}
+
+// TryAcquire the lease for the holder.
+func (l *FileLock) TryAcquire(ctx context.Context, holder string, now time.Time, ttl time.Duration) (bool, error) {
+	acquired := false
+	err := l.withLease(func(current *lease) (*lease, error) {
+		if current != nil && current.Holder != holder && now.Before(current.ExpiresAt) {
+			return nil, nil
+		}
+		acquired = true
+		return &lease{Holder: holder, ExpiresAt: now.Add(ttl)}, nil
+	})
+	return acquired, err
+}
+
+// Release the lease if it is owned by the holder.
+func (l *FileLock) Release(ctx context.Context, holder string) error {
+	return l.withLease(func(current *lease) (*lease, error) {
+		if current == nil || current.Holder != holder {
+			return nil, nil
+		}
+		return &lease{}, nil
+	})
+}
+
+// Reads the current lease under an exclusive lock and writes back the
+// lease returned by fn, if any.
+func (l *FileLock) withLease(fn func(current *lease) (*lease, error)) error {
+	if err := l.guard.Lock(); err != nil {
+		return errors.Wrap(err, "could not lock lease file")
+	}
+	defer func() {
+		_ = l.guard.Unlock()
+	}()
+	f, err := os.OpenFile(l.path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	var current *lease
+	if len(data) > 0 {
+		current = &lease{}
+		if err = json.Unmarshal(data, current); err != nil {
+			return errors.Wrapf(err, "could not decode lease file %s", l.path)
+		}
+		if current.Holder == "" {
+			current = nil
+		}
+	}
+	next, err := fn(current)
+	if err != nil || next == nil {
+		return err
+	}
+	data, err = json.Marshal(next)
+	if err != nil {
+		return err
+	}
+	if err = f.Truncate(0); err != nil {
+		return err
+	}
+	if _, err = f.WriteAt(data, 0); err != nil {
+		return err
+	}
+	return f.Sync()
+}
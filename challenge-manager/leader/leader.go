@@ -0,0 +1,186 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+// Package leader implements lease-based leader election for running several
+// instances of the same validator in an active/passive configuration. Instances
+// compete for a lease through a pluggable lock backend, and only the current
+// leaseholder is expected to act onchain.
+package leader
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	utilTime "github.com/OffchainLabs/bold/time"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/pkg/errors"
+)
+
+var (
+	srvlog = log.New("service", "leader")
+
+	isLeaderGauge      = metrics.NewRegisteredGauge("arb/validator/leader/is_leader", nil)
+	leaderChangesCount = metrics.NewRegisteredCounter("arb/validator/leader/changes", nil)
+)
+
+func init() {
+	srvlog.SetHandler(log.StreamHandler(os.Stdout, log.LogfmtFormat()))
+}
+
+// Lock is a backend through which instances compete for a leader lease.
+type Lock interface {
+	// TryAcquire acquires the lease for the holder if it is free or has expired,
+	// or extends it if the holder already owns it. The lease is valid for ttl
+	// from now. Returns true if the holder owns the lease after the call.
+	TryAcquire(ctx context.Context, holder string, now time.Time, ttl time.Duration) (bool, error)
+	// Release gives up the lease if it is owned by the holder.
+	Release(ctx context.Context, holder string) error
+}
+
+// Elector periodically tries to acquire or renew a leader lease and keeps
+// track of whether this instance is currently the leader.
+type Elector struct {
+	lock          Lock
+	id            string
+	leaseDuration time.Duration
+	renewInterval time.Duration
+	timeRef       utilTime.Reference
+
+	mu          sync.RWMutex
+	leader      bool
+	leaseExpiry time.Time
+}
+
+type Opt func(e *Elector)
+
+// WithLeaseDuration sets how long an acquired lease is valid for. Followers can
+// take over at most this long after the leader stops renewing. Defaults to 30s.
+func WithLeaseDuration(d time.Duration) Opt {
+	return func(e *Elector) {
+		e.leaseDuration = d
+	}
+}
+
+// WithRenewInterval sets how often the lease is acquired or renewed. Defaults
+// to a third of the lease duration.
+func WithRenewInterval(d time.Duration) Opt {
+	return func(e *Elector) {
+		e.renewInterval = d
+	}
+}
+
+// WithTimeReference sets the time reference used for lease expiries.
+func WithTimeReference(ref utilTime.Reference) Opt {
+	return func(e *Elector) {
+		e.timeRef = ref
+	}
+}
+
+// NewElector creates an elector competing for the lease with the given
+// instance id, which must be unique among the instances sharing a lock.
+func NewElector(lock Lock, id string, opts ...Opt) (*Elector, error) {
+	if lock == nil {
+		return nil, errors.New("lock backend cannot be nil")
+	}
+	if id == "" {
+		return nil, errors.New("instance id cannot be empty")
+	}
+	e := &Elector{
+		lock:          lock,
+		id:            id,
+		leaseDuration: 30 * time.Second,
+		timeRef:       utilTime.NewRealTimeReference(),
+	}
+	for _, o := range opts {
+		o(e)
+	}
+	if e.renewInterval == 0 {
+		e.renewInterval = e.leaseDuration / 3
+	}
+	if e.renewInterval >= e.leaseDuration {
+		return nil, errors.New("renew interval must be shorter than the lease duration")
+	}
+	return e, nil
+}
+
+// Id of this instance.
+func (e *Elector) Id() string {
+	return e.id
+}
+
+// IsLeader returns true if this instance holds an unexpired lease. The lease is
+// considered lost once it expires locally, even if renewal has not yet failed,
+// so that two instances never both believe they are the leader.
+func (e *Elector) IsLeader() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.leader && e.timeRef.Get().Before(e.leaseExpiry)
+}
+
+// Start competes for the lease until the context is canceled, at which point
+// the lease is released if held.
+func (e *Elector) Start(ctx context.Context) {
+	e.Campaign(ctx)
+	ticker := e.timeRef.NewTicker(e.renewInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C():
+			e.Campaign(ctx)
+		case <-ctx.Done():
+			e.resign()
+			return
+		}
+	}
+}
+
+// Campaign makes a single attempt to acquire or renew the lease.
+func (e *Elector) Campaign(ctx context.Context) {
+	now := e.timeRef.Get()
+	acquired, err := e.lock.TryAcquire(ctx, e.id, now, e.leaseDuration)
+	if err != nil {
+		// Keep the current lease, which remains valid until it expires locally.
+		srvlog.Error("Could not acquire leader lease", log.Ctx{"id": e.id, "err": err})
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if acquired {
+		e.leaseExpiry = now.Add(e.leaseDuration)
+	}
+	e.setLeaderLocked(acquired)
+}
+
+func (e *Elector) resign() {
+	e.mu.Lock()
+	wasLeader := e.leader
+	e.setLeaderLocked(false)
+	e.mu.Unlock()
+	if !wasLeader {
+		return
+	}
+	// The parent context is done, so release with a fresh one.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := e.lock.Release(ctx, e.id); err != nil {
+		srvlog.Error("Could not release leader lease", log.Ctx{"id": e.id, "err": err})
+	}
+}
+
+func (e *Elector) setLeaderLocked(leader bool) {
+	if e.leader == leader {
+		return
+	}
+	e.leader = leader
+	leaderChangesCount.Inc(1)
+	if leader {
+		isLeaderGauge.Update(1)
+		srvlog.Info("Acquired leader lease, now acting as leader", log.Ctx{"id": e.id})
+	} else {
+		isLeaderGauge.Update(0)
+		srvlog.Info("Lost leader lease, now following", log.Ctx{"id": e.id})
+	}
+}
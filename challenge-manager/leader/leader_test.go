@@ -0,0 +1,174 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package leader
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	utilTime "github.com/OffchainLabs/bold/time"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileLock(t *testing.T) {
+	ctx := context.Background()
+	lock := NewFileLock(filepath.Join(t.TempDir(), "lease"))
+	now := time.Unix(1000, 0)
+
+	ok, err := lock.TryAcquire(ctx, "alice", now, time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// Bob cannot take an unexpired lease, but Alice can renew it.
+	ok, err = lock.TryAcquire(ctx, "bob", now.Add(time.Second), time.Minute)
+	require.NoError(t, err)
+	require.False(t, ok)
+	ok, err = lock.TryAcquire(ctx, "alice", now.Add(30*time.Second), time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// Once Alice stops renewing and the lease expires, Bob takes over.
+	ok, err = lock.TryAcquire(ctx, "bob", now.Add(90*time.Second), time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = lock.TryAcquire(ctx, "alice", now.Add(91*time.Second), time.Minute)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// Releasing is a no-op for non-holders.
+	require.NoError(t, lock.Release(ctx, "alice"))
+	ok, err = lock.TryAcquire(ctx, "alice", now.Add(92*time.Second), time.Minute)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, lock.Release(ctx, "bob"))
+	ok, err = lock.TryAcquire(ctx, "alice", now.Add(93*time.Second), time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestSQLiteLock(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	// Each connection to an in-memory database has its own database.
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { require.NoError(t, db.Close()) })
+	lock, err := NewSQLiteLock(ctx, db, "leases")
+	require.NoError(t, err)
+	now := time.Unix(1000, 0)
+
+	ok, err := lock.TryAcquire(ctx, "alice", now, time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// Bob cannot take an unexpired lease, but Alice can renew it.
+	ok, err = lock.TryAcquire(ctx, "bob", now.Add(time.Second), time.Minute)
+	require.NoError(t, err)
+	require.False(t, ok)
+	ok, err = lock.TryAcquire(ctx, "alice", now.Add(30*time.Second), time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// The renewal extended the lease past its first expiry.
+	ok, err = lock.TryAcquire(ctx, "bob", now.Add(61*time.Second), time.Minute)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// Once Alice stops renewing and the lease expires, Bob steals it.
+	ok, err = lock.TryAcquire(ctx, "bob", now.Add(90*time.Second), time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = lock.TryAcquire(ctx, "alice", now.Add(91*time.Second), time.Minute)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// Releasing is a no-op for non-holders.
+	require.NoError(t, lock.Release(ctx, "alice"))
+	ok, err = lock.TryAcquire(ctx, "alice", now.Add(92*time.Second), time.Minute)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, lock.Release(ctx, "bob"))
+	ok, err = lock.TryAcquire(ctx, "alice", now.Add(93*time.Second), time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// The table already exists for a second backend on the same database.
+	other, err := NewSQLiteLock(ctx, db, "leases")
+	require.NoError(t, err)
+	ok, err = other.TryAcquire(ctx, "bob", now.Add(94*time.Second), time.Minute)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestSQLiteLock_RejectsInvalidTableNames(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, db.Close()) })
+	for _, name := range []string{"", "1leases", "leases; DROP TABLE x", "lea-ses", `"leases"`} {
+		_, err = NewSQLiteLock(ctx, db, name)
+		require.ErrorContains(t, err, "invalid lease table name", name)
+	}
+}
+
+func TestElector_Failover(t *testing.T) {
+	ctx := context.Background()
+	timeRef := utilTime.NewArtificialTimeReference()
+	lock := NewFileLock(filepath.Join(t.TempDir(), "lease"))
+	alice, err := NewElector(lock, "alice", WithLeaseDuration(time.Minute), WithTimeReference(timeRef))
+	require.NoError(t, err)
+	bob, err := NewElector(lock, "bob", WithLeaseDuration(time.Minute), WithTimeReference(timeRef))
+	require.NoError(t, err)
+
+	alice.Campaign(ctx)
+	bob.Campaign(ctx)
+	require.True(t, alice.IsLeader())
+	require.False(t, bob.IsLeader())
+
+	// Alice stops renewing, so her lease expires locally before Bob can take over.
+	timeRef.Add(time.Minute)
+	require.False(t, alice.IsLeader())
+	bob.Campaign(ctx)
+	require.True(t, bob.IsLeader())
+	alice.Campaign(ctx)
+	require.False(t, alice.IsLeader())
+}
+
+func TestElector_ReleasesLeaseOnShutdown(t *testing.T) {
+	timeRef := utilTime.NewArtificialTimeReference()
+	lock := NewFileLock(filepath.Join(t.TempDir(), "lease"))
+	alice, err := NewElector(lock, "alice", WithTimeReference(timeRef))
+	require.NoError(t, err)
+	bob, err := NewElector(lock, "bob", WithTimeReference(timeRef))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		alice.Start(ctx)
+		close(done)
+	}()
+	require.Eventually(t, alice.IsLeader, time.Second, 10*time.Millisecond)
+	cancel()
+	<-done
+	require.False(t, alice.IsLeader())
+
+	// Bob does not have to wait for the lease to expire.
+	bob.Campaign(context.Background())
+	require.True(t, bob.IsLeader())
+}
+
+func TestNewElector_Validation(t *testing.T) {
+	lock := NewFileLock(filepath.Join(t.TempDir(), "lease"))
+	_, err := NewElector(nil, "alice")
+	require.ErrorContains(t, err, "lock backend cannot be nil")
+	_, err = NewElector(lock, "")
+	require.ErrorContains(t, err, "instance id cannot be empty")
+	_, err = NewElector(lock, "alice", WithLeaseDuration(time.Second), WithRenewInterval(time.Second))
+	require.ErrorContains(t, err, "renew interval must be shorter")
+}
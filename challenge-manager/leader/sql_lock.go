@@ -0,0 +1,67 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package leader
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Table names are interpolated into statements, as they cannot be bound as
+// parameters, so only plain identifiers are accepted.
+var tableNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// SQLiteLock is a lock backend storing the lease in a single row of a SQLite
+// table. The database handle is opened by the caller, who is responsible for
+// registering the SQLite driver.
+type SQLiteLock struct {
+	db        *sqlx.DB
+	tableName string
+}
+
+// NewSQLiteLock creates a lock backend storing the lease in the given table,
+// creating the table if it does not exist. The table name must be a plain
+// identifier of letters, digits and underscores.
+func NewSQLiteLock(ctx context.Context, db *sqlx.DB, tableName string) (*SQLiteLock, error) {
+	if !tableNameRegexp.MatchString(tableName) {
+		return nil, fmt.Errorf("invalid lease table name %q", tableName)
+	}
+	if _, err := db.ExecContext(ctx,
+		"CREATE TABLE IF NOT EXISTS "+tableName+" ("+
+			"id INTEGER PRIMARY KEY CHECK (id = 0),"+
+			"holder TEXT NOT NULL,"+
+			"expiresAt INTEGER NOT NULL"+
+			")"); err != nil {
+		return nil, err
+	}
+	return &SQLiteLock{db: db, tableName: tableName}, nil
+}
+
+// TryAcquire the lease for the holder. The lease row is only overwritten if
+// it is owned by the holder or has expired, in a single atomic statement.
+func (l *SQLiteLock) TryAcquire(ctx context.Context, holder string, now time.Time, ttl time.Duration) (bool, error) {
+	if _, err := l.db.ExecContext(ctx,
+		"INSERT INTO "+l.tableName+" (id, holder, expiresAt) VALUES (0, ?, ?) "+
+			"ON CONFLICT(id) DO UPDATE SET holder = excluded.holder, expiresAt = excluded.expiresAt "+
+			"WHERE "+l.tableName+".holder = excluded.holder OR "+l.tableName+".expiresAt <= ?",
+		holder, now.Add(ttl).UnixNano(), now.UnixNano(),
+	); err != nil {
+		return false, err
+	}
+	var current string
+	if err := l.db.GetContext(ctx, &current, "SELECT holder FROM "+l.tableName+" WHERE id = 0"); err != nil {
+		return false, err
+	}
+	return current == holder, nil
+}
+
+// Release the lease if it is owned by the holder.
+func (l *SQLiteLock) Release(ctx context.Context, holder string) error {
+	_, err := l.db.ExecContext(ctx, "DELETE FROM "+l.tableName+" WHERE id = 0 AND holder = ?", holder)
+	return err
+}
@@ -6,26 +6,38 @@
 package challengemanager
 
 import (
+	"bytes"
 	"context"
 	"crypto/rand"
 	"errors"
 	"fmt"
 	"math/big"
+	"net/http"
 	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/OffchainLabs/bold/accounting"
+	"github.com/OffchainLabs/bold/alerts"
 	"github.com/OffchainLabs/bold/api"
 	"github.com/OffchainLabs/bold/assertions"
 	protocol "github.com/OffchainLabs/bold/chain-abstraction"
 	watcher "github.com/OffchainLabs/bold/challenge-manager/chain-watcher"
+	"github.com/OffchainLabs/bold/challenge-manager/confirmer"
 	edgetracker "github.com/OffchainLabs/bold/challenge-manager/edge-tracker"
+	"github.com/OffchainLabs/bold/challenge-manager/leader"
+	"github.com/OffchainLabs/bold/challenge-manager/scheduler"
+	"github.com/OffchainLabs/bold/challenge-manager/stakers"
 	"github.com/OffchainLabs/bold/challenge-manager/types"
 	"github.com/OffchainLabs/bold/containers/threadsafe"
+	"github.com/OffchainLabs/bold/events"
 	l2stateprovider "github.com/OffchainLabs/bold/layer2-state-provider"
+	"github.com/OffchainLabs/bold/layer2-state-provider/fixture"
 	retry "github.com/OffchainLabs/bold/runtime"
 	"github.com/OffchainLabs/bold/solgen/go/challengeV2gen"
 	"github.com/OffchainLabs/bold/solgen/go/rollupgen"
-	utilTime "github.com/OffchainLabs/bold/time"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/log"
@@ -34,8 +46,13 @@ import (
 
 var (
 	srvlog = log.New("service", "challenge-manager")
+	// ErrShuttingDown is returned when new work is requested of a challenge manager which is stopping.
+	ErrShuttingDown = errors.New("challenge manager is shutting down")
 )
 
+// How often a stopping challenge manager checks whether its edge trackers have exited.
+const trackerShutdownPollInterval = 100 * time.Millisecond
+
 func init() {
 	srvlog.SetHandler(log.StreamHandler(os.Stdout, log.LogfmtFormat()))
 }
@@ -45,36 +62,87 @@ type Opt = func(val *Manager)
 // Manager defines an offchain, challenge manager, which will be
 // an active participant in interacting with the on-chain contracts.
 type Manager struct {
-	chain                       protocol.Protocol
-	chalManagerAddr             common.Address
-	rollupAddr                  common.Address
-	rollup                      *rollupgen.RollupCore
-	rollupFilterer              *rollupgen.RollupCoreFilterer
-	chalManager                 *challengeV2gen.EdgeChallengeManagerFilterer
-	backend                     bind.ContractBackend
-	client                      *rpc.Client
-	stateManager                l2stateprovider.Provider
-	address                     common.Address
-	name                        string
-	timeRef                     utilTime.Reference
-	edgeTrackerWakeInterval     time.Duration
-	chainWatcherInterval        time.Duration
-	watcher                     *watcher.Watcher
-	trackedEdgeIds              *threadsafe.Set[protocol.EdgeId]
+	chain                   protocol.Protocol
+	chalManagerAddr         common.Address
+	rollupAddr              common.Address
+	rollup                  *rollupgen.RollupCore
+	rollupFilterer          *rollupgen.RollupCoreFilterer
+	chalManager             *challengeV2gen.EdgeChallengeManagerFilterer
+	backend                 bind.ContractBackend
+	client                  *rpc.Client
+	stateManager            l2stateprovider.Provider
+	address                 common.Address
+	name                    string
+	edgeTrackerWakeInterval time.Duration
+	edgeTrackerWorkers      int
+	scheduler               *scheduler.Scheduler
+	chainWatcherInterval    time.Duration
+	watcher                 *watcher.Watcher
+	trackedEdgeIds          *threadsafe.Set[protocol.EdgeId]
+	// Held while marking edges as tracked or no longer tracked.
+	trackLock                   sync.Mutex
 	batchIndexForAssertionCache *threadsafe.Map[protocol.AssertionHash, edgetracker.AssociatedAssertionMetadata]
 	assertionManager            *assertions.Manager
 	assertionPostingInterval    time.Duration
 	assertionScanningInterval   time.Duration
 	assertionConfirmingInterval time.Duration
 	averageTimeForBlockCreation time.Duration
-	mode                        types.Mode
-	maxDelaySeconds             int
+	// Mode of the challenge manager, which can be switched at runtime.
+	mode            atomic.Uint32
+	maxDelaySeconds int
 
 	challengedAssertions *threadsafe.Set[protocol.AssertionHash]
+	// Alerts
+	alerts               *alerts.Dispatcher
+	lowBalanceThreshold  *big.Int
+	balanceCheckInterval time.Duration
+	// Diagnosis of assertions we disagree with.
+	diagnoser *assertions.Diagnoser
+	// High availability
+	elector *leader.Elector
+	// Staker identities
+	stakers *stakers.Pool
+	// Moves made by edge trackers
+	strategy edgetracker.Strategy
+	// Protocol events observed onchain and moves made
+	events *events.Bus
+	// Costs and recoveries of challenges
+	ledger *accounting.Ledger
+	// Confirmer mode, whose confirmer is created once the mode is first entered and runs
+	// until the mode is left.
+	confirmer          *confirmer.Confirmer
+	confirmerGasBudget *big.Int
+	cancelConfirmer    context.CancelFunc
 	// API
 	apiAddr     string
 	api         *api.Server
 	apiDBConfig *api.DBConfig
+	adminToken  string
+	// Runtime controls
+	movesPaused atomic.Bool
+	// Lifecycle
+	lifecycleLock  sync.Mutex
+	cancelWork     context.CancelFunc
+	cancelRoutines context.CancelFunc
+	// Contexts of the routines and in-flight moves, kept to start watching
+	// challenges once switched to a mode which does.
+	routinesCtx           context.Context
+	workCtx               context.Context
+	challengeRoutinesOnce sync.Once
+	routines              sync.WaitGroup
+	stopping              atomic.Bool
+	activeTrackers        *threadsafe.Set[protocol.EdgeId]
+	// Registry of the edge trackers being run, for introspection.
+	trackers *threadsafe.Map[protocol.EdgeId, *edgetracker.Tracker]
+	// Where summaries of completed challenges are kept once evicted from memory.
+	challengeArchive watcher.ChallengeArchive
+	// Speculative precomputation of history commitments and proofs
+	speculativeDepth   uint8
+	speculativeWorkers int
+	speculative        *l2stateprovider.SpeculativeProvider
+	// Recording of the requests made to the state provider and its responses
+	recordProvider bool
+	recorder       *fixture.Recorder
 }
 
 // WithName is a human-readable identifier for this challenge manager for logging purposes.
@@ -91,14 +159,22 @@ func WithAddress(addr common.Address) Opt {
 	}
 }
 
-// WithEdgeTrackerWakeInterval specifies how often each edge tracker goroutine will
-// act on its responsibilities.
+// WithEdgeTrackerWakeInterval specifies how long an edge tracker waits to act again
+// if it is not woken up earlier by a chain event in its challenge.
 func WithEdgeTrackerWakeInterval(d time.Duration) Opt {
 	return func(val *Manager) {
 		val.edgeTrackerWakeInterval = d
 	}
 }
 
+// WithEdgeTrackerWorkers specifies the maximum number of edge trackers acting
+// concurrently. Trackers closest to their challenge period deadline act first.
+func WithEdgeTrackerWorkers(n int) Opt {
+	return func(val *Manager) {
+		val.edgeTrackerWorkers = n
+	}
+}
+
 func WithAssertionPostingInterval(d time.Duration) Opt {
 	return func(val *Manager) {
 		val.assertionPostingInterval = d
@@ -120,7 +196,7 @@ func WithAssertionConfirmingInterval(d time.Duration) Opt {
 // WithMode specifies the mode of the challenge manager.
 func WithMode(m types.Mode) Opt {
 	return func(val *Manager) {
-		val.mode = m
+		val.mode.Store(uint32(m))
 	}
 }
 
@@ -138,6 +214,111 @@ func WithAPIDB(config *api.DBConfig) Opt {
 	}
 }
 
+// WithAlerts specifies the dispatcher used to notify operators about notable protocol events.
+func WithAlerts(d *alerts.Dispatcher) Opt {
+	return func(val *Manager) {
+		val.alerts = d
+	}
+}
+
+// WithLowBalanceThreshold enables periodically checking the balance of the staker, or of
+// every staker of the pool set with [WithStakerPool], and raising an alert for each
+// below the given threshold in wei.
+func WithLowBalanceThreshold(threshold *big.Int) Opt {
+	return func(val *Manager) {
+		val.lowBalanceThreshold = threshold
+	}
+}
+
+// WithBalanceCheckInterval specifies how often the staker's balance is checked against
+// the threshold set with [WithLowBalanceThreshold]. The default is one minute.
+func WithBalanceCheckInterval(d time.Duration) Opt {
+	return func(val *Manager) {
+		val.balanceCheckInterval = d
+	}
+}
+
+// WithDivergenceDiagnoser specifies the diagnoser used to produce divergence reports for
+// assertions we disagree with. Reports are exposed over the API if it is enabled.
+func WithDivergenceDiagnoser(d *assertions.Diagnoser) Opt {
+	return func(val *Manager) {
+		val.diagnoser = d
+	}
+}
+
+// WithLeaderElection runs the challenge manager as one of several instances of the
+// same validator, of which only the holder of the leader lease acts onchain. Followers
+// keep watching the chain and tracking honest edges so that failover is instant.
+func WithLeaderElection(e *leader.Elector) Opt {
+	return func(val *Manager) {
+		val.elector = e
+	}
+}
+
+// WithStakerPool uses a pool of staker keys to stake on assertions and rivals and to
+// fund the mini-stakes of level zero challenge edges, selecting a key per role.
+func WithStakerPool(p *stakers.Pool) Opt {
+	return func(val *Manager) {
+		val.stakers = p
+	}
+}
+
+// WithStrategy sets the strategy deciding which moves the edge trackers make,
+// such as edgetracker.ConfirmOnly or edgetracker.LazyBisect. Defaults to the
+// honest strategy.
+func WithStrategy(s edgetracker.Strategy) Opt {
+	return func(val *Manager) {
+		val.strategy = s
+	}
+}
+
+// WithEventBus sets the bus on which protocol events observed onchain and the moves
+// made by the challenge manager are published. Defaults to a new bus, which can be
+// subscribed to through [Manager.EventBus].
+func WithEventBus(bus *events.Bus) Opt {
+	return func(val *Manager) {
+		val.events = bus
+	}
+}
+
+// WithLedger records the refunds of mini-stakes observed onchain in the given ledger,
+// and serves the costs it records through the API. The assertion chain should be
+// given the same ledger to record the transactions it makes.
+func WithLedger(ledger *accounting.Ledger) Opt {
+	return func(val *Manager) {
+		val.ledger = ledger
+	}
+}
+
+// WithConfirmerGasBudget caps the wei spent on confirmations in confirmer mode by the
+// account given with [WithAddress]. Confirmations are not capped by default.
+func WithConfirmerGasBudget(budget *big.Int) Opt {
+	return func(val *Manager) {
+		val.confirmerGasBudget = budget
+	}
+}
+
+// WithSpeculativePrecomputation precomputes the history commitments and prefix proofs
+// tracked edges need to bisect and open subchallenges, for the given number of
+// bisection levels below each edge and with the given number of workers, so that they
+// are ready when the moves are made. Precomputations are canceled once edges have made
+// their moves. Disabled by default.
+func WithSpeculativePrecomputation(depth uint8, workers int) Opt {
+	return func(val *Manager) {
+		val.speculativeDepth = depth
+		val.speculativeWorkers = workers
+	}
+}
+
+// WithProviderRecording records the requests made to the state provider and its
+// responses, so they can be written as a fixture and replayed in a regression test,
+// such as after an incident. Disabled by default.
+func WithProviderRecording() Opt {
+	return func(val *Manager) {
+		val.recordProvider = true
+	}
+}
+
 func WithRPCClient(client *rpc.Client) Opt {
 	return func(val *Manager) {
 		val.client = client
@@ -159,7 +340,6 @@ func New(
 		chain:                       chain,
 		stateManager:                stateManager,
 		address:                     common.Address{},
-		timeRef:                     utilTime.NewRealTimeReference(),
 		rollupAddr:                  rollupAddr,
 		chainWatcherInterval:        time.Millisecond * 500,
 		trackedEdgeIds:              threadsafe.NewSet[protocol.EdgeId](),
@@ -169,20 +349,48 @@ func New(
 		assertionConfirmingInterval: time.Second * 10,
 		averageTimeForBlockCreation: time.Second * 12,
 		challengedAssertions:        threadsafe.NewSet[protocol.AssertionHash](),
+		balanceCheckInterval:        time.Minute,
+		activeTrackers:              threadsafe.NewSet[protocol.EdgeId](),
+		trackers:                    threadsafe.NewMap[protocol.EdgeId, *edgetracker.Tracker](),
+		strategy:                    edgetracker.Honest(),
 	}
 	for _, o := range opts {
 		o(m)
 	}
+	if m.events == nil {
+		m.events = events.NewBus()
+	}
+	if m.recordProvider {
+		m.recorder = fixture.NewRecorder(m.stateManager)
+		m.stateManager = m.recorder
+	}
+	if m.speculativeDepth > 0 {
+		m.speculative = l2stateprovider.NewSpeculativeProvider(
+			m.stateManager,
+			l2stateprovider.WithSpeculativeDepth(m.speculativeDepth),
+			l2stateprovider.WithSpeculativeWorkers(m.speculativeWorkers),
+		)
+		m.stateManager = m.speculative
+	}
 
 	if m.edgeTrackerWakeInterval == 0 {
-		// Generating a random integer between 0 and 60 second to wake up the edge tracker.
+		// Generating a random integer between 1 and 60 second to wake up the edge tracker.
 		// This is to avoid all edge trackers waking up at the same time across participants.
 		n, err := rand.Int(rand.Reader, new(big.Int).SetUint64(60))
 		if err != nil {
 			return nil, err
 		}
-		m.edgeTrackerWakeInterval = time.Second * time.Duration(n.Uint64())
+		m.edgeTrackerWakeInterval = time.Second * time.Duration(n.Uint64()+1)
+	}
+	schedulerOpts := []scheduler.Opt{scheduler.WithIdleInterval(m.edgeTrackerWakeInterval)}
+	if m.edgeTrackerWorkers != 0 {
+		schedulerOpts = append(schedulerOpts, scheduler.WithWorkers(m.edgeTrackerWorkers))
 	}
+	sched, err := scheduler.New(schedulerOpts...)
+	if err != nil {
+		return nil, err
+	}
+	m.scheduler = sched
 
 	chalManager, err := m.chain.SpecChallengeManager(ctx)
 	if err != nil {
@@ -210,7 +418,21 @@ func New(
 	m.rollupFilterer = rollupFilterer
 	m.chalManagerAddr = chalManagerAddr
 	m.chalManager = chalManagerFilterer
-	watcher, err := watcher.New(m.chain, m, m.stateManager, backend, m.chainWatcherInterval, numBigStepLevels, m.name)
+	watcher, err := watcher.New(
+		m.chain,
+		m,
+		m.stateManager,
+		backend,
+		m.chainWatcherInterval,
+		numBigStepLevels,
+		m.name,
+		watcher.WithAlerts(m.alerts),
+		watcher.WithTrackerWaker(m.scheduler),
+		watcher.WithEventBus(m.events),
+		watcher.WithChallengeArchive(m.challengeArchive),
+		watcher.WithChallengeEvictor(m),
+		watcher.WithLedger(m.ledger),
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -227,23 +449,46 @@ func New(
 		m.stateManager,
 		m.assertionPostingInterval,
 		m.averageTimeForBlockCreation,
+		assertions.WithAlerts(m.alerts),
+		assertions.WithDivergenceDiagnoser(m.diagnoser),
+		assertions.WithStakerPool(m.stakers),
+		assertions.WithEventBus(m.events),
 	)
 	if err != nil {
 		return nil, err
 	}
 	m.assertionManager = assertionManager
 
+	if m.Mode() == types.ConfirmerMode {
+		if err := m.initConfirmer(); err != nil {
+			return nil, err
+		}
+	}
+
 	if m.apiAddr != "" && m.client == nil {
 		return nil, errors.New("go-ethereum RPC client required to enable API service")
 	}
 
 	if m.apiAddr != "" {
-		a, err := api.NewServer(&api.Config{
+		cfg := &api.Config{
 			Address:            m.apiAddr,
 			EdgesProvider:      m.watcher,
 			AssertionsProvider: m.chain,
 			DBConfig:           m.apiDBConfig,
-		})
+			EventBus:           m.events,
+		}
+		if m.diagnoser != nil {
+			cfg.DivergencesProvider = m.diagnoser
+		}
+		cfg.TrackersProvider = m
+		if m.ledger != nil {
+			cfg.AccountingProvider = m.ledger
+		}
+		if m.adminToken != "" {
+			cfg.AdminProvider = m
+			cfg.AdminToken = m.adminToken
+		}
+		a, err := api.NewServer(cfg)
 		if err != nil {
 			return nil, err
 		}
@@ -253,19 +498,82 @@ func New(
 	return m, nil
 }
 
-// IsTrackingEdge returns true if we are currently tracking a specified edge id as an edge tracker goroutine.
+// IsTrackingEdge returns true if we are currently tracking a specified edge id with an edge tracker.
 func (m *Manager) IsTrackingEdge(edgeId protocol.EdgeId) bool {
 	return m.trackedEdgeIds.Has(edgeId)
 }
 
-// MarkTrackedEdge marks an edge id as being tracked by our challenge manager.
-func (m *Manager) MarkTrackedEdge(edgeId protocol.EdgeId) {
+// MarkTrackedEdge marks an edge id as being tracked by our challenge manager. Returns
+// false if it already is, in which case the caller must not run a tracker for it.
+func (m *Manager) MarkTrackedEdge(edgeId protocol.EdgeId) bool {
+	m.trackLock.Lock()
+	defer m.trackLock.Unlock()
+	if m.trackedEdgeIds.Has(edgeId) {
+		return false
+	}
 	m.trackedEdgeIds.Insert(edgeId)
+	m.activeTrackers.Insert(edgeId)
+	return true
+}
+
+// Marks an edge id as no longer tracked unless its tracker is running, so that a
+// tracker can be spawned for it again. Returns false if its tracker is running.
+func (m *Manager) untrackExitedEdge(edgeId protocol.EdgeId) bool {
+	m.trackLock.Lock()
+	defer m.trackLock.Unlock()
+	if m.activeTrackers.Has(edgeId) {
+		return false
+	}
+	m.trackedEdgeIds.Delete(edgeId)
+	return true
+}
+
+// MarkTrackerExited marks the tracker of an edge id as no longer running. The edge
+// remains tracked, so that no new tracker is spawned for it.
+func (m *Manager) MarkTrackerExited(edgeId protocol.EdgeId) {
+	m.activeTrackers.Delete(edgeId)
+	m.trackers.Delete(edgeId)
+}
+
+// ScheduleTracker runs an edge tracker on the scheduler until it should despawn.
+// Returns false if the challenge manager is stopping.
+func (m *Manager) ScheduleTracker(topLevelAssertionHash protocol.AssertionHash, tracker *edgetracker.Tracker) bool {
+	if m.stopping.Load() {
+		return false
+	}
+	m.trackers.Put(tracker.EdgeId(), tracker)
+	if !m.scheduler.Schedule(topLevelAssertionHash, tracker) {
+		m.trackers.Delete(tracker.EdgeId())
+		return false
+	}
+	return true
+}
+
+// TrackerSnapshots returns snapshots of the edge trackers being run, ordered by edge id.
+func (m *Manager) TrackerSnapshots() []*edgetracker.Snapshot {
+	snapshots := make([]*edgetracker.Snapshot, 0, m.trackers.NumItems())
+	_ = m.trackers.ForEach(func(_ protocol.EdgeId, tracker *edgetracker.Tracker) error {
+		snapshots = append(snapshots, tracker.Snapshot())
+		return nil
+	})
+	sort.Slice(snapshots, func(i, j int) bool {
+		return bytes.Compare(snapshots[i].EdgeId.Bytes(), snapshots[j].EdgeId.Bytes()) < 0
+	})
+	return snapshots
+}
+
+// TrackerSnapshot returns a snapshot of the edge tracker being run for an edge, if any.
+func (m *Manager) TrackerSnapshot(edgeId common.Hash) (*edgetracker.Snapshot, bool) {
+	tracker, ok := m.trackers.TryGet(protocol.EdgeId{Hash: edgeId})
+	if !ok {
+		return nil, false
+	}
+	return tracker.Snapshot(), true
 }
 
 // Mode returns the mode of the challenge manager.
 func (m *Manager) Mode() types.Mode {
-	return m.mode
+	return types.Mode(m.mode.Load())
 }
 
 // MaxDelaySeconds returns the maximum number of seconds that the challenge manager will wait open a challenge.
@@ -273,8 +581,24 @@ func (m *Manager) MaxDelaySeconds() int {
 	return m.maxDelaySeconds
 }
 
+// IsLeader returns true if this instance should act onchain, which is always
+// the case unless leader election is enabled.
+func (m *Manager) IsLeader() bool {
+	if m.elector == nil {
+		return true
+	}
+	return m.elector.IsLeader()
+}
+
 // TrackEdge spawns an edge tracker for an edge if it is not currently being tracked.
+// In confirmer mode, honest edges are confirmed without being tracked.
 func (m *Manager) TrackEdge(ctx context.Context, edge protocol.SpecEdge) error {
+	if m.stopping.Load() {
+		return ErrShuttingDown
+	}
+	if m.Mode() == types.ConfirmerMode {
+		return nil
+	}
 	if m.trackedEdgeIds.Has(edge.Id()) {
 		return nil
 	}
@@ -282,10 +606,20 @@ func (m *Manager) TrackEdge(ctx context.Context, edge protocol.SpecEdge) error {
 	if err != nil {
 		return err
 	}
-	go trk.Spawn(ctx)
+	trk.Spawn(ctx)
 	return nil
 }
 
+// ProveOneStep confirms an honest edge of length one at the lowest challenge level
+// by one step proof, on behalf of the confirmer.
+func (m *Manager) ProveOneStep(ctx context.Context, edge protocol.SpecEdge) error {
+	trk, err := m.getTrackerForEdge(ctx, edge)
+	if err != nil {
+		return err
+	}
+	return trk.SubmitOneStepProof(ctx)
+}
+
 // Gets an edge tracker for an edge by retrieving its associated assertion creation info.
 func (m *Manager) getTrackerForEdge(ctx context.Context, edge protocol.SpecEdge) (*edgetracker.Tracker, error) {
 	// Retry until you get the previous assertion Hash.
@@ -345,16 +679,41 @@ func (m *Manager) getTrackerForEdge(ctx context.Context, edge protocol.SpecEdge)
 			m.watcher,
 			m,
 			&edgeTrackerAssertionInfo,
-			edgetracker.WithActInterval(m.edgeTrackerWakeInterval),
-			edgetracker.WithTimeReference(m.timeRef),
-			edgetracker.WithValidatorName(m.name),
+			m.edgeTrackerOpts()...,
 		)
 	})
 }
+
+// Options of the trackers of the edges the challenge manager tracks.
+func (m *Manager) edgeTrackerOpts() []edgetracker.Opt {
+	opts := []edgetracker.Opt{
+		edgetracker.WithValidatorName(m.name),
+		edgetracker.WithAlerts(m.alerts),
+		edgetracker.WithStakerPool(m.stakers),
+		edgetracker.WithStrategy(m.strategy),
+		edgetracker.WithEventBus(m.events),
+	}
+	if m.speculative != nil {
+		opts = append(opts, edgetracker.WithPrecomputer(m.speculative))
+	}
+	return opts
+}
+
 func (m *Manager) Watcher() *watcher.Watcher {
 	return m.watcher
 }
 
+// Recorder of the state provider, if enabled with WithProviderRecording.
+func (m *Manager) Recorder() *fixture.Recorder {
+	return m.recorder
+}
+
+// EventBus on which protocol events observed onchain and the moves made by the
+// challenge manager are published.
+func (m *Manager) EventBus() *events.Bus {
+	return m.events
+}
+
 func (m *Manager) ChallengeManager() *challengeV2gen.EdgeChallengeManagerFilterer {
 	return m.chalManager
 }
@@ -364,25 +723,240 @@ func (m *Manager) Start(ctx context.Context) {
 		"validatorAddress": m.address.Hex(),
 	})
 
+	// In-flight moves use the work context, which is only canceled once a graceful
+	// shutdown times out, while routines stop as soon as a shutdown begins.
+	m.lifecycleLock.Lock()
+	workCtx, cancelWork := context.WithCancel(ctx)
+	ctx, m.cancelRoutines = context.WithCancel(workCtx)
+	m.cancelWork = cancelWork
+	m.routinesCtx, m.workCtx = ctx, workCtx
+	m.lifecycleLock.Unlock()
+
+	if m.elector != nil {
+		// Campaign once before starting any routines so that a sole instance
+		// acts as leader right away. The lease is held until in-flight moves
+		// have finished on shutdown.
+		m.elector.Campaign(workCtx)
+		m.goRoutine(func() { m.elector.Start(workCtx) })
+	}
+
 	// Start the assertion manager.
-	go m.assertionManager.Start(ctx)
+	m.goRoutine(func() { m.assertionManager.Start(ctx) })
 
-	// Watcher tower and resolve modes don't monitor challenges.
-	if m.mode == types.WatchTowerMode || m.mode == types.ResolveMode {
-		return
+	if m.lowBalanceThreshold != nil {
+		m.goRoutine(func() { m.checkBalanceRoutine(ctx) })
 	}
 
-	// Start watching for ongoing chain events in the background.
-	go m.watcher.Start(ctx)
-
 	if m.api != nil {
-		go func() {
-			if err := m.api.Start(ctx); err != nil {
+		m.goRoutine(func() {
+			if err := m.api.Start(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
 				srvlog.Error("Could not start API server", log.Ctx{
 					"address": m.apiAddr,
 					"err":     err,
 				})
 			}
-		}()
+		})
+	}
+
+	// Watcher tower and resolve modes don't monitor challenges, until switched
+	// to a mode which does.
+	if monitorsChallenges(m.Mode()) {
+		m.startChallengeRoutines()
+	}
+}
+
+func monitorsChallenges(mode types.Mode) bool {
+	return mode != types.WatchTowerMode && mode != types.ResolveMode
+}
+
+// Starts watching challenges and acting on them, once the challenge manager has
+// started and unless it is stopping.
+func (m *Manager) startChallengeRoutines() {
+	m.lifecycleLock.Lock()
+	defer m.lifecycleLock.Unlock()
+	if m.routinesCtx == nil || m.stopping.Load() {
+		return
+	}
+	m.challengeRoutinesOnce.Do(func() {
+		ctx, workCtx := m.routinesCtx, m.workCtx
+		// Run edge trackers, which are woken up by the watcher. None are spawned in
+		// confirmer mode, which confirms honest edges instead.
+		m.goRoutine(func() { m.scheduler.Start(workCtx) })
+		if m.speculative != nil {
+			m.goRoutine(func() { m.speculative.Start(ctx) })
+		}
+
+		// Start watching for ongoing chain events in the background.
+		m.goRoutine(func() { m.watcher.Start(ctx) })
+	})
+	m.syncConfirmerLocked()
+}
+
+// Creates the confirmer of confirmer mode, if not yet created.
+func (m *Manager) initConfirmer() error {
+	if m.confirmer != nil {
+		return nil
+	}
+	confirmerOpts := []confirmer.Opt{
+		confirmer.WithInterval(m.assertionConfirmingInterval),
+		confirmer.WithEventBus(m.events),
+		confirmer.WithOneStepProver(m),
+		confirmer.WithValidatorName(m.name),
+	}
+	if m.confirmerGasBudget != nil {
+		confirmerOpts = append(confirmerOpts, confirmer.WithGasBudget(m.address, m.confirmerGasBudget))
+	}
+	c, err := confirmer.New(m.chain, m.watcher, m, confirmerOpts...)
+	if err != nil {
+		return err
+	}
+	m.confirmer = c
+	return nil
+}
+
+// Runs the confirmer while in confirmer mode, and stops it once the mode is left.
+func (m *Manager) syncConfirmer() {
+	m.lifecycleLock.Lock()
+	defer m.lifecycleLock.Unlock()
+	m.syncConfirmerLocked()
+}
+
+func (m *Manager) syncConfirmerLocked() {
+	if m.routinesCtx == nil || m.stopping.Load() {
+		return
+	}
+	running := m.cancelConfirmer != nil
+	switch {
+	case m.Mode() == types.ConfirmerMode && !running && m.confirmer != nil:
+		ctx, cancel := context.WithCancel(m.routinesCtx)
+		m.cancelConfirmer = cancel
+		c := m.confirmer
+		m.goRoutine(func() { c.Start(ctx) })
+	case m.Mode() != types.ConfirmerMode && running:
+		m.cancelConfirmer()
+		m.cancelConfirmer = nil
+	}
+}
+
+func (m *Manager) goRoutine(fn func()) {
+	m.routines.Add(1)
+	go func() {
+		defer m.routines.Done()
+		fn()
+	}()
+}
+
+// Stop gracefully shuts down the challenge manager. It stops watching the chain,
+// posting assertions and spawning edge trackers, and then waits for active edge
+// trackers to finish their in-flight moves and exit. If the context is done first,
+// in-flight moves are canceled. Finally, the API server and its database are shut
+// down. Returns the ids of the edges whose trackers were still active when the
+// context was done, which will be picked up again on restart.
+func (m *Manager) Stop(ctx context.Context) ([]protocol.EdgeId, error) {
+	if !m.stopping.CompareAndSwap(false, true) {
+		return nil, ErrShuttingDown
+	}
+	srvlog.Info("Stopping challenge manager", log.Ctx{"validatorName": m.name})
+	m.scheduler.Drain()
+	m.lifecycleLock.Lock()
+	cancelRoutines, cancelWork := m.cancelRoutines, m.cancelWork
+	m.lifecycleLock.Unlock()
+	if cancelRoutines != nil {
+		cancelRoutines()
+	}
+
+	active := m.awaitTrackers(ctx)
+	if len(active) > 0 {
+		srvlog.Warn("Canceling in-flight moves of edge trackers still active at shutdown", log.Ctx{
+			"validatorName": m.name,
+			"numTrackers":   len(active),
+			"edgeIds":       active,
+		})
+	}
+	if cancelWork != nil {
+		cancelWork()
+	}
+
+	var err error
+	if m.api != nil {
+		if apiErr := m.api.Stop(ctx); apiErr != nil {
+			err = fmt.Errorf("could not stop API server: %w", apiErr)
+		}
+	}
+	routinesDone := make(chan struct{})
+	go func() {
+		m.routines.Wait()
+		close(routinesDone)
+	}()
+	select {
+	case <-routinesDone:
+	case <-ctx.Done():
+		srvlog.Warn("Timed out waiting for challenge manager routines to exit")
+	}
+	srvlog.Info("Stopped challenge manager", log.Ctx{"validatorName": m.name})
+	return active, err
+}
+
+// Waits until no edge trackers are active or the context is done, returning
+// the ids of the edges whose trackers are still active.
+func (m *Manager) awaitTrackers(ctx context.Context) []protocol.EdgeId {
+	ticker := time.NewTicker(trackerShutdownPollInterval)
+	defer ticker.Stop()
+	for m.activeTrackers.NumItems() > 0 {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			active := make([]protocol.EdgeId, 0)
+			m.activeTrackers.ForEach(func(id protocol.EdgeId) {
+				active = append(active, id)
+			})
+			return active
+		}
+	}
+	return nil
+}
+
+type balanceReader interface {
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+}
+
+// Periodically checks the balance of every staker, those of the staker pool if one is
+// configured, and raises a low balance alert for each below the configured threshold.
+func (m *Manager) checkBalanceRoutine(ctx context.Context) {
+	reader, ok := m.backend.(balanceReader)
+	if !ok {
+		srvlog.Warn("Backend cannot read balances, low balance alerts are disabled")
+		return
+	}
+	ticker := time.NewTicker(m.balanceCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.checkBalances(ctx, reader)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (m *Manager) checkBalances(ctx context.Context, reader balanceReader) {
+	addrs := []common.Address{m.address}
+	if m.stakers != nil {
+		ids := m.stakers.Identities()
+		addrs = make([]common.Address, len(ids))
+		for i, id := range ids {
+			addrs[i] = id.Address
+		}
+	}
+	for _, addr := range addrs {
+		balance, err := reader.BalanceAt(ctx, addr, nil)
+		if err != nil {
+			srvlog.Error("Could not get staker balance", log.Ctx{"err": err, "address": addr})
+			continue
+		}
+		if balance.Cmp(m.lowBalanceThreshold) < 0 {
+			m.alerts.Notify(ctx, alerts.NewLowBalanceEvent(addr, balance, m.lowBalanceThreshold))
+		}
 	}
 }
@@ -5,20 +5,30 @@ package challengemanager
 
 import (
 	"context"
+	"errors"
+	"math/big"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/OffchainLabs/bold/alerts"
 	protocol "github.com/OffchainLabs/bold/chain-abstraction"
+	solimpl "github.com/OffchainLabs/bold/chain-abstraction/sol-implementation"
 	watcher "github.com/OffchainLabs/bold/challenge-manager/chain-watcher"
 	edgetracker "github.com/OffchainLabs/bold/challenge-manager/edge-tracker"
+	"github.com/OffchainLabs/bold/challenge-manager/leader"
+	"github.com/OffchainLabs/bold/challenge-manager/stakers"
 	"github.com/OffchainLabs/bold/challenge-manager/types"
 	"github.com/OffchainLabs/bold/containers/option"
+	"github.com/OffchainLabs/bold/events"
 	l2stateprovider "github.com/OffchainLabs/bold/layer2-state-provider"
+	"github.com/OffchainLabs/bold/layer2-state-provider/fixture"
 	"github.com/OffchainLabs/bold/solgen/go/challengeV2gen"
 	"github.com/OffchainLabs/bold/solgen/go/rollupgen"
 	"github.com/OffchainLabs/bold/testing/mocks"
 	"github.com/OffchainLabs/bold/testing/setup"
-	customTime "github.com/OffchainLabs/bold/time"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/rpc"
@@ -44,6 +54,20 @@ func TestEdgeTracker_Act(t *testing.T) {
 	err = tkr.Act(ctx)
 	require.NoError(t, err)
 	require.Equal(t, edgetracker.EdgeConfirming, tkr.CurrentState())
+
+	// Waiting for confirmation is the outcome of the last action, but does not
+	// crowd out the transitions that got the tracker there.
+	snapshot := tkr.Snapshot()
+	require.Equal(t, tkr.EdgeId().Hash, snapshot.EdgeId)
+	require.Equal(t, edgetracker.EdgeConfirming.String(), snapshot.State)
+	require.Len(t, snapshot.Transitions, 2)
+	require.Equal(t, edgetracker.EdgeBisecting.String(), snapshot.Transitions[0].To)
+	require.NotEmpty(t, snapshot.Transitions[0].Event)
+	require.Equal(t, edgetracker.EdgeBisecting.String(), snapshot.Transitions[1].From)
+	require.Equal(t, edgetracker.EdgeConfirming.String(), snapshot.Transitions[1].To)
+	require.Equal(t, edgetracker.EdgeConfirming.String(), snapshot.LastAction.From)
+	require.Equal(t, edgetracker.EdgeConfirming.String(), snapshot.LastAction.To)
+	require.Empty(t, snapshot.LastAction.Err)
 }
 
 func TestEdgeTracker_Act_ChallengedEdgeCannotConfirmByTime(t *testing.T) {
@@ -101,6 +125,234 @@ func TestEdgeTracker_Act_ChallengedEdgeCannotConfirmByTime(t *testing.T) {
 	require.Equal(t, edgetracker.EdgeConfirming, tkr.CurrentState())
 }
 
+func TestEdgeTracker_Act_ConfirmOnlyStrategy(t *testing.T) {
+	ctx := context.Background()
+	createdData, err := setup.CreateTwoValidatorFork(ctx, &setup.CreateForkConfig{}, setup.WithMockOneStepProver())
+	require.NoError(t, err)
+
+	tkr, _ := setupEdgeTrackersForBisection(
+		t, ctx, createdData, option.None[uint64](), edgetracker.WithStrategy(edgetracker.ConfirmOnly()),
+	)
+
+	// The edge is rivaled and not confirmable, so the tracker waits instead of bisecting.
+	err = tkr.Act(ctx)
+	require.NoError(t, err)
+	require.Equal(t, edgetracker.EdgeStarted, tkr.CurrentState())
+}
+
+func TestEdgeTracker_Act_RecordsMoveErrors(t *testing.T) {
+	ctx := context.Background()
+	createdData, err := setup.CreateTwoValidatorFork(ctx, &setup.CreateForkConfig{}, setup.WithMockOneStepProver())
+	require.NoError(t, err)
+
+	failing := edgetracker.StrategyFunc(func(context.Context, *edgetracker.Situation) ([]edgetracker.Move, error) {
+		return nil, errors.New("strategy failed")
+	})
+	tkr, _ := setupEdgeTrackersForBisection(t, ctx, createdData, option.None[uint64](), edgetracker.WithStrategy(failing))
+
+	// The tracker goes back to waiting, and the error of the move is recorded.
+	require.NoError(t, tkr.Act(ctx))
+	require.Equal(t, edgetracker.EdgeStarted, tkr.CurrentState())
+	snapshot := tkr.Snapshot()
+	require.Equal(t, "strategy failed", snapshot.LastAction.Err)
+}
+
+func TestEdgeTracker_Act_LazyBisectStrategy(t *testing.T) {
+	ctx := context.Background()
+	createdData, err := setup.CreateTwoValidatorFork(ctx, &setup.CreateForkConfig{}, setup.WithMockOneStepProver())
+	require.NoError(t, err)
+
+	chalManager, err := createdData.Chains[0].SpecChallengeManager(ctx)
+	require.NoError(t, err)
+	chalPeriodBlocks, err := chalManager.ChallengePeriodBlocks(ctx)
+	require.NoError(t, err)
+
+	tkr, _ := setupEdgeTrackersForBisection(
+		t, ctx, createdData, option.None[uint64](), edgetracker.WithStrategy(edgetracker.LazyBisect(chalPeriodBlocks/2)),
+	)
+
+	// The rival is still far from being confirmable by time, so the tracker holds off.
+	err = tkr.Act(ctx)
+	require.NoError(t, err)
+	require.Equal(t, edgetracker.EdgeStarted, tkr.CurrentState())
+
+	// Once half of the challenge period has elapsed, the tracker bisects.
+	for i := uint64(0); i < chalPeriodBlocks/2; i++ {
+		createdData.Backend.Commit()
+	}
+	err = tkr.Act(ctx)
+	require.NoError(t, err)
+	require.Equal(t, edgetracker.EdgeBisecting, tkr.CurrentState())
+}
+
+func TestHonestStrategy_ConfirmsBeforeOneStepProof(t *testing.T) {
+	ctx := context.Background()
+	// A small step edge of length one, which may be one step proven.
+	edge := &mocks.MockSpecEdge{}
+	edge.On("StartCommitment").Return(protocol.Height(0), common.Hash{})
+	edge.On("EndCommitment").Return(protocol.Height(1), common.Hash{})
+	edge.On("GetChallengeLevel").Return(protocol.ChallengeLevel(2))
+	edge.On("GetTotalChallengeLevels", ctx).Return(uint8(3))
+
+	// Such an edge cannot be proven without a rival, so it is confirmed if it can
+	// be, and one step proven otherwise.
+	moves, err := edgetracker.Honest().Moves(ctx, edgetracker.NewSituation(edge, edgetracker.EdgeStarted, nil))
+	require.NoError(t, err)
+	require.Equal(t, []edgetracker.Move{edgetracker.Confirm, edgetracker.OneStepProve}, moves)
+	edge.AssertNotCalled(t, "HasRival", ctx)
+
+	// Once the tracker is proving the edge, it only proves it.
+	moves, err = edgetracker.Honest().Moves(ctx, edgetracker.NewSituation(edge, edgetracker.EdgeAtOneStepProof, nil))
+	require.NoError(t, err)
+	require.Equal(t, []edgetracker.Move{edgetracker.OneStepProve}, moves)
+}
+
+// Counts the prefix proofs computed by a state provider.
+type countingStateProvider struct {
+	l2stateprovider.Provider
+	prefixProofs atomic.Int64
+	lock         sync.Mutex
+	// Prefix proofs computed at the block challenge level, by height and prefix height.
+	blockProofs map[[2]l2stateprovider.Height]int
+}
+
+func (c *countingStateProvider) PrefixProof(
+	ctx context.Context, req *l2stateprovider.HistoryCommitmentRequest, prefixHeight l2stateprovider.Height,
+) ([]byte, error) {
+	c.prefixProofs.Add(1)
+	if len(req.UpperChallengeOriginHeights) == 0 && req.UpToHeight.IsSome() {
+		c.lock.Lock()
+		if c.blockProofs == nil {
+			c.blockProofs = make(map[[2]l2stateprovider.Height]int)
+		}
+		c.blockProofs[[2]l2stateprovider.Height{req.UpToHeight.Unwrap(), prefixHeight}]++
+		c.lock.Unlock()
+	}
+	return c.Provider.PrefixProof(ctx, req, prefixHeight)
+}
+
+func (c *countingStateProvider) numBlockProofs(height, prefixHeight l2stateprovider.Height) int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.blockProofs[[2]l2stateprovider.Height{height, prefixHeight}]
+}
+
+func TestEdgeTracker_SpeculativePrecomputation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	createdData, err := setup.CreateTwoValidatorFork(ctx, &setup.CreateForkConfig{}, setup.WithMockOneStepProver())
+	require.NoError(t, err)
+
+	counting := &countingStateProvider{Provider: createdData.HonestStateManager}
+	speculative := l2stateprovider.NewSpeculativeProvider(counting, l2stateprovider.WithSpeculativeDepth(1))
+	go speculative.Start(ctx)
+	createdData.HonestStateManager = speculative
+
+	tkr, _ := setupEdgeTrackersForBisection(t, ctx, createdData, option.None[uint64](), edgetracker.WithPrecomputer(speculative))
+
+	// Spawning the tracker precomputes the bisection of its edge.
+	proofs := counting.prefixProofs.Load()
+	tkr.Spawn(ctx)
+	require.Eventually(t, func() bool {
+		return counting.prefixProofs.Load() == proofs+1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// The bisection is made with the precomputed proof.
+	require.NoError(t, tkr.Act(ctx))
+	require.Equal(t, edgetracker.EdgeBisecting, tkr.CurrentState())
+	require.NoError(t, tkr.Act(ctx))
+	require.Equal(t, edgetracker.EdgeConfirming, tkr.CurrentState())
+	require.Equal(t, proofs+1, counting.prefixProofs.Load())
+}
+
+func TestEdgeTracker_SpeculativePrecomputation_KeptForChildren(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	createdData, err := setup.CreateTwoValidatorFork(ctx, &setup.CreateForkConfig{}, setup.WithMockOneStepProver())
+	require.NoError(t, err)
+
+	counting := &countingStateProvider{Provider: createdData.HonestStateManager}
+	speculative := l2stateprovider.NewSpeculativeProvider(counting, l2stateprovider.WithSpeculativeDepth(2))
+	go speculative.Start(ctx)
+	createdData.HonestStateManager = speculative
+
+	tkr, _ := setupEdgeTrackersForBisection(t, ctx, createdData, option.None[uint64](), edgetracker.WithPrecomputer(speculative))
+	chalManager, err := createdData.Chains[0].SpecChallengeManager(ctx)
+	require.NoError(t, err)
+	someEdge, err := chalManager.GetEdge(ctx, tkr.EdgeId())
+	require.NoError(t, err)
+	end, _ := someEdge.Unwrap().EndCommitment()
+	endHeight := l2stateprovider.Height(end)
+	mid := endHeight / 2
+
+	// Spawning the tracker precomputes the bisections of its edge and of its children.
+	proofs := counting.prefixProofs.Load()
+	tkr.Spawn(ctx)
+	require.Eventually(t, func() bool {
+		return counting.prefixProofs.Load() == proofs+3
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, tkr.Act(ctx))
+	require.Equal(t, edgetracker.EdgeBisecting, tkr.CurrentState())
+	require.NoError(t, tkr.Act(ctx))
+	require.Equal(t, edgetracker.EdgeConfirming, tkr.CurrentState())
+
+	// The bisections of the children are served from what was precomputed before
+	// their parent bisected, rather than computed again.
+	for _, child := range [][2]l2stateprovider.Height{{0, mid}, {mid, endHeight}} {
+		req := &l2stateprovider.HistoryCommitmentRequest{
+			WasmModuleRoot:              common.Hash{},
+			FromBatch:                   0,
+			ToBatch:                     1,
+			UpperChallengeOriginHeights: []l2stateprovider.Height{},
+			FromHeight:                  0,
+			UpToHeight:                  option.Some(child[1]),
+		}
+		childMid := (child[0] + child[1]) / 2
+		_, err = speculative.PrefixProof(ctx, req, childMid)
+		require.NoError(t, err)
+		require.Equal(t, 1, counting.numBlockProofs(child[1], childMid))
+	}
+}
+
+func TestProviderRecording_Replayed(t *testing.T) {
+	ctx := context.Background()
+	newValidator := func(createdData *setup.CreatedValidatorFork, provider l2stateprovider.Provider, opts ...Opt) *Manager {
+		m, err := New(
+			ctx,
+			createdData.Chains[0],
+			createdData.Backend,
+			provider,
+			createdData.Addrs.Rollup,
+			append([]Opt{WithName("alice"), WithMode(types.MakeMode), WithEdgeTrackerWakeInterval(100 * time.Millisecond)}, opts...)...,
+		)
+		require.NoError(t, err)
+		return m
+	}
+
+	// Record the requests made to the provider while creating a level zero edge.
+	createdData, err := setup.CreateTwoValidatorFork(ctx, &setup.CreateForkConfig{}, setup.WithMockOneStepProver())
+	require.NoError(t, err)
+	recording := newValidator(createdData, createdData.HonestStateManager, WithProviderRecording())
+	recordedEdge, _, _, err := recording.addBlockChallengeLevelZeroEdge(ctx, createdData.Leaf1)
+	require.NoError(t, err)
+	f := recording.Recorder().Fixture()
+	require.NotEmpty(t, f.Calls)
+	require.Nil(t, newValidator(createdData, createdData.HonestStateManager).Recorder())
+
+	// On another chain, the same edge is created from the recorded responses alone.
+	createdData, err = setup.CreateTwoValidatorFork(ctx, &setup.CreateForkConfig{}, setup.WithMockOneStepProver())
+	require.NoError(t, err)
+	replayer, err := fixture.NewReplayer(f)
+	require.NoError(t, err)
+	replayedEdge, _, _, err := newValidator(createdData, replayer).addBlockChallengeLevelZeroEdge(ctx, createdData.Leaf1)
+	require.NoError(t, err)
+	recordedHeight, recordedCommit := recordedEdge.EndCommitment()
+	replayedHeight, replayedCommit := replayedEdge.EndCommitment()
+	require.Equal(t, recordedHeight, replayedHeight)
+	require.Equal(t, recordedCommit, replayedCommit)
+}
+
 func TestEdgeTracker_Act_ConfirmedByTime(t *testing.T) {
 	ctx := context.Background()
 	createdData, err := setup.CreateTwoValidatorFork(ctx, &setup.CreateForkConfig{}, setup.WithMockOneStepProver())
@@ -188,7 +440,6 @@ func TestEdgeTracker_Act_ShouldDespawn_HasConfirmableAncestor(t *testing.T) {
 		honestParent.Watcher(),
 		honestParent.ChallengeManager(),
 		assertionInfo,
-		edgetracker.WithTimeReference(customTime.NewArtificialTimeReference()),
 	)
 	require.NoError(t, err)
 	childTracker2, err := edgetracker.New(
@@ -199,7 +450,6 @@ func TestEdgeTracker_Act_ShouldDespawn_HasConfirmableAncestor(t *testing.T) {
 		honestParent.Watcher(),
 		honestParent.ChallengeManager(),
 		assertionInfo,
-		edgetracker.WithTimeReference(customTime.NewArtificialTimeReference()),
 	)
 	require.NoError(t, err)
 
@@ -262,6 +512,7 @@ func setupEdgeTrackersForBisection(
 	ctx context.Context,
 	createdData *setup.CreatedValidatorFork,
 	delayEvilRootEdgeCreationByBlocks option.Option[uint64],
+	honestTrackerOpts ...edgetracker.Opt,
 ) (*edgetracker.Tracker, *edgetracker.Tracker) {
 	honestValidator, err := New(
 		ctx,
@@ -331,8 +582,7 @@ func setupEdgeTrackersForBisection(
 		honestWatcher,
 		honestValidator,
 		assertionInfo,
-		edgetracker.WithTimeReference(customTime.NewArtificialTimeReference()),
-		edgetracker.WithValidatorName(honestValidator.name),
+		append([]edgetracker.Opt{edgetracker.WithValidatorName(honestValidator.name)}, honestTrackerOpts...)...,
 	)
 	require.NoError(t, err)
 
@@ -347,7 +597,6 @@ func setupEdgeTrackersForBisection(
 		evilWatcher,
 		evilValidator,
 		assertionInfo,
-		edgetracker.WithTimeReference(customTime.NewArtificialTimeReference()),
 		edgetracker.WithValidatorName(evilValidator.name),
 	)
 	require.NoError(t, err)
@@ -413,3 +662,378 @@ func TestCanSetAPIEndpoint(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, "localhost:1234", v.apiAddr)
 }
+
+func TestConfirmerMode(t *testing.T) {
+	ctx := context.Background()
+	p := &mocks.MockProtocol{}
+	cm := &mocks.MockSpecChallengeManager{}
+	p.On("SpecChallengeManager", ctx).Return(cm, nil)
+	cm.On("NumBigSteps", ctx).Return(uint8(1), nil)
+	cfg, err := setup.ChainsWithEdgeChallengeManager()
+	require.NoError(t, err)
+	p.On("Backend").Return(cfg.Backend)
+
+	// Enforcing a gas budget requires the account sending confirmations.
+	_, err = New(ctx, p, cfg.Backend, &mocks.MockStateManager{}, cfg.Addrs.Rollup,
+		WithMode(types.ConfirmerMode), WithConfirmerGasBudget(big.NewInt(1)))
+	require.ErrorContains(t, err, "gas budget requires the account")
+	w, err := New(ctx, p, cfg.Backend, &mocks.MockStateManager{}, cfg.Addrs.Rollup,
+		WithMode(types.WatchTowerMode), WithConfirmerGasBudget(big.NewInt(1)))
+	require.NoError(t, err)
+	require.ErrorContains(t, w.SetMode(types.ConfirmerMode), "gas budget requires the account")
+	require.Equal(t, types.WatchTowerMode, w.Mode())
+
+	v, err := New(ctx, p, cfg.Backend, &mocks.MockStateManager{}, cfg.Addrs.Rollup,
+		WithMode(types.ConfirmerMode),
+		WithAddress(cfg.Accounts[1].AccountAddr),
+		WithConfirmerGasBudget(big.NewInt(1)),
+	)
+	require.NoError(t, err)
+	require.NotNil(t, v.confirmer)
+	require.Equal(t, "confirmer", v.AdminStatus().Mode)
+
+	// Honest edges are confirmed by the confirmer rather than tracked.
+	edge := &mocks.MockSpecEdge{}
+	require.NoError(t, v.TrackEdge(ctx, edge))
+	require.False(t, v.IsTrackingEdge(protocol.EdgeId{}))
+	edge.AssertNotCalled(t, "AssertionHash", ctx)
+	require.False(t, v.MovesAllowed())
+	require.ErrorContains(t, v.ForceTrackEdge(ctx, common.Hash{1}), "confirmer mode")
+}
+
+func TestConfirmerMode_SwitchedAtRuntime(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	createdData, err := setup.CreateTwoValidatorFork(ctx, &setup.CreateForkConfig{}, setup.WithMockOneStepProver())
+	require.NoError(t, err)
+
+	v, err := New(
+		ctx,
+		createdData.Chains[0],
+		createdData.Backend,
+		createdData.HonestStateManager,
+		createdData.Addrs.Rollup,
+		WithName("alice"),
+		WithMode(types.WatchTowerMode),
+		WithAssertionScanningInterval(100*time.Millisecond),
+	)
+	require.NoError(t, err)
+	v.Start(ctx)
+	defer func() {
+		_, _ = v.Stop(ctx)
+	}()
+	confirmerRunning := func() bool {
+		v.lifecycleLock.Lock()
+		defer v.lifecycleLock.Unlock()
+		return v.cancelConfirmer != nil
+	}
+	require.Nil(t, v.confirmer)
+
+	// Confirmer mode can be selected like any other mode, and left again.
+	require.NoError(t, v.SetMode(types.ConfirmerMode))
+	require.Equal(t, "confirmer", v.AdminStatus().Mode)
+	require.True(t, confirmerRunning())
+	require.Eventually(t, v.watcher.IsSynced, 10*time.Second, 50*time.Millisecond)
+	require.False(t, v.MovesAllowed())
+
+	require.NoError(t, v.SetMode(types.DefensiveMode))
+	require.False(t, confirmerRunning())
+	require.True(t, v.MovesAllowed())
+
+	require.NoError(t, v.SetMode(types.ConfirmerMode))
+	require.True(t, confirmerRunning())
+	require.NoError(t, v.SetMode(types.WatchTowerMode))
+	require.False(t, confirmerRunning())
+}
+
+func TestRuntimeControls(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	createdData, err := setup.CreateTwoValidatorFork(ctx, &setup.CreateForkConfig{}, setup.WithMockOneStepProver())
+	require.NoError(t, err)
+
+	v, err := New(
+		ctx,
+		createdData.Chains[0],
+		createdData.Backend,
+		createdData.HonestStateManager,
+		createdData.Addrs.Rollup,
+		WithName("alice"),
+		WithMode(types.WatchTowerMode),
+		WithAssertionScanningInterval(100*time.Millisecond),
+	)
+	require.NoError(t, err)
+	v.Start(ctx)
+	defer func() {
+		_, _ = v.Stop(ctx)
+	}()
+
+	// Watchtowers do not watch challenges until switched to a mode which does.
+	require.Never(t, v.watcher.IsSynced, 300*time.Millisecond, 50*time.Millisecond)
+	require.NoError(t, v.SetMode(types.DefensiveMode))
+	require.Eventually(t, v.watcher.IsSynced, 10*time.Second, 50*time.Millisecond)
+	require.Equal(t, "defensive", v.AdminStatus().Mode)
+
+	// Paused moves include opening challenges.
+	v.SetMovesPaused(true)
+	require.False(t, v.MovesAllowed())
+	require.ErrorIs(t, v.ChallengeAssertion(ctx, createdData.Leaf2.Id()), ErrMovesPaused)
+	v.SetMovesPaused(false)
+	require.True(t, v.MovesAllowed())
+
+	v.SetPostingPaused(true)
+	require.Error(t, v.SetPostingInterval(0))
+	require.NoError(t, v.SetPostingInterval(time.Minute))
+	status := v.AdminStatus()
+	require.True(t, status.PostingPaused)
+	require.Equal(t, "1m0s", status.PostingInterval)
+
+	require.Error(t, v.ForceTrackEdge(ctx, common.Hash{1}))
+}
+
+func TestIsLeader(t *testing.T) {
+	v, _, _ := setupValidator(t)
+	require.True(t, v.IsLeader(), "instances without leader election always act")
+
+	ctx := context.Background()
+	lock := leader.NewFileLock(filepath.Join(t.TempDir(), "lease"))
+	ok, err := lock.TryAcquire(ctx, "other", time.Now(), time.Hour)
+	require.NoError(t, err)
+	require.True(t, ok)
+	elector, err := leader.NewElector(lock, "self")
+	require.NoError(t, err)
+	WithLeaderElection(elector)(v)
+	elector.Campaign(ctx)
+	require.False(t, v.IsLeader())
+
+	require.NoError(t, lock.Release(ctx, "other"))
+	elector.Campaign(ctx)
+	require.True(t, v.IsLeader())
+}
+
+func TestStop(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	createdData, err := setup.CreateTwoValidatorFork(ctx, &setup.CreateForkConfig{}, setup.WithMockOneStepProver())
+	require.NoError(t, err)
+
+	v, err := New(
+		ctx,
+		createdData.Chains[0],
+		createdData.Backend,
+		createdData.HonestStateManager,
+		createdData.Addrs.Rollup,
+		WithName("alice"),
+		WithMode(types.MakeMode),
+		WithEdgeTrackerWakeInterval(100*time.Millisecond),
+		WithAssertionScanningInterval(100*time.Millisecond),
+		WithAPIEnabled("127.0.0.1:0"),
+		WithRPCClient(&rpc.Client{}),
+	)
+	require.NoError(t, err)
+	v.Start(ctx)
+
+	// The manager challenges the evil assertion and tracks its level zero edge.
+	require.Eventually(t, func() bool {
+		return v.activeTrackers.NumItems() > 0
+	}, 10*time.Second, 50*time.Millisecond)
+	require.Eventually(t, func() bool {
+		return len(v.TrackerSnapshots()) > 0
+	}, 10*time.Second, 50*time.Millisecond)
+	snapshot, ok := v.TrackerSnapshot(v.TrackerSnapshots()[0].EdgeId)
+	require.True(t, ok)
+	require.NotEmpty(t, snapshot.State)
+
+	stopCtx, stopCancel := context.WithTimeout(ctx, 10*time.Second)
+	defer stopCancel()
+	active, err := v.Stop(stopCtx)
+	require.NoError(t, err)
+	require.Empty(t, active, "trackers should exit after finishing their in-flight moves")
+	require.Equal(t, uint64(0), v.activeTrackers.NumItems())
+	require.Empty(t, v.TrackerSnapshots())
+
+	// No new work is accepted once stopped.
+	require.ErrorIs(t, v.ChallengeAssertion(ctx, createdData.Leaf2.Id()), ErrShuttingDown)
+	_, err = v.Stop(stopCtx)
+	require.ErrorIs(t, err, ErrShuttingDown)
+}
+
+func TestEventBus(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	createdData, err := setup.CreateTwoValidatorFork(ctx, &setup.CreateForkConfig{}, setup.WithMockOneStepProver())
+	require.NoError(t, err)
+
+	bus := events.NewBus()
+	sub := bus.Subscribe("test")
+	defer sub.Unsubscribe()
+	managers := make([]*Manager, 2)
+	for i, name := range []string{"alice", "bob"} {
+		stateManager := createdData.HonestStateManager
+		opts := []Opt{
+			WithName(name),
+			WithMode(types.MakeMode),
+			WithEdgeTrackerWakeInterval(100 * time.Millisecond),
+			WithAssertionScanningInterval(100 * time.Millisecond),
+		}
+		if name == "alice" {
+			opts = append(opts, WithEventBus(bus))
+		} else {
+			stateManager = createdData.EvilStateManager
+		}
+		managers[i], err = New(
+			ctx,
+			createdData.Chains[i],
+			createdData.Backend,
+			stateManager,
+			createdData.Addrs.Rollup,
+			opts...,
+		)
+		require.NoError(t, err)
+	}
+	require.Equal(t, bus, managers[0].EventBus())
+	require.NotEqual(t, bus, managers[1].EventBus())
+	for _, m := range managers {
+		m.Start(ctx)
+	}
+
+	// Both validators add their block challenge edges and bisect them.
+	added := make(map[protocol.EdgeId]int)
+	var assertionsCreated int
+	var bisected bool
+	var bisectedByAlice bool
+	require.Eventually(t, func() bool {
+		for {
+			select {
+			case ev := <-sub.Events():
+				switch ev := ev.(type) {
+				case *events.AssertionCreated:
+					assertionsCreated++
+				case *events.EdgeAdded:
+					added[ev.EdgeId]++
+				case *events.EdgeBisected:
+					bisected = true
+				case *events.MoveSubmitted:
+					// Only moves made by the manager publishing to the bus are published.
+					require.Equal(t, "alice", ev.Validator)
+					if ev.Move == edgetracker.Bisect.String() {
+						bisectedByAlice = true
+					}
+				}
+			default:
+				return assertionsCreated >= 2 && bisected && bisectedByAlice
+			}
+		}
+	}, 20*time.Second, 50*time.Millisecond)
+
+	// Events observed onchain are published once, even though the chain watcher
+	// scans the boundary block of its ranges twice.
+	require.GreaterOrEqual(t, len(added), 2)
+	for edgeId, n := range added {
+		require.Equal(t, 1, n, "edge %#x added more than once", edgeId.Hash)
+	}
+}
+
+func TestStop_ReportsActiveTrackers(t *testing.T) {
+	v, _, _ := setupValidator(t)
+	edgeId := protocol.EdgeId{Hash: common.BytesToHash([]byte("foo"))}
+	v.MarkTrackedEdge(edgeId)
+
+	// A tracker stuck in a move is reported once the shutdown times out.
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	active, err := v.Stop(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []protocol.EdgeId{edgeId}, active)
+
+	v.MarkTrackerExited(edgeId)
+	require.True(t, v.IsTrackingEdge(edgeId))
+}
+
+func TestMarkTrackedEdge(t *testing.T) {
+	v, _, _ := setupValidator(t)
+	edgeId := protocol.EdgeId{Hash: common.BytesToHash([]byte("foo"))}
+
+	// Only one tracker is run for an edge, however many try to spawn at once.
+	var marked atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v.MarkTrackedEdge(edgeId) {
+				marked.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, int64(1), marked.Load())
+
+	// A running tracker is not replaced when forcing the edge to be tracked again.
+	require.False(t, v.untrackExitedEdge(edgeId))
+	require.True(t, v.IsTrackingEdge(edgeId))
+
+	// Once it exited, a new one can be spawned.
+	v.MarkTrackerExited(edgeId)
+	require.True(t, v.untrackExitedEdge(edgeId))
+	require.False(t, v.IsTrackingEdge(edgeId))
+	require.True(t, v.MarkTrackedEdge(edgeId))
+}
+
+type recordingAlertSink struct {
+	lock   sync.Mutex
+	events []*alerts.Event
+}
+
+func (*recordingAlertSink) Name() string {
+	return "recording"
+}
+
+func (s *recordingAlertSink) Send(_ context.Context, ev *alerts.Event) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.events = append(s.events, ev)
+	return nil
+}
+
+func TestCheckBalances_EveryStakerOfThePool(t *testing.T) {
+	ctx := context.Background()
+	cfg, err := setup.ChainsWithEdgeChallengeManager()
+	require.NoError(t, err)
+	chain, err := solimpl.NewAssertionChain(
+		ctx,
+		cfg.Addrs.Rollup,
+		cfg.Accounts[1].TxOpts,
+		cfg.Backend,
+		solimpl.WithStakerIdentities(cfg.Accounts[2].TxOpts, cfg.Accounts[3].TxOpts),
+	)
+	require.NoError(t, err)
+	pool, err := stakers.NewPool(chain)
+	require.NoError(t, err)
+	sink := &recordingAlertSink{}
+	m := &Manager{
+		address:             cfg.Accounts[1].AccountAddr,
+		alerts:              alerts.NewDispatcher(alerts.WithSink(sink)),
+		lowBalanceThreshold: new(big.Int).Lsh(big.NewInt(1), 200),
+	}
+
+	m.checkBalances(ctx, cfg.Backend)
+	m.alerts.Flush()
+	require.Len(t, sink.events, 1)
+	require.Equal(t, cfg.Accounts[1].AccountAddr.Hex(), sink.events[0].Key)
+
+	m.stakers = pool
+	m.checkBalances(ctx, cfg.Backend)
+	m.alerts.Flush()
+	keys := make([]string, 0, len(sink.events))
+	for _, ev := range sink.events[1:] {
+		require.Equal(t, alerts.LowBalance, ev.Kind)
+		keys = append(keys, ev.Key)
+	}
+	// The primary staker was alerted on already, within the dedup window.
+	want := make([]string, 0)
+	for _, addr := range chain.StakerAddresses()[1:] {
+		want = append(want, addr.Hex())
+	}
+	require.Equal(t, want, keys)
+}
@@ -0,0 +1,212 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+// Package planner estimates what a challenge costs the honest party in the worst
+// case, so that operators can budget funds before defending a chain. Estimates can be
+// made before a challenge starts, from the challenge manager's parameters alone, or
+// mid-challenge, from the honest edges tracked so far.
+package planner
+
+import (
+	"math/big"
+	"math/bits"
+	"time"
+
+	protocol "github.com/OffchainLabs/bold/chain-abstraction"
+	challengetree "github.com/OffchainLabs/bold/challenge-manager/challenge-tree"
+	"github.com/pkg/errors"
+)
+
+// GasCosts are the gas used by each kind of move.
+type GasCosts struct {
+	CreateLayerZeroEdge       uint64 `json:"createLayerZeroEdge"`
+	BisectEdge                uint64 `json:"bisectEdge"`
+	ConfirmEdgeByTime         uint64 `json:"confirmEdgeByTime"`
+	ConfirmEdgeByChildren     uint64 `json:"confirmEdgeByChildren"`
+	ConfirmEdgeByClaim        uint64 `json:"confirmEdgeByClaim"`
+	ConfirmEdgeByOneStepProof uint64 `json:"confirmEdgeByOneStepProof"`
+	ConfirmAssertion          uint64 `json:"confirmAssertion"`
+}
+
+// DefaultGasCosts are conservative bounds of the gas used by each move. Costs measured
+// on the target chain, such as from the accounting ledger, make estimates tighter.
+var DefaultGasCosts = GasCosts{
+	CreateLayerZeroEdge:       600_000,
+	BisectEdge:                300_000,
+	ConfirmEdgeByTime:         200_000,
+	ConfirmEdgeByChildren:     100_000,
+	ConfirmEdgeByClaim:        100_000,
+	ConfirmEdgeByOneStepProof: 1_500_000,
+	ConfirmAssertion:          300_000,
+}
+
+// Params of the challenge manager and chain an estimate is made for.
+type Params struct {
+	LayerZeroHeights      protocol.LayerZeroHeights
+	NumBigSteps           uint8
+	ChallengePeriodBlocks uint64
+	// Stake locked by creating a level zero edge, in units of the stake token.
+	MiniStake *big.Int
+	// Price paid per unit of gas, in wei.
+	GasPrice *big.Int
+	// Average time between blocks of the chain the challenge manager is deployed on.
+	BlockTime time.Duration
+	// Defaults to DefaultGasCosts.
+	GasCosts *GasCosts
+}
+
+// LevelEstimate is the worst case of a single challenge level.
+type LevelEstimate struct {
+	Level protocol.ChallengeLevel `json:"level"`
+	// Height of the level zero edge at the level.
+	Height               uint64   `json:"height"`
+	LevelZeroEdges       uint64   `json:"levelZeroEdges"`
+	Bisections           uint64   `json:"bisections"`
+	ConfirmationsByTime  uint64   `json:"confirmationsByTime"`
+	ConfirmationsByChild uint64   `json:"confirmationsByChildren"`
+	ConfirmationsByClaim uint64   `json:"confirmationsByClaim"`
+	OneStepProofs        uint64   `json:"oneStepProofs"`
+	MiniStakes           *big.Int `json:"miniStakes"`
+	Gas                  uint64   `json:"gas"`
+}
+
+// Moves at the level.
+func (l *LevelEstimate) Moves() uint64 {
+	return l.LevelZeroEdges + l.Bisections + l.ConfirmationsByTime + l.ConfirmationsByChild + l.ConfirmationsByClaim + l.OneStepProofs
+}
+
+// Estimate of the worst case of a challenge for the honest party.
+type Estimate struct {
+	Levels []*LevelEstimate `json:"levels"`
+	// Total moves, including confirming the assertion once the challenge is won.
+	Moves uint64 `json:"moves"`
+	// Total mini-stakes locked, in units of the stake token.
+	MiniStakes *big.Int `json:"miniStakes"`
+	Gas        uint64   `json:"gas"`
+	// Total gas cost, in wei.
+	GasCost           *big.Int      `json:"gasCost"`
+	MinDurationBlocks uint64        `json:"minDurationBlocks"`
+	MinDuration       time.Duration `json:"minDuration"`
+}
+
+// EstimateChallenge estimates the worst case of a challenge which has not started
+// yet. The honest party creates a level zero edge at every level and bisects it down
+// to a one-step edge, as its rival disagrees with every bisection. Each bisection
+// leaves an unrivaled sibling which has to be confirmed by time, every bisected edge
+// is then confirmed by its children, and the level zero edges of subchallenges by
+// their claims, down to a one-step proof at the last level.
+//
+// A challenge cannot take less than a challenge period, as at least one honest edge
+// has to be confirmed by time, followed by the confirmations by children and claim
+// back up to the level zero block edge, and the assertion, one block each.
+func EstimateChallenge(p *Params) (*Estimate, error) {
+	return estimate(p, nil)
+}
+
+// EstimateRemaining estimates the worst case of what remains of a challenge, given
+// the honest edges tracked so far in its tree. Levels the challenge has reached only
+// need the bisections below the shortest honest edge at the level, and no new level
+// zero edge. Edges are assumed to be unconfirmed.
+func EstimateRemaining(p *Params, tree *challengetree.HonestChallengeTree) (*Estimate, error) {
+	if tree == nil {
+		return nil, errors.New("no challenge tree")
+	}
+	shortest := make(map[protocol.ChallengeLevel]uint64)
+	_ = tree.GetEdges().ForEach(func(_ protocol.EdgeId, e protocol.SpecEdge) error {
+		start, _ := e.StartCommitment()
+		end, _ := e.EndCommitment()
+		length := uint64(end - start)
+		level := e.GetChallengeLevel()
+		if current, ok := shortest[level]; !ok || length < current {
+			shortest[level] = length
+		}
+		return nil
+	})
+	return estimate(p, shortest)
+}
+
+func estimate(p *Params, started map[protocol.ChallengeLevel]uint64) (*Estimate, error) {
+	if p == nil {
+		return nil, errors.New("no estimate params")
+	}
+	if p.MiniStake == nil || p.GasPrice == nil {
+		return nil, errors.New("mini-stake and gas price are required")
+	}
+	gas := p.GasCosts
+	if gas == nil {
+		gas = &DefaultGasCosts
+	}
+	totalLevels := uint64(p.NumBigSteps) + 2
+	est := &Estimate{
+		Levels:     make([]*LevelEstimate, 0, totalLevels),
+		MiniStakes: new(big.Int),
+	}
+	// Confirmations which have to follow one another once an edge is confirmed by time.
+	sequentialConfirmations := uint64(1)
+	for i := uint64(0); i < totalLevels; i++ {
+		level := protocol.ChallengeLevel(i)
+		height := levelHeight(p, level)
+		if height == 0 {
+			return nil, errors.Errorf("level zero height of level %d is zero", i)
+		}
+		l := &LevelEstimate{
+			Level:      level,
+			Height:     height,
+			MiniStakes: new(big.Int),
+		}
+		length := height
+		if shortest, ok := started[level]; ok {
+			length = shortest
+		} else {
+			l.LevelZeroEdges = 1
+			l.MiniStakes.Set(p.MiniStake)
+		}
+		l.Bisections = bisections(length)
+		// Every bisection, including those already made, leaves a sibling to confirm
+		// by time and an edge to confirm by its children.
+		l.ConfirmationsByTime = bisections(height)
+		l.ConfirmationsByChild = bisections(height)
+		if !level.IsBlockChallengeLevel() {
+			l.ConfirmationsByClaim = 1
+		}
+		if i == totalLevels-1 {
+			l.OneStepProofs = 1
+		}
+		l.Gas = l.LevelZeroEdges*gas.CreateLayerZeroEdge +
+			l.Bisections*gas.BisectEdge +
+			l.ConfirmationsByTime*gas.ConfirmEdgeByTime +
+			l.ConfirmationsByChild*gas.ConfirmEdgeByChildren +
+			l.ConfirmationsByClaim*gas.ConfirmEdgeByClaim +
+			l.OneStepProofs*gas.ConfirmEdgeByOneStepProof
+		sequentialConfirmations += l.ConfirmationsByChild + l.ConfirmationsByClaim
+		est.Levels = append(est.Levels, l)
+		est.Moves += l.Moves()
+		est.Gas += l.Gas
+		est.MiniStakes.Add(est.MiniStakes, l.MiniStakes)
+	}
+	est.Moves++
+	est.Gas += gas.ConfirmAssertion
+	est.GasCost = new(big.Int).Mul(p.GasPrice, new(big.Int).SetUint64(est.Gas))
+	est.MinDurationBlocks = p.ChallengePeriodBlocks + sequentialConfirmations
+	est.MinDuration = time.Duration(est.MinDurationBlocks) * p.BlockTime
+	return est, nil
+}
+
+func levelHeight(p *Params, level protocol.ChallengeLevel) uint64 {
+	switch {
+	case level.IsBlockChallengeLevel():
+		return p.LayerZeroHeights.BlockChallengeHeight
+	case uint8(level) <= p.NumBigSteps:
+		return p.LayerZeroHeights.BigStepChallengeHeight
+	default:
+		return p.LayerZeroHeights.SmallStepChallengeHeight
+	}
+}
+
+// Bisections needed to bring an edge of the given length down to a one-step edge.
+func bisections(length uint64) uint64 {
+	if length <= 1 {
+		return 0
+	}
+	return uint64(bits.Len64(length - 1))
+}
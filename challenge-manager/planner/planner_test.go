@@ -0,0 +1,113 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package planner
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	protocol "github.com/OffchainLabs/bold/chain-abstraction"
+	challengetree "github.com/OffchainLabs/bold/challenge-manager/challenge-tree"
+	"github.com/OffchainLabs/bold/challenge-manager/challenge-tree/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type honestEdge struct {
+	*mock.Edge
+}
+
+func (honestEdge) Honest() {}
+
+func testParams() *Params {
+	return &Params{
+		LayerZeroHeights: protocol.LayerZeroHeights{
+			BlockChallengeHeight:     1 << 5,
+			BigStepChallengeHeight:   1 << 4,
+			SmallStepChallengeHeight: 1 << 3,
+		},
+		NumBigSteps:           1,
+		ChallengePeriodBlocks: 100,
+		MiniStake:             big.NewInt(10),
+		GasPrice:              big.NewInt(2),
+		BlockTime:             12 * time.Second,
+		GasCosts: &GasCosts{
+			CreateLayerZeroEdge:       1000,
+			BisectEdge:                100,
+			ConfirmEdgeByTime:         10,
+			ConfirmEdgeByChildren:     1,
+			ConfirmEdgeByClaim:        1,
+			ConfirmEdgeByOneStepProof: 5000,
+			ConfirmAssertion:          50,
+		},
+	}
+}
+
+func TestEstimateChallenge(t *testing.T) {
+	est, err := EstimateChallenge(testParams())
+	require.NoError(t, err)
+	require.Len(t, est.Levels, 3)
+
+	for i, bisections := range []uint64{5, 4, 3} {
+		l := est.Levels[i]
+		require.Equal(t, uint64(1), l.LevelZeroEdges)
+		require.Equal(t, bisections, l.Bisections)
+		require.Equal(t, bisections, l.ConfirmationsByTime)
+		require.Equal(t, bisections, l.ConfirmationsByChild)
+		require.Equal(t, int64(10), l.MiniStakes.Int64())
+	}
+	require.Equal(t, uint64(0), est.Levels[0].ConfirmationsByClaim)
+	require.Equal(t, uint64(1), est.Levels[1].ConfirmationsByClaim)
+	require.Equal(t, uint64(1), est.Levels[2].OneStepProofs)
+
+	// 3 level zero edges, 12 bisections and as many confirmations by time and by
+	// children, 2 confirmations by claim, a one-step proof and the assertion.
+	require.Equal(t, uint64(3+3*12+2+1+1), est.Moves)
+	require.Equal(t, int64(30), est.MiniStakes.Int64())
+	wantGas := uint64(3*1000 + 12*100 + 12*10 + 12*1 + 2*1 + 5000 + 50)
+	require.Equal(t, wantGas, est.Gas)
+	require.Equal(t, int64(2*wantGas), est.GasCost.Int64())
+
+	// A challenge period, then confirmations by children and claim back up to the
+	// assertion.
+	require.Equal(t, uint64(100+12+2+1), est.MinDurationBlocks)
+	require.Equal(t, time.Duration(est.MinDurationBlocks)*12*time.Second, est.MinDuration)
+
+	_, err = EstimateChallenge(&Params{GasPrice: big.NewInt(1)})
+	require.ErrorContains(t, err, "mini-stake")
+	p := testParams()
+	p.LayerZeroHeights.SmallStepChallengeHeight = 0
+	_, err = EstimateChallenge(p)
+	require.ErrorContains(t, err, "level 2")
+}
+
+func TestEstimateRemaining(t *testing.T) {
+	p := testParams()
+	tree := challengetree.New(protocol.AssertionHash{}, nil, nil, p.NumBigSteps, "")
+	// The block challenge was bisected down to length 4.
+	for _, e := range []*mock.Edge{
+		{ID: "blk-0.a-32.a", EdgeType: 0, StartHeight: 0, EndHeight: 32, ClaimID: "assertion", TotalChallengeLevels: 3},
+		{ID: "blk-0.a-16.a", EdgeType: 0, StartHeight: 0, EndHeight: 16, TotalChallengeLevels: 3},
+		{ID: "blk-0.a-8.a", EdgeType: 0, StartHeight: 0, EndHeight: 8, TotalChallengeLevels: 3},
+		{ID: "blk-0.a-4.a", EdgeType: 0, StartHeight: 0, EndHeight: 4, TotalChallengeLevels: 3},
+	} {
+		require.NoError(t, tree.AddHonestEdge(honestEdge{e}))
+	}
+
+	full, err := EstimateChallenge(p)
+	require.NoError(t, err)
+	remaining, err := EstimateRemaining(p, tree)
+	require.NoError(t, err)
+
+	block := remaining.Levels[0]
+	require.Equal(t, uint64(0), block.LevelZeroEdges)
+	require.Equal(t, uint64(2), block.Bisections)
+	require.Equal(t, uint64(5), block.ConfirmationsByChild)
+	require.Equal(t, int64(0), block.MiniStakes.Int64())
+	require.Equal(t, full.Levels[1], remaining.Levels[1])
+	require.Equal(t, full.Levels[2], remaining.Levels[2])
+	require.Equal(t, full.Moves-4, remaining.Moves)
+	require.Equal(t, int64(20), remaining.MiniStakes.Int64())
+	require.Equal(t, full.MinDurationBlocks, remaining.MinDurationBlocks)
+}
@@ -0,0 +1,164 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package replay
+
+import (
+	"context"
+
+	protocol "github.com/OffchainLabs/bold/chain-abstraction"
+	"github.com/OffchainLabs/bold/containers/option"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+// ErrReadOnly is returned when a replay edge is asked to make a move on chain.
+var ErrReadOnly = errors.New("replay edges are read only")
+
+// An edge as of the replayer's current block, answering from the events replayed
+// so far rather than from the chain.
+type edge struct {
+	record *EdgeRecord
+	r      *Replayer
+}
+
+func (e *edge) Id() protocol.EdgeId {
+	return protocol.EdgeId{Hash: e.record.Id}
+}
+
+func (e *edge) GetChallengeLevel() protocol.ChallengeLevel {
+	return protocol.ChallengeLevel(e.record.Level)
+}
+
+func (e *edge) GetReversedChallengeLevel() protocol.ChallengeLevel {
+	return protocol.ChallengeLevel(e.r.totalChallengeLevels() - 1 - e.record.Level)
+}
+
+func (e *edge) GetTotalChallengeLevels(_ context.Context) uint8 {
+	return e.r.totalChallengeLevels()
+}
+
+func (e *edge) StartCommitment() (protocol.Height, common.Hash) {
+	return protocol.Height(e.record.StartHeight), e.record.StartRoot
+}
+
+func (e *edge) EndCommitment() (protocol.Height, common.Hash) {
+	return protocol.Height(e.record.EndHeight), e.record.EndRoot
+}
+
+func (e *edge) CreatedAtBlock() (uint64, error) {
+	return e.record.CreatedAtBlock, nil
+}
+
+func (e *edge) MutualId() protocol.MutualId {
+	return protocol.MutualId(e.record.MutualId)
+}
+
+func (e *edge) OriginId() protocol.OriginId {
+	return protocol.OriginId(e.record.OriginId)
+}
+
+func (e *edge) ClaimId() option.Option[protocol.ClaimId] {
+	if e.record.ClaimId == nil {
+		return option.None[protocol.ClaimId]()
+	}
+	return option.Some(protocol.ClaimId(*e.record.ClaimId))
+}
+
+func (e *edge) MiniStaker() option.Option[common.Address] {
+	if e.record.MiniStaker == nil {
+		return option.None[common.Address]()
+	}
+	return option.Some(*e.record.MiniStaker)
+}
+
+func (e *edge) HasConfirmedRival(_ context.Context) (bool, error) {
+	for _, rival := range e.r.mutuals[e.record.MutualId] {
+		if rival.record.Id != e.record.Id && e.r.confirmed[rival.record.Id] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (e *edge) HasChildren(_ context.Context) (bool, error) {
+	_, ok := e.r.children[e.record.Id]
+	return ok, nil
+}
+
+func (e *edge) LowerChild(_ context.Context) (option.Option[protocol.EdgeId], error) {
+	children, ok := e.r.children[e.record.Id]
+	if !ok {
+		return option.None[protocol.EdgeId](), nil
+	}
+	return option.Some(protocol.EdgeId{Hash: children[0]}), nil
+}
+
+func (e *edge) UpperChild(_ context.Context) (option.Option[protocol.EdgeId], error) {
+	children, ok := e.r.children[e.record.Id]
+	if !ok {
+		return option.None[protocol.EdgeId](), nil
+	}
+	return option.Some(protocol.EdgeId{Hash: children[1]}), nil
+}
+
+func (e *edge) AssertionHash(_ context.Context) (protocol.AssertionHash, error) {
+	return protocol.AssertionHash{Hash: e.r.trace.ChallengedAssertion}, nil
+}
+
+// TimeUnrivaled in blocks, until the earliest rival was added or the current block.
+func (e *edge) TimeUnrivaled(_ context.Context) (uint64, error) {
+	until := e.r.block
+	for _, rival := range e.r.mutuals[e.record.MutualId] {
+		if rival.record.Id != e.record.Id && rival.record.CreatedAtBlock < until {
+			until = rival.record.CreatedAtBlock
+		}
+	}
+	if until <= e.record.CreatedAtBlock {
+		return 0, nil
+	}
+	return until - e.record.CreatedAtBlock, nil
+}
+
+func (e *edge) HasRival(_ context.Context) (bool, error) {
+	return len(e.r.mutuals[e.record.MutualId]) > 1, nil
+}
+
+func (e *edge) Status(_ context.Context) (protocol.EdgeStatus, error) {
+	if e.r.confirmed[e.record.Id] {
+		return protocol.EdgeConfirmed, nil
+	}
+	return protocol.EdgePending, nil
+}
+
+func (e *edge) HasLengthOneRival(ctx context.Context) (bool, error) {
+	hasRival, err := e.HasRival(ctx)
+	if err != nil {
+		return false, err
+	}
+	return hasRival && e.record.EndHeight-e.record.StartHeight == 1, nil
+}
+
+func (e *edge) TopLevelClaimHeight(_ context.Context) (protocol.OriginHeights, error) {
+	return e.r.originHeights(e.record), nil
+}
+
+func (*edge) Bisect(
+	_ context.Context,
+	_ common.Hash,
+	_ []byte,
+) (protocol.VerifiedHonestEdge, protocol.VerifiedHonestEdge, error) {
+	return nil, nil, ErrReadOnly
+}
+
+func (*edge) ConfirmByTimer(_ context.Context, _ []protocol.EdgeId) error {
+	return ErrReadOnly
+}
+
+func (*edge) ConfirmByClaim(_ context.Context, _ protocol.ClaimId) error {
+	return ErrReadOnly
+}
+
+func (*edge) ConfirmByChildren(_ context.Context) error {
+	return ErrReadOnly
+}
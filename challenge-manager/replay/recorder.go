@@ -0,0 +1,425 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package replay
+
+import (
+	"context"
+	"fmt"
+
+	protocol "github.com/OffchainLabs/bold/chain-abstraction"
+	challengetree "github.com/OffchainLabs/bold/challenge-manager/challenge-tree"
+	l2stateprovider "github.com/OffchainLabs/bold/layer2-state-provider"
+	"github.com/OffchainLabs/bold/solgen/go/challengeV2gen"
+	"github.com/OffchainLabs/bold/solgen/go/rollupgen"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+// Recorder records challenges from the chain into traces, along with the answers
+// of a validator's state provider to the history checks made by the honest
+// challenge logic.
+type Recorder struct {
+	chain         protocol.AssertionChain
+	rollupAddr    common.Address
+	histChecker   l2stateprovider.HistoryChecker
+	validatorName string
+}
+
+// RecorderOpt to configure the recorder.
+type RecorderOpt func(r *Recorder)
+
+// WithValidatorName sets the name of the validator recorded in traces.
+func WithValidatorName(name string) RecorderOpt {
+	return func(r *Recorder) {
+		r.validatorName = name
+	}
+}
+
+// NewRecorder creates a recorder for challenges on the given rollup, answering
+// history checks with the given state provider.
+func NewRecorder(
+	chain protocol.AssertionChain,
+	rollupAddr common.Address,
+	histChecker l2stateprovider.HistoryChecker,
+	opts ...RecorderOpt,
+) *Recorder {
+	r := &Recorder{
+		chain:         chain,
+		rollupAddr:    rollupAddr,
+		histChecker:   histChecker,
+		validatorName: "unknown-validator",
+	}
+	for _, o := range opts {
+		o(r)
+	}
+	return r
+}
+
+// Record the challenge on the given assertion from the events emitted between
+// two blocks, inclusive.
+func (r *Recorder) Record(
+	ctx context.Context,
+	challengedAssertion protocol.AssertionHash,
+	fromBlock,
+	toBlock uint64,
+) (*Trace, error) {
+	if fromBlock > toBlock {
+		return nil, fmt.Errorf("from block %d is after to block %d", fromBlock, toBlock)
+	}
+	manager, err := r.chain.SpecChallengeManager(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get challenge manager")
+	}
+	challengePeriod, err := manager.ChallengePeriodBlocks(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get challenge period")
+	}
+	numBigStepLevels, err := manager.NumBigSteps(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get number of big step levels")
+	}
+	challenged, err := r.chain.GetAssertion(ctx, challengedAssertion)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not get challenged assertion %#x", challengedAssertion)
+	}
+	firstChildBlock, err := challenged.FirstChildCreationBlock()
+	if err != nil {
+		return nil, err
+	}
+	trace := &Trace{
+		Version:               TraceVersion,
+		ValidatorName:         r.validatorName,
+		ChallengedAssertion:   challengedAssertion.Hash,
+		FirstChildBlock:       firstChildBlock,
+		ChallengePeriodBlocks: challengePeriod,
+		NumBigStepLevels:      numBigStepLevels,
+		FromBlock:             fromBlock,
+		ToBlock:               toBlock,
+	}
+	filterOpts := &bind.FilterOpts{
+		Start:   fromBlock,
+		End:     &toBlock,
+		Context: ctx,
+	}
+	if err = r.recordAssertions(ctx, trace, filterOpts); err != nil {
+		return nil, err
+	}
+	edges, err := r.recordEdges(ctx, trace, manager, filterOpts)
+	if err != nil {
+		return nil, err
+	}
+	if err = r.recordEdgeEvents(trace, manager, filterOpts); err != nil {
+		return nil, err
+	}
+	sortEvents(trace.Events)
+	if err = r.recordHistoryAnswers(ctx, trace, edges); err != nil {
+		return nil, err
+	}
+	return trace, nil
+}
+
+// Records the challenged assertion and the creation of its children.
+func (r *Recorder) recordAssertions(ctx context.Context, trace *Trace, filterOpts *bind.FilterOpts) error {
+	filterer, err := rollupgen.NewRollupUserLogicFilterer(r.rollupAddr, r.chain.Backend())
+	if err != nil {
+		return err
+	}
+	it, err := filterer.FilterAssertionCreated(filterOpts, nil, [][32]byte{trace.ChallengedAssertion})
+	if err != nil {
+		return err
+	}
+	var hashes []common.Hash
+	err = collect(it, func() {
+		e := it.Event
+		hashes = append(hashes, e.AssertionHash)
+		trace.Events = append(trace.Events, newEvent(AssertionCreated, e.Raw, func(ev *Event) {
+			ev.AssertionHash = e.AssertionHash
+		}))
+	})
+	if err != nil {
+		return err
+	}
+	for _, h := range append([]common.Hash{trace.ChallengedAssertion}, hashes...) {
+		assertionHash := protocol.AssertionHash{Hash: h}
+		info, err := r.chain.ReadAssertionCreationInfo(ctx, assertionHash)
+		if err != nil {
+			return errors.Wrapf(err, "could not read creation info of assertion %#x", h)
+		}
+		unrivaled, err := r.chain.AssertionUnrivaledBlocks(ctx, assertionHash)
+		if err != nil {
+			return errors.Wrapf(err, "could not get unrivaled blocks of assertion %#x", h)
+		}
+		trace.Assertions = append(trace.Assertions, &AssertionRecord{
+			Hash:            h,
+			CreationInfo:    info,
+			UnrivaledBlocks: unrivaled,
+		})
+	}
+	return nil
+}
+
+// Records the edges added to the challenge on the challenged assertion, returning
+// them in the order they were added.
+func (r *Recorder) recordEdges(
+	ctx context.Context,
+	trace *Trace,
+	manager protocol.SpecChallengeManager,
+	filterOpts *bind.FilterOpts,
+) ([]protocol.SpecEdge, error) {
+	filterer, err := challengeV2gen.NewEdgeChallengeManagerFilterer(manager.Address(), r.chain.Backend())
+	if err != nil {
+		return nil, err
+	}
+	it, err := filterer.FilterEdgeAdded(filterOpts, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var added []*challengeV2gen.EdgeChallengeManagerEdgeAdded
+	if err = collect(it, func() {
+		e := it.Event
+		added = append(added, e)
+	}); err != nil {
+		return nil, err
+	}
+	var edges []protocol.SpecEdge
+	for _, e := range added {
+		edgeOpt, err := manager.GetEdge(ctx, protocol.EdgeId{Hash: e.EdgeId})
+		if err != nil {
+			return nil, err
+		}
+		if edgeOpt.IsNone() {
+			return nil, fmt.Errorf("edge %#x was added but does not exist", e.EdgeId)
+		}
+		edge := edgeOpt.Unwrap()
+		assertionHash, err := edge.AssertionHash(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if assertionHash.Hash != trace.ChallengedAssertion {
+			continue
+		}
+		record, err := r.edgeRecord(ctx, edge)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not record edge %#x", e.EdgeId)
+		}
+		trace.Edges = append(trace.Edges, record)
+		trace.Events = append(trace.Events, newEvent(EdgeAdded, e.Raw, func(ev *Event) {
+			ev.EdgeId = e.EdgeId
+		}))
+		edges = append(edges, edge)
+	}
+	return edges, nil
+}
+
+func (r *Recorder) edgeRecord(ctx context.Context, edge protocol.SpecEdge) (*EdgeRecord, error) {
+	startHeight, startRoot := edge.StartCommitment()
+	endHeight, endRoot := edge.EndCommitment()
+	createdAt, err := edge.CreatedAtBlock()
+	if err != nil {
+		return nil, err
+	}
+	heights, err := r.chain.TopLevelClaimHeights(ctx, edge.Id())
+	if err != nil {
+		return nil, err
+	}
+	originHeights := make([]uint64, len(heights.ChallengeOriginHeights))
+	for i, h := range heights.ChallengeOriginHeights {
+		originHeights[i] = uint64(h)
+	}
+	record := &EdgeRecord{
+		Id:             edge.Id().Hash,
+		Level:          edge.GetChallengeLevel().Uint8(),
+		OriginId:       common.Hash(edge.OriginId()),
+		MutualId:       common.Hash(edge.MutualId()),
+		StartHeight:    uint64(startHeight),
+		StartRoot:      startRoot,
+		EndHeight:      uint64(endHeight),
+		EndRoot:        endRoot,
+		CreatedAtBlock: createdAt,
+		OriginHeights:  originHeights,
+	}
+	if edge.ClaimId().IsSome() {
+		claimId := common.Hash(edge.ClaimId().Unwrap())
+		record.ClaimId = &claimId
+	}
+	if edge.MiniStaker().IsSome() {
+		staker := edge.MiniStaker().Unwrap()
+		record.MiniStaker = &staker
+	}
+	return record, nil
+}
+
+// Records bisections and confirmations of the recorded edges.
+func (r *Recorder) recordEdgeEvents(
+	trace *Trace,
+	manager protocol.SpecChallengeManager,
+	filterOpts *bind.FilterOpts,
+) error {
+	filterer, err := challengeV2gen.NewEdgeChallengeManagerFilterer(manager.Address(), r.chain.Backend())
+	if err != nil {
+		return err
+	}
+	inChallenge := make(map[common.Hash]bool, len(trace.Edges))
+	for _, e := range trace.Edges {
+		inChallenge[e.Id] = true
+	}
+	add := func(kind EventKind, edgeId common.Hash, raw types.Log, fill func(ev *Event)) {
+		if !inChallenge[edgeId] {
+			return
+		}
+		trace.Events = append(trace.Events, newEvent(kind, raw, func(ev *Event) {
+			ev.EdgeId = edgeId
+			if fill != nil {
+				fill(ev)
+			}
+		}))
+	}
+	bisected, err := filterer.FilterEdgeBisected(filterOpts, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	if err = collect(bisected, func() {
+		e := bisected.Event
+		add(EdgeBisected, e.EdgeId, e.Raw, func(ev *Event) {
+			ev.LowerChildId = e.LowerChildId
+			ev.UpperChildId = e.UpperChildId
+		})
+	}); err != nil {
+		return err
+	}
+	byChildren, err := filterer.FilterEdgeConfirmedByChildren(filterOpts, nil, nil)
+	if err != nil {
+		return err
+	}
+	if err = collect(byChildren, func() {
+		e := byChildren.Event
+		add(EdgeConfirmedByChildren, e.EdgeId, e.Raw, nil)
+	}); err != nil {
+		return err
+	}
+	byClaim, err := filterer.FilterEdgeConfirmedByClaim(filterOpts, nil, nil)
+	if err != nil {
+		return err
+	}
+	if err = collect(byClaim, func() {
+		e := byClaim.Event
+		add(EdgeConfirmedByClaim, e.EdgeId, e.Raw, func(ev *Event) {
+			ev.ClaimingEdgeId = e.ClaimingEdgeId
+		})
+	}); err != nil {
+		return err
+	}
+	byTime, err := filterer.FilterEdgeConfirmedByTime(filterOpts, nil, nil)
+	if err != nil {
+		return err
+	}
+	if err = collect(byTime, func() {
+		e := byTime.Event
+		add(EdgeConfirmedByTime, e.EdgeId, e.Raw, nil)
+	}); err != nil {
+		return err
+	}
+	byOsp, err := filterer.FilterEdgeConfirmedByOneStepProof(filterOpts, nil, nil)
+	if err != nil {
+		return err
+	}
+	return collect(byOsp, func() {
+		e := byOsp.Event
+		add(EdgeConfirmedByOneStepProof, e.EdgeId, e.Raw, nil)
+	})
+}
+
+// Adds the recorded edges to an honest challenge tree, as the chain watcher
+// would, so that every history check the tree makes is answered by the state
+// provider and recorded.
+func (r *Recorder) recordHistoryAnswers(ctx context.Context, trace *Trace, edges []protocol.SpecEdge) error {
+	checker := &recordingHistoryChecker{
+		inner:   r.histChecker,
+		trace:   trace,
+		answers: make(map[string]bool),
+	}
+	tree := challengetree.New(
+		protocol.AssertionHash{Hash: trace.ChallengedAssertion},
+		r.chain,
+		checker,
+		trace.NumBigStepLevels,
+		r.validatorName,
+	)
+	// Edges added before the honest block challenge edge cannot be checked until
+	// it is, so they are retried until no more progress is made.
+	pending := edges
+	for len(pending) > 0 {
+		var deferred []protocol.SpecEdge
+		for _, edge := range pending {
+			if _, err := tree.AddEdge(ctx, edge); err != nil {
+				if errors.Is(err, challengetree.ErrNoHonestRootEdge) {
+					deferred = append(deferred, edge)
+					continue
+				}
+				return errors.Wrapf(err, "could not add edge %#x to challenge tree", edge.Id())
+			}
+		}
+		if len(deferred) == len(pending) {
+			break
+		}
+		pending = deferred
+	}
+	return nil
+}
+
+// Records the answers of a history checker.
+type recordingHistoryChecker struct {
+	inner   l2stateprovider.HistoryChecker
+	trace   *Trace
+	answers map[string]bool
+}
+
+func (c *recordingHistoryChecker) AgreesWithHistoryCommitment(
+	ctx context.Context,
+	challengeLevel protocol.ChallengeLevel,
+	request *l2stateprovider.HistoryCommitmentRequest,
+	commit l2stateprovider.History,
+) (bool, error) {
+	agrees, err := c.inner.AgreesWithHistoryCommitment(ctx, challengeLevel, request, commit)
+	if err != nil {
+		return false, err
+	}
+	answer := newHistoryAnswer(challengeLevel, request, commit, agrees)
+	if _, ok := c.answers[answer.key()]; !ok {
+		c.answers[answer.key()] = agrees
+		c.trace.HistoryAnswers = append(c.trace.HistoryAnswers, answer)
+	}
+	return agrees, nil
+}
+
+func newEvent(kind EventKind, raw types.Log, fill func(ev *Event)) *Event {
+	ev := &Event{
+		Kind:     kind,
+		Block:    raw.BlockNumber,
+		LogIndex: raw.Index,
+	}
+	fill(ev)
+	return ev
+}
+
+// Iterator over the events of a generated contract filterer.
+type eventIterator interface {
+	Next() bool
+	Error() error
+	Close() error
+}
+
+// Calls fn for every event of a filter iterator, which reads the current event
+// from the iterator.
+func collect(it eventIterator, fn func()) error {
+	defer func() {
+		_ = it.Close()
+	}()
+	for it.Next() {
+		fn()
+	}
+	return it.Error()
+}
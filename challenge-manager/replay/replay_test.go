@@ -0,0 +1,230 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	protocol "github.com/OffchainLabs/bold/chain-abstraction"
+	edgetracker "github.com/OffchainLabs/bold/challenge-manager/edge-tracker"
+	"github.com/OffchainLabs/bold/testing/adversary"
+	"github.com/OffchainLabs/bold/testing/setup"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	ctx := context.Background()
+	createdData, trace := recordBisectedChallenge(t, ctx)
+
+	// Both block challenge edges and their children are recorded, along with the
+	// creation of the rival assertions and the bisections.
+	require.Equal(t, TraceVersion, int(trace.Version))
+	parent, err := createdData.Leaf1.PrevId(ctx)
+	require.NoError(t, err)
+	require.Equal(t, parent, protocol.AssertionHash{Hash: trace.ChallengedAssertion})
+	require.GreaterOrEqual(t, len(trace.Edges), 4)
+	kinds := make(map[EventKind]int)
+	for _, ev := range trace.Events {
+		kinds[ev.Kind]++
+	}
+	require.Equal(t, 2, kinds[AssertionCreated])
+	require.Equal(t, 2, kinds[EdgeBisected])
+	require.Equal(t, len(trace.Edges), kinds[EdgeAdded])
+	require.NotEmpty(t, trace.HistoryAnswers)
+
+	// The trace survives being written out and read back.
+	var buf bytes.Buffer
+	require.NoError(t, trace.Write(&buf))
+	trace, err = ReadTrace(&buf)
+	require.NoError(t, err)
+
+	r, err := New(trace)
+	require.NoError(t, err)
+	decisions, err := r.Run(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, decisions)
+
+	// The honest block challenge edge is rivaled, so it keeps on being bisected
+	// and cannot be confirmed, while its timer stopped once its rival was added.
+	honestRoot := honestRootRecord(t, trace)
+	rootDecisions := decisionsOn(decisions, honestRoot.Id)
+	require.NotEmpty(t, rootDecisions)
+	last := rootDecisions[len(rootDecisions)-1]
+	require.Equal(t, trace.ToBlock, last.Block)
+	require.Equal(t, []edgetracker.Move{edgetracker.Confirm, edgetracker.Bisect}, last.Moves)
+	require.Equal(t, NotConfirmable, last.Confirmation)
+	rivalCreatedAt := rivalRecord(t, trace, honestRoot).CreatedAtBlock
+	var assertionUnrivaled uint64
+	for _, a := range trace.Assertions {
+		if a.Hash == trace.ChallengedAssertion {
+			assertionUnrivaled = a.UnrivaledBlocks
+		}
+	}
+	require.Equal(t, assertionUnrivaled+rivalCreatedAt-honestRoot.CreatedAtBlock, uint64(last.PathTimer))
+
+	// Honest edges added by the bisection are decided on from then on.
+	require.Greater(t, len(decisionsAt(decisions, trace.ToBlock)), 1)
+}
+
+func TestReplay_WhatIf(t *testing.T) {
+	ctx := context.Background()
+	createdData, trace := recordBisectedChallenge(t, ctx)
+	honestRoot := honestRootRecord(t, trace)
+
+	t.Run("shorter challenge period", func(t *testing.T) {
+		r, err := New(trace, WithChallengePeriodBlocks(1))
+		require.NoError(t, err)
+		decisions, err := r.Run(ctx)
+		require.NoError(t, err)
+		rootDecisions := decisionsOn(decisions, honestRoot.Id)
+		require.Equal(t, ConfirmableByTime, rootDecisions[len(rootDecisions)-1].Confirmation)
+	})
+	t.Run("other strategy", func(t *testing.T) {
+		r, err := New(trace, WithStrategy(edgetracker.ConfirmOnly()))
+		require.NoError(t, err)
+		decisions, err := r.Run(ctx)
+		require.NoError(t, err)
+		require.NotEmpty(t, decisions)
+		for _, d := range decisions {
+			require.Equal(t, []edgetracker.Move{edgetracker.Confirm}, d.Moves)
+		}
+	})
+	t.Run("other state provider", func(t *testing.T) {
+		// The evil validator's state provider agrees with the other side of the
+		// challenge, which it replays as honest.
+		r, err := New(trace, WithHistoryChecker(createdData.EvilStateManager))
+		require.NoError(t, err)
+		decisions, err := r.Run(ctx)
+		require.NoError(t, err)
+		require.NotEmpty(t, decisions)
+		require.Empty(t, decisionsOn(decisions, honestRoot.Id))
+		rival := rivalRecord(t, trace, honestRoot)
+		require.NotEmpty(t, decisionsOn(decisions, rival.Id))
+	})
+}
+
+func TestReplay_DrivenByTimeReference(t *testing.T) {
+	ctx := context.Background()
+	_, trace := recordBisectedChallenge(t, ctx)
+	honestRoot := honestRootRecord(t, trace)
+
+	r, err := New(trace)
+	require.NoError(t, err)
+	require.NoError(t, r.AdvanceTo(honestRoot.CreatedAtBlock-1))
+	decisions, err := r.Decisions(ctx)
+	require.NoError(t, err)
+	require.Empty(t, decisions)
+
+	// Once the honest edge is added, it is unrivaled until its rival is.
+	r.TimeReference().Add(r.blockTime)
+	decisions, err = r.Decisions(ctx)
+	require.NoError(t, err)
+	require.Len(t, decisions, 1)
+	require.Equal(t, honestRoot.Id, decisions[0].EdgeId.Hash)
+	require.Equal(t, []edgetracker.Move{edgetracker.Confirm}, decisions[0].Moves)
+
+	require.ErrorContains(t, r.AdvanceTo(honestRoot.CreatedAtBlock-1), "cannot go back")
+}
+
+func TestReplay_MissingAnswers(t *testing.T) {
+	ctx := context.Background()
+	_, trace := recordBisectedChallenge(t, ctx)
+	trace.HistoryAnswers = nil
+
+	r, err := New(trace)
+	require.NoError(t, err)
+	_, err = r.Run(ctx)
+	require.ErrorIs(t, err, ErrNotInTrace)
+}
+
+func TestReadTrace_UnsupportedVersion(t *testing.T) {
+	_, err := ReadTrace(strings.NewReader(`{"version": 99}`))
+	require.ErrorContains(t, err, "unsupported trace version 99")
+}
+
+// Records a challenge in which both validators added their block challenge
+// edges and bisected them once.
+func recordBisectedChallenge(t *testing.T, ctx context.Context) (*setup.CreatedValidatorFork, *Trace) {
+	t.Helper()
+	createdData, err := setup.CreateTwoValidatorFork(ctx, &setup.CreateForkConfig{}, setup.WithMockOneStepProver())
+	require.NoError(t, err)
+
+	players := []*adversary.Player{
+		adversary.NewPlayer("honest", createdData.Chains[0], createdData.HonestStateManager),
+		adversary.NewPlayer("evil", createdData.Chains[1], createdData.EvilStateManager),
+	}
+	for _, p := range players {
+		engaged, err := p.Engage(ctx)
+		require.NoError(t, err)
+		require.True(t, engaged)
+		createdData.Backend.Commit()
+	}
+	for _, p := range players {
+		moves, err := p.Moves(ctx)
+		require.NoError(t, err)
+		for _, m := range moves {
+			require.Equal(t, adversary.Bisect, m.Kind)
+			require.NoError(t, p.Make(ctx, m))
+		}
+	}
+	createdData.Backend.Commit()
+
+	header, err := createdData.Backend.HeaderByNumber(ctx, nil)
+	require.NoError(t, err)
+	recorder := NewRecorder(
+		createdData.Chains[0],
+		createdData.Addrs.Rollup,
+		createdData.HonestStateManager,
+		WithValidatorName("alice"),
+	)
+	parent, err := createdData.Leaf1.PrevId(ctx)
+	require.NoError(t, err)
+	trace, err := recorder.Record(ctx, parent, 0, header.Number.Uint64())
+	require.NoError(t, err)
+	return createdData, trace
+}
+
+func honestRootRecord(t *testing.T, trace *Trace) *EdgeRecord {
+	t.Helper()
+	// The honest validator added its block challenge edge first.
+	for _, e := range trace.Edges {
+		if e.Level == 0 && e.ClaimId != nil {
+			return e
+		}
+	}
+	t.Fatal("no block challenge level zero edge in trace")
+	return nil
+}
+
+func rivalRecord(t *testing.T, trace *Trace, of *EdgeRecord) *EdgeRecord {
+	t.Helper()
+	for _, e := range trace.Edges {
+		if e.MutualId == of.MutualId && e.Id != of.Id {
+			return e
+		}
+	}
+	t.Fatalf("no rival of edge %#x in trace", of.Id)
+	return nil
+}
+
+func decisionsOn(decisions []*Decision, edgeId common.Hash) []*Decision {
+	var on []*Decision
+	for _, d := range decisions {
+		if d.EdgeId.Hash == edgeId {
+			on = append(on, d)
+		}
+	}
+	return on
+}
+
+func decisionsAt(decisions []*Decision, block uint64) []*Decision {
+	var at []*Decision
+	for _, d := range decisions {
+		if d.Block == block {
+			at = append(at, d)
+		}
+	}
+	return at
+}
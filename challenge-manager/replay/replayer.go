@@ -0,0 +1,504 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+// Package replay records challenges from the chain into portable traces, and
+// replays them offline against the honest challenge logic. A replay reproduces
+// the decisions the honest validator would have made at every block of a recorded
+// challenge, and lets a fix to the strategy or state provider be tried against
+// the exact historical sequence of events, without a chain.
+package replay
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	protocol "github.com/OffchainLabs/bold/chain-abstraction"
+	challengetree "github.com/OffchainLabs/bold/challenge-manager/challenge-tree"
+	edgetracker "github.com/OffchainLabs/bold/challenge-manager/edge-tracker"
+	l2stateprovider "github.com/OffchainLabs/bold/layer2-state-provider"
+	utilTime "github.com/OffchainLabs/bold/time"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+// Confirmation is how an edge can be confirmed at a block, if at all.
+type Confirmation uint8
+
+const (
+	NotConfirmable Confirmation = iota
+	ConfirmableByChildren
+	ConfirmableByClaim
+	ConfirmableByTime
+)
+
+func (c Confirmation) String() string {
+	switch c {
+	case NotConfirmable:
+		return "not_confirmable"
+	case ConfirmableByChildren:
+		return "by_children"
+	case ConfirmableByClaim:
+		return "by_claim"
+	case ConfirmableByTime:
+		return "by_time"
+	default:
+		return fmt.Sprintf("confirmation(%d)", uint8(c))
+	}
+}
+
+// Decision the honest validator would make on one of its edges at a block.
+type Decision struct {
+	Block          uint64
+	EdgeId         protocol.EdgeId
+	ChallengeLevel protocol.ChallengeLevel
+	StartHeight    protocol.Height
+	EndHeight      protocol.Height
+	// Honest path timer of the edge, or zero if it cannot be computed yet, such
+	// as when the edge was bisected but its lower child not yet added.
+	PathTimer    challengetree.PathTimer
+	Confirmation Confirmation
+	// Moves the strategy would attempt on the edge, in order, were its tracker in
+	// the started state.
+	Moves []edgetracker.Move
+}
+
+// Replayer replays a trace against the honest challenge tree and an edge tracker
+// strategy. Blocks are mapped onto an artificial time reference, and the events
+// of a block are replayed once the time reference reaches it.
+type Replayer struct {
+	trace                 *Trace
+	histChecker           l2stateprovider.HistoryChecker
+	strategy              edgetracker.Strategy
+	challengePeriodBlocks uint64
+	timeRef               *utilTime.ArtificialTimeReference
+	blockTime             time.Duration
+	tree                  *challengetree.HonestChallengeTree
+
+	block      uint64
+	next       int
+	records    map[common.Hash]*EdgeRecord
+	assertions map[common.Hash]*AssertionRecord
+	edges      map[common.Hash]*edge
+	mutuals    map[common.Hash][]*edge
+	children   map[common.Hash][2]common.Hash
+	confirmed  map[common.Hash]bool
+	// Confirmed level zero edges, by the id of the edge they claim.
+	confirmedClaimants map[common.Hash]common.Hash
+	honest             []*edge
+	pending            []*edge
+}
+
+// Opt to configure the replayer.
+type Opt func(r *Replayer)
+
+// WithHistoryChecker answers history checks with the given checker instead of the
+// recorded answers, to try out a state provider against a recorded challenge.
+func WithHistoryChecker(histChecker l2stateprovider.HistoryChecker) Opt {
+	return func(r *Replayer) {
+		r.histChecker = histChecker
+	}
+}
+
+// WithStrategy decides moves with the given strategy instead of the honest one.
+func WithStrategy(strategy edgetracker.Strategy) Opt {
+	return func(r *Replayer) {
+		r.strategy = strategy
+	}
+}
+
+// WithChallengePeriodBlocks overrides the recorded challenge period.
+func WithChallengePeriodBlocks(blocks uint64) Opt {
+	return func(r *Replayer) {
+		r.challengePeriodBlocks = blocks
+	}
+}
+
+// WithTimeReference replays against the given time reference, so that the
+// caller can drive the replay by advancing it.
+func WithTimeReference(timeRef *utilTime.ArtificialTimeReference) Opt {
+	return func(r *Replayer) {
+		r.timeRef = timeRef
+	}
+}
+
+// WithBlockTime sets the duration of a block on the time reference, which is
+// one second by default. Block zero is at the Unix epoch.
+func WithBlockTime(d time.Duration) Opt {
+	return func(r *Replayer) {
+		r.blockTime = d
+	}
+}
+
+// New creates a replayer of a trace, starting at the first block of the trace.
+func New(trace *Trace, opts ...Opt) (*Replayer, error) {
+	if trace == nil {
+		return nil, errors.New("no trace to replay")
+	}
+	r := &Replayer{
+		trace:                 trace,
+		strategy:              edgetracker.Honest(),
+		challengePeriodBlocks: trace.ChallengePeriodBlocks,
+		blockTime:             time.Second,
+		records:               make(map[common.Hash]*EdgeRecord, len(trace.Edges)),
+		assertions:            make(map[common.Hash]*AssertionRecord, len(trace.Assertions)),
+		edges:                 make(map[common.Hash]*edge, len(trace.Edges)),
+		mutuals:               make(map[common.Hash][]*edge),
+		children:              make(map[common.Hash][2]common.Hash),
+		confirmed:             make(map[common.Hash]bool),
+		confirmedClaimants:    make(map[common.Hash]common.Hash),
+	}
+	for _, o := range opts {
+		o(r)
+	}
+	if r.blockTime <= 0 {
+		return nil, errors.New("block time must be positive")
+	}
+	if r.histChecker == nil {
+		answers := make(map[string]bool, len(trace.HistoryAnswers))
+		for _, a := range trace.HistoryAnswers {
+			answers[a.key()] = a.Agrees
+		}
+		r.histChecker = &traceHistoryChecker{answers: answers}
+	}
+	if r.timeRef == nil {
+		r.timeRef = utilTime.NewArtificialTimeReference()
+	}
+	for _, e := range trace.Edges {
+		r.records[e.Id] = e
+	}
+	for _, a := range trace.Assertions {
+		r.assertions[a.Hash] = a
+	}
+	r.tree = challengetree.New(
+		protocol.AssertionHash{Hash: trace.ChallengedAssertion},
+		&traceMetadataReader{r: r},
+		r.histChecker,
+		trace.NumBigStepLevels,
+		trace.ValidatorName,
+	)
+	r.timeRef.Set(r.timeOf(trace.FromBlock))
+	return r, nil
+}
+
+// TimeReference the replay runs against.
+func (r *Replayer) TimeReference() *utilTime.ArtificialTimeReference {
+	return r.timeRef
+}
+
+// Block of the time reference's current time.
+func (r *Replayer) Block() uint64 {
+	since := r.timeRef.Get().Sub(time.Unix(0, 0))
+	if since < 0 {
+		return 0
+	}
+	return uint64(since / r.blockTime)
+}
+
+// AdvanceTo moves the time reference forward to the given block.
+func (r *Replayer) AdvanceTo(block uint64) error {
+	if block < r.Block() {
+		return fmt.Errorf("cannot go back from block %d to %d", r.Block(), block)
+	}
+	r.timeRef.Set(r.timeOf(block))
+	return nil
+}
+
+func (r *Replayer) timeOf(block uint64) time.Time {
+	return time.Unix(0, 0).Add(time.Duration(block) * r.blockTime)
+}
+
+// Decisions the honest validator would make at the current block of the time
+// reference, on each of its edges which is neither confirmed nor lost, in the
+// order the edges were added.
+func (r *Replayer) Decisions(ctx context.Context) ([]*Decision, error) {
+	if err := r.sync(ctx); err != nil {
+		return nil, err
+	}
+	var decisions []*Decision
+	for _, e := range r.honest {
+		if r.confirmed[e.record.Id] {
+			continue
+		}
+		lost, err := e.HasConfirmedRival(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if lost {
+			continue
+		}
+		d, err := r.decide(ctx, e)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not decide on edge %#x", e.record.Id)
+		}
+		decisions = append(decisions, d)
+	}
+	return decisions, nil
+}
+
+// Run replays the whole trace, returning the decisions made at every block
+// with events, and at the last block of the trace.
+func (r *Replayer) Run(ctx context.Context) ([]*Decision, error) {
+	blocks := make(map[uint64]bool)
+	for _, ev := range r.trace.Events {
+		blocks[ev.Block] = true
+	}
+	blocks[r.trace.ToBlock] = true
+	ordered := make([]uint64, 0, len(blocks))
+	for b := range blocks {
+		if b >= r.Block() && b <= r.trace.ToBlock {
+			ordered = append(ordered, b)
+		}
+	}
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i] < ordered[j] })
+	var decisions []*Decision
+	for _, b := range ordered {
+		if err := r.AdvanceTo(b); err != nil {
+			return nil, err
+		}
+		ds, err := r.Decisions(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not decide at block %d", b)
+		}
+		decisions = append(decisions, ds...)
+	}
+	return decisions, nil
+}
+
+// Replays the events up to the current block of the time reference.
+func (r *Replayer) sync(ctx context.Context) error {
+	r.block = r.Block()
+	for r.next < len(r.trace.Events) && r.trace.Events[r.next].Block <= r.block {
+		if err := r.apply(ctx, r.trace.Events[r.next]); err != nil {
+			return err
+		}
+		r.next++
+	}
+	return nil
+}
+
+func (r *Replayer) apply(ctx context.Context, ev *Event) error {
+	switch ev.Kind {
+	case AssertionCreated:
+		// Assertions are read from their records.
+		return nil
+	case EdgeAdded:
+		record, ok := r.records[ev.EdgeId]
+		if !ok {
+			return errors.Wrapf(ErrNotInTrace, "edge %#x", ev.EdgeId)
+		}
+		e := &edge{record: record, r: r}
+		r.edges[record.Id] = e
+		r.mutuals[record.MutualId] = append(r.mutuals[record.MutualId], e)
+		return r.addEdge(ctx, e)
+	case EdgeBisected:
+		r.children[ev.EdgeId] = [2]common.Hash{ev.LowerChildId, ev.UpperChildId}
+		return nil
+	case EdgeConfirmedByChildren, EdgeConfirmedByClaim, EdgeConfirmedByTime, EdgeConfirmedByOneStepProof:
+		r.confirmed[ev.EdgeId] = true
+		if record, ok := r.records[ev.EdgeId]; ok && record.ClaimId != nil {
+			r.confirmedClaimants[*record.ClaimId] = record.Id
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown event kind %q", ev.Kind)
+	}
+}
+
+// Adds an edge to the honest challenge tree, deferring it until the honest block
+// challenge edge is added if need be, as the chain watcher does.
+func (r *Replayer) addEdge(ctx context.Context, e *edge) error {
+	added, err := r.tryAddEdge(ctx, e)
+	if err != nil {
+		return err
+	}
+	if !added {
+		r.pending = append(r.pending, e)
+		return nil
+	}
+	pending := r.pending
+	r.pending = nil
+	for _, p := range pending {
+		added, err := r.tryAddEdge(ctx, p)
+		if err != nil {
+			return err
+		}
+		if !added {
+			r.pending = append(r.pending, p)
+		}
+	}
+	return nil
+}
+
+func (r *Replayer) tryAddEdge(ctx context.Context, e *edge) (bool, error) {
+	agreement, err := r.tree.AddEdge(ctx, e)
+	if err != nil {
+		if errors.Is(err, challengetree.ErrNoHonestRootEdge) {
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "could not add edge %#x to challenge tree", e.record.Id)
+	}
+	if agreement.IsHonestEdge {
+		r.honest = append(r.honest, e)
+	}
+	return true, nil
+}
+
+// Decides on an honest edge as an edge tracker in the started state would.
+func (r *Replayer) decide(ctx context.Context, e *edge) (*Decision, error) {
+	startHeight, _ := e.StartCommitment()
+	endHeight, _ := e.EndCommitment()
+	d := &Decision{
+		Block:          r.block,
+		EdgeId:         e.Id(),
+		ChallengeLevel: e.GetChallengeLevel(),
+		StartHeight:    startHeight,
+		EndHeight:      endHeight,
+	}
+	timer, err := r.pathTimer(ctx, e.Id())
+	switch {
+	case errors.Is(err, challengetree.ErrNoLowerChildYet):
+	case err != nil:
+		return nil, err
+	default:
+		d.PathTimer = timer
+	}
+	switch {
+	case r.childrenConfirmed(e):
+		d.Confirmation = ConfirmableByChildren
+	case r.claimConfirmed(e):
+		d.Confirmation = ConfirmableByClaim
+	case err == nil && uint64(timer) >= r.challengePeriodBlocks:
+		d.Confirmation = ConfirmableByTime
+	}
+	d.Moves, err = r.strategy.Moves(ctx, edgetracker.NewSituation(e, edgetracker.EdgeStarted, &environment{r: r}))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not determine moves")
+	}
+	return d, nil
+}
+
+func (r *Replayer) pathTimer(ctx context.Context, edgeId protocol.EdgeId) (challengetree.PathTimer, error) {
+	response, err := r.tree.ComputeAncestorsWithTimers(ctx, edgeId, r.block)
+	if err != nil {
+		return 0, err
+	}
+	return r.tree.ComputeHonestPathTimer(ctx, edgeId, response.AncestorLocalTimers, r.block)
+}
+
+func (r *Replayer) childrenConfirmed(e *edge) bool {
+	children, ok := r.children[e.record.Id]
+	return ok && r.confirmed[children[0]] && r.confirmed[children[1]]
+}
+
+func (r *Replayer) claimConfirmed(e *edge) bool {
+	_, ok := r.confirmedClaimants[e.record.Id]
+	return ok
+}
+
+func (r *Replayer) totalChallengeLevels() uint8 {
+	return r.trace.NumBigStepLevels + 2
+}
+
+func (r *Replayer) originHeights(record *EdgeRecord) protocol.OriginHeights {
+	heights := make([]protocol.Height, len(record.OriginHeights))
+	for i, h := range record.OriginHeights {
+		heights[i] = protocol.Height(h)
+	}
+	return protocol.OriginHeights{ChallengeOriginHeights: heights}
+}
+
+// The environment of the replayed edges, as of the replayer's current block.
+type environment struct {
+	r *Replayer
+}
+
+func (e *environment) ChallengePeriodBlocks(_ context.Context) (uint64, error) {
+	return e.r.challengePeriodBlocks, nil
+}
+
+func (e *environment) Headroom(ctx context.Context, edge protocol.SpecEdge) (uint64, error) {
+	timer, err := e.r.pathTimer(ctx, edge.Id())
+	if err != nil {
+		return 0, err
+	}
+	if uint64(timer) >= e.r.challengePeriodBlocks {
+		return 0, nil
+	}
+	return e.r.challengePeriodBlocks - uint64(timer), nil
+}
+
+func (e *environment) ChallengeBlocksElapsed(_ context.Context, _ protocol.SpecEdge) (uint64, error) {
+	if e.r.block <= e.r.trace.FirstChildBlock {
+		return 0, nil
+	}
+	return e.r.block - e.r.trace.FirstChildBlock, nil
+}
+
+// Answers the challenge tree's questions about assertions and edges from the trace.
+type traceMetadataReader struct {
+	r *Replayer
+}
+
+func (m *traceMetadataReader) AssertionUnrivaledBlocks(
+	_ context.Context, assertionHash protocol.AssertionHash,
+) (uint64, error) {
+	a, ok := m.r.assertions[assertionHash.Hash]
+	if !ok {
+		return 0, errors.Wrapf(ErrNotInTrace, "assertion %#x", assertionHash.Hash)
+	}
+	return a.UnrivaledBlocks, nil
+}
+
+func (m *traceMetadataReader) TopLevelAssertion(
+	_ context.Context, edgeId protocol.EdgeId,
+) (protocol.AssertionHash, error) {
+	if _, ok := m.r.records[edgeId.Hash]; !ok {
+		return protocol.AssertionHash{}, errors.Wrapf(ErrNotInTrace, "edge %#x", edgeId.Hash)
+	}
+	return protocol.AssertionHash{Hash: m.r.trace.ChallengedAssertion}, nil
+}
+
+func (m *traceMetadataReader) TopLevelClaimHeights(
+	_ context.Context, edgeId protocol.EdgeId,
+) (protocol.OriginHeights, error) {
+	record, ok := m.r.records[edgeId.Hash]
+	if !ok {
+		return protocol.OriginHeights{}, errors.Wrapf(ErrNotInTrace, "edge %#x", edgeId.Hash)
+	}
+	return m.r.originHeights(record), nil
+}
+
+func (*traceMetadataReader) SpecChallengeManager(_ context.Context) (protocol.SpecChallengeManager, error) {
+	return nil, errors.New("no challenge manager in a replay")
+}
+
+func (m *traceMetadataReader) ReadAssertionCreationInfo(
+	_ context.Context, id protocol.AssertionHash,
+) (*protocol.AssertionCreatedInfo, error) {
+	a, ok := m.r.assertions[id.Hash]
+	if !ok || a.CreationInfo == nil {
+		return nil, errors.Wrapf(ErrNotInTrace, "creation info of assertion %#x", id.Hash)
+	}
+	return a.CreationInfo, nil
+}
+
+// Answers history checks with the answers recorded in a trace.
+type traceHistoryChecker struct {
+	answers map[string]bool
+}
+
+func (c *traceHistoryChecker) AgreesWithHistoryCommitment(
+	_ context.Context,
+	challengeLevel protocol.ChallengeLevel,
+	request *l2stateprovider.HistoryCommitmentRequest,
+	commit l2stateprovider.History,
+) (bool, error) {
+	key := newHistoryAnswer(challengeLevel, request, commit, false).key()
+	agrees, ok := c.answers[key]
+	if !ok {
+		return false, errors.Wrapf(ErrNotInTrace, "history check %s", key)
+	}
+	return agrees, nil
+}
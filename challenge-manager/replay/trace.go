@@ -0,0 +1,193 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package replay
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	protocol "github.com/OffchainLabs/bold/chain-abstraction"
+	l2stateprovider "github.com/OffchainLabs/bold/layer2-state-provider"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+// TraceVersion is the version of the trace format written by this package.
+const TraceVersion = 1
+
+// ErrNotInTrace is returned when a replay needs an answer the trace does not have,
+// such as a history commitment check the recorded validator never made.
+var ErrNotInTrace = errors.New("not in trace")
+
+// Trace of a single challenge, holding everything needed to replay the honest
+// validator's view of it without a chain.
+type Trace struct {
+	Version uint64 `json:"version"`
+	// Name of the validator whose state provider answered the history checks.
+	ValidatorName string `json:"validatorName"`
+	// Hash of the challenged assertion, the parent of the rival assertions.
+	ChallengedAssertion common.Hash `json:"challengedAssertion"`
+	// Block at which the first child of the challenged assertion was created.
+	FirstChildBlock       uint64 `json:"firstChildBlock"`
+	ChallengePeriodBlocks uint64 `json:"challengePeriodBlocks"`
+	NumBigStepLevels      uint8  `json:"numBigStepLevels"`
+	// Range of blocks the events were recorded from, inclusive.
+	FromBlock uint64 `json:"fromBlock"`
+	ToBlock   uint64 `json:"toBlock"`
+
+	Assertions     []*AssertionRecord `json:"assertions"`
+	Edges          []*EdgeRecord      `json:"edges"`
+	Events         []*Event           `json:"events"`
+	HistoryAnswers []*HistoryAnswer   `json:"historyAnswers"`
+}
+
+// AssertionRecord holds what the challenge logic reads about an assertion.
+type AssertionRecord struct {
+	Hash         common.Hash                    `json:"hash"`
+	CreationInfo *protocol.AssertionCreatedInfo `json:"creationInfo"`
+	// Number of blocks the assertion was unrivaled for, as of the end of the
+	// recording if it had no rival by then.
+	UnrivaledBlocks uint64 `json:"unrivaledBlocks"`
+}
+
+// EdgeRecord holds the immutable properties of an edge in the challenge.
+type EdgeRecord struct {
+	Id             common.Hash     `json:"id"`
+	Level          uint8           `json:"level"`
+	OriginId       common.Hash     `json:"originId"`
+	MutualId       common.Hash     `json:"mutualId"`
+	ClaimId        *common.Hash    `json:"claimId,omitempty"`
+	MiniStaker     *common.Address `json:"miniStaker,omitempty"`
+	StartHeight    uint64          `json:"startHeight"`
+	StartRoot      common.Hash     `json:"startRoot"`
+	EndHeight      uint64          `json:"endHeight"`
+	EndRoot        common.Hash     `json:"endRoot"`
+	CreatedAtBlock uint64          `json:"createdAtBlock"`
+	// Heights at which the challenges above the edge's level originated.
+	OriginHeights []uint64 `json:"originHeights"`
+}
+
+// EventKind of a recorded event.
+type EventKind string
+
+const (
+	AssertionCreated            EventKind = "assertion_created"
+	EdgeAdded                   EventKind = "edge_added"
+	EdgeBisected                EventKind = "edge_bisected"
+	EdgeConfirmedByChildren     EventKind = "edge_confirmed_by_children"
+	EdgeConfirmedByClaim        EventKind = "edge_confirmed_by_claim"
+	EdgeConfirmedByTime         EventKind = "edge_confirmed_by_time"
+	EdgeConfirmedByOneStepProof EventKind = "edge_confirmed_by_one_step_proof"
+)
+
+// Event emitted on chain during the challenge. Only the fields relevant to its
+// kind are set.
+type Event struct {
+	Kind     EventKind `json:"kind"`
+	Block    uint64    `json:"block"`
+	LogIndex uint      `json:"logIndex"`
+	// Set for assertion creations.
+	AssertionHash common.Hash `json:"assertionHash"`
+	// Set for all edge events.
+	EdgeId common.Hash `json:"edgeId"`
+	// Set for bisections.
+	LowerChildId common.Hash `json:"lowerChildId"`
+	UpperChildId common.Hash `json:"upperChildId"`
+	// Set for confirmations by claim.
+	ClaimingEdgeId common.Hash `json:"claimingEdgeId"`
+}
+
+// HistoryAnswer is the state provider's answer to whether it agrees with a
+// history commitment.
+type HistoryAnswer struct {
+	ChallengeLevel              uint8       `json:"challengeLevel"`
+	WasmModuleRoot              common.Hash `json:"wasmModuleRoot"`
+	FromBatch                   uint64      `json:"fromBatch"`
+	ToBatch                     uint64      `json:"toBatch"`
+	UpperChallengeOriginHeights []uint64    `json:"upperChallengeOriginHeights"`
+	FromHeight                  uint64      `json:"fromHeight"`
+	UpToHeight                  *uint64     `json:"upToHeight,omitempty"`
+	Height                      uint64      `json:"height"`
+	MerkleRoot                  common.Hash `json:"merkleRoot"`
+	Agrees                      bool        `json:"agrees"`
+}
+
+func newHistoryAnswer(
+	challengeLevel protocol.ChallengeLevel,
+	request *l2stateprovider.HistoryCommitmentRequest,
+	commit l2stateprovider.History,
+	agrees bool,
+) *HistoryAnswer {
+	heights := make([]uint64, len(request.UpperChallengeOriginHeights))
+	for i, h := range request.UpperChallengeOriginHeights {
+		heights[i] = uint64(h)
+	}
+	answer := &HistoryAnswer{
+		ChallengeLevel:              challengeLevel.Uint8(),
+		WasmModuleRoot:              request.WasmModuleRoot,
+		FromBatch:                   uint64(request.FromBatch),
+		ToBatch:                     uint64(request.ToBatch),
+		UpperChallengeOriginHeights: heights,
+		FromHeight:                  uint64(request.FromHeight),
+		Height:                      commit.Height,
+		MerkleRoot:                  commit.MerkleRoot,
+		Agrees:                      agrees,
+	}
+	if request.UpToHeight.IsSome() {
+		upTo := uint64(request.UpToHeight.Unwrap())
+		answer.UpToHeight = &upTo
+	}
+	return answer
+}
+
+// Identifies the question an answer responds to.
+func (a *HistoryAnswer) key() string {
+	upTo := "none"
+	if a.UpToHeight != nil {
+		upTo = fmt.Sprint(*a.UpToHeight)
+	}
+	return fmt.Sprintf(
+		"%d/%#x/%d-%d/%v/%d-%s/%d:%#x",
+		a.ChallengeLevel,
+		a.WasmModuleRoot,
+		a.FromBatch,
+		a.ToBatch,
+		a.UpperChallengeOriginHeights,
+		a.FromHeight,
+		upTo,
+		a.Height,
+		a.MerkleRoot,
+	)
+}
+
+// Write the trace as JSON.
+func (t *Trace) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(t)
+}
+
+// ReadTrace reads a trace written by Write, checking that its version is supported.
+func ReadTrace(r io.Reader) (*Trace, error) {
+	t := &Trace{}
+	if err := json.NewDecoder(r).Decode(t); err != nil {
+		return nil, errors.Wrap(err, "could not decode trace")
+	}
+	if t.Version != TraceVersion {
+		return nil, fmt.Errorf("unsupported trace version %d, expected %d", t.Version, TraceVersion)
+	}
+	return t, nil
+}
+
+// Sorts events in the order they were emitted on chain.
+func sortEvents(events []*Event) {
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Block != events[j].Block {
+			return events[i].Block < events[j].Block
+		}
+		return events[i].LogIndex < events[j].LogIndex
+	})
+}
@@ -0,0 +1,35 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package challengemanager
+
+import (
+	protocol "github.com/OffchainLabs/bold/chain-abstraction"
+	watcher "github.com/OffchainLabs/bold/challenge-manager/chain-watcher"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// WithChallengeArchive sets the archive the summaries of completed challenges are
+// persisted to before they are evicted from memory. Without an archive, the summaries
+// are lost once evicted.
+func WithChallengeArchive(archive watcher.ChallengeArchive) Opt {
+	return func(val *Manager) {
+		val.challengeArchive = archive
+	}
+}
+
+// EvictChallenge releases what the challenge manager kept for a completed challenge
+// once the watcher evicts it: the challenge's edge trackers exit, and its edges and
+// claimed assertion are forgotten. The watcher no longer adds edges of the challenge,
+// so no trackers are spawned for them again.
+func (m *Manager) EvictChallenge(summary *watcher.ChallengeSummary) {
+	assertionHash := protocol.AssertionHash{Hash: summary.AssertionHash}
+	m.scheduler.EvictChallenge(assertionHash)
+	for _, edgeId := range summary.HonestEdgeIds {
+		m.trackedEdgeIds.Delete(protocol.EdgeId{Hash: edgeId})
+	}
+	if summary.ClaimedAssertionHash != (common.Hash{}) {
+		m.batchIndexForAssertionCache.Delete(protocol.AssertionHash{Hash: summary.ClaimedAssertionHash})
+	}
+	m.challengedAssertions.Delete(assertionHash)
+}
@@ -0,0 +1,395 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+// Package scheduler runs the actions of edge trackers on a bounded pool of workers,
+// prioritizing the edges closest to their challenge period deadline. Trackers are
+// woken up by chain events relevant to their challenge rather than polling on a
+// timer, with an idle interval as a fallback for progress that emits no events,
+// such as timers accumulating as blocks are produced.
+package scheduler
+
+import (
+	"container/heap"
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	protocol "github.com/OffchainLabs/bold/chain-abstraction"
+	utilTime "github.com/OffchainLabs/bold/time"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/pkg/errors"
+)
+
+var (
+	srvlog = log.New("service", "scheduler")
+
+	scheduledGauge = metrics.NewRegisteredGauge("arb/validator/scheduler/scheduled", nil)
+	readyGauge     = metrics.NewRegisteredGauge("arb/validator/scheduler/ready", nil)
+	runningGauge   = metrics.NewRegisteredGauge("arb/validator/scheduler/running", nil)
+	stepsCounter   = metrics.NewRegisteredCounter("arb/validator/scheduler/steps", nil)
+	wakeupsCounter = metrics.NewRegisteredCounter("arb/validator/scheduler/wakeups", nil)
+)
+
+func init() {
+	srvlog.SetHandler(log.StreamHandler(os.Stdout, log.LogfmtFormat()))
+}
+
+// Job is the pending work of an edge, run repeatedly until it is done.
+type Job interface {
+	EdgeId() protocol.EdgeId
+	// Headroom returns the number of blocks left before the challenge period
+	// deadline relevant to the job. Jobs with less headroom run first.
+	Headroom(ctx context.Context) (uint64, error)
+	// Step runs the next actions of the job, returning true once it is done.
+	Step(ctx context.Context) bool
+	// Exit is called once the job is no longer scheduled, either because it
+	// is done or because the scheduler is draining.
+	Exit()
+}
+
+// Scheduler runs the steps of jobs on a bounded pool of workers. A job is run
+// when woken up by an event in its challenge, or after the idle interval has
+// elapsed since its last step. Among the jobs ready to run, those with the
+// least headroom are run first.
+type Scheduler struct {
+	numWorkers   int
+	idleInterval time.Duration
+	timeRef      utilTime.Reference
+
+	lock       sync.Mutex
+	jobs       map[protocol.EdgeId]*entry
+	challenges map[protocol.AssertionHash]map[protocol.EdgeId]*entry
+	ready      *entryQueue
+	waiting    *entryQueue
+	seq        uint64
+	draining   bool
+	// Closed and replaced whenever the queues change, to notify idle workers.
+	changed chan struct{}
+}
+
+type entry struct {
+	job       Job
+	challenge protocol.AssertionHash
+	headroom  uint64
+	seq       uint64
+	nextRun   time.Time
+	running   bool
+	woken     bool
+	evicted   bool
+	// The queue the entry is in, if it is not running.
+	queue *entryQueue
+	index int
+}
+
+type Opt func(s *Scheduler)
+
+// WithWorkers sets the maximum number of job steps run concurrently. Defaults to 16.
+func WithWorkers(n int) Opt {
+	return func(s *Scheduler) {
+		s.numWorkers = n
+	}
+}
+
+// WithIdleInterval sets how long a job waits for a wakeup after a step before it
+// is run again regardless. Defaults to one minute.
+func WithIdleInterval(d time.Duration) Opt {
+	return func(s *Scheduler) {
+		s.idleInterval = d
+	}
+}
+
+// WithTimeReference sets the time reference used to run idle jobs again, which
+// is useful for testing with a fake time reference. Defaults to the real time.
+func WithTimeReference(ref utilTime.Reference) Opt {
+	return func(s *Scheduler) {
+		s.timeRef = ref
+	}
+}
+
+// New creates a scheduler. No jobs are run until it is started.
+func New(opts ...Opt) (*Scheduler, error) {
+	s := &Scheduler{
+		numWorkers:   16,
+		idleInterval: time.Minute,
+		timeRef:      utilTime.NewRealTimeReference(),
+		jobs:         make(map[protocol.EdgeId]*entry),
+		challenges:   make(map[protocol.AssertionHash]map[protocol.EdgeId]*entry),
+		ready: &entryQueue{less: func(a, b *entry) bool {
+			if a.headroom != b.headroom {
+				return a.headroom < b.headroom
+			}
+			return a.seq < b.seq
+		}},
+		waiting: &entryQueue{less: func(a, b *entry) bool {
+			return a.nextRun.Before(b.nextRun)
+		}},
+		changed: make(chan struct{}),
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	if s.numWorkers <= 0 {
+		return nil, errors.New("number of workers must be positive")
+	}
+	if s.idleInterval <= 0 {
+		return nil, errors.New("idle interval must be positive")
+	}
+	return s, nil
+}
+
+// Schedule adds a job for an edge in the challenge of the given assertion. New jobs
+// are run as soon as a worker is free, as their headroom is not yet known. Returns
+// false if a job for the edge is already scheduled or the scheduler is draining.
+func (s *Scheduler) Schedule(challenge protocol.AssertionHash, job Job) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.draining {
+		return false
+	}
+	if _, ok := s.jobs[job.EdgeId()]; ok {
+		return false
+	}
+	e := &entry{
+		job:       job,
+		challenge: challenge,
+		seq:       s.seq,
+	}
+	s.seq++
+	s.jobs[job.EdgeId()] = e
+	if _, ok := s.challenges[challenge]; !ok {
+		s.challenges[challenge] = make(map[protocol.EdgeId]*entry)
+	}
+	s.challenges[challenge][job.EdgeId()] = e
+	heap.Push(s.ready, e)
+	s.notifyLocked()
+	return true
+}
+
+// WakeChallenge makes every job in the challenge of the given assertion ready to
+// run. Jobs with a step in progress are run again once it completes.
+func (s *Scheduler) WakeChallenge(challenge protocol.AssertionHash) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	woken := false
+	for _, e := range s.challenges[challenge] {
+		switch {
+		case e.running:
+			e.woken = true
+		case e.queue == s.waiting:
+			heap.Remove(s.waiting, e.index)
+			heap.Push(s.ready, e)
+			woken = true
+		}
+	}
+	if woken {
+		wakeupsCounter.Inc(1)
+		s.notifyLocked()
+	}
+}
+
+// EvictChallenge removes every job in the challenge of the given assertion, such as
+// once the challenge is complete. Jobs without a step in progress exit right away,
+// while the others exit once their step completes.
+func (s *Scheduler) EvictChallenge(challenge protocol.AssertionHash) {
+	s.lock.Lock()
+	exited := make([]Job, 0, len(s.challenges[challenge]))
+	for _, e := range s.challenges[challenge] {
+		if e.running {
+			e.evicted = true
+			continue
+		}
+		if e.queue != nil {
+			heap.Remove(e.queue, e.index)
+		}
+		s.removeLocked(e)
+		exited = append(exited, e.job)
+	}
+	s.notifyLocked()
+	s.lock.Unlock()
+	for _, job := range exited {
+		job.Exit()
+	}
+}
+
+// NumJobs returns the number of scheduled jobs.
+func (s *Scheduler) NumJobs() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return len(s.jobs)
+}
+
+// Start runs the workers until the context is done or the scheduler has drained.
+// Steps are run with the given context.
+func (s *Scheduler) Start(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < s.numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.work(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+// Drain stops scheduling new jobs and running new steps. Jobs without a step in
+// progress exit right away, while the others exit once their step completes.
+func (s *Scheduler) Drain() {
+	s.lock.Lock()
+	if s.draining {
+		s.lock.Unlock()
+		return
+	}
+	s.draining = true
+	exited := make([]Job, 0, len(s.jobs))
+	for _, e := range s.jobs {
+		if !e.running {
+			s.removeLocked(e)
+			exited = append(exited, e.job)
+		}
+	}
+	s.ready.entries, s.waiting.entries = nil, nil
+	s.notifyLocked()
+	s.lock.Unlock()
+	for _, job := range exited {
+		job.Exit()
+	}
+}
+
+func (s *Scheduler) work(ctx context.Context) {
+	for {
+		e := s.next(ctx)
+		if e == nil {
+			return
+		}
+		stepsCounter.Inc(1)
+		done := e.job.Step(ctx)
+		var headroom uint64
+		var err error
+		if !done {
+			headroom, err = e.job.Headroom(ctx)
+			if err != nil {
+				srvlog.Debug("Could not compute job headroom", log.Ctx{"edgeId": e.job.EdgeId(), "err": err})
+			}
+		}
+		s.lock.Lock()
+		e.running = false
+		runningGauge.Dec(1)
+		if done || s.draining || e.evicted {
+			s.removeLocked(e)
+			s.notifyLocked()
+			s.lock.Unlock()
+			e.job.Exit()
+			continue
+		}
+		if err == nil {
+			e.headroom = headroom
+		}
+		if e.woken {
+			e.woken = false
+			heap.Push(s.ready, e)
+		} else {
+			e.nextRun = s.timeRef.Get().Add(s.idleInterval)
+			heap.Push(s.waiting, e)
+		}
+		s.notifyLocked()
+		s.lock.Unlock()
+	}
+}
+
+// Blocks until a job is ready to run and returns it, or returns nil if the
+// context is done or the scheduler is draining.
+func (s *Scheduler) next(ctx context.Context) *entry {
+	for {
+		s.lock.Lock()
+		if s.draining {
+			s.lock.Unlock()
+			return nil
+		}
+		now := s.timeRef.Get()
+		for s.waiting.Len() > 0 && !s.waiting.entries[0].nextRun.After(now) {
+			heap.Push(s.ready, heap.Pop(s.waiting))
+		}
+		if s.ready.Len() > 0 {
+			e := heap.Pop(s.ready).(*entry)
+			e.running = true
+			runningGauge.Inc(1)
+			readyGauge.Update(int64(s.ready.Len()))
+			s.lock.Unlock()
+			return e
+		}
+		var ticker utilTime.GenericTimeTicker
+		var timeout <-chan time.Time
+		if s.waiting.Len() > 0 {
+			ticker = s.timeRef.NewTicker(s.waiting.entries[0].nextRun.Sub(now))
+			timeout = ticker.C()
+		}
+		changed := s.changed
+		s.lock.Unlock()
+
+		select {
+		case <-changed:
+		case <-timeout:
+		case <-ctx.Done():
+		}
+		if ticker != nil {
+			ticker.Stop()
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+func (s *Scheduler) removeLocked(e *entry) {
+	id := e.job.EdgeId()
+	delete(s.jobs, id)
+	if jobs, ok := s.challenges[e.challenge]; ok {
+		delete(jobs, id)
+		if len(jobs) == 0 {
+			delete(s.challenges, e.challenge)
+		}
+	}
+}
+
+func (s *Scheduler) notifyLocked() {
+	scheduledGauge.Update(int64(len(s.jobs)))
+	readyGauge.Update(int64(s.ready.Len()))
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// A priority queue of entries, which tracks the index of each entry within it.
+type entryQueue struct {
+	entries []*entry
+	less    func(a, b *entry) bool
+}
+
+func (q *entryQueue) Len() int { return len(q.entries) }
+
+func (q *entryQueue) Less(i, j int) bool { return q.less(q.entries[i], q.entries[j]) }
+
+func (q *entryQueue) Swap(i, j int) {
+	q.entries[i], q.entries[j] = q.entries[j], q.entries[i]
+	q.entries[i].index = i
+	q.entries[j].index = j
+}
+
+func (q *entryQueue) Push(x any) {
+	e := x.(*entry)
+	e.queue = q
+	e.index = len(q.entries)
+	q.entries = append(q.entries, e)
+}
+
+func (q *entryQueue) Pop() any {
+	n := len(q.entries)
+	e := q.entries[n-1]
+	q.entries[n-1] = nil
+	e.queue = nil
+	e.index = -1
+	q.entries = q.entries[:n-1]
+	return e
+}
@@ -0,0 +1,248 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	protocol "github.com/OffchainLabs/bold/chain-abstraction"
+	utilTime "github.com/OffchainLabs/bold/time"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+type steps struct {
+	lock  sync.Mutex
+	order []string
+}
+
+func (s *steps) add(name string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.order = append(s.order, name)
+}
+
+func (s *steps) get() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]string{}, s.order...)
+}
+
+type fakeJob struct {
+	name     string
+	headroom uint64
+	steps    *steps
+	// The job is done after this many steps, if non-zero.
+	doneAfter int
+	numSteps  int
+	// If set, called at the start of every step.
+	onStep func()
+	exited atomic.Bool
+}
+
+func (j *fakeJob) EdgeId() protocol.EdgeId {
+	return protocol.EdgeId{Hash: common.BytesToHash([]byte(j.name))}
+}
+
+func (j *fakeJob) Headroom(_ context.Context) (uint64, error) {
+	return j.headroom, nil
+}
+
+func (j *fakeJob) Step(_ context.Context) bool {
+	if j.onStep != nil {
+		j.onStep()
+	}
+	j.steps.add(j.name)
+	j.numSteps++
+	return j.doneAfter != 0 && j.numSteps >= j.doneAfter
+}
+
+func (j *fakeJob) Exit() {
+	j.exited.Store(true)
+}
+
+var (
+	challengeA = protocol.AssertionHash{Hash: common.BytesToHash([]byte("a"))}
+	challengeB = protocol.AssertionHash{Hash: common.BytesToHash([]byte("b"))}
+)
+
+func TestScheduler_PrioritizesLeastHeadroom(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s, err := New(WithWorkers(1), WithIdleInterval(time.Hour))
+	require.NoError(t, err)
+
+	st := &steps{}
+	require.True(t, s.Schedule(challengeA, &fakeJob{name: "far", headroom: 30, steps: st}))
+	require.True(t, s.Schedule(challengeA, &fakeJob{name: "urgent", headroom: 10, steps: st}))
+	require.True(t, s.Schedule(challengeA, &fakeJob{name: "near", headroom: 20, steps: st}))
+	require.False(t, s.Schedule(challengeA, &fakeJob{name: "near", steps: st}), "already scheduled")
+	go s.Start(ctx)
+
+	// Headroom is unknown before the first step, so new jobs run in order.
+	require.Eventually(t, func() bool { return len(st.get()) == 3 }, time.Second, 10*time.Millisecond)
+	require.Equal(t, []string{"far", "urgent", "near"}, st.get())
+
+	// Woken up jobs then run by increasing headroom.
+	s.WakeChallenge(challengeA)
+	require.Eventually(t, func() bool { return len(st.get()) == 6 }, time.Second, 10*time.Millisecond)
+	require.Equal(t, []string{"urgent", "near", "far"}, st.get()[3:])
+}
+
+func TestScheduler_WakeChallenge(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s, err := New(WithWorkers(2), WithIdleInterval(time.Hour))
+	require.NoError(t, err)
+
+	st := &steps{}
+	require.True(t, s.Schedule(challengeA, &fakeJob{name: "a", steps: st}))
+	require.True(t, s.Schedule(challengeB, &fakeJob{name: "b", steps: st}))
+	go s.Start(ctx)
+	require.Eventually(t, func() bool { return len(st.get()) == 2 }, time.Second, 10*time.Millisecond)
+
+	// Only the jobs of the woken up challenge run again before the idle interval.
+	s.WakeChallenge(challengeB)
+	require.Eventually(t, func() bool { return len(st.get()) == 3 }, time.Second, 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, []string{"b"}, st.get()[2:])
+}
+
+func TestScheduler_IdleIntervalAndDoneJobs(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s, err := New(WithIdleInterval(10 * time.Millisecond))
+	require.NoError(t, err)
+
+	job := &fakeJob{name: "a", steps: &steps{}, doneAfter: 3}
+	require.True(t, s.Schedule(challengeA, job))
+	go s.Start(ctx)
+
+	require.Eventually(t, job.exited.Load, time.Second, 10*time.Millisecond)
+	require.Len(t, job.steps.get(), 3)
+	require.Equal(t, 0, s.NumJobs())
+}
+
+func TestScheduler_IdleIntervalWithTimeReference(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	timeRef := utilTime.NewArtificialTimeReference()
+	s, err := New(WithWorkers(1), WithIdleInterval(time.Minute), WithTimeReference(timeRef))
+	require.NoError(t, err)
+
+	st := &steps{}
+	require.True(t, s.Schedule(challengeA, &fakeJob{name: "far", headroom: 30, steps: st}))
+	require.True(t, s.Schedule(challengeA, &fakeJob{name: "urgent", headroom: 10, steps: st}))
+	go s.Start(ctx)
+	require.Eventually(t, func() bool { return len(st.get()) == 2 }, time.Second, 10*time.Millisecond)
+
+	// Jobs are not run again before the idle interval has elapsed.
+	timeRef.Add(30 * time.Second)
+	time.Sleep(50 * time.Millisecond)
+	require.Len(t, st.get(), 2)
+
+	// Once it has, they run by increasing headroom.
+	timeRef.Add(30 * time.Second)
+	require.Eventually(t, func() bool { return len(st.get()) == 4 }, time.Second, 10*time.Millisecond)
+	require.Equal(t, []string{"urgent", "far"}, st.get()[2:])
+}
+
+func TestScheduler_BoundedWorkers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s, err := New(WithWorkers(2), WithIdleInterval(time.Hour))
+	require.NoError(t, err)
+
+	var running, maxRunning atomic.Int64
+	onStep := func() {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for m := maxRunning.Load(); n > m && !maxRunning.CompareAndSwap(m, n); m = maxRunning.Load() {
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	st := &steps{}
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		require.True(t, s.Schedule(challengeA, &fakeJob{name: name, steps: st, doneAfter: 1, onStep: onStep}))
+	}
+	go s.Start(ctx)
+	require.Eventually(t, func() bool { return s.NumJobs() == 0 }, time.Second, 10*time.Millisecond)
+	require.Len(t, st.get(), 5)
+	require.Equal(t, int64(2), maxRunning.Load())
+}
+
+func TestScheduler_Drain(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s, err := New(WithWorkers(1), WithIdleInterval(time.Hour))
+	require.NoError(t, err)
+
+	st := &steps{}
+	started, unblock := make(chan struct{}), make(chan struct{})
+	inFlight := &fakeJob{name: "in-flight", steps: st, onStep: func() {
+		close(started)
+		<-unblock
+	}}
+	idle := &fakeJob{name: "idle", steps: st}
+	require.True(t, s.Schedule(challengeA, inFlight))
+	require.True(t, s.Schedule(challengeA, idle))
+	done := make(chan struct{})
+	go func() {
+		s.Start(ctx)
+		close(done)
+	}()
+	<-started
+
+	// Idle jobs exit right away, while in-flight steps are allowed to complete.
+	s.Drain()
+	require.True(t, idle.exited.Load())
+	require.False(t, inFlight.exited.Load())
+	require.False(t, s.Schedule(challengeA, &fakeJob{name: "new", steps: st}))
+
+	close(unblock)
+	<-done
+	require.True(t, inFlight.exited.Load())
+	require.Equal(t, []string{"in-flight"}, st.get())
+	require.Equal(t, 0, s.NumJobs())
+}
+
+func TestScheduler_EvictChallenge(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s, err := New(WithWorkers(1), WithIdleInterval(time.Hour))
+	require.NoError(t, err)
+
+	st := &steps{}
+	started, unblock := make(chan struct{}), make(chan struct{})
+	inFlight := &fakeJob{name: "in-flight", steps: st, onStep: func() {
+		close(started)
+		<-unblock
+	}}
+	queued := &fakeJob{name: "queued", steps: st}
+	other := &fakeJob{name: "other", steps: st}
+	require.True(t, s.Schedule(challengeA, inFlight))
+	require.True(t, s.Schedule(challengeA, queued))
+	require.True(t, s.Schedule(challengeB, other))
+	go s.Start(ctx)
+	<-started
+
+	// Queued jobs of the challenge exit right away, and in-flight ones once their
+	// step completes. Jobs of other challenges keep running.
+	s.EvictChallenge(challengeA)
+	require.True(t, queued.exited.Load())
+	require.False(t, inFlight.exited.Load())
+	close(unblock)
+	require.Eventually(t, inFlight.exited.Load, time.Second, 10*time.Millisecond)
+	require.Eventually(t, func() bool { return len(st.get()) == 2 }, time.Second, 10*time.Millisecond)
+	require.Equal(t, []string{"in-flight", "other"}, st.get())
+	require.False(t, other.exited.Load())
+	require.Equal(t, 1, s.NumJobs())
+
+	// Evicted jobs can be scheduled again.
+	require.True(t, s.Schedule(challengeA, &fakeJob{name: "queued", steps: st}))
+}
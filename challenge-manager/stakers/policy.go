@@ -0,0 +1,98 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package stakers
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+// ErrNoEligibleIdentity is returned by a policy if no candidate satisfies it.
+var ErrNoEligibleIdentity = errors.New("no eligible staker identity")
+
+// Policy selects one of the candidate identities for a role. Candidates are
+// never empty and are ordered as configured for the role.
+type Policy interface {
+	Select(role Role, candidates []Identity) (common.Address, error)
+}
+
+// PolicyFunc adapts a function to a policy.
+type PolicyFunc func(role Role, candidates []Identity) (common.Address, error)
+
+func (f PolicyFunc) Select(role Role, candidates []Identity) (common.Address, error) {
+	return f(role, candidates)
+}
+
+// Fixed always selects the given identity, if it is a candidate.
+func Fixed(addr common.Address) Policy {
+	return PolicyFunc(func(_ Role, candidates []Identity) (common.Address, error) {
+		for _, c := range candidates {
+			if c.Address == addr {
+				return addr, nil
+			}
+		}
+		return common.Address{}, ErrNoEligibleIdentity
+	})
+}
+
+type roundRobin struct {
+	lock sync.Mutex
+	next int
+}
+
+// RoundRobin rotates through the candidates on every selection.
+func RoundRobin() Policy {
+	return &roundRobin{}
+}
+
+func (r *roundRobin) Select(_ Role, candidates []Identity) (common.Address, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	c := candidates[r.next%len(candidates)]
+	r.next++
+	return c.Address, nil
+}
+
+// PreferUnstaked narrows the candidates to unstaked identities, if there are any,
+// before deferring to the next policy. Rivals can only be staked with a fresh
+// stake, so an identity already staked elsewhere would have to be withdrawn first.
+func PreferUnstaked(next Policy) Policy {
+	return PolicyFunc(func(role Role, candidates []Identity) (common.Address, error) {
+		unstaked := make([]Identity, 0, len(candidates))
+		for _, c := range candidates {
+			if !c.Staked {
+				unstaked = append(unstaked, c)
+			}
+		}
+		if len(unstaked) == 0 {
+			return next.Select(role, candidates)
+		}
+		return next.Select(role, unstaked)
+	})
+}
+
+// HighestBalance selects the candidate with the highest balance, rotating
+// stake costs towards the best funded keys. Candidates below the minimum
+// balance, or whose balance is unknown, are never selected.
+func HighestBalance(minBalance *big.Int) Policy {
+	return PolicyFunc(func(_ Role, candidates []Identity) (common.Address, error) {
+		var best *Identity
+		for i := range candidates {
+			c := &candidates[i]
+			if c.Balance == nil || (minBalance != nil && c.Balance.Cmp(minBalance) < 0) {
+				continue
+			}
+			if best == nil || c.Balance.Cmp(best.Balance) > 0 {
+				best = c
+			}
+		}
+		if best == nil {
+			return common.Address{}, ErrNoEligibleIdentity
+		}
+		return best.Address, nil
+	})
+}
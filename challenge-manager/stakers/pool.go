@@ -0,0 +1,253 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+// Package stakers manages a pool of staker identities controlled by a single
+// challenge manager process, selecting which key to use for each kind of
+// stake through configurable policies and tracking the status of each key.
+package stakers
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+
+	protocol "github.com/OffchainLabs/bold/chain-abstraction"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/pkg/errors"
+)
+
+var srvlog = log.New("service", "stakers")
+
+func init() {
+	srvlog.SetHandler(log.StreamHandler(os.Stdout, log.LogfmtFormat()))
+}
+
+// Role is a kind of stake an identity can be selected for.
+type Role uint8
+
+const (
+	// AssertionPoster stakes on assertions following our local chain.
+	AssertionPoster Role = iota
+	// RivalStaker stakes on rivals to assertions we disagree with.
+	RivalStaker
+	// MiniStaker funds the mini-stakes of level zero challenge edges.
+	MiniStaker
+)
+
+func (r Role) String() string {
+	switch r {
+	case AssertionPoster:
+		return "assertion_poster"
+	case RivalStaker:
+		return "rival_staker"
+	case MiniStaker:
+		return "mini_staker"
+	default:
+		return fmt.Sprintf("role(%d)", uint8(r))
+	}
+}
+
+// Identity is the tracked status of a staker key.
+type Identity struct {
+	Address  common.Address `json:"address"`
+	Staked   bool           `json:"staked"`
+	Balance  *big.Int       `json:"balance,omitempty"`
+	Uses     uint64         `json:"uses"`
+	LastUsed time.Time      `json:"lastUsed"`
+	LastRole string         `json:"lastRole,omitempty"`
+}
+
+// BalanceFunc reads the balance of an address relevant to staking.
+type BalanceFunc func(ctx context.Context, addr common.Address) (*big.Int, error)
+
+// Pool selects staker identities of a multi-staker chain for each role.
+type Pool struct {
+	chain    protocol.MultiStakerChain
+	balances BalanceFunc
+	roles    map[Role][]common.Address
+	policies map[Role]Policy
+
+	lock       sync.Mutex
+	identities map[common.Address]*Identity
+}
+
+type Opt func(p *Pool)
+
+// WithRole restricts the identities eligible for a role. By default, every
+// identity of the chain is eligible for every role.
+func WithRole(role Role, addrs ...common.Address) Opt {
+	return func(p *Pool) {
+		p.roles[role] = addrs
+	}
+}
+
+// WithPolicy sets the policy used to select an identity for a role.
+func WithPolicy(role Role, policy Policy) Opt {
+	return func(p *Pool) {
+		p.policies[role] = policy
+	}
+}
+
+// WithBalanceFunc sets how identity balances are read, for example to use the
+// balance of the stake token instead of the native balance used by default.
+func WithBalanceFunc(fn BalanceFunc) Opt {
+	return func(p *Pool) {
+		p.balances = fn
+	}
+}
+
+type balanceReader interface {
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+}
+
+// NewPool creates a pool over the staker keys of a chain. By default, assertions
+// are posted with the chain's primary key, rivals are staked by an unstaked key
+// where possible, and mini-stakes rotate across all keys.
+func NewPool(chain protocol.MultiStakerChain, opts ...Opt) (*Pool, error) {
+	addrs := chain.StakerAddresses()
+	if len(addrs) == 0 {
+		return nil, errors.New("chain has no staker keys")
+	}
+	p := &Pool{
+		chain: chain,
+		roles: make(map[Role][]common.Address),
+		policies: map[Role]Policy{
+			AssertionPoster: Fixed(addrs[0]),
+			RivalStaker:     PreferUnstaked(RoundRobin()),
+			MiniStaker:      RoundRobin(),
+		},
+		identities: make(map[common.Address]*Identity),
+	}
+	if reader, ok := chain.Backend().(balanceReader); ok {
+		p.balances = func(ctx context.Context, addr common.Address) (*big.Int, error) {
+			return reader.BalanceAt(ctx, addr, nil)
+		}
+	}
+	for _, addr := range addrs {
+		p.identities[addr] = &Identity{Address: addr}
+	}
+	for _, o := range opts {
+		o(p)
+	}
+	for role, roleAddrs := range p.roles {
+		if len(roleAddrs) == 0 {
+			return nil, fmt.Errorf("no identities configured for role %s", role)
+		}
+		for _, addr := range roleAddrs {
+			if _, ok := p.identities[addr]; !ok {
+				return nil, fmt.Errorf("identity %#x for role %s is not a staker key of the chain", addr, role)
+			}
+		}
+	}
+	return p, nil
+}
+
+// Selection is an identity selected for a role, along with a view of the chain
+// acting on its behalf.
+type Selection struct {
+	protocol.AssertionChain
+	Address common.Address
+	role    Role
+	pool    *Pool
+}
+
+// Used records a use of the selected identity. It is called once the transaction
+// the identity was selected for succeeded, so that failed attempts are not counted.
+func (s *Selection) Used() {
+	s.pool.lock.Lock()
+	defer s.pool.lock.Unlock()
+	id := s.pool.identities[s.Address]
+	id.Uses++
+	id.LastUsed = time.Now()
+	id.LastRole = s.role.String()
+}
+
+// Select chooses an identity for a role and returns a view of the chain acting
+// on its behalf. The status of the eligible identities is refreshed first so
+// that policies can take stake status and balances into account.
+func (p *Pool) Select(ctx context.Context, role Role) (*Selection, error) {
+	candidates, err := p.refresh(ctx, p.eligible(role))
+	if err != nil {
+		return nil, err
+	}
+	policy, ok := p.policies[role]
+	if !ok {
+		return nil, fmt.Errorf("no policy configured for role %s", role)
+	}
+	addr, err := policy.Select(role, candidates)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not select identity for role %s", role)
+	}
+	chain, err := p.chain.ForStaker(addr)
+	if err != nil {
+		return nil, err
+	}
+	srvlog.Debug("Selected staker identity", log.Ctx{"role": role.String(), "address": addr})
+	return &Selection{AssertionChain: chain, Address: addr, role: role, pool: p}, nil
+}
+
+// Refresh updates the stake status and balance of every identity.
+func (p *Pool) Refresh(ctx context.Context) error {
+	_, err := p.refresh(ctx, p.chain.StakerAddresses())
+	return err
+}
+
+// Identities returns a snapshot of the status of every identity, in the order
+// of the chain's staker keys.
+func (p *Pool) Identities() []Identity {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	addrs := p.chain.StakerAddresses()
+	ids := make([]Identity, len(addrs))
+	for i, addr := range addrs {
+		ids[i] = p.snapshotLocked(addr)
+	}
+	return ids
+}
+
+func (p *Pool) eligible(role Role) []common.Address {
+	if addrs, ok := p.roles[role]; ok {
+		return addrs
+	}
+	return p.chain.StakerAddresses()
+}
+
+func (p *Pool) refresh(ctx context.Context, addrs []common.Address) ([]Identity, error) {
+	candidates := make([]Identity, len(addrs))
+	for i, addr := range addrs {
+		chain, err := p.chain.ForStaker(addr)
+		if err != nil {
+			return nil, err
+		}
+		staked, err := chain.IsStaked(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not check stake of %#x", addr)
+		}
+		var balance *big.Int
+		if p.balances != nil {
+			balance, err = p.balances(ctx, addr)
+			if err != nil {
+				return nil, errors.Wrapf(err, "could not read balance of %#x", addr)
+			}
+		}
+		p.lock.Lock()
+		id := p.identities[addr]
+		id.Staked = staked
+		id.Balance = balance
+		candidates[i] = p.snapshotLocked(addr)
+		p.lock.Unlock()
+	}
+	return candidates, nil
+}
+
+func (p *Pool) snapshotLocked(addr common.Address) Identity {
+	id := *p.identities[addr]
+	if id.Balance != nil {
+		id.Balance = new(big.Int).Set(id.Balance)
+	}
+	return id
+}
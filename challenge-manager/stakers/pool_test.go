@@ -0,0 +1,138 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package stakers_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	protocol "github.com/OffchainLabs/bold/chain-abstraction"
+	solimpl "github.com/OffchainLabs/bold/chain-abstraction/sol-implementation"
+	"github.com/OffchainLabs/bold/challenge-manager/stakers"
+	"github.com/OffchainLabs/bold/testing/setup"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func setupMultiStakerChain(t *testing.T) (*solimpl.AssertionChain, *setup.ChainSetup) {
+	t.Helper()
+	cfg, err := setup.ChainsWithEdgeChallengeManager()
+	require.NoError(t, err)
+	chain, err := solimpl.NewAssertionChain(
+		context.Background(),
+		cfg.Addrs.Rollup,
+		cfg.Accounts[1].TxOpts,
+		cfg.Backend,
+		solimpl.WithStakerIdentities(cfg.Accounts[2].TxOpts, cfg.Accounts[3].TxOpts),
+	)
+	require.NoError(t, err)
+	return chain, cfg
+}
+
+func TestPool_Select(t *testing.T) {
+	ctx := context.Background()
+	chain, cfg := setupMultiStakerChain(t)
+	addrs := chain.StakerAddresses()
+	require.Equal(t, 3, len(addrs))
+
+	pool, err := stakers.NewPool(chain, stakers.WithRole(stakers.MiniStaker, addrs[1], addrs[2]))
+	require.NoError(t, err)
+
+	// Assertions are posted with the primary key by default.
+	poster, err := pool.Select(ctx, stakers.AssertionPoster)
+	require.NoError(t, err)
+	require.Equal(t, addrs[0], poster.Address)
+
+	// Stake the primary key, so rivals prefer the other, unstaked keys.
+	genesisHash, err := chain.GenesisAssertionHash(ctx)
+	require.NoError(t, err)
+	genesisInfo, err := chain.ReadAssertionCreationInfo(ctx, protocol.AssertionHash{Hash: genesisHash})
+	require.NoError(t, err)
+	for i := uint64(0); i < 100; i++ {
+		cfg.Backend.Commit()
+	}
+	_, err = poster.NewStakeOnNewAssertion(ctx, genesisInfo, &protocol.ExecutionState{
+		GlobalState:   protocol.GoGlobalState{BlockHash: common.BytesToHash([]byte("foo")), Batch: 1},
+		MachineStatus: protocol.MachineStatusFinished,
+	})
+	require.NoError(t, err)
+	poster.Used()
+	for i := 0; i < 4; i++ {
+		rival, err := pool.Select(ctx, stakers.RivalStaker)
+		require.NoError(t, err)
+		staked, err := rival.IsStaked(ctx)
+		require.NoError(t, err)
+		require.False(t, staked)
+		rival.Used()
+	}
+
+	ids := pool.Identities()
+	require.Equal(t, 3, len(ids))
+	require.True(t, ids[0].Staked)
+	require.False(t, ids[1].Staked)
+	require.Equal(t, uint64(1), ids[0].Uses)
+	require.Equal(t, uint64(2), ids[1].Uses)
+	require.Equal(t, uint64(2), ids[2].Uses)
+	require.Equal(t, stakers.RivalStaker.String(), ids[1].LastRole)
+	require.NotNil(t, ids[1].Balance)
+
+	// Mini-stakes rotate across the keys configured for them only.
+	for i := 0; i < 4; i++ {
+		miniStaker, err := pool.Select(ctx, stakers.MiniStaker)
+		require.NoError(t, err)
+		require.Equal(t, addrs[1+i%2], miniStaker.Address)
+		miniStaker.Used()
+	}
+	ids = pool.Identities()
+	require.Equal(t, uint64(1), ids[0].Uses)
+	require.Equal(t, uint64(4), ids[1].Uses)
+	require.Equal(t, uint64(4), ids[2].Uses)
+
+	// Selections whose transaction did not succeed are not counted as uses.
+	_, err = pool.Select(ctx, stakers.MiniStaker)
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), pool.Identities()[1].Uses)
+}
+
+func TestPool_HighestBalance(t *testing.T) {
+	ctx := context.Background()
+	chain, _ := setupMultiStakerChain(t)
+	addrs := chain.StakerAddresses()
+	balances := map[common.Address]*big.Int{
+		addrs[0]: big.NewInt(10),
+		addrs[1]: big.NewInt(30),
+		addrs[2]: big.NewInt(20),
+	}
+	pool, err := stakers.NewPool(
+		chain,
+		stakers.WithBalanceFunc(func(_ context.Context, addr common.Address) (*big.Int, error) {
+			return balances[addr], nil
+		}),
+		stakers.WithPolicy(stakers.MiniStaker, stakers.HighestBalance(big.NewInt(15))),
+	)
+	require.NoError(t, err)
+
+	selected, err := pool.Select(ctx, stakers.MiniStaker)
+	require.NoError(t, err)
+	require.Equal(t, addrs[1], selected.Address)
+
+	// As the best funded key is spent, selection rotates to the next one.
+	balances[addrs[1]] = big.NewInt(15)
+	selected, err = pool.Select(ctx, stakers.MiniStaker)
+	require.NoError(t, err)
+	require.Equal(t, addrs[2], selected.Address)
+
+	// No key meets the minimum balance.
+	balances[addrs[1]] = big.NewInt(1)
+	balances[addrs[2]] = big.NewInt(1)
+	_, err = pool.Select(ctx, stakers.MiniStaker)
+	require.ErrorIs(t, err, stakers.ErrNoEligibleIdentity)
+}
+
+func TestNewPool_UnknownIdentity(t *testing.T) {
+	chain, cfg := setupMultiStakerChain(t)
+	_, err := stakers.NewPool(chain, stakers.WithRole(stakers.RivalStaker, cfg.Accounts[0].TxOpts.From))
+	require.ErrorContains(t, err, "is not a staker key of the chain")
+}
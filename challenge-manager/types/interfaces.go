@@ -24,4 +24,7 @@ type ChallengeCreator interface {
 type ChallengeReader interface {
 	Mode() Mode
 	MaxDelaySeconds() int
+	// IsLeader returns false if this instance is a follower in a high
+	// availability setup and should not act onchain.
+	IsLeader() bool
 }
@@ -1,5 +1,7 @@
 package types
 
+import "fmt"
+
 type Mode uint8
 
 const (
@@ -11,4 +13,33 @@ const (
 	ResolveMode
 	// Make nodes: continually create new nodes, challenging bad assertions
 	MakeMode
+	// Confirmer nodes: don't stake or make moves, but confirm every confirmable honest edge
+	// and assertion on behalf of others, within a gas budget. Unlike the other modes,
+	// confirmer mode is not ordered with respect to them.
+	ConfirmerMode
 )
+
+var modeNames = map[Mode]string{
+	WatchTowerMode: "watchtower",
+	DefensiveMode:  "defensive",
+	ResolveMode:    "resolve",
+	MakeMode:       "make",
+	ConfirmerMode:  "confirmer",
+}
+
+func (m Mode) String() string {
+	if name, ok := modeNames[m]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown(%d)", m)
+}
+
+// ParseMode parses the name of a mode, as returned by its String method.
+func ParseMode(name string) (Mode, error) {
+	for mode, modeName := range modeNames {
+		if modeName == name {
+			return mode, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown mode %q", name)
+}
@@ -0,0 +1,137 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+// Command bold provides operator tools for the BOLD challenge protocol.
+//
+// Usage:
+//
+//	bold estimate --rpc-url <url> --rollup <address> [flags]
+//
+// The estimate subcommand reads the parameters of the challenge manager of a rollup
+// and prints the worst-case cost and duration of a challenge as JSON, so that funds
+// can be budgeted before defending the chain.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"time"
+
+	solimpl "github.com/OffchainLabs/bold/chain-abstraction/sol-implementation"
+	"github.com/OffchainLabs/bold/challenge-manager/planner"
+	"github.com/OffchainLabs/bold/solgen/go/challengeV2gen"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	switch os.Args[1] {
+	case "estimate":
+		if err := estimate(os.Args[2:]); err != nil {
+			// skipcq: RVV-A0003
+			log.Fatal(err)
+		}
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: bold estimate --rpc-url <url> --rollup <address> [flags]")
+	os.Exit(2)
+}
+
+func estimate(args []string) error {
+	fs := flag.NewFlagSet("estimate", flag.ExitOnError)
+	rpcURL := fs.String("rpc-url", "", "RPC endpoint of the chain the rollup is deployed on")
+	rollup := fs.String("rollup", "", "address of the rollup contract")
+	blockTime := fs.Duration("block-time", 12*time.Second, "average time between blocks")
+	gasPriceFlag := fs.String("gas-price", "", "gas price in wei, defaults to the price suggested by the node")
+	gasCosts := planner.DefaultGasCosts
+	fs.Uint64Var(&gasCosts.CreateLayerZeroEdge, "gas-create-layer-zero-edge", gasCosts.CreateLayerZeroEdge, "gas used to create a level zero edge")
+	fs.Uint64Var(&gasCosts.BisectEdge, "gas-bisect-edge", gasCosts.BisectEdge, "gas used to bisect an edge")
+	fs.Uint64Var(&gasCosts.ConfirmEdgeByTime, "gas-confirm-by-time", gasCosts.ConfirmEdgeByTime, "gas used to confirm an edge by time")
+	fs.Uint64Var(&gasCosts.ConfirmEdgeByChildren, "gas-confirm-by-children", gasCosts.ConfirmEdgeByChildren, "gas used to confirm an edge by its children")
+	fs.Uint64Var(&gasCosts.ConfirmEdgeByClaim, "gas-confirm-by-claim", gasCosts.ConfirmEdgeByClaim, "gas used to confirm an edge by its claim")
+	fs.Uint64Var(&gasCosts.ConfirmEdgeByOneStepProof, "gas-confirm-by-osp", gasCosts.ConfirmEdgeByOneStepProof, "gas used to confirm an edge by a one-step proof")
+	fs.Uint64Var(&gasCosts.ConfirmAssertion, "gas-confirm-assertion", gasCosts.ConfirmAssertion, "gas used to confirm an assertion")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *rpcURL == "" || !common.IsHexAddress(*rollup) {
+		return fmt.Errorf("--rpc-url and a valid --rollup address are required")
+	}
+
+	ctx := context.Background()
+	client, err := ethclient.DialContext(ctx, *rpcURL)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	chain, err := solimpl.NewAssertionChain(ctx, common.HexToAddress(*rollup), &bind.TransactOpts{}, client)
+	if err != nil {
+		return err
+	}
+	manager, err := chain.SpecChallengeManager(ctx)
+	if err != nil {
+		return err
+	}
+	heights, err := manager.LayerZeroHeights(ctx)
+	if err != nil {
+		return err
+	}
+	numBigSteps, err := manager.NumBigSteps(ctx)
+	if err != nil {
+		return err
+	}
+	challengePeriodBlocks, err := manager.ChallengePeriodBlocks(ctx)
+	if err != nil {
+		return err
+	}
+	caller, err := challengeV2gen.NewEdgeChallengeManagerCaller(manager.Address(), client)
+	if err != nil {
+		return err
+	}
+	miniStake, err := caller.StakeAmount(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return err
+	}
+	var gasPrice *big.Int
+	if *gasPriceFlag != "" {
+		var ok bool
+		gasPrice, ok = new(big.Int).SetString(*gasPriceFlag, 10)
+		if !ok {
+			return fmt.Errorf("invalid --gas-price %q", *gasPriceFlag)
+		}
+	} else {
+		gasPrice, err = client.SuggestGasPrice(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	est, err := planner.EstimateChallenge(&planner.Params{
+		LayerZeroHeights:      *heights,
+		NumBigSteps:           numBigSteps,
+		ChallengePeriodBlocks: challengePeriodBlocks,
+		MiniStake:             miniStake,
+		GasPrice:              gasPrice,
+		BlockTime:             *blockTime,
+		GasCosts:              &gasCosts,
+	})
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(est)
+}
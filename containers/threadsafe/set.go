@@ -25,6 +25,17 @@ func (s *Set[T]) Insert(t T) {
 	s.items[t] = true
 }
 
+// InsertIfAbsent inserts an item, returning false if it was already in the set.
+func (s *Set[T]) InsertIfAbsent(t T) bool {
+	s.Lock()
+	defer s.Unlock()
+	if s.items[t] {
+		return false
+	}
+	s.items[t] = true
+	return true
+}
+
 func (s *Set[T]) NumItems() uint64 {
 	s.RLock()
 	defer s.RUnlock()
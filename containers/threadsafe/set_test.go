@@ -19,6 +19,19 @@ func TestInsert(t *testing.T) {
 	}
 }
 
+func TestInsertIfAbsent(t *testing.T) {
+	s := NewSet[int]()
+	if !s.InsertIfAbsent(1) {
+		t.Errorf("Expected item to be inserted")
+	}
+	if s.InsertIfAbsent(1) {
+		t.Errorf("Expected item to already exist")
+	}
+	if s.NumItems() != 1 {
+		t.Errorf("Expected 1 item, got %d", s.NumItems())
+	}
+}
+
 func TestHasSet(t *testing.T) {
 	s := NewSet[int]()
 	s.Insert(1)
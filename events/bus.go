@@ -0,0 +1,225 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+// Package events defines an in-process publish/subscribe bus carrying typed protocol
+// events, such as edges being added or assertions being confirmed. The chain watcher
+// and assertion scanner publish what they observe onchain once, and other components
+// of the validator, as well as user plugins, subscribe to the events they need instead
+// of observing the chain on their own.
+package events
+
+import (
+	"context"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	srvlog           = log.New("service", "events")
+	publishedCounter = metrics.NewRegisteredCounter("arb/validator/events/published", nil)
+	droppedCounter   = metrics.NewRegisteredCounter("arb/validator/events/dropped", nil)
+)
+
+func init() {
+	srvlog.SetHandler(log.StreamHandler(os.Stdout, log.LogfmtFormat()))
+}
+
+const defaultBufferSize = 256
+
+// Backpressure defines what happens to events published while a subscriber's
+// buffer is full.
+type Backpressure uint8
+
+const (
+	// DropOldest discards the oldest buffered event to make room for the new one.
+	DropOldest Backpressure = iota
+	// DropNewest discards the new event, keeping the buffered ones.
+	DropNewest
+	// Block makes publishers wait until the subscriber has room for the event or
+	// the publishing context is done. Blocking subscribers slow down the chain
+	// watcher and assertion scanner, so should only be used by components which
+	// must see every event and keep up with them.
+	Block
+)
+
+type SubscribeOpt func(s *Subscription)
+
+// WithKinds only delivers events of the given kinds to the subscriber.
+func WithKinds(kinds ...Kind) SubscribeOpt {
+	return func(s *Subscription) {
+		s.kinds = make(map[Kind]bool, len(kinds))
+		for _, k := range kinds {
+			s.kinds[k] = true
+		}
+	}
+}
+
+// WithBufferSize sets the number of events buffered for the subscriber. Defaults to 256.
+func WithBufferSize(size int) SubscribeOpt {
+	return func(s *Subscription) {
+		s.bufferSize = size
+	}
+}
+
+// WithBackpressure sets what happens to events published while the subscriber's
+// buffer is full. Defaults to dropping the oldest event.
+func WithBackpressure(b Backpressure) SubscribeOpt {
+	return func(s *Subscription) {
+		s.backpressure = b
+	}
+}
+
+// Bus delivers published events to its subscribers. A nil bus is valid and drops
+// every event, so components can hold an optional bus without checking for it at
+// every call site.
+type Bus struct {
+	// Publishers hold the read lock while delivering, so that subscriptions are
+	// only closed once no publisher can send to them.
+	lock sync.RWMutex
+	subs map[*Subscription]struct{}
+}
+
+// NewBus creates an event bus without subscribers.
+func NewBus() *Bus {
+	return &Bus{
+		subs: make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish delivers an event to every subscriber of its kind, applying each
+// subscriber's backpressure policy if its buffer is full.
+func (b *Bus) Publish(ctx context.Context, ev Event) {
+	if b == nil || ev == nil {
+		return
+	}
+	publishedCounter.Inc(1)
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	for s := range b.subs {
+		if s.wants(ev.Kind()) {
+			s.deliver(ctx, ev)
+		}
+	}
+}
+
+// Subscribe to events published on the bus. The name identifies the subscriber in
+// logs. Subscribers must call Unsubscribe once they no longer read events. Nothing
+// is ever delivered to subscribers of a nil bus.
+func (b *Bus) Subscribe(name string, opts ...SubscribeOpt) *Subscription {
+	s := &Subscription{
+		name:       name,
+		bufferSize: defaultBufferSize,
+		done:       make(chan struct{}),
+		bus:        b,
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	s.ch = make(chan Event, s.bufferSize)
+	if b == nil {
+		return s
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.subs[s] = struct{}{}
+	return s
+}
+
+// NumSubscribers currently subscribed to the bus.
+func (b *Bus) NumSubscribers() int {
+	if b == nil {
+		return 0
+	}
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	return len(b.subs)
+}
+
+// Subscription to events published on a bus.
+type Subscription struct {
+	name         string
+	kinds        map[Kind]bool
+	bufferSize   int
+	backpressure Backpressure
+	ch           chan Event
+	done         chan struct{}
+	closeOnce    sync.Once
+	dropped      atomic.Uint64
+	bus          *Bus
+}
+
+// Events delivered to the subscriber. The channel is closed once unsubscribed.
+func (s *Subscription) Events() <-chan Event {
+	return s.ch
+}
+
+// Dropped is the number of events which were not delivered to the subscriber
+// because its buffer was full.
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Unsubscribe from the bus, closing the events channel. Safe to call more than once.
+func (s *Subscription) Unsubscribe() {
+	s.closeOnce.Do(func() {
+		// Publishers blocked on the subscriber give up before the subscription is
+		// removed, as they hold the bus' read lock.
+		close(s.done)
+		if s.bus != nil {
+			s.bus.lock.Lock()
+			delete(s.bus.subs, s)
+			s.bus.lock.Unlock()
+		}
+		close(s.ch)
+	})
+}
+
+func (s *Subscription) wants(kind Kind) bool {
+	return s.kinds == nil || s.kinds[kind]
+}
+
+func (s *Subscription) deliver(ctx context.Context, ev Event) {
+	select {
+	case s.ch <- ev:
+		return
+	default:
+	}
+	switch s.backpressure {
+	case Block:
+		select {
+		case s.ch <- ev:
+		case <-s.done:
+		case <-ctx.Done():
+			s.drop(ev)
+		}
+	case DropNewest:
+		s.drop(ev)
+	default:
+		// Other publishers may race to fill the freed slot, in which case the
+		// new event is dropped instead.
+		select {
+		case oldest := <-s.ch:
+			s.drop(oldest)
+		default:
+		}
+		select {
+		case s.ch <- ev:
+		default:
+			s.drop(ev)
+		}
+	}
+}
+
+func (s *Subscription) drop(ev Event) {
+	droppedCounter.Inc(1)
+	if s.dropped.Add(1) == 1 {
+		srvlog.Warn("Subscriber is not keeping up with events, dropping them", log.Ctx{
+			"subscriber": s.name,
+			"kind":       ev.Kind(),
+		})
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	protocol "github.com/OffchainLabs/bold/chain-abstraction"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+func assertionCreated(i byte) *AssertionCreated {
+	return &AssertionCreated{AssertionHash: protocol.AssertionHash{Hash: common.Hash{i}}}
+}
+
+func receive(t *testing.T, s *Subscription) Event {
+	t.Helper()
+	select {
+	case ev := <-s.Events():
+		return ev
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+		return nil
+	}
+}
+
+func TestBus_Publish(t *testing.T) {
+	ctx := context.Background()
+	b := NewBus()
+	all := b.Subscribe("all")
+	defer all.Unsubscribe()
+	edges := b.Subscribe("edges", WithKinds(KindEdgeAdded, KindEdgeConfirmed))
+	defer edges.Unsubscribe()
+
+	b.Publish(ctx, assertionCreated(1))
+	b.Publish(ctx, &EdgeConfirmed{EdgeId: protocol.EdgeId{Hash: common.Hash{2}}, By: ByTime})
+
+	require.Equal(t, assertionCreated(1), receive(t, all))
+	ev := receive(t, all)
+	require.Equal(t, KindEdgeConfirmed, ev.Kind())
+
+	// Only events of the subscribed kinds are delivered.
+	confirmed, ok := receive(t, edges).(*EdgeConfirmed)
+	require.True(t, ok)
+	require.Equal(t, ByTime, confirmed.By)
+	require.Empty(t, edges.Events())
+}
+
+func TestBus_NilBusDropsEvents(t *testing.T) {
+	var b *Bus
+	sub := b.Subscribe("sub")
+	b.Publish(context.Background(), assertionCreated(1))
+	require.Empty(t, sub.Events())
+	require.Equal(t, 0, b.NumSubscribers())
+	sub.Unsubscribe()
+	_, ok := <-sub.Events()
+	require.False(t, ok)
+}
+
+func TestBus_Backpressure(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("drop oldest", func(t *testing.T) {
+		b := NewBus()
+		s := b.Subscribe("slow", WithBufferSize(2))
+		defer s.Unsubscribe()
+		for i := byte(1); i <= 3; i++ {
+			b.Publish(ctx, assertionCreated(i))
+		}
+		require.Equal(t, uint64(1), s.Dropped())
+		require.Equal(t, assertionCreated(2), receive(t, s))
+		require.Equal(t, assertionCreated(3), receive(t, s))
+	})
+	t.Run("drop newest", func(t *testing.T) {
+		b := NewBus()
+		s := b.Subscribe("slow", WithBufferSize(2), WithBackpressure(DropNewest))
+		defer s.Unsubscribe()
+		for i := byte(1); i <= 3; i++ {
+			b.Publish(ctx, assertionCreated(i))
+		}
+		require.Equal(t, uint64(1), s.Dropped())
+		require.Equal(t, assertionCreated(1), receive(t, s))
+		require.Equal(t, assertionCreated(2), receive(t, s))
+	})
+	t.Run("block", func(t *testing.T) {
+		b := NewBus()
+		s := b.Subscribe("slow", WithBufferSize(1), WithBackpressure(Block))
+		defer s.Unsubscribe()
+		b.Publish(ctx, assertionCreated(1))
+
+		published := make(chan struct{})
+		go func() {
+			b.Publish(ctx, assertionCreated(2))
+			close(published)
+		}()
+		select {
+		case <-published:
+			t.Fatal("publisher did not wait for the subscriber")
+		case <-time.After(50 * time.Millisecond):
+		}
+		require.Equal(t, assertionCreated(1), receive(t, s))
+		<-published
+		require.Equal(t, assertionCreated(2), receive(t, s))
+		require.Equal(t, uint64(0), s.Dropped())
+
+		// Publishers stop waiting once their context is done.
+		b.Publish(ctx, assertionCreated(3))
+		timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		b.Publish(timeoutCtx, assertionCreated(4))
+		require.Equal(t, uint64(1), s.Dropped())
+	})
+}
+
+func TestBus_Unsubscribe(t *testing.T) {
+	ctx := context.Background()
+	b := NewBus()
+	s := b.Subscribe("gone", WithBufferSize(1), WithBackpressure(Block))
+	b.Publish(ctx, assertionCreated(1))
+
+	// A publisher blocked on the subscriber is released once it unsubscribes.
+	published := make(chan struct{})
+	go func() {
+		b.Publish(ctx, assertionCreated(2))
+		close(published)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	s.Unsubscribe()
+	<-published
+	s.Unsubscribe()
+	require.Equal(t, 0, b.NumSubscribers())
+
+	// Buffered events can still be read until the channel is closed.
+	var received []Event
+	for ev := range s.Events() {
+		received = append(received, ev)
+	}
+	require.NotEmpty(t, received)
+	require.Equal(t, assertionCreated(1), received[0])
+}
+
+func TestLogPublisher(t *testing.T) {
+	ctx := context.Background()
+	b := NewBus()
+	s := b.Subscribe("test")
+	defer s.Unsubscribe()
+	p := NewLogPublisher(b)
+
+	first := types.Log{BlockNumber: 1, TxHash: common.Hash{1}, Index: 0}
+	second := types.Log{BlockNumber: 2, TxHash: common.Hash{2}, Index: 0}
+	p.Publish(ctx, first, assertionCreated(1))
+	p.Publish(ctx, second, assertionCreated(2))
+
+	// Logs observed again in an overlapping range are only published once, until
+	// the scanner moves past them.
+	p.Publish(ctx, second, assertionCreated(2))
+	p.Prune(2)
+	p.Publish(ctx, second, assertionCreated(2))
+	p.Publish(ctx, first, assertionCreated(1))
+
+	require.Equal(t, assertionCreated(1), receive(t, s))
+	require.Equal(t, assertionCreated(2), receive(t, s))
+	require.Equal(t, assertionCreated(1), receive(t, s))
+	require.Empty(t, s.Events())
+
+	var nilPublisher *LogPublisher
+	nilPublisher.Publish(ctx, first, assertionCreated(1))
+	nilPublisher.Prune(2)
+}
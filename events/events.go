@@ -0,0 +1,109 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package events
+
+import (
+	protocol "github.com/OffchainLabs/bold/chain-abstraction"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Kind of a protocol event.
+type Kind string
+
+const (
+	KindEdgeAdded          Kind = "edge_added"
+	KindEdgeBisected       Kind = "edge_bisected"
+	KindEdgeConfirmed      Kind = "edge_confirmed"
+	KindAssertionCreated   Kind = "assertion_created"
+	KindAssertionConfirmed Kind = "assertion_confirmed"
+	KindMoveSubmitted      Kind = "move_submitted"
+)
+
+// Event published on the bus. Subscribers switch on the concrete type of the
+// event, or on its kind.
+type Event interface {
+	Kind() Kind
+}
+
+// ConfirmedBy is the means by which an edge was confirmed onchain.
+type ConfirmedBy string
+
+const (
+	ByChildren     ConfirmedBy = "children"
+	ByClaim        ConfirmedBy = "claim"
+	ByTime         ConfirmedBy = "time"
+	ByOneStepProof ConfirmedBy = "osp"
+)
+
+// EdgeAdded is published once for every edge added onchain, after the chain
+// watcher has processed it.
+type EdgeAdded struct {
+	EdgeId      protocol.EdgeId
+	MutualId    protocol.MutualId
+	OriginId    protocol.OriginId
+	ClaimId     protocol.ClaimId
+	Level       uint8
+	Length      uint64
+	HasRival    bool
+	IsLayerZero bool
+	Block       uint64
+}
+
+func (*EdgeAdded) Kind() Kind { return KindEdgeAdded }
+
+// EdgeBisected is published once for every bisection made onchain.
+type EdgeBisected struct {
+	EdgeId                  protocol.EdgeId
+	LowerChildId            protocol.EdgeId
+	UpperChildId            protocol.EdgeId
+	LowerChildAlreadyExists bool
+	Block                   uint64
+}
+
+func (*EdgeBisected) Kind() Kind { return KindEdgeBisected }
+
+// EdgeConfirmed is published once for every edge confirmed onchain, after the
+// chain watcher has processed it.
+type EdgeConfirmed struct {
+	EdgeId   protocol.EdgeId
+	MutualId protocol.MutualId
+	By       ConfirmedBy
+	// Set if the edge was confirmed by claim.
+	ClaimingEdgeId protocol.EdgeId
+	Block          uint64
+}
+
+func (*EdgeConfirmed) Kind() Kind { return KindEdgeConfirmed }
+
+// AssertionCreated is published once for every assertion created onchain.
+type AssertionCreated struct {
+	AssertionHash       protocol.AssertionHash
+	ParentAssertionHash protocol.AssertionHash
+	Block               uint64
+}
+
+func (*AssertionCreated) Kind() Kind { return KindAssertionCreated }
+
+// AssertionConfirmed is published once for every assertion confirmed onchain.
+type AssertionConfirmed struct {
+	AssertionHash protocol.AssertionHash
+	BlockHash     common.Hash
+	SendRoot      common.Hash
+	Block         uint64
+}
+
+func (*AssertionConfirmed) Kind() Kind { return KindAssertionConfirmed }
+
+// MoveSubmitted is published by the challenge manager when one of its moves in a
+// challenge was accepted onchain.
+type MoveSubmitted struct {
+	// Edge the move was made on, or the edge it added.
+	EdgeId              protocol.EdgeId
+	ChallengedAssertion protocol.AssertionHash
+	// Name of the move, such as bisect or confirm.
+	Move      string
+	Validator string
+}
+
+func (*MoveSubmitted) Kind() Kind { return KindMoveSubmitted }
@@ -0,0 +1,66 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package events
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+type logKey struct {
+	block  uint64
+	txHash common.Hash
+	index  uint
+}
+
+// LogPublisher publishes events observed in onchain logs at most once. Scanners
+// observe logs more than once, as consecutive scan ranges share their boundary
+// block and ranges are scanned again after errors. A nil bus drops every event.
+type LogPublisher struct {
+	bus  *Bus
+	lock sync.Mutex
+	seen map[logKey]struct{}
+}
+
+// NewLogPublisher creates a publisher of onchain log events to a bus.
+func NewLogPublisher(bus *Bus) *LogPublisher {
+	return &LogPublisher{
+		bus:  bus,
+		seen: make(map[logKey]struct{}),
+	}
+}
+
+// Publish the event observed in a log, unless it was already published.
+func (p *LogPublisher) Publish(ctx context.Context, raw types.Log, ev Event) {
+	if p == nil || p.bus == nil {
+		return
+	}
+	key := logKey{block: raw.BlockNumber, txHash: raw.TxHash, index: raw.Index}
+	p.lock.Lock()
+	_, ok := p.seen[key]
+	p.seen[key] = struct{}{}
+	p.lock.Unlock()
+	if ok {
+		return
+	}
+	p.bus.Publish(ctx, ev)
+}
+
+// Prune forgets the logs of blocks before the given block, which the scanner has
+// moved past.
+func (p *LogPublisher) Prune(fromBlock uint64) {
+	if p == nil {
+		return
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for key := range p.seen {
+		if key.block < fromBlock {
+			delete(p.seen, key)
+		}
+	}
+}
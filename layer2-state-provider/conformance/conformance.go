@@ -0,0 +1,283 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+// Package conformance checks that an l2stateprovider.Provider behaves as the challenge
+// manager expects, so that integrators of an execution engine can run their
+// implementation through the same battery of checks as the mock state provider:
+//
+//	func TestConformance(t *testing.T) {
+//		conformance.Run(t, newProvider(t), &conformance.Config{
+//			FromBatch:            0,
+//			ToBatch:              1,
+//			ChallengeLeafHeights: []l2stateprovider.Height{32, 32, 32},
+//		})
+//	}
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	protocol "github.com/OffchainLabs/bold/chain-abstraction"
+	"github.com/OffchainLabs/bold/containers/option"
+	l2stateprovider "github.com/OffchainLabs/bold/layer2-state-provider"
+	commitments "github.com/OffchainLabs/bold/state-commitments/history"
+	inclusionproofs "github.com/OffchainLabs/bold/state-commitments/inclusion-proofs"
+	prefixproofs "github.com/OffchainLabs/bold/state-commitments/prefix-proofs"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+// Config describes the assertion the provider is checked against.
+type Config struct {
+	WasmModuleRoot common.Hash
+	// The batch range of the assertion, whose execution states the provider must have.
+	FromBatch l2stateprovider.Batch
+	ToBatch   l2stateprovider.Batch
+	// Heights of the history commitments at each challenge level, starting with the
+	// block challenge level, as configured in the challenge manager contract.
+	ChallengeLeafHeights []l2stateprovider.Height
+	// Optional, a state the provider does not have, which it must reject with
+	// ErrNoExecutionState. Defaults to the state after ToBatch with a different block hash.
+	UnknownState *protocol.ExecutionState
+	// Optional, a state the provider has yet to catch up to, which it must reject with
+	// ErrChainCatchingUp. The check is skipped if not set.
+	CatchingUpState *protocol.ExecutionState
+}
+
+// Run runs every check against the provider as a subtest of t.
+func Run(t *testing.T, provider l2stateprovider.Provider, cfg *Config) {
+	require.NotEmpty(t, cfg.ChallengeLeafHeights, "conformance config needs challenge leaf heights")
+	c := &checker{provider: provider, cfg: cfg}
+	t.Run("execution state agreement", c.checkExecutionStateAgreement)
+	t.Run("commitment heights per level", c.checkCommitmentHeights)
+	t.Run("consistency between levels", c.checkLevelConsistency)
+	t.Run("prefix proofs", c.checkPrefixProofs)
+	t.Run("one step proof data", c.checkOneStepProofData)
+	t.Run("history commitment agreement", c.checkHistoryCommitmentAgreement)
+}
+
+type checker struct {
+	provider l2stateprovider.Provider
+	cfg      *Config
+}
+
+// Number of challenge levels below the block challenge level.
+func (c *checker) numSubchallengeLevels() int {
+	return len(c.cfg.ChallengeLeafHeights) - 1
+}
+
+// Requests a commitment below subchallenges originating at the given heights of the
+// levels above.
+func (c *checker) request(
+	origins []l2stateprovider.Height,
+	upTo option.Option[l2stateprovider.Height],
+) *l2stateprovider.HistoryCommitmentRequest {
+	return &l2stateprovider.HistoryCommitmentRequest{
+		WasmModuleRoot:              c.cfg.WasmModuleRoot,
+		FromBatch:                   c.cfg.FromBatch,
+		ToBatch:                     c.cfg.ToBatch,
+		UpperChallengeOriginHeights: origins,
+		FromHeight:                  0,
+		UpToHeight:                  upTo,
+	}
+}
+
+// Origin heights of the first subchallenge at each level above the given one.
+func firstOrigins(level int) []l2stateprovider.Height {
+	return make([]l2stateprovider.Height, level)
+}
+
+func (c *checker) commitment(t *testing.T, req *l2stateprovider.HistoryCommitmentRequest) commitments.History {
+	t.Helper()
+	commit, err := c.provider.HistoryCommitment(context.Background(), req)
+	require.NoError(t, err, "history commitment at origins %v up to %v", req.UpperChallengeOriginHeights, req.UpToHeight)
+	return commit
+}
+
+// Heights to check below the leaf height of a level, without duplicates.
+func sampleHeights(leafHeight l2stateprovider.Height) []l2stateprovider.Height {
+	heights := []l2stateprovider.Height{0}
+	for _, h := range []l2stateprovider.Height{leafHeight / 2, leafHeight - 1} {
+		if h > heights[len(heights)-1] {
+			heights = append(heights, h)
+		}
+	}
+	return heights
+}
+
+func (c *checker) checkExecutionStateAgreement(t *testing.T) {
+	ctx := context.Background()
+	state, err := c.provider.ExecutionStateAfterBatchCount(ctx, uint64(c.cfg.ToBatch))
+	require.NoError(t, err)
+	require.Equal(t, uint64(c.cfg.ToBatch), state.GlobalState.Batch, "state after batch count is in a different batch")
+	require.NoError(t, c.provider.AgreesWithExecutionState(ctx, state), "provider disagrees with its own state")
+
+	unknown := c.cfg.UnknownState
+	if unknown == nil {
+		unknown = &protocol.ExecutionState{
+			GlobalState:   state.GlobalState,
+			MachineStatus: state.MachineStatus,
+		}
+		unknown.GlobalState.BlockHash[0] ^= 0xff
+	}
+	err = c.provider.AgreesWithExecutionState(ctx, unknown)
+	require.ErrorIs(t, err, l2stateprovider.ErrNoExecutionState)
+
+	if c.cfg.CatchingUpState != nil {
+		err = c.provider.AgreesWithExecutionState(ctx, c.cfg.CatchingUpState)
+		require.ErrorIs(t, err, l2stateprovider.ErrChainCatchingUp)
+	}
+}
+
+// A commitment without an end height commits to all the leaves of its level, and one
+// with an end height to the leaves up to it.
+func (c *checker) checkCommitmentHeights(t *testing.T) {
+	for level, leafHeight := range c.cfg.ChallengeLeafHeights {
+		commit := c.commitment(t, c.request(firstOrigins(level), option.None[l2stateprovider.Height]()))
+		require.Equal(t, uint64(leafHeight), commit.Height, "full commitment height at level %d", level)
+
+		for _, h := range sampleHeights(leafHeight) {
+			commit = c.commitment(t, c.request(firstOrigins(level), option.Some(h)))
+			require.Equal(t, uint64(h), commit.Height, "commitment height at level %d", level)
+			root, err := inclusionproofs.CalculateRootFromProof(commit.LastLeafProof, commit.Height, commit.LastLeaf)
+			require.NoError(t, err)
+			require.Equal(t, commit.Merkle, root, "last leaf proof at level %d height %d", level, h)
+		}
+	}
+}
+
+// A subchallenge originating at a height of the level above starts at that height's
+// leaf, and ends at the next one.
+func (c *checker) checkLevelConsistency(t *testing.T) {
+	for level := 1; level <= c.numSubchallengeLevels(); level++ {
+		for _, origin := range sampleHeights(c.cfg.ChallengeLeafHeights[level-1]) {
+			upper := c.request(firstOrigins(level-1), option.Some(origin))
+			start := c.commitment(t, upper)
+			upper.UpToHeight = option.Some(origin + 1)
+			end := c.commitment(t, upper)
+
+			origins := append(firstOrigins(level-1), origin)
+			subCommit := c.commitment(t, c.request(origins, option.None[l2stateprovider.Height]()))
+			require.Equal(t, start.LastLeaf, subCommit.FirstLeaf, "level %d subchallenge at %d does not start at its origin", level, origin)
+			require.Equal(t, end.LastLeaf, subCommit.LastLeaf, "level %d subchallenge at %d does not end after its origin", level, origin)
+		}
+	}
+}
+
+// Prefix proofs verify that the commitment up to a height is a prefix of the full one.
+func (c *checker) checkPrefixProofs(t *testing.T) {
+	ctx := context.Background()
+	for level, leafHeight := range c.cfg.ChallengeLeafHeights {
+		req := c.request(firstOrigins(level), option.Some(leafHeight))
+		full := c.commitment(t, req)
+		for _, h := range sampleHeights(leafHeight) {
+			prefix := c.commitment(t, c.request(firstOrigins(level), option.Some(h)))
+			packed, err := c.provider.PrefixProof(ctx, req, h)
+			require.NoError(t, err, "prefix proof at level %d height %d", level, h)
+			expansion, proof, err := unpackPrefixProof(packed)
+			require.NoError(t, err)
+			err = prefixproofs.VerifyPrefixProof(&prefixproofs.VerifyPrefixProofConfig{
+				PreRoot:      prefix.Merkle,
+				PreSize:      uint64(h) + 1,
+				PostRoot:     full.Merkle,
+				PostSize:     uint64(leafHeight) + 1,
+				PreExpansion: expansion,
+				PrefixProof:  proof,
+			})
+			require.NoError(t, err, "prefix proof at level %d height %d", level, h)
+		}
+	}
+}
+
+func unpackPrefixProof(packed []byte) ([]common.Hash, []common.Hash, error) {
+	args, err := l2stateprovider.ProofArgs.Unpack(packed)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(args) != 2 {
+		return nil, nil, fmt.Errorf("prefix proof has %d arguments, expected 2", len(args))
+	}
+	expansion, ok := args[0].([][32]byte)
+	if !ok {
+		return nil, nil, fmt.Errorf("prefix expansion has type %T", args[0])
+	}
+	proof, ok := args[1].([][32]byte)
+	if !ok {
+		return nil, nil, fmt.Errorf("prefix proof has type %T", args[1])
+	}
+	return toHashes(expansion), toHashes(proof), nil
+}
+
+func toHashes(b [][32]byte) []common.Hash {
+	hashes := make([]common.Hash, len(b))
+	for i := range b {
+		hashes[i] = b[i]
+	}
+	return hashes
+}
+
+// The one step proof data of a step at the last level proves the leaves before and after
+// the step are included in the commitments up to them.
+func (c *checker) checkOneStepProofData(t *testing.T) {
+	if c.numSubchallengeLevels() == 0 {
+		t.Skip("one step proofs need a subchallenge level")
+	}
+	ctx := context.Background()
+	level := c.numSubchallengeLevels()
+	origins := firstOrigins(level)
+	for _, h := range sampleHeights(c.cfg.ChallengeLeafHeights[level]) {
+		data, startProof, endProof, err := c.provider.OneStepProofData(
+			ctx,
+			c.cfg.WasmModuleRoot,
+			c.cfg.FromBatch,
+			c.cfg.ToBatch,
+			origins,
+			0,
+			h,
+		)
+		require.NoError(t, err, "one step proof data at height %d", h)
+		require.NotEmpty(t, data.Proof, "one step proof at height %d", h)
+
+		before := c.commitment(t, c.request(firstOrigins(level), option.Some(h)))
+		after := c.commitment(t, c.request(firstOrigins(level), option.Some(h+1)))
+		require.Equal(t, before.LastLeaf, data.BeforeHash, "before hash at height %d", h)
+		require.Equal(t, after.LastLeaf, data.AfterHash, "after hash at height %d", h)
+
+		root, err := inclusionproofs.CalculateRootFromProof(startProof, uint64(h), data.BeforeHash)
+		require.NoError(t, err)
+		require.Equal(t, before.Merkle, root, "start leaf inclusion proof at height %d", h)
+		root, err = inclusionproofs.CalculateRootFromProof(endProof, uint64(h)+1, data.AfterHash)
+		require.NoError(t, err)
+		require.Equal(t, after.Merkle, root, "end leaf inclusion proof at height %d", h)
+	}
+}
+
+// The provider agrees with its own commitments at every level, and only those.
+func (c *checker) checkHistoryCommitmentAgreement(t *testing.T) {
+	ctx := context.Background()
+	for level, leafHeight := range c.cfg.ChallengeLeafHeights {
+		for _, h := range sampleHeights(leafHeight) {
+			req := c.request(firstOrigins(level), option.Some(h))
+			commit := c.commitment(t, req)
+			challengeLevel := protocol.ChallengeLevel(level)
+
+			agrees, err := c.provider.AgreesWithHistoryCommitment(ctx, challengeLevel, req, l2stateprovider.History{
+				Height:     commit.Height,
+				MerkleRoot: commit.Merkle,
+			})
+			require.NoError(t, err)
+			require.True(t, agrees, "disagrees with own commitment at level %d height %d", level, h)
+
+			otherRoot := commit.Merkle
+			otherRoot[0] ^= 0xff
+			agrees, err = c.provider.AgreesWithHistoryCommitment(ctx, challengeLevel, req, l2stateprovider.History{
+				Height:     commit.Height,
+				MerkleRoot: otherRoot,
+			})
+			require.NoError(t, err)
+			require.False(t, agrees, "agrees with a different commitment at level %d height %d", level, h)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package conformance
+
+import (
+	"testing"
+
+	l2stateprovider "github.com/OffchainLabs/bold/layer2-state-provider"
+	challenge_testing "github.com/OffchainLabs/bold/testing"
+	statemanager "github.com/OffchainLabs/bold/testing/mocks/state-provider"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMockStateProvider(t *testing.T) {
+	provider, err := statemanager.NewForSimpleMachine()
+	require.NoError(t, err)
+	Run(t, provider, &Config{
+		FromBatch: 0,
+		ToBatch:   1,
+		ChallengeLeafHeights: []l2stateprovider.Height{
+			challenge_testing.LevelZeroBlockEdgeHeight,
+			challenge_testing.LevelZeroBigStepEdgeHeight,
+			challenge_testing.LevelZeroSmallStepEdgeHeight,
+		},
+	})
+}
@@ -0,0 +1,254 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+// Package fixture records the answers of an l2stateprovider.Provider and replays them,
+// so that the behavior of a validator against a real execution node, such as during an
+// incident, can be turned into a deterministic regression test.
+//
+// A Recorder wraps a provider and records every request made to it along with the
+// responses, including errors, in the order they were given. A Replayer serves those
+// responses for the same requests, and fails any request that was not recorded, or
+// made more times than it was, with ErrNotRecorded. Fixtures are stored as
+// gzip-compressed JSON.
+package fixture
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	protocol "github.com/OffchainLabs/bold/chain-abstraction"
+	l2stateprovider "github.com/OffchainLabs/bold/layer2-state-provider"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Version of the fixture format written by this package.
+const Version = 1
+
+// ErrNotRecorded is returned when replaying a request that was not made while recording,
+// or made more times than it was.
+var ErrNotRecorded = errors.New("request was not recorded")
+
+// Fixture holds the requests made to a provider and its responses to them.
+type Fixture struct {
+	Version uint64 `json:"version"`
+	// Calls in the order their requests were first made.
+	Calls []*Call `json:"calls"`
+}
+
+// Call of a provider method with a request, and every response it gave to it.
+type Call struct {
+	Method  string          `json:"method"`
+	Request json.RawMessage `json:"request"`
+	// Responses in the order they were given.
+	Responses []*Response `json:"responses"`
+}
+
+// Response of a provider, either a result or an error.
+type Response struct {
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *RecordedError  `json:"error,omitempty"`
+	// Number of consecutive times the response was given.
+	Times uint64 `json:"times"`
+}
+
+// RecordedError is an error returned by a provider, with a code for the provider's
+// sentinel errors so that they can still be checked with errors.Is when replayed.
+type RecordedError struct {
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message"`
+}
+
+// Methods of the provider.
+const (
+	executionStateAfterBatchCountMethod = "ExecutionStateAfterBatchCount"
+	agreesWithExecutionStateMethod      = "AgreesWithExecutionState"
+	historyCommitmentMethod             = "HistoryCommitment"
+	prefixProofMethod                   = "PrefixProof"
+	oneStepProofDataMethod              = "OneStepProofData"
+	agreesWithHistoryCommitmentMethod   = "AgreesWithHistoryCommitment"
+)
+
+// The sentinel errors of the provider, by code.
+var sentinelErrors = map[string]error{
+	"no_execution_state":       l2stateprovider.ErrNoExecutionState,
+	"chain_catching_up":        l2stateprovider.ErrChainCatchingUp,
+	"unknown_wasm_module_root": l2stateprovider.ErrUnknownWasmModuleRoot,
+}
+
+func newRecordedError(err error) *RecordedError {
+	recorded := &RecordedError{Message: err.Error()}
+	for code, sentinel := range sentinelErrors {
+		if errors.Is(err, sentinel) {
+			recorded.Code = code
+			break
+		}
+	}
+	return recorded
+}
+
+// A replayed error, with the message of the recorded one that wraps the sentinel error
+// of its code, if any.
+type replayedError struct {
+	message  string
+	sentinel error
+}
+
+func (e *replayedError) Error() string {
+	return e.message
+}
+
+func (e *replayedError) Unwrap() error {
+	return e.sentinel
+}
+
+func (e *RecordedError) err() error {
+	return &replayedError{message: e.Message, sentinel: sentinelErrors[e.Code]}
+}
+
+// Write the fixture as gzip-compressed JSON.
+func (f *Fixture) Write(w io.Writer) error {
+	zw, err := gzip.NewWriterLevel(w, gzip.BestCompression)
+	if err != nil {
+		return err
+	}
+	if err = json.NewEncoder(zw).Encode(f); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// WriteFile writes the fixture to a file, replacing it if it exists.
+func (f *Fixture) WriteFile(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err = f.Write(file); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// ReadFixture reads a fixture written by Write, checking that its version is supported.
+func ReadFixture(r io.Reader) (*Fixture, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("could not decompress fixture: %w", err)
+	}
+	defer zr.Close()
+	f := &Fixture{}
+	if err = json.NewDecoder(zr).Decode(f); err != nil {
+		return nil, fmt.Errorf("could not decode fixture: %w", err)
+	}
+	if f.Version != Version {
+		return nil, fmt.Errorf("unsupported fixture version %d, expected %d", f.Version, Version)
+	}
+	return f, nil
+}
+
+// ReadFile reads a fixture from a file written by WriteFile.
+func ReadFile(path string) (*Fixture, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadFixture(file)
+}
+
+// Identifies the request of a call.
+func callKey(method string, request []byte) string {
+	return method + ":" + string(request)
+}
+
+// Recorded form of a history commitment request, with the optional height as a pointer.
+type historyCommitmentRequest struct {
+	WasmModuleRoot              common.Hash              `json:"wasmModuleRoot"`
+	FromBatch                   l2stateprovider.Batch    `json:"fromBatch"`
+	ToBatch                     l2stateprovider.Batch    `json:"toBatch"`
+	UpperChallengeOriginHeights []l2stateprovider.Height `json:"upperChallengeOriginHeights"`
+	FromHeight                  l2stateprovider.Height   `json:"fromHeight"`
+	UpToHeight                  *l2stateprovider.Height  `json:"upToHeight,omitempty"`
+}
+
+func toRecorded(req *l2stateprovider.HistoryCommitmentRequest) *historyCommitmentRequest {
+	if req == nil {
+		return nil
+	}
+	r := &historyCommitmentRequest{
+		WasmModuleRoot:              req.WasmModuleRoot,
+		FromBatch:                   req.FromBatch,
+		ToBatch:                     req.ToBatch,
+		UpperChallengeOriginHeights: req.UpperChallengeOriginHeights,
+		FromHeight:                  req.FromHeight,
+	}
+	// Requests with no origin heights are the same request, whether nil or empty.
+	if r.UpperChallengeOriginHeights == nil {
+		r.UpperChallengeOriginHeights = []l2stateprovider.Height{}
+	}
+	if req.UpToHeight.IsSome() {
+		upTo := req.UpToHeight.Unwrap()
+		r.UpToHeight = &upTo
+	}
+	return r
+}
+
+type executionStateAfterBatchCountRequest struct {
+	BatchCount uint64 `json:"batchCount"`
+}
+
+type agreesWithExecutionStateRequest struct {
+	State *protocol.ExecutionState `json:"state"`
+}
+
+type prefixProofRequest struct {
+	Request      *historyCommitmentRequest `json:"request"`
+	PrefixHeight l2stateprovider.Height    `json:"prefixHeight"`
+}
+
+type oneStepProofDataRequest struct {
+	WasmModuleRoot              common.Hash              `json:"wasmModuleRoot"`
+	FromBatch                   l2stateprovider.Batch    `json:"fromBatch"`
+	ToBatch                     l2stateprovider.Batch    `json:"toBatch"`
+	UpperChallengeOriginHeights []l2stateprovider.Height `json:"upperChallengeOriginHeights"`
+	FromHeight                  l2stateprovider.Height   `json:"fromHeight"`
+	UpToHeight                  l2stateprovider.Height   `json:"upToHeight"`
+}
+
+func newOneStepProofDataRequest(
+	wasmModuleRoot common.Hash,
+	fromBatch,
+	toBatch l2stateprovider.Batch,
+	upperChallengeOriginHeights []l2stateprovider.Height,
+	fromHeight,
+	upToHeight l2stateprovider.Height,
+) *oneStepProofDataRequest {
+	if upperChallengeOriginHeights == nil {
+		upperChallengeOriginHeights = []l2stateprovider.Height{}
+	}
+	return &oneStepProofDataRequest{
+		WasmModuleRoot:              wasmModuleRoot,
+		FromBatch:                   fromBatch,
+		ToBatch:                     toBatch,
+		UpperChallengeOriginHeights: upperChallengeOriginHeights,
+		FromHeight:                  fromHeight,
+		UpToHeight:                  upToHeight,
+	}
+}
+
+type oneStepProofDataResponse struct {
+	Data                    *protocol.OneStepData `json:"data"`
+	StartLeafInclusionProof []common.Hash         `json:"startLeafInclusionProof"`
+	EndLeafInclusionProof   []common.Hash         `json:"endLeafInclusionProof"`
+}
+
+type agreesWithHistoryCommitmentRequest struct {
+	ChallengeLevel protocol.ChallengeLevel   `json:"challengeLevel"`
+	Request        *historyCommitmentRequest `json:"request"`
+	Commit         l2stateprovider.History   `json:"commit"`
+}
@@ -0,0 +1,133 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package fixture
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+
+	protocol "github.com/OffchainLabs/bold/chain-abstraction"
+	"github.com/OffchainLabs/bold/containers/option"
+	l2stateprovider "github.com/OffchainLabs/bold/layer2-state-provider"
+	"github.com/OffchainLabs/bold/layer2-state-provider/conformance"
+	challenge_testing "github.com/OffchainLabs/bold/testing"
+	statemanager "github.com/OffchainLabs/bold/testing/mocks/state-provider"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplayer_PassesConformance(t *testing.T) {
+	provider, err := statemanager.NewForSimpleMachine()
+	require.NoError(t, err)
+	cfg := &conformance.Config{
+		FromBatch: 0,
+		ToBatch:   1,
+		ChallengeLeafHeights: []l2stateprovider.Height{
+			challenge_testing.LevelZeroBlockEdgeHeight,
+			challenge_testing.LevelZeroBigStepEdgeHeight,
+			challenge_testing.LevelZeroSmallStepEdgeHeight,
+		},
+	}
+	recorder := NewRecorder(provider)
+	conformance.Run(t, recorder, cfg)
+
+	path := filepath.Join(t.TempDir(), "simple-machine.json.gz")
+	require.NoError(t, recorder.Fixture().WriteFile(path))
+	f, err := ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, recorder.Fixture(), f)
+
+	// The suite makes the same requests again, all of which are served from the fixture.
+	replayer, err := NewReplayer(f)
+	require.NoError(t, err)
+	conformance.Run(t, replayer, cfg)
+}
+
+// Catches up to the chain after being asked a few times.
+type catchingUpProvider struct {
+	statemanager.L2StateBackend
+	calls int
+}
+
+func (p *catchingUpProvider) AgreesWithExecutionState(ctx context.Context, _ *protocol.ExecutionState) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	p.calls++
+	if p.calls <= 2 {
+		return l2stateprovider.ErrChainCatchingUp
+	}
+	return nil
+}
+
+func TestReplayer_ServesResponsesInOrder(t *testing.T) {
+	ctx := context.Background()
+	recorder := NewRecorder(&catchingUpProvider{})
+	state := &protocol.ExecutionState{GlobalState: protocol.GoGlobalState{Batch: 1}}
+	for i := 0; i < 2; i++ {
+		require.ErrorIs(t, recorder.AgreesWithExecutionState(ctx, state), l2stateprovider.ErrChainCatchingUp)
+	}
+	require.NoError(t, recorder.AgreesWithExecutionState(ctx, state))
+
+	// Calls cut short by their context are not recorded.
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	require.ErrorIs(t, recorder.AgreesWithExecutionState(canceled, state), context.Canceled)
+
+	f := recorder.Fixture()
+	require.Len(t, f.Calls, 1)
+	require.Len(t, f.Calls[0].Responses, 2)
+	require.Equal(t, uint64(2), f.Calls[0].Responses[0].Times)
+	require.Equal(t, "chain_catching_up", f.Calls[0].Responses[0].Error.Code)
+
+	var buf bytes.Buffer
+	require.NoError(t, f.Write(&buf))
+	f, err := ReadFixture(&buf)
+	require.NoError(t, err)
+	replayer, err := NewReplayer(f)
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		require.ErrorIs(t, replayer.AgreesWithExecutionState(ctx, state), l2stateprovider.ErrChainCatchingUp)
+	}
+	require.NoError(t, replayer.AgreesWithExecutionState(ctx, state))
+	// The request was not made any more times while recording.
+	require.ErrorIs(t, replayer.AgreesWithExecutionState(ctx, state), ErrNotRecorded)
+
+	// Unless the last response is repeated once the recorded ones run out.
+	repeating, err := NewReplayer(f, WithRepeatedLastResponse())
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		require.ErrorIs(t, repeating.AgreesWithExecutionState(ctx, state), l2stateprovider.ErrChainCatchingUp)
+	}
+	for i := 0; i < 3; i++ {
+		require.NoError(t, repeating.AgreesWithExecutionState(ctx, state))
+	}
+
+	err = replayer.AgreesWithExecutionState(ctx, &protocol.ExecutionState{GlobalState: protocol.GoGlobalState{Batch: 2}})
+	require.ErrorIs(t, err, ErrNotRecorded)
+	_, err = replayer.HistoryCommitment(ctx, &l2stateprovider.HistoryCommitmentRequest{
+		UpperChallengeOriginHeights: []l2stateprovider.Height{},
+		UpToHeight:                  option.None[l2stateprovider.Height](),
+	})
+	require.ErrorIs(t, err, ErrNotRecorded)
+	_, _, _, err = replayer.OneStepProofData(ctx, common.Hash{}, 0, 1, nil, 0, 1)
+	require.ErrorIs(t, err, ErrNotRecorded)
+}
+
+func TestNewReplayer_RejectsInvalidFixtures(t *testing.T) {
+	_, err := NewReplayer(&Fixture{Version: Version + 1})
+	require.ErrorContains(t, err, "unsupported fixture version")
+
+	call := &Call{Method: historyCommitmentMethod, Request: []byte(`{}`), Responses: []*Response{{Times: 1}}}
+	_, err = NewReplayer(&Fixture{Version: Version, Calls: []*Call{call, call}})
+	require.ErrorContains(t, err, "more than once")
+
+	_, err = NewReplayer(&Fixture{Version: Version, Calls: []*Call{{Method: historyCommitmentMethod, Request: []byte(`{}`)}}})
+	require.ErrorContains(t, err, "no responses")
+
+	_, err = ReadFixture(bytes.NewReader([]byte("not gzip")))
+	require.ErrorContains(t, err, "could not decompress fixture")
+}
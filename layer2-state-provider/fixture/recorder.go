@@ -0,0 +1,170 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package fixture
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sync"
+
+	protocol "github.com/OffchainLabs/bold/chain-abstraction"
+	l2stateprovider "github.com/OffchainLabs/bold/layer2-state-provider"
+	commitments "github.com/OffchainLabs/bold/state-commitments/history"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var srvlog = log.New("service", "fixture")
+
+var _ l2stateprovider.Provider = (*Recorder)(nil)
+
+// Recorder is a provider that records the requests made to the provider it wraps, and
+// its responses to them. Calls cut short by their context are not recorded, as their
+// outcome depends on the caller rather than the provider, and neither are responses
+// that cannot be encoded.
+type Recorder struct {
+	provider l2stateprovider.Provider
+	lock     sync.Mutex
+	calls    []*Call
+	byKey    map[string]*Call
+}
+
+func NewRecorder(provider l2stateprovider.Provider) *Recorder {
+	return &Recorder{
+		provider: provider,
+		byKey:    make(map[string]*Call),
+	}
+}
+
+// Fixture of the calls recorded so far.
+func (r *Recorder) Fixture() *Fixture {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	calls := make([]*Call, len(r.calls))
+	for i, c := range r.calls {
+		responses := make([]*Response, len(c.Responses))
+		for j, resp := range c.Responses {
+			copied := *resp
+			responses[j] = &copied
+		}
+		calls[i] = &Call{Method: c.Method, Request: c.Request, Responses: responses}
+	}
+	return &Fixture{Version: Version, Calls: calls}
+}
+
+// Records a response of the provider. A response that cannot be encoded is logged and
+// left out of the fixture, so recording never changes what callers of the provider get.
+func (r *Recorder) record(ctx context.Context, method string, request any, result any, err error) {
+	if ctx.Err() != nil {
+		return
+	}
+	encodedRequest, encodeErr := json.Marshal(request)
+	if encodeErr != nil {
+		srvlog.Error("Could not record provider request", "method", method, "err", encodeErr)
+		return
+	}
+	resp := &Response{Times: 1}
+	if err != nil {
+		resp.Error = newRecordedError(err)
+	} else {
+		resp.Result, encodeErr = json.Marshal(result)
+		if encodeErr != nil {
+			srvlog.Error("Could not record provider response", "method", method, "err", encodeErr)
+			return
+		}
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	key := callKey(method, encodedRequest)
+	call, ok := r.byKey[key]
+	if !ok {
+		call = &Call{Method: method, Request: encodedRequest}
+		r.byKey[key] = call
+		r.calls = append(r.calls, call)
+	}
+	if n := len(call.Responses); n > 0 && sameResponse(call.Responses[n-1], resp) {
+		call.Responses[n-1].Times++
+	} else {
+		call.Responses = append(call.Responses, resp)
+	}
+}
+
+func sameResponse(a, b *Response) bool {
+	if (a.Error == nil) != (b.Error == nil) {
+		return false
+	}
+	if a.Error != nil {
+		return *a.Error == *b.Error
+	}
+	return bytes.Equal(a.Result, b.Result)
+}
+
+func (r *Recorder) ExecutionStateAfterBatchCount(ctx context.Context, batchCount uint64) (*protocol.ExecutionState, error) {
+	state, err := r.provider.ExecutionStateAfterBatchCount(ctx, batchCount)
+	r.record(ctx, executionStateAfterBatchCountMethod, &executionStateAfterBatchCountRequest{
+		BatchCount: batchCount,
+	}, state, err)
+	return state, err
+}
+
+func (r *Recorder) AgreesWithExecutionState(ctx context.Context, state *protocol.ExecutionState) error {
+	err := r.provider.AgreesWithExecutionState(ctx, state)
+	r.record(ctx, agreesWithExecutionStateMethod, &agreesWithExecutionStateRequest{
+		State: state,
+	}, struct{}{}, err)
+	return err
+}
+
+func (r *Recorder) HistoryCommitment(ctx context.Context, req *l2stateprovider.HistoryCommitmentRequest) (commitments.History, error) {
+	history, err := r.provider.HistoryCommitment(ctx, req)
+	r.record(ctx, historyCommitmentMethod, toRecorded(req), history, err)
+	return history, err
+}
+
+func (r *Recorder) PrefixProof(ctx context.Context, req *l2stateprovider.HistoryCommitmentRequest, prefixHeight l2stateprovider.Height) ([]byte, error) {
+	proof, err := r.provider.PrefixProof(ctx, req, prefixHeight)
+	r.record(ctx, prefixProofMethod, &prefixProofRequest{
+		Request:      toRecorded(req),
+		PrefixHeight: prefixHeight,
+	}, proof, err)
+	return proof, err
+}
+
+func (r *Recorder) OneStepProofData(
+	ctx context.Context,
+	wasmModuleRoot common.Hash,
+	fromBatch,
+	toBatch l2stateprovider.Batch,
+	upperChallengeOriginHeights []l2stateprovider.Height,
+	fromHeight,
+	upToHeight l2stateprovider.Height,
+) (*protocol.OneStepData, []common.Hash, []common.Hash, error) {
+	data, startProof, endProof, err := r.provider.OneStepProofData(
+		ctx, wasmModuleRoot, fromBatch, toBatch, upperChallengeOriginHeights, fromHeight, upToHeight,
+	)
+	r.record(ctx, oneStepProofDataMethod, newOneStepProofDataRequest(
+		wasmModuleRoot, fromBatch, toBatch, upperChallengeOriginHeights, fromHeight, upToHeight,
+	), &oneStepProofDataResponse{
+		Data:                    data,
+		StartLeafInclusionProof: startProof,
+		EndLeafInclusionProof:   endProof,
+	}, err)
+	return data, startProof, endProof, err
+}
+
+func (r *Recorder) AgreesWithHistoryCommitment(
+	ctx context.Context,
+	challengeLevel protocol.ChallengeLevel,
+	historyCommitMetadata *l2stateprovider.HistoryCommitmentRequest,
+	commit l2stateprovider.History,
+) (bool, error) {
+	agrees, err := r.provider.AgreesWithHistoryCommitment(ctx, challengeLevel, historyCommitMetadata, commit)
+	r.record(ctx, agreesWithHistoryCommitmentMethod, &agreesWithHistoryCommitmentRequest{
+		ChallengeLevel: challengeLevel,
+		Request:        toRecorded(historyCommitMetadata),
+		Commit:         commit,
+	}, agrees, err)
+	return agrees, err
+}
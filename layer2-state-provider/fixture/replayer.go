@@ -0,0 +1,168 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package fixture
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	protocol "github.com/OffchainLabs/bold/chain-abstraction"
+	l2stateprovider "github.com/OffchainLabs/bold/layer2-state-provider"
+	commitments "github.com/OffchainLabs/bold/state-commitments/history"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var _ l2stateprovider.Provider = (*Replayer)(nil)
+
+// Replayer is a provider that answers requests with the responses of a fixture. A
+// request made several times is answered with the recorded responses in order.
+// Requests that were not recorded, or made more times than they were recorded, fail
+// with ErrNotRecorded.
+type Replayer struct {
+	lock  sync.Mutex
+	calls map[string]*replayedCall
+	// Whether the last response to a request is repeated once the recorded ones run out.
+	repeatLastResponse bool
+}
+
+type ReplayerOpt func(*Replayer)
+
+// WithRepeatedLastResponse answers requests made more times than they were recorded
+// with their last recorded response, for callers that poll the provider a number of
+// times which depends on timing, such as until the chain caught up.
+func WithRepeatedLastResponse() ReplayerOpt {
+	return func(r *Replayer) {
+		r.repeatLastResponse = true
+	}
+}
+
+type replayedCall struct {
+	*Call
+	// Number of times the call was replayed.
+	served uint64
+}
+
+func NewReplayer(f *Fixture, opts ...ReplayerOpt) (*Replayer, error) {
+	if f.Version != Version {
+		return nil, fmt.Errorf("unsupported fixture version %d, expected %d", f.Version, Version)
+	}
+	calls := make(map[string]*replayedCall, len(f.Calls))
+	for _, c := range f.Calls {
+		key := callKey(c.Method, c.Request)
+		if _, ok := calls[key]; ok {
+			return nil, fmt.Errorf("fixture has %s request %s more than once", c.Method, c.Request)
+		}
+		if len(c.Responses) == 0 {
+			return nil, fmt.Errorf("fixture has no responses to %s request %s", c.Method, c.Request)
+		}
+		calls[key] = &replayedCall{Call: c}
+	}
+	r := &Replayer{calls: calls}
+	for _, o := range opts {
+		o(r)
+	}
+	return r, nil
+}
+
+// The recorded response to the next time a request is made.
+func (r *Replayer) next(method string, request any) (*Response, error) {
+	encodedRequest, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	call, ok := r.calls[callKey(method, encodedRequest)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s %s", ErrNotRecorded, method, encodedRequest)
+	}
+	served := call.served
+	for _, resp := range call.Responses {
+		if served < resp.Times {
+			call.served++
+			return resp, nil
+		}
+		served -= resp.Times
+	}
+	if !r.repeatLastResponse {
+		return nil, fmt.Errorf("%w: %s %s made more than %d times", ErrNotRecorded, method, encodedRequest, call.served)
+	}
+	call.served++
+	return call.Responses[len(call.Responses)-1], nil
+}
+
+func replay[T any](ctx context.Context, r *Replayer, method string, request any) (T, error) {
+	var result T
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+	resp, err := r.next(method, request)
+	if err != nil {
+		return result, err
+	}
+	if resp.Error != nil {
+		return result, resp.Error.err()
+	}
+	if err = json.Unmarshal(resp.Result, &result); err != nil {
+		return result, fmt.Errorf("could not decode recorded %s response: %w", method, err)
+	}
+	return result, nil
+}
+
+func (r *Replayer) ExecutionStateAfterBatchCount(ctx context.Context, batchCount uint64) (*protocol.ExecutionState, error) {
+	return replay[*protocol.ExecutionState](ctx, r, executionStateAfterBatchCountMethod, &executionStateAfterBatchCountRequest{
+		BatchCount: batchCount,
+	})
+}
+
+func (r *Replayer) AgreesWithExecutionState(ctx context.Context, state *protocol.ExecutionState) error {
+	_, err := replay[struct{}](ctx, r, agreesWithExecutionStateMethod, &agreesWithExecutionStateRequest{
+		State: state,
+	})
+	return err
+}
+
+func (r *Replayer) HistoryCommitment(ctx context.Context, req *l2stateprovider.HistoryCommitmentRequest) (commitments.History, error) {
+	return replay[commitments.History](ctx, r, historyCommitmentMethod, toRecorded(req))
+}
+
+func (r *Replayer) PrefixProof(ctx context.Context, req *l2stateprovider.HistoryCommitmentRequest, prefixHeight l2stateprovider.Height) ([]byte, error) {
+	return replay[[]byte](ctx, r, prefixProofMethod, &prefixProofRequest{
+		Request:      toRecorded(req),
+		PrefixHeight: prefixHeight,
+	})
+}
+
+func (r *Replayer) OneStepProofData(
+	ctx context.Context,
+	wasmModuleRoot common.Hash,
+	fromBatch,
+	toBatch l2stateprovider.Batch,
+	upperChallengeOriginHeights []l2stateprovider.Height,
+	fromHeight,
+	upToHeight l2stateprovider.Height,
+) (*protocol.OneStepData, []common.Hash, []common.Hash, error) {
+	resp, err := replay[*oneStepProofDataResponse](ctx, r, oneStepProofDataMethod, newOneStepProofDataRequest(
+		wasmModuleRoot, fromBatch, toBatch, upperChallengeOriginHeights, fromHeight, upToHeight,
+	))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return resp.Data, resp.StartLeafInclusionProof, resp.EndLeafInclusionProof, nil
+}
+
+func (r *Replayer) AgreesWithHistoryCommitment(
+	ctx context.Context,
+	challengeLevel protocol.ChallengeLevel,
+	historyCommitMetadata *l2stateprovider.HistoryCommitmentRequest,
+	commit l2stateprovider.History,
+) (bool, error) {
+	return replay[bool](ctx, r, agreesWithHistoryCommitmentMethod, &agreesWithHistoryCommitmentRequest{
+		ChallengeLevel: challengeLevel,
+		Request:        toRecorded(historyCommitMetadata),
+		Commit:         commit,
+	})
+}
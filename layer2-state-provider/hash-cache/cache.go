@@ -0,0 +1,298 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+// Package hashcache caches the hashes collected from Arbitrator machines on disk, so
+// that the expensive collection is only run once per request, even across restarts.
+// Entries are checksummed, and are evicted least recently used first once the cache
+// grows beyond its size limit.
+package hashcache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/pkg/errors"
+)
+
+var (
+	srvlog         = log.New("service", "hash-cache")
+	hitsCounter    = metrics.NewRegisteredCounter("arb/validator/hashcache/hits", nil)
+	missesCounter  = metrics.NewRegisteredCounter("arb/validator/hashcache/misses", nil)
+	corruptCounter = metrics.NewRegisteredCounter("arb/validator/hashcache/corrupted", nil)
+	evictedCounter = metrics.NewRegisteredCounter("arb/validator/hashcache/evicted", nil)
+	bytesGauge     = metrics.NewRegisteredGauge("arb/validator/hashcache/bytes", nil)
+	entriesGauge   = metrics.NewRegisteredGauge("arb/validator/hashcache/entries", nil)
+)
+
+func init() {
+	srvlog.SetHandler(log.StreamHandler(os.Stdout, log.LogfmtFormat()))
+}
+
+const (
+	// Identifies the format of entry files, and is changed along with it.
+	magic         = "BOLDHC01"
+	entrySuffix   = ".hashes"
+	tmpSuffix     = ".tmp"
+	checksumBytes = sha256.Size
+)
+
+// ErrCorrupted is returned for entries whose contents do not match their checksum or key.
+var ErrCorrupted = errors.New("corrupted hash cache entry")
+
+type Opt func(c *Cache)
+
+// WithMaxBytes limits the total size of the entries on disk. Defaults to 1GiB.
+func WithMaxBytes(n int64) Opt {
+	return func(c *Cache) {
+		c.maxBytes = n
+	}
+}
+
+// Cache stores lists of hashes on disk by key, one file per entry. It is safe for
+// concurrent use, and the same directory can be reused by the next run. Keys are
+// namespaced by chain, so that validators of different chains can share a directory.
+type Cache struct {
+	dir      string
+	chain    string
+	maxBytes int64
+	lock     sync.Mutex
+	entries  map[string]*entryInfo
+	size     int64
+}
+
+type entryInfo struct {
+	name     string
+	size     int64
+	lastUsed time.Time
+}
+
+// New opens the cache of a chain, identified by its chain id and rollup address, in the
+// given directory, creating it if needed. Entries left by a previous run are indexed,
+// and evicted if they exceed the size limit.
+func New(dir string, chainId uint64, rollup common.Address, opts ...Opt) (*Cache, error) {
+	c := &Cache{
+		dir:      dir,
+		chain:    fmt.Sprintf("%d/%#x/", chainId, rollup),
+		maxBytes: 1 << 30,
+		entries:  make(map[string]*entryInfo),
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	if c.maxBytes <= 0 {
+		return nil, errors.New("hash cache size limit must be positive")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		name := f.Name()
+		if strings.HasSuffix(name, tmpSuffix) {
+			// Left over by an interrupted write.
+			_ = os.Remove(filepath.Join(dir, name))
+			continue
+		}
+		if !strings.HasSuffix(name, entrySuffix) {
+			continue
+		}
+		info, infoErr := f.Info()
+		if infoErr != nil {
+			return nil, infoErr
+		}
+		c.entries[name] = &entryInfo{name: name, size: info.Size(), lastUsed: info.ModTime()}
+		c.size += info.Size()
+	}
+	c.lock.Lock()
+	c.evictLocked()
+	c.lock.Unlock()
+	return c, nil
+}
+
+// Get returns the hashes stored under a key. Corrupted entries are removed, and
+// reported as missing, while entries which could not be read are kept.
+func (c *Cache) Get(key string) ([]common.Hash, bool) {
+	key = c.chain + key
+	name := fileName(key)
+	c.lock.Lock()
+	info, ok := c.entries[name]
+	if ok {
+		info.lastUsed = time.Now()
+	}
+	c.lock.Unlock()
+	if !ok {
+		missesCounter.Inc(1)
+		return nil, false
+	}
+	path := filepath.Join(c.dir, name)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		srvlog.Warn("Could not read hash cache entry", log.Ctx{"key": key, "err": err})
+		missesCounter.Inc(1)
+		return nil, false
+	}
+	hashes, err := decode(data, key)
+	if err != nil {
+		corruptCounter.Inc(1)
+		srvlog.Warn("Removing corrupted hash cache entry", log.Ctx{"key": key, "err": err})
+		c.lock.Lock()
+		c.removeLocked(name)
+		c.lock.Unlock()
+		missesCounter.Inc(1)
+		return nil, false
+	}
+	// Recency survives restarts through the modification time.
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	hitsCounter.Inc(1)
+	return hashes, true
+}
+
+// Put stores hashes under a key, replacing any previous entry, and evicts the least
+// recently used entries if the cache exceeds its size limit.
+func (c *Cache) Put(key string, hashes []common.Hash) error {
+	key = c.chain + key
+	data := encode(key, hashes)
+	if int64(len(data)) > c.maxBytes {
+		return errors.Errorf("hash cache entry of %d bytes exceeds the size limit", len(data))
+	}
+	name := fileName(key)
+	path := filepath.Join(c.dir, name)
+	// Written to a temporary file first so that readers never see a partial entry.
+	tmp, err := os.CreateTemp(c.dir, name+".*"+tmpSuffix)
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if prev, ok := c.entries[name]; ok {
+		c.size -= prev.size
+	}
+	c.entries[name] = &entryInfo{name: name, size: int64(len(data)), lastUsed: time.Now()}
+	c.size += int64(len(data))
+	c.evictLocked()
+	return nil
+}
+
+// Size returns the total size of the entries on disk.
+func (c *Cache) Size() int64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.size
+}
+
+func (c *Cache) evictLocked() {
+	if c.size > c.maxBytes {
+		byAge := make([]*entryInfo, 0, len(c.entries))
+		for _, info := range c.entries {
+			byAge = append(byAge, info)
+		}
+		sort.Slice(byAge, func(i, j int) bool {
+			return byAge[i].lastUsed.Before(byAge[j].lastUsed)
+		})
+		for _, info := range byAge {
+			if c.size <= c.maxBytes {
+				break
+			}
+			c.removeLocked(info.name)
+			evictedCounter.Inc(1)
+		}
+	}
+	bytesGauge.Update(c.size)
+	entriesGauge.Update(int64(len(c.entries)))
+}
+
+func (c *Cache) removeLocked(name string) {
+	info, ok := c.entries[name]
+	if !ok {
+		return
+	}
+	delete(c.entries, name)
+	c.size -= info.size
+	_ = os.Remove(filepath.Join(c.dir, name))
+}
+
+func fileName(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:]) + entrySuffix
+}
+
+// Entries are laid out as the magic, the length of the key and the key, the number of
+// hashes and the hashes, followed by a checksum of all of the above.
+func encode(key string, hashes []common.Hash) []byte {
+	buf := bytes.NewBuffer(make([]byte, 0, len(magic)+4+len(key)+8+len(hashes)*common.HashLength+checksumBytes))
+	buf.WriteString(magic)
+	_ = binary.Write(buf, binary.BigEndian, uint32(len(key)))
+	buf.WriteString(key)
+	_ = binary.Write(buf, binary.BigEndian, uint64(len(hashes)))
+	for _, h := range hashes {
+		buf.Write(h.Bytes())
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	buf.Write(sum[:])
+	return buf.Bytes()
+}
+
+func decode(data []byte, key string) ([]common.Hash, error) {
+	if len(data) < len(magic)+4+8+checksumBytes {
+		return nil, errors.Wrap(ErrCorrupted, "entry too short")
+	}
+	body, sum := data[:len(data)-checksumBytes], data[len(data)-checksumBytes:]
+	if expected := sha256.Sum256(body); !bytes.Equal(expected[:], sum) {
+		return nil, errors.Wrap(ErrCorrupted, "checksum mismatch")
+	}
+	if string(body[:len(magic)]) != magic {
+		return nil, errors.Wrap(ErrCorrupted, "unknown format")
+	}
+	body = body[len(magic):]
+	keyLen := uint64(binary.BigEndian.Uint32(body))
+	body = body[4:]
+	if uint64(len(body)) < keyLen+8 {
+		return nil, errors.Wrap(ErrCorrupted, "truncated key")
+	}
+	if string(body[:keyLen]) != key {
+		return nil, errors.Wrap(ErrCorrupted, "key mismatch")
+	}
+	body = body[keyLen:]
+	count := binary.BigEndian.Uint64(body)
+	body = body[8:]
+	if uint64(len(body)) != count*common.HashLength {
+		return nil, errors.Wrap(ErrCorrupted, "unexpected number of hashes")
+	}
+	hashes := make([]common.Hash, count)
+	for i := range hashes {
+		hashes[i] = common.BytesToHash(body[i*common.HashLength : (i+1)*common.HashLength])
+	}
+	return hashes, nil
+}
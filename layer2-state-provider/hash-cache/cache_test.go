@@ -0,0 +1,225 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package hashcache
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/OffchainLabs/bold/containers/option"
+	l2stateprovider "github.com/OffchainLabs/bold/layer2-state-provider"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+const testChainId = 42161
+
+var testRollup = common.Address{1}
+
+func hashes(n int, seed byte) []common.Hash {
+	hs := make([]common.Hash, n)
+	for i := range hs {
+		hs[i] = common.Hash{seed, byte(i)}
+	}
+	return hs
+}
+
+func TestCache_PersistsAcrossRestarts(t *testing.T) {
+	dir := t.TempDir()
+	c, err := New(dir, testChainId, testRollup)
+	require.NoError(t, err)
+
+	_, ok := c.Get("a")
+	require.False(t, ok)
+	require.NoError(t, c.Put("a", hashes(3, 1)))
+	got, ok := c.Get("a")
+	require.True(t, ok)
+	require.Equal(t, hashes(3, 1), got)
+
+	// An interrupted write is cleaned up on restart.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "partial"+tmpSuffix), []byte{1}, 0o644))
+	c, err = New(dir, testChainId, testRollup)
+	require.NoError(t, err)
+	got, ok = c.Get("a")
+	require.True(t, ok)
+	require.Equal(t, hashes(3, 1), got)
+	_, err = os.Stat(filepath.Join(dir, "partial"+tmpSuffix))
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestCache_DetectsCorruption(t *testing.T) {
+	dir := t.TempDir()
+	c, err := New(dir, testChainId, testRollup)
+	require.NoError(t, err)
+	require.NoError(t, c.Put("a", hashes(3, 1)))
+
+	path := filepath.Join(dir, fileName(c.chain+"a"))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	data[len(magic)+10] ^= 0xff
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+
+	_, ok := c.Get("a")
+	require.False(t, ok)
+	_, err = os.Stat(path)
+	require.True(t, os.IsNotExist(err))
+	require.Equal(t, int64(0), c.Size())
+
+	_, err = decode(encode("a", hashes(1, 1)), "b")
+	require.ErrorIs(t, err, ErrCorrupted)
+
+	// Entries which cannot be read are kept, as they may be read again later.
+	require.NoError(t, c.Put("b", hashes(3, 2)))
+	path = filepath.Join(dir, fileName(c.chain+"b"))
+	require.NoError(t, os.Rename(path, path+".moved"))
+	_, ok = c.Get("b")
+	require.False(t, ok)
+	require.NotZero(t, c.Size())
+	require.NoError(t, os.Rename(path+".moved", path))
+	got, ok := c.Get("b")
+	require.True(t, ok)
+	require.Equal(t, hashes(3, 2), got)
+}
+
+func TestCache_NamespacedByChain(t *testing.T) {
+	dir := t.TempDir()
+	c, err := New(dir, testChainId, testRollup)
+	require.NoError(t, err)
+	require.NoError(t, c.Put("a", hashes(3, 1)))
+
+	// Caches of other chains sharing the directory do not see the entry.
+	for _, other := range []struct {
+		chainId uint64
+		rollup  common.Address
+	}{
+		{testChainId + 1, testRollup},
+		{testChainId, common.Address{2}},
+	} {
+		otherCache, err := New(dir, other.chainId, other.rollup)
+		require.NoError(t, err)
+		_, ok := otherCache.Get("a")
+		require.False(t, ok)
+	}
+	got, ok := c.Get("a")
+	require.True(t, ok)
+	require.Equal(t, hashes(3, 1), got)
+}
+
+func TestCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	dir := t.TempDir()
+	entrySize := int64(len(encode(fmt.Sprintf("%d/%#x/a", testChainId, testRollup), hashes(4, 1))))
+	c, err := New(dir, testChainId, testRollup, WithMaxBytes(2*entrySize))
+	require.NoError(t, err)
+
+	require.NoError(t, c.Put("a", hashes(4, 1)))
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, c.Put("b", hashes(4, 2)))
+	time.Sleep(10 * time.Millisecond)
+	_, ok := c.Get("a")
+	require.True(t, ok)
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, c.Put("c", hashes(4, 3)))
+
+	require.Equal(t, 2*entrySize, c.Size())
+	_, ok = c.Get("b")
+	require.False(t, ok)
+	_, ok = c.Get("a")
+	require.True(t, ok)
+	_, ok = c.Get("c")
+	require.True(t, ok)
+
+	// A smaller limit on restart evicts down to it.
+	c, err = New(dir, testChainId, testRollup, WithMaxBytes(entrySize))
+	require.NoError(t, err)
+	require.Equal(t, entrySize, c.Size())
+
+	require.Error(t, c.Put("d", hashes(100, 4)))
+}
+
+type countingCollector struct {
+	calls int
+}
+
+func (c *countingCollector) CollectMachineHashes(_ context.Context, cfg *l2stateprovider.HashCollectorConfig) ([]common.Hash, error) {
+	c.calls++
+	return hashes(int(cfg.NumDesiredHashes), byte(cfg.StepSize)), nil
+}
+
+func (c *countingCollector) L2MessageStatesUpTo(
+	_ context.Context,
+	fromHeight l2stateprovider.Height,
+	toHeight option.Option[l2stateprovider.Height],
+	_, _ l2stateprovider.Batch,
+) ([]common.Hash, error) {
+	c.calls++
+	return hashes(int(toHeight.Unwrap()-fromHeight)+1, 0), nil
+}
+
+func TestMachineHashCollector(t *testing.T) {
+	ctx := context.Background()
+	c, err := New(t.TempDir(), testChainId, testRollup)
+	require.NoError(t, err)
+	inner := &countingCollector{}
+	collector := NewMachineHashCollector(inner, c)
+
+	cfg := &l2stateprovider.HashCollectorConfig{
+		WasmModuleRoot:       common.Hash{1},
+		FromBatch:            1,
+		BlockChallengeHeight: 2,
+		StepHeights:          []l2stateprovider.Height{3},
+		NumDesiredHashes:     8,
+		MachineStartIndex:    4,
+		StepSize:             5,
+	}
+	got, err := collector.CollectMachineHashes(ctx, cfg)
+	require.NoError(t, err)
+	require.Equal(t, hashes(8, 5), got)
+	got, err = collector.CollectMachineHashes(ctx, cfg)
+	require.NoError(t, err)
+	require.Equal(t, hashes(8, 5), got)
+	require.Equal(t, 1, inner.calls)
+
+	// Fewer hashes are served from those cached.
+	fewer := *cfg
+	fewer.NumDesiredHashes = 3
+	got, err = collector.CollectMachineHashes(ctx, &fewer)
+	require.NoError(t, err)
+	require.Equal(t, hashes(3, 5), got)
+	require.Equal(t, 1, inner.calls)
+
+	// More hashes, or a different step size, are collected.
+	more := *cfg
+	more.NumDesiredHashes = 16
+	_, err = collector.CollectMachineHashes(ctx, &more)
+	require.NoError(t, err)
+	require.Equal(t, 2, inner.calls)
+	other := *cfg
+	other.StepSize = 6
+	got, err = collector.CollectMachineHashes(ctx, &other)
+	require.NoError(t, err)
+	require.Equal(t, hashes(8, 6), got)
+	require.Equal(t, 3, inner.calls)
+}
+
+func TestL2MessageStateCollector(t *testing.T) {
+	ctx := context.Background()
+	c, err := New(t.TempDir(), testChainId, testRollup)
+	require.NoError(t, err)
+	inner := &countingCollector{}
+	collector := NewL2MessageStateCollector(inner, c)
+
+	for i := 0; i < 2; i++ {
+		got, collectErr := collector.L2MessageStatesUpTo(ctx, 1, option.Some(l2stateprovider.Height(4)), 0, 1)
+		require.NoError(t, collectErr)
+		require.Equal(t, hashes(4, 0), got)
+	}
+	require.Equal(t, 1, inner.calls)
+	_, err = collector.L2MessageStatesUpTo(ctx, 1, option.Some(l2stateprovider.Height(4)), 0, 2)
+	require.NoError(t, err)
+	require.Equal(t, 2, inner.calls)
+}
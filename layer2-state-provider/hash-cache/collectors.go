@@ -0,0 +1,109 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package hashcache
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/OffchainLabs/bold/containers/option"
+	l2stateprovider "github.com/OffchainLabs/bold/layer2-state-provider"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// MachineHashCollector caches the machine hashes collected by another collector.
+type MachineHashCollector struct {
+	collector l2stateprovider.MachineHashCollector
+	cache     *Cache
+}
+
+// NewMachineHashCollector wraps a machine hash collector with a cache.
+func NewMachineHashCollector(collector l2stateprovider.MachineHashCollector, cache *Cache) *MachineHashCollector {
+	return &MachineHashCollector{collector: collector, cache: cache}
+}
+
+// CollectMachineHashes returns the cached hashes of a request, or collects and caches
+// them. Hashes cached for a request for more of them are reused, as collection
+// always starts from the same machine index.
+func (m *MachineHashCollector) CollectMachineHashes(
+	ctx context.Context,
+	cfg *l2stateprovider.HashCollectorConfig,
+) ([]common.Hash, error) {
+	key := machineHashesKey(cfg)
+	cached, ok := m.cache.Get(key)
+	if ok && uint64(len(cached)) >= cfg.NumDesiredHashes {
+		return cached[:cfg.NumDesiredHashes], nil
+	}
+	hashes, err := m.collector.CollectMachineHashes(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if len(hashes) <= len(cached) {
+		return hashes, nil
+	}
+	if putErr := m.cache.Put(key, hashes); putErr != nil {
+		srvlog.Error("Could not cache machine hashes", log.Ctx{"key": key, "err": putErr})
+	}
+	return hashes, nil
+}
+
+// L2MessageStateCollector caches the message states collected by another collector.
+type L2MessageStateCollector struct {
+	collector l2stateprovider.L2MessageStateCollector
+	cache     *Cache
+}
+
+// NewL2MessageStateCollector wraps an L2 message state collector with a cache.
+func NewL2MessageStateCollector(collector l2stateprovider.L2MessageStateCollector, cache *Cache) *L2MessageStateCollector {
+	return &L2MessageStateCollector{collector: collector, cache: cache}
+}
+
+// L2MessageStatesUpTo returns the cached message states of a range, or collects and
+// caches them.
+func (m *L2MessageStateCollector) L2MessageStatesUpTo(
+	ctx context.Context,
+	fromHeight l2stateprovider.Height,
+	toHeight option.Option[l2stateprovider.Height],
+	fromBatch,
+	toBatch l2stateprovider.Batch,
+) ([]common.Hash, error) {
+	key := messageStatesKey(fromHeight, toHeight, fromBatch, toBatch)
+	if hashes, ok := m.cache.Get(key); ok {
+		return hashes, nil
+	}
+	hashes, err := m.collector.L2MessageStatesUpTo(ctx, fromHeight, toHeight, fromBatch, toBatch)
+	if err != nil {
+		return nil, err
+	}
+	if putErr := m.cache.Put(key, hashes); putErr != nil {
+		srvlog.Error("Could not cache message states", log.Ctx{"key": key, "err": putErr})
+	}
+	return hashes, nil
+}
+
+func machineHashesKey(cfg *l2stateprovider.HashCollectorConfig) string {
+	return fmt.Sprintf(
+		"machine/%#x/%d/%d/%v/%d/%d",
+		cfg.WasmModuleRoot,
+		cfg.FromBatch,
+		cfg.BlockChallengeHeight,
+		cfg.StepHeights,
+		cfg.MachineStartIndex,
+		cfg.StepSize,
+	)
+}
+
+func messageStatesKey(
+	fromHeight l2stateprovider.Height,
+	toHeight option.Option[l2stateprovider.Height],
+	fromBatch,
+	toBatch l2stateprovider.Batch,
+) string {
+	to := "all"
+	if toHeight.IsSome() {
+		to = fmt.Sprintf("%d", toHeight.Unwrap())
+	}
+	return fmt.Sprintf("messages/%d/%d/%d/%s", fromBatch, toBatch, fromHeight, to)
+}
@@ -77,9 +77,25 @@ type HistoryCommitmentProvider struct {
 	machineHashCollector    MachineHashCollector
 	proofCollector          ProofCollector
 	challengeLeafHeights    []Height
+	collectorPool           *CollectorPool
+	minChunkHashes          uint64
+	leafChunkHashes         uint64
+	moduleRoots             *ModuleRootRegistry
 	ExecutionProvider
 }
 
+// The default number of machine hashes collected at a time to compute a commitment.
+const defaultLeafChunkHashes = 1 << 16
+
+// WithLeafChunkSize sets the number of machine hashes collected at a time to compute a
+// history commitment or prefix proof, which bounds the number of leaves held in memory.
+// Defaults to 65536.
+func WithLeafChunkSize(numHashes uint64) HistoryCommitmentProviderOpt {
+	return func(p *HistoryCommitmentProvider) {
+		p.leafChunkHashes = numHashes
+	}
+}
+
 // NewHistoryCommitmentProvider creates an instance of a struct which can compute history commitments
 // over any number of challenge levels for BOLD.
 func NewHistoryCommitmentProvider(
@@ -88,14 +104,23 @@ func NewHistoryCommitmentProvider(
 	proofCollector ProofCollector,
 	challengeLeafHeights []Height,
 	executionProvider ExecutionProvider,
+	opts ...HistoryCommitmentProviderOpt,
 ) *HistoryCommitmentProvider {
-	return &HistoryCommitmentProvider{
+	p := &HistoryCommitmentProvider{
 		l2MessageStateCollector: l2MessageStateCollector,
 		machineHashCollector:    machineHashCollector,
 		proofCollector:          proofCollector,
 		challengeLeafHeights:    challengeLeafHeights,
+		leafChunkHashes:         defaultLeafChunkHashes,
 		ExecutionProvider:       executionProvider,
 	}
+	for _, o := range opts {
+		o(p)
+	}
+	if p.leafChunkHashes == 0 {
+		p.leafChunkHashes = defaultLeafChunkHashes
+	}
+	return p
 }
 
 // A list of heights that have been validated to be non-empty
@@ -109,21 +134,30 @@ func (p *HistoryCommitmentProvider) HistoryCommitment(
 	ctx context.Context,
 	req *HistoryCommitmentRequest,
 ) (commitments.History, error) {
-	hashes, err := p.historyCommitmentImpl(ctx, req)
+	leaves, _, err := p.historyLeaves(ctx, req)
 	if err != nil {
 		return commitments.History{}, err
 	}
-	return commitments.New(hashes)
+	return commitments.NewFromIterator(leaves)
 }
 
-func (p *HistoryCommitmentProvider) historyCommitmentImpl(
+// Returns an iterator over the leaves of a history commitment, along with their number.
+// Machine hashes are collected as they are iterated over, a chunk at a time.
+func (p *HistoryCommitmentProvider) historyLeaves(
 	ctx context.Context,
 	req *HistoryCommitmentRequest,
-) ([]common.Hash, error) {
+) (commitments.LeafIterator, uint64, error) {
+	// Block challenge leaves come from the L2 message states, which do not depend on the
+	// module root, so we check it is known up front for every level.
+	if p.moduleRoots != nil {
+		if _, err := p.moduleRoots.Backend(req.WasmModuleRoot); err != nil {
+			return nil, 0, err
+		}
+	}
 	// Validate the input heights for correctness.
 	validatedHeights, err := p.validateOriginHeights(req.UpperChallengeOriginHeights)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	// If the call is for message number ranges only, we get the hashes for
 	// those states and return a commitment for them.
@@ -137,9 +171,9 @@ func (p *HistoryCommitmentProvider) historyCommitmentImpl(
 			req.ToBatch,
 		)
 		if hashesErr != nil {
-			return nil, hashesErr
+			return nil, 0, hashesErr
 		}
-		return hashes, nil
+		return commitments.SliceIterator(hashes), uint64(len(hashes)), nil
 	} else {
 		fromBlockChallengeHeight = validatedHeights[0]
 	}
@@ -151,23 +185,23 @@ func (p *HistoryCommitmentProvider) historyCommitmentImpl(
 	// the machine from the inputs, and figure out, in what increments, we need to do so.
 	machineStartIndex, err := p.computeMachineStartIndex(validatedHeights, req.FromHeight)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	// We compute the stepwise increments we need for stepping through the machine.
 	stepSize, err := p.computeStepSize(desiredChallengeLevel)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	// Compute how many machine hashes we need to collect at the desired challenge level.
 	numHashes, err := p.computeRequiredNumberOfHashes(desiredChallengeLevel, req.FromHeight, req.UpToHeight)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	// Collect the machine hashes at the specified challenge level based on the values we computed.
-	return p.machineHashCollector.CollectMachineHashes(
+	return p.machineHashIterator(
 		ctx,
 		&HashCollectorConfig{
 			WasmModuleRoot:       req.WasmModuleRoot,
@@ -181,7 +215,79 @@ func (p *HistoryCommitmentProvider) historyCommitmentImpl(
 			MachineStartIndex: machineStartIndex,
 			StepSize:          stepSize,
 		},
-	)
+	), numHashes, nil
+}
+
+// Iterates over the machine hashes of a collection, collecting them a chunk at a time.
+type machineHashIterator struct {
+	ctx       context.Context
+	p         *HistoryCommitmentProvider
+	cfg       HashCollectorConfig
+	chunkSize uint64
+	// Number of hashes collected so far.
+	collected uint64
+	chunk     []common.Hash
+}
+
+func (p *HistoryCommitmentProvider) machineHashIterator(ctx context.Context, cfg *HashCollectorConfig) *machineHashIterator {
+	chunkSize := p.leafChunkHashes
+	// Chunks are large enough to still be split over all the collectors of the pool.
+	if p.collectorPool != nil && chunkSize < p.minChunkHashes*uint64(p.collectorPool.Size()) {
+		chunkSize = p.minChunkHashes * uint64(p.collectorPool.Size())
+	}
+	return &machineHashIterator{
+		ctx:       ctx,
+		p:         p,
+		cfg:       *cfg,
+		chunkSize: chunkSize,
+	}
+}
+
+func (it *machineHashIterator) Next() (common.Hash, bool, error) {
+	if len(it.chunk) == 0 {
+		if it.collected == it.cfg.NumDesiredHashes {
+			return common.Hash{}, false, nil
+		}
+		size := it.cfg.NumDesiredHashes - it.collected
+		if size > it.chunkSize {
+			size = it.chunkSize
+		}
+		chunk := it.cfg
+		chunk.StepHeights = append([]Height(nil), it.cfg.StepHeights...)
+		chunk.MachineStartIndex = it.cfg.MachineStartIndex + OpcodeIndex(it.collected*uint64(it.cfg.StepSize))
+		chunk.NumDesiredHashes = size
+		hashes, err := it.p.collectMachineHashes(it.ctx, &chunk)
+		if err != nil {
+			return common.Hash{}, false, err
+		}
+		if uint64(len(hashes)) != size {
+			return common.Hash{}, false, fmt.Errorf(
+				"collector returned %d hashes from machine index %d, expected %d",
+				len(hashes),
+				chunk.MachineStartIndex,
+				size,
+			)
+		}
+		it.chunk = hashes
+		it.collected += size
+	}
+	leaf := it.chunk[0]
+	it.chunk = it.chunk[1:]
+	return leaf, true, nil
+}
+
+// Yields at most a number of leaves of an iterator.
+type limitedIterator struct {
+	it        commitments.LeafIterator
+	remaining uint64
+}
+
+func (it *limitedIterator) Next() (common.Hash, bool, error) {
+	if it.remaining == 0 {
+		return common.Hash{}, false, nil
+	}
+	it.remaining--
+	return it.it.Next()
 }
 
 // AgreesWithHistoryCommitment checks if the l2 state provider agrees with a specified start and end
@@ -264,7 +370,7 @@ func (p *HistoryCommitmentProvider) PrefixProof(
 	prefixHeight Height,
 ) ([]byte, error) {
 	// Obtain the leaves we need to produce our Merkle expansion.
-	leaves, err := p.historyCommitmentImpl(
+	leaves, numLeaves, err := p.historyLeaves(
 		ctx,
 		req,
 	)
@@ -275,7 +381,7 @@ func (p *HistoryCommitmentProvider) PrefixProof(
 	lowCommitmentNumLeaves := uint64(prefixHeight + 1)
 	var highCommitmentNumLeaves uint64
 	if req.UpToHeight.IsNone() {
-		highCommitmentNumLeaves = uint64(len(leaves))
+		highCommitmentNumLeaves = numLeaves
 	} else {
 		// Else if it is provided, we expect the number of leaves to be the difference
 		// between the to and from height + 1.
@@ -286,9 +392,9 @@ func (p *HistoryCommitmentProvider) PrefixProof(
 		highCommitmentNumLeaves = uint64(upTo) - uint64(req.FromHeight) + 1
 	}
 
-	// Validate we are within bounds of the leaves slice.
-	if highCommitmentNumLeaves > uint64(len(leaves)) {
-		return nil, fmt.Errorf("high prefix size out of bounds, got %d, leaves length %d", highCommitmentNumLeaves, len(leaves))
+	// Validate we are within bounds of the leaves.
+	if highCommitmentNumLeaves > numLeaves {
+		return nil, fmt.Errorf("high prefix size out of bounds, got %d, leaves length %d", highCommitmentNumLeaves, numLeaves)
 	}
 
 	// Validate low vs high commitment.
@@ -296,40 +402,22 @@ func (p *HistoryCommitmentProvider) PrefixProof(
 		return nil, fmt.Errorf("low prefix size %d was greater than high prefix size %d", lowCommitmentNumLeaves, highCommitmentNumLeaves)
 	}
 
-	prefixExpansion, err := prefixproofs.ExpansionFromLeaves(leaves[:lowCommitmentNumLeaves])
+	// Build both commitments and the proof in a single pass over the leaves.
+	builder, err := commitments.NewPrefixBuilder(lowCommitmentNumLeaves, highCommitmentNumLeaves)
 	if err != nil {
 		return nil, err
 	}
-	prefixProof, err := prefixproofs.GeneratePrefixProof(
-		lowCommitmentNumLeaves,
-		prefixExpansion,
-		leaves[lowCommitmentNumLeaves:highCommitmentNumLeaves],
-		prefixproofs.RootFetcherFromExpansion,
-	)
-	if err != nil {
+	if err = builder.AppendAll(&limitedIterator{it: leaves, remaining: highCommitmentNumLeaves}); err != nil {
 		return nil, err
 	}
-	bigCommit, err := commitments.New(leaves[:highCommitmentNumLeaves])
-	if err != nil {
-		return nil, err
-	}
-
-	prefixCommit, err := commitments.New(leaves[:lowCommitmentNumLeaves])
+	proof, err := builder.PrefixProof()
 	if err != nil {
 		return nil, err
 	}
-	_, numRead := prefixproofs.MerkleExpansionFromCompact(prefixProof, lowCommitmentNumLeaves)
-	onlyProof := prefixProof[numRead:]
+	prefixExpansion, onlyProof := proof.PreExpansion, proof.PrefixProof
 
 	// We verify our prefix proof before an onchain submission as an extra safety-check.
-	if err = prefixproofs.VerifyPrefixProof(&prefixproofs.VerifyPrefixProofConfig{
-		PreRoot:      prefixCommit.Merkle,
-		PreSize:      lowCommitmentNumLeaves,
-		PostRoot:     bigCommit.Merkle,
-		PostSize:     highCommitmentNumLeaves,
-		PreExpansion: prefixExpansion,
-		PrefixProof:  onlyProof,
-	}); err != nil {
+	if err = prefixproofs.VerifyPrefixProof(proof); err != nil {
 		return nil, fmt.Errorf("could not verify prefix proof locally: %w", err)
 	}
 	return ProofArgs.Pack(&prefixExpansion, &onlyProof)
@@ -1,10 +1,14 @@
 package l2stateprovider
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"testing"
 
 	"github.com/OffchainLabs/bold/containers/option"
+	commitments "github.com/OffchainLabs/bold/state-commitments/history"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/stretchr/testify/require"
 )
 
@@ -221,3 +225,43 @@ func TestLeafHeightAtChallengeLevel(t *testing.T) {
 		t.Errorf("Unexpected error: %v", err)
 	}
 }
+
+func TestHistoryCommitmentProvider_CollectsLeavesInChunks(t *testing.T) {
+	ctx := context.Background()
+	req := &HistoryCommitmentRequest{
+		UpperChallengeOriginHeights: []Height{1},
+		UpToHeight:                  option.None[Height](),
+	}
+	chunked := &opcodeCollector{}
+	p := NewHistoryCommitmentProvider(nil, chunked, nil, []Height{4, 8, 16}, nil, WithLeafChunkSize(2))
+	whole := &opcodeCollector{}
+	unchunked := NewHistoryCommitmentProvider(nil, whole, nil, []Height{4, 8, 16}, nil)
+
+	// The 9 leaves of the commitment are collected 2 at a time.
+	leaves := make([]common.Hash, 9)
+	for i := range leaves {
+		leaves[i] = opcodeHash(OpcodeIndex(i * 16))
+	}
+	want, err := commitments.New(leaves)
+	require.NoError(t, err)
+	got, err := p.HistoryCommitment(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+	require.Equal(t, int32(5), chunked.calls.Load())
+
+	// Prefix proofs are the same as if the leaves were collected at once.
+	for _, upTo := range []option.Option[Height]{option.None[Height](), option.Some(Height(4))} {
+		req.UpToHeight = upTo
+		proof, err := p.PrefixProof(ctx, req, 1)
+		require.NoError(t, err)
+		wantProof, err := unchunked.PrefixProof(ctx, req, 1)
+		require.NoError(t, err)
+		require.Equal(t, wantProof, proof)
+	}
+	require.Equal(t, int32(5+5+3), chunked.calls.Load())
+	require.Equal(t, int32(2), whole.calls.Load())
+
+	chunked.err = errors.New("machine crashed")
+	_, err = p.HistoryCommitment(ctx, req)
+	require.ErrorContains(t, err, "machine crashed")
+}
@@ -0,0 +1,119 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package l2stateprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ErrUnknownWasmModuleRoot is returned for requests about machines of a WASM module
+// root that no backend was registered for, such as after an upgrade of the chain the
+// validator was not configured for.
+var ErrUnknownWasmModuleRoot = errors.New("no machine backend for wasm module root")
+
+// MachineBackend executes the machines of a WASM module root.
+type MachineBackend struct {
+	HashCollector  MachineHashCollector
+	ProofCollector ProofCollector
+}
+
+// ModuleRootRegistry maps WASM module roots to the backends executing their machines.
+// A chain's module root can be upgraded, and the machines of an assertion's children
+// are then of the new root, while those of the assertion itself remain of the old one,
+// so a validator needs a backend for every root its pending assertions are executed by.
+// The registry collects machine hashes and proofs from the backend of the requested root.
+type ModuleRootRegistry struct {
+	lock     sync.RWMutex
+	backends map[common.Hash]*MachineBackend
+}
+
+func NewModuleRootRegistry() *ModuleRootRegistry {
+	return &ModuleRootRegistry{
+		backends: make(map[common.Hash]*MachineBackend),
+	}
+}
+
+// Register the backend executing the machines of a module root, replacing any previous one.
+func (r *ModuleRootRegistry) Register(wasmModuleRoot common.Hash, backend *MachineBackend) error {
+	if backend == nil || backend.HashCollector == nil || backend.ProofCollector == nil {
+		return fmt.Errorf("incomplete machine backend for wasm module root %#x", wasmModuleRoot)
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.backends[wasmModuleRoot] = backend
+	return nil
+}
+
+// Unregister the backend of a module root, once no pending assertion is executed by it.
+func (r *ModuleRootRegistry) Unregister(wasmModuleRoot common.Hash) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	delete(r.backends, wasmModuleRoot)
+}
+
+// Backend executing the machines of a module root, or ErrUnknownWasmModuleRoot.
+func (r *ModuleRootRegistry) Backend(wasmModuleRoot common.Hash) (*MachineBackend, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	backend, ok := r.backends[wasmModuleRoot]
+	if !ok {
+		return nil, fmt.Errorf("%w %#x", ErrUnknownWasmModuleRoot, wasmModuleRoot)
+	}
+	return backend, nil
+}
+
+// WasmModuleRoots that have a backend, in ascending order.
+func (r *ModuleRootRegistry) WasmModuleRoots() []common.Hash {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	roots := make([]common.Hash, 0, len(r.backends))
+	for root := range r.backends {
+		roots = append(roots, root)
+	}
+	sort.Slice(roots, func(i, j int) bool {
+		return roots[i].Big().Cmp(roots[j].Big()) < 0
+	})
+	return roots
+}
+
+// WithModuleRootRegistry collects machine hashes and proofs from the backends of the
+// registry instead of the provider's collectors, and rejects requests about any other
+// module root with ErrUnknownWasmModuleRoot.
+func WithModuleRootRegistry(registry *ModuleRootRegistry) HistoryCommitmentProviderOpt {
+	return func(p *HistoryCommitmentProvider) {
+		p.moduleRoots = registry
+		p.machineHashCollector = registry
+		p.proofCollector = registry
+	}
+}
+
+// CollectMachineHashes with the backend of the config's module root.
+func (r *ModuleRootRegistry) CollectMachineHashes(ctx context.Context, cfg *HashCollectorConfig) ([]common.Hash, error) {
+	backend, err := r.Backend(cfg.WasmModuleRoot)
+	if err != nil {
+		return nil, err
+	}
+	return backend.HashCollector.CollectMachineHashes(ctx, cfg)
+}
+
+// CollectProof with the backend of the module root.
+func (r *ModuleRootRegistry) CollectProof(
+	ctx context.Context,
+	wasmModuleRoot common.Hash,
+	fromBatch Batch,
+	blockChallengeHeight Height,
+	machineIndex OpcodeIndex,
+) ([]byte, error) {
+	backend, err := r.Backend(wasmModuleRoot)
+	if err != nil {
+		return nil, err
+	}
+	return backend.ProofCollector.CollectProof(ctx, wasmModuleRoot, fromBatch, blockChallengeHeight, machineIndex)
+}
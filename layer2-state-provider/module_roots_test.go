@@ -0,0 +1,89 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package l2stateprovider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/OffchainLabs/bold/containers/option"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+type rootProofCollector struct {
+	proof []byte
+}
+
+func (c *rootProofCollector) CollectProof(
+	_ context.Context,
+	_ common.Hash,
+	_ Batch,
+	_ Height,
+	_ OpcodeIndex,
+) ([]byte, error) {
+	return c.proof, nil
+}
+
+func TestModuleRootRegistry(t *testing.T) {
+	ctx := context.Background()
+	oldRoot, newRoot := common.Hash{2}, common.Hash{1}
+	oldCollector, newCollector := &opcodeCollector{}, &opcodeCollector{}
+	registry := NewModuleRootRegistry()
+	require.NoError(t, registry.Register(oldRoot, &MachineBackend{
+		HashCollector:  oldCollector,
+		ProofCollector: &rootProofCollector{proof: []byte("old")},
+	}))
+	require.NoError(t, registry.Register(newRoot, &MachineBackend{
+		HashCollector:  newCollector,
+		ProofCollector: &rootProofCollector{proof: []byte("new")},
+	}))
+	require.Equal(t, []common.Hash{newRoot, oldRoot}, registry.WasmModuleRoots())
+
+	cfg := &HashCollectorConfig{
+		WasmModuleRoot:   newRoot,
+		StepHeights:      []Height{1},
+		NumDesiredHashes: 4,
+		StepSize:         1,
+	}
+	hashes, err := registry.CollectMachineHashes(ctx, cfg)
+	require.NoError(t, err)
+	require.Len(t, hashes, 4)
+	require.Equal(t, int32(1), newCollector.calls.Load())
+	require.Equal(t, int32(0), oldCollector.calls.Load())
+
+	proof, err := registry.CollectProof(ctx, oldRoot, 0, 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, []byte("old"), proof)
+
+	registry.Unregister(oldRoot)
+	_, err = registry.CollectProof(ctx, oldRoot, 0, 0, 0)
+	require.ErrorIs(t, err, ErrUnknownWasmModuleRoot)
+	cfg.WasmModuleRoot = oldRoot
+	_, err = registry.CollectMachineHashes(ctx, cfg)
+	require.ErrorIs(t, err, ErrUnknownWasmModuleRoot)
+	require.Equal(t, []common.Hash{newRoot}, registry.WasmModuleRoots())
+
+	require.ErrorContains(t, registry.Register(oldRoot, nil), "incomplete machine backend")
+	require.ErrorContains(t, registry.Register(oldRoot, &MachineBackend{HashCollector: oldCollector}), "incomplete machine backend")
+}
+
+func TestWithModuleRootRegistry(t *testing.T) {
+	ctx := context.Background()
+	registry := NewModuleRootRegistry()
+	require.NoError(t, registry.Register(common.Hash{1}, &MachineBackend{
+		HashCollector:  &opcodeCollector{},
+		ProofCollector: &rootProofCollector{},
+	}))
+	// Requests about an unknown module root are rejected before any state is collected.
+	p := NewHistoryCommitmentProvider(nil, nil, nil, []Height{4, 8, 16}, nil, WithModuleRootRegistry(registry))
+	_, err := p.HistoryCommitment(ctx, &HistoryCommitmentRequest{
+		WasmModuleRoot:              common.Hash{2},
+		FromBatch:                   0,
+		ToBatch:                     1,
+		UpperChallengeOriginHeights: []Height{},
+		UpToHeight:                  option.None[Height](),
+	})
+	require.ErrorIs(t, err, ErrUnknownWasmModuleRoot)
+}
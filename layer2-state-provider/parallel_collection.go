@@ -0,0 +1,195 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package l2stateprovider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+
+	"github.com/ethereum/go-ethereum/common"
+	"golang.org/x/sync/errgroup"
+)
+
+// HistoryCommitmentProviderOpt configures a history commitment provider.
+type HistoryCommitmentProviderOpt func(p *HistoryCommitmentProvider)
+
+// WithParallelCollection splits machine hash collections into contiguous sub-ranges of
+// at least minChunkHashes hashes, which are collected concurrently by the collectors of
+// the pool and reassembled in order. Smaller collections are left to the provider's
+// machine hash collector.
+func WithParallelCollection(pool *CollectorPool, minChunkHashes uint64) HistoryCommitmentProviderOpt {
+	return func(p *HistoryCommitmentProvider) {
+		p.collectorPool = pool
+		p.minChunkHashes = minChunkHashes
+	}
+}
+
+// CollectorPool is a pool of machine hash collectors, each collecting one sub-range
+// at a time. Collectors can run in the same process, or in worker processes such as
+// with a ProcessCollector.
+type CollectorPool struct {
+	idle chan MachineHashCollector
+	size int
+}
+
+// NewCollectorPool creates a pool of the given collectors.
+func NewCollectorPool(collectors ...MachineHashCollector) (*CollectorPool, error) {
+	if len(collectors) == 0 {
+		return nil, errors.New("collector pool needs at least one collector")
+	}
+	p := &CollectorPool{
+		idle: make(chan MachineHashCollector, len(collectors)),
+		size: len(collectors),
+	}
+	for _, c := range collectors {
+		p.idle <- c
+	}
+	return p, nil
+}
+
+// Size is the number of collectors in the pool.
+func (p *CollectorPool) Size() int {
+	return p.size
+}
+
+// Collects a sub-range with the next idle collector.
+func (p *CollectorPool) collect(ctx context.Context, cfg *HashCollectorConfig) ([]common.Hash, error) {
+	var collector MachineHashCollector
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case collector = <-p.idle:
+	}
+	defer func() { p.idle <- collector }()
+	return collector.CollectMachineHashes(ctx, cfg)
+}
+
+// Collects machine hashes, split across the collector pool if the collection is large
+// enough. The hash at index i of a collection is the machine hash at opcode
+// MachineStartIndex + i*StepSize, so a sub-range starting at index i starts there.
+func (p *HistoryCommitmentProvider) collectMachineHashes(ctx context.Context, cfg *HashCollectorConfig) ([]common.Hash, error) {
+	if p.collectorPool == nil || p.minChunkHashes == 0 {
+		return p.machineHashCollector.CollectMachineHashes(ctx, cfg)
+	}
+	numChunks := cfg.NumDesiredHashes / p.minChunkHashes
+	if numChunks > uint64(p.collectorPool.Size()) {
+		numChunks = uint64(p.collectorPool.Size())
+	}
+	if numChunks < 2 {
+		return p.machineHashCollector.CollectMachineHashes(ctx, cfg)
+	}
+	chunkSize := cfg.NumDesiredHashes / numChunks
+	remainder := cfg.NumDesiredHashes % numChunks
+	hashes := make([]common.Hash, cfg.NumDesiredHashes)
+	eg, egCtx := errgroup.WithContext(ctx)
+	start := uint64(0)
+	for i := uint64(0); i < numChunks; i++ {
+		size := chunkSize
+		// The remainder is spread over the first chunks.
+		if i < remainder {
+			size++
+		}
+		chunkStart := start
+		chunk := *cfg
+		chunk.StepHeights = append([]Height(nil), cfg.StepHeights...)
+		chunk.MachineStartIndex = cfg.MachineStartIndex + OpcodeIndex(chunkStart*uint64(cfg.StepSize))
+		chunk.NumDesiredHashes = size
+		eg.Go(func() error {
+			collected, err := p.collectorPool.collect(egCtx, &chunk)
+			if err != nil {
+				return err
+			}
+			if uint64(len(collected)) != chunk.NumDesiredHashes {
+				return fmt.Errorf(
+					"collector returned %d hashes from machine index %d, expected %d",
+					len(collected),
+					chunk.MachineStartIndex,
+					chunk.NumDesiredHashes,
+				)
+			}
+			copy(hashes[chunkStart:], collected)
+			return nil
+		})
+		start += size
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+	return hashes, nil
+}
+
+// ProcessCollector collects machine hashes in a worker process, started for each
+// collection. The worker is given the collection's config as JSON on its standard
+// input, and writes the collected hashes as a JSON array to its standard output,
+// which is how ServeCollectorProcess implements it.
+type ProcessCollector struct {
+	path string
+	args []string
+	env  []string
+}
+
+type ProcessCollectorOpt func(c *ProcessCollector)
+
+// WithProcessEnv sets environment variables of the worker process, in addition to
+// those of the current process.
+func WithProcessEnv(env ...string) ProcessCollectorOpt {
+	return func(c *ProcessCollector) {
+		c.env = env
+	}
+}
+
+// NewProcessCollector creates a collector running the worker at the given path with
+// the given arguments.
+func NewProcessCollector(path string, args []string, opts ...ProcessCollectorOpt) *ProcessCollector {
+	c := &ProcessCollector{path: path, args: args}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
+
+// CollectMachineHashes runs a worker process for the collection. The process is
+// killed if the context is done first.
+func (c *ProcessCollector) CollectMachineHashes(ctx context.Context, cfg *HashCollectorConfig) ([]common.Hash, error) {
+	input, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.CommandContext(ctx, c.path, c.args...)
+	if len(c.env) > 0 {
+		cmd.Env = append(cmd.Environ(), c.env...)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err = cmd.Run(); err != nil {
+		return nil, fmt.Errorf("collector process failed: %w: %s", err, stderr.String())
+	}
+	var hashes []common.Hash
+	if err = json.Unmarshal(stdout.Bytes(), &hashes); err != nil {
+		return nil, fmt.Errorf("could not decode hashes from collector process: %w", err)
+	}
+	return hashes, nil
+}
+
+// ServeCollectorProcess is the worker side of a ProcessCollector. It reads a collection
+// config from r, collects the machine hashes with the given collector and writes them
+// to w.
+func ServeCollectorProcess(ctx context.Context, collector MachineHashCollector, r io.Reader, w io.Writer) error {
+	cfg := &HashCollectorConfig{}
+	if err := json.NewDecoder(r).Decode(cfg); err != nil {
+		return fmt.Errorf("could not decode collector config: %w", err)
+	}
+	hashes, err := collector.CollectMachineHashes(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(hashes)
+}
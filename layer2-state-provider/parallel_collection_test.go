@@ -0,0 +1,175 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package l2stateprovider
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+// Collects the hash of each opcode index it steps to, tracking how many of its
+// collections run at once.
+type opcodeCollector struct {
+	running    *atomic.Int32
+	maxRunning *atomic.Int32
+	calls      atomic.Int32
+	release    chan struct{}
+	err        error
+}
+
+func opcodeHash(index OpcodeIndex) common.Hash {
+	var h common.Hash
+	binary.BigEndian.PutUint64(h[24:], uint64(index))
+	return h
+}
+
+func (c *opcodeCollector) CollectMachineHashes(ctx context.Context, cfg *HashCollectorConfig) ([]common.Hash, error) {
+	c.calls.Add(1)
+	if c.running != nil {
+		if n := c.running.Add(1); n > c.maxRunning.Load() {
+			c.maxRunning.Store(n)
+		}
+		defer c.running.Add(-1)
+	}
+	if c.release != nil {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-c.release:
+		}
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	hashes := make([]common.Hash, cfg.NumDesiredHashes)
+	for i := range hashes {
+		hashes[i] = opcodeHash(cfg.MachineStartIndex + OpcodeIndex(uint64(i)*uint64(cfg.StepSize)))
+	}
+	return hashes, nil
+}
+
+func TestParallelCollection(t *testing.T) {
+	ctx := context.Background()
+	cfg := &HashCollectorConfig{
+		StepHeights:       []Height{1},
+		NumDesiredHashes:  103,
+		MachineStartIndex: 7,
+		StepSize:          3,
+	}
+	want, err := (&opcodeCollector{}).CollectMachineHashes(ctx, cfg)
+	require.NoError(t, err)
+
+	running, maxRunning := &atomic.Int32{}, &atomic.Int32{}
+	release := make(chan struct{})
+	workers := make([]MachineHashCollector, 4)
+	for i := range workers {
+		workers[i] = &opcodeCollector{running: running, maxRunning: maxRunning, release: release}
+	}
+	pool, err := NewCollectorPool(workers...)
+	require.NoError(t, err)
+	local := &opcodeCollector{}
+	p := NewHistoryCommitmentProvider(nil, local, nil, nil, nil, WithParallelCollection(pool, 10))
+
+	done := make(chan struct{})
+	var got []common.Hash
+	go func() {
+		defer close(done)
+		got, err = p.collectMachineHashes(ctx, cfg)
+	}()
+	require.Eventually(t, func() bool {
+		return running.Load() == 4
+	}, time.Second*5, time.Millisecond*10)
+	close(release)
+	<-done
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+	require.Equal(t, int32(4), maxRunning.Load())
+	require.Equal(t, int32(0), local.calls.Load())
+
+	// Collections too small to split are left to the local collector.
+	small := *cfg
+	small.NumDesiredHashes = 19
+	got, err = p.collectMachineHashes(ctx, &small)
+	require.NoError(t, err)
+	require.Len(t, got, 19)
+	require.Equal(t, int32(1), local.calls.Load())
+}
+
+func TestParallelCollection_Errors(t *testing.T) {
+	ctx := context.Background()
+	cfg := &HashCollectorConfig{NumDesiredHashes: 20, StepSize: 1}
+
+	pool, err := NewCollectorPool(&opcodeCollector{}, &opcodeCollector{err: errors.New("bad machine")})
+	require.NoError(t, err)
+	p := NewHistoryCommitmentProvider(nil, nil, nil, nil, nil, WithParallelCollection(pool, 10))
+	_, err = p.collectMachineHashes(ctx, cfg)
+	require.ErrorContains(t, err, "bad machine")
+
+	pool, err = NewCollectorPool(&opcodeCollector{}, &shortCollector{})
+	require.NoError(t, err)
+	p = NewHistoryCommitmentProvider(nil, nil, nil, nil, nil, WithParallelCollection(pool, 10))
+	_, err = p.collectMachineHashes(ctx, cfg)
+	require.ErrorContains(t, err, "expected 10")
+
+	_, err = NewCollectorPool()
+	require.Error(t, err)
+}
+
+type shortCollector struct{}
+
+func (*shortCollector) CollectMachineHashes(context.Context, *HashCollectorConfig) ([]common.Hash, error) {
+	return []common.Hash{{}}, nil
+}
+
+const collectorWorkerEnv = "BOLD_TEST_COLLECTOR_WORKER"
+
+// Runs as the worker process of the process collector tests.
+func TestCollectorWorkerProcess(t *testing.T) {
+	if os.Getenv(collectorWorkerEnv) == "" {
+		t.Skip("only run as a collector worker process")
+	}
+	if err := ServeCollectorProcess(context.Background(), &opcodeCollector{}, os.Stdin, os.Stdout); err != nil {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func TestProcessCollector(t *testing.T) {
+	ctx := context.Background()
+	workers := make([]MachineHashCollector, 2)
+	for i := range workers {
+		workers[i] = NewProcessCollector(
+			os.Args[0],
+			[]string{"-test.run=^TestCollectorWorkerProcess$"},
+			WithProcessEnv(collectorWorkerEnv+"=1"),
+		)
+	}
+	pool, err := NewCollectorPool(workers...)
+	require.NoError(t, err)
+	p := NewHistoryCommitmentProvider(nil, nil, nil, nil, nil, WithParallelCollection(pool, 8))
+
+	cfg := &HashCollectorConfig{
+		WasmModuleRoot:    common.Hash{1},
+		StepHeights:       []Height{2},
+		NumDesiredHashes:  17,
+		MachineStartIndex: 5,
+		StepSize:          2,
+	}
+	want, err := (&opcodeCollector{}).CollectMachineHashes(ctx, cfg)
+	require.NoError(t, err)
+	got, err := p.collectMachineHashes(ctx, cfg)
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+
+	_, err = NewProcessCollector(os.Args[0], []string{"-test.run=^TestCollectorWorkerProcess$"}).CollectMachineHashes(ctx, cfg)
+	require.Error(t, err)
+}
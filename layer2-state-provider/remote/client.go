@@ -0,0 +1,227 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package remote
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	protocol "github.com/OffchainLabs/bold/chain-abstraction"
+	l2stateprovider "github.com/OffchainLabs/bold/layer2-state-provider"
+	commitments "github.com/OffchainLabs/bold/state-commitments/history"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	_ l2stateprovider.Provider             = (*Client)(nil)
+	_ l2stateprovider.MachineHashCollector = (*Client)(nil)
+)
+
+// Client is a state provider backed by a remote server.
+type Client struct {
+	baseURL    string
+	token      string
+	timeout    time.Duration
+	httpClient *http.Client
+}
+
+type ClientOpt func(c *Client)
+
+// WithToken sets the bearer token presented to the server.
+func WithToken(token string) ClientOpt {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithTimeout bounds each call to the server. Defaults to ten minutes.
+func WithTimeout(timeout time.Duration) ClientOpt {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for calls, such as to configure TLS.
+func WithHTTPClient(httpClient *http.Client) ClientOpt {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// NewClient creates a client for the server at the given URL, such as http://node:8547.
+func NewClient(url string, opts ...ClientOpt) *Client {
+	c := &Client{
+		baseURL:    fmt.Sprintf("%s/v%d", strings.TrimSuffix(url, "/"), ProtocolVersion),
+		timeout:    defaultRequestTimeout,
+		httpClient: http.DefaultClient,
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
+
+// ExecutionStateAfterBatchCount produces the execution state the server asserts to after
+// the given batch count.
+func (c *Client) ExecutionStateAfterBatchCount(ctx context.Context, batchCount uint64) (*protocol.ExecutionState, error) {
+	state := &protocol.ExecutionState{}
+	if err := c.call(ctx, executionStateAfterBatchCountRoute, &executionStateAfterBatchCountRequest{BatchCount: batchCount}, state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// AgreesWithExecutionState returns ErrNoExecutionState or ErrChainCatchingUp as returned
+// by the server's provider.
+func (c *Client) AgreesWithExecutionState(ctx context.Context, state *protocol.ExecutionState) error {
+	return c.call(ctx, agreesWithExecutionStateRoute, &agreesWithExecutionStateRequest{State: state}, nil)
+}
+
+func (c *Client) HistoryCommitment(ctx context.Context, req *l2stateprovider.HistoryCommitmentRequest) (commitments.History, error) {
+	resp := &historyCommitmentResponse{}
+	if err := c.call(ctx, historyCommitmentRoute, toWire(req), resp); err != nil {
+		return commitments.History{}, err
+	}
+	return resp.History, nil
+}
+
+func (c *Client) PrefixProof(ctx context.Context, req *l2stateprovider.HistoryCommitmentRequest, prefixHeight l2stateprovider.Height) ([]byte, error) {
+	resp := &prefixProofResponse{}
+	if err := c.call(ctx, prefixProofRoute, &prefixProofRequest{Request: toWire(req), PrefixHeight: prefixHeight}, resp); err != nil {
+		return nil, err
+	}
+	return resp.Proof, nil
+}
+
+func (c *Client) OneStepProofData(
+	ctx context.Context,
+	wasmModuleRoot common.Hash,
+	fromBatch,
+	toBatch l2stateprovider.Batch,
+	upperChallengeOriginHeights []l2stateprovider.Height,
+	fromHeight,
+	upToHeight l2stateprovider.Height,
+) (*protocol.OneStepData, []common.Hash, []common.Hash, error) {
+	req := &oneStepProofDataRequest{
+		WasmModuleRoot:              wasmModuleRoot,
+		FromBatch:                   fromBatch,
+		ToBatch:                     toBatch,
+		UpperChallengeOriginHeights: upperChallengeOriginHeights,
+		FromHeight:                  fromHeight,
+		UpToHeight:                  upToHeight,
+	}
+	resp := &oneStepProofDataResponse{}
+	if err := c.call(ctx, oneStepProofDataRoute, req, resp); err != nil {
+		return nil, nil, nil, err
+	}
+	return resp.Data, resp.StartLeafInclusionProof, resp.EndLeafInclusionProof, nil
+}
+
+func (c *Client) AgreesWithHistoryCommitment(
+	ctx context.Context,
+	challengeLevel protocol.ChallengeLevel,
+	historyCommitMetadata *l2stateprovider.HistoryCommitmentRequest,
+	commit l2stateprovider.History,
+) (bool, error) {
+	req := &agreesWithHistoryCommitmentRequest{
+		ChallengeLevel: challengeLevel,
+		Request:        toWire(historyCommitMetadata),
+		Commit:         commit,
+	}
+	resp := &agreesWithHistoryCommitmentResponse{}
+	if err := c.call(ctx, agreesWithHistoryCommitmentRoute, req, resp); err != nil {
+		return false, err
+	}
+	return resp.Agrees, nil
+}
+
+// CollectMachineHashes streams the machine hashes collected by the server, which must
+// be configured with a machine hash collector.
+func (c *Client) CollectMachineHashes(ctx context.Context, cfg *l2stateprovider.HashCollectorConfig) ([]common.Hash, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	resp, err := c.post(ctx, machineHashesRoute, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	hashes := make([]common.Hash, 0, cfg.NumDesiredHashes)
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var frame machineHashesFrame
+		if err = dec.Decode(&frame); err != nil {
+			return nil, fmt.Errorf("machine hashes stream ended after %d hashes: %w", len(hashes), err)
+		}
+		if frame.Error != nil {
+			return nil, frame.Error.err()
+		}
+		if frame.Total != nil {
+			if *frame.Total != uint64(len(hashes)) {
+				return nil, fmt.Errorf("received %d machine hashes, server sent %d", len(hashes), *frame.Total)
+			}
+			return hashes, nil
+		}
+		hashes = append(hashes, frame.Hashes...)
+	}
+}
+
+// Posts the request to the route, and decodes the response into resp if it is not nil.
+func (c *Client) call(ctx context.Context, route string, req, resp any) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	httpResp, err := c.post(ctx, route, req)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+	if resp == nil {
+		return nil
+	}
+	if err = json.NewDecoder(httpResp.Body).Decode(resp); err != nil {
+		return fmt.Errorf("could not decode response from %s: %w", route, err)
+	}
+	return nil
+}
+
+// Posts the request to the route, returning the response if it succeeded, and otherwise
+// the error it carries.
+func (c *Client) post(ctx context.Context, route string, req any) (*http.Response, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+route, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusOK {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	errResp := &errorResponse{}
+	if err = json.Unmarshal(respBody, errResp); err != nil || errResp.Code == "" {
+		// Servers of other protocol versions do not have the route.
+		if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedVersion, route)
+		}
+		return nil, fmt.Errorf("remote state provider responded with status %d: %s", resp.StatusCode, respBody)
+	}
+	return nil, errResp.err()
+}
@@ -0,0 +1,174 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+// Package remote serves an l2stateprovider.Provider over HTTP, so that the challenge
+// manager can run on separate hardware from the execution node backing its provider.
+//
+// The protocol is versioned by path prefix, and every method of the provider is a POST
+// of a JSON request to its own route under the prefix. Requests are authenticated with
+// a shared bearer token. Failures are returned as a JSON error with a code, which lets
+// the client restore the provider's sentinel errors such as ErrNoExecutionState.
+// Machine hashes, which can number in the millions for a single request, are streamed
+// as a sequence of JSON frames rather than a single document.
+package remote
+
+import (
+	"errors"
+
+	protocol "github.com/OffchainLabs/bold/chain-abstraction"
+	"github.com/OffchainLabs/bold/containers/option"
+	l2stateprovider "github.com/OffchainLabs/bold/layer2-state-provider"
+	commitments "github.com/OffchainLabs/bold/state-commitments/history"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ProtocolVersion of the remote state provider protocol, which prefixes all its routes.
+const ProtocolVersion = 1
+
+var (
+	ErrUnauthorized       = errors.New("remote state provider rejected the token")
+	ErrUnsupportedVersion = errors.New("remote state provider does not support the protocol version")
+)
+
+const (
+	executionStateAfterBatchCountRoute = "/execution-state-after-batch-count"
+	agreesWithExecutionStateRoute      = "/agrees-with-execution-state"
+	historyCommitmentRoute             = "/history-commitment"
+	prefixProofRoute                   = "/prefix-proof"
+	oneStepProofDataRoute              = "/one-step-proof-data"
+	agreesWithHistoryCommitmentRoute   = "/agrees-with-history-commitment"
+	machineHashesRoute                 = "/machine-hashes"
+)
+
+// Codes of the errors returned by the server.
+const (
+	codeNoExecutionState = "no_execution_state"
+	codeChainCatchingUp  = "chain_catching_up"
+	codeUnauthorized     = "unauthorized"
+	codeBadRequest       = "bad_request"
+	codeInternal         = "internal"
+)
+
+type errorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Restores the provider's sentinel error for a code, so callers can keep using errors.Is.
+func (e *errorResponse) err() error {
+	switch e.Code {
+	case codeNoExecutionState:
+		return l2stateprovider.ErrNoExecutionState
+	case codeChainCatchingUp:
+		return l2stateprovider.ErrChainCatchingUp
+	case codeUnauthorized:
+		return ErrUnauthorized
+	default:
+		return errors.New(e.Message)
+	}
+}
+
+// Wire form of a history commitment request, with the optional height as a pointer.
+type historyCommitmentRequest struct {
+	WasmModuleRoot              common.Hash              `json:"wasmModuleRoot"`
+	FromBatch                   l2stateprovider.Batch    `json:"fromBatch"`
+	ToBatch                     l2stateprovider.Batch    `json:"toBatch"`
+	UpperChallengeOriginHeights []l2stateprovider.Height `json:"upperChallengeOriginHeights"`
+	FromHeight                  l2stateprovider.Height   `json:"fromHeight"`
+	UpToHeight                  *l2stateprovider.Height  `json:"upToHeight,omitempty"`
+}
+
+func toWire(req *l2stateprovider.HistoryCommitmentRequest) *historyCommitmentRequest {
+	if req == nil {
+		return nil
+	}
+	w := &historyCommitmentRequest{
+		WasmModuleRoot:              req.WasmModuleRoot,
+		FromBatch:                   req.FromBatch,
+		ToBatch:                     req.ToBatch,
+		UpperChallengeOriginHeights: req.UpperChallengeOriginHeights,
+		FromHeight:                  req.FromHeight,
+	}
+	if req.UpToHeight.IsSome() {
+		upTo := req.UpToHeight.Unwrap()
+		w.UpToHeight = &upTo
+	}
+	return w
+}
+
+func (w *historyCommitmentRequest) request() *l2stateprovider.HistoryCommitmentRequest {
+	if w == nil {
+		return nil
+	}
+	req := &l2stateprovider.HistoryCommitmentRequest{
+		WasmModuleRoot:              w.WasmModuleRoot,
+		FromBatch:                   w.FromBatch,
+		ToBatch:                     w.ToBatch,
+		UpperChallengeOriginHeights: w.UpperChallengeOriginHeights,
+		FromHeight:                  w.FromHeight,
+		UpToHeight:                  option.None[l2stateprovider.Height](),
+	}
+	if w.UpperChallengeOriginHeights == nil {
+		req.UpperChallengeOriginHeights = []l2stateprovider.Height{}
+	}
+	if w.UpToHeight != nil {
+		req.UpToHeight = option.Some(*w.UpToHeight)
+	}
+	return req
+}
+
+type executionStateAfterBatchCountRequest struct {
+	BatchCount uint64 `json:"batchCount"`
+}
+
+type agreesWithExecutionStateRequest struct {
+	State *protocol.ExecutionState `json:"state"`
+}
+
+type historyCommitmentResponse struct {
+	History commitments.History `json:"history"`
+}
+
+type prefixProofRequest struct {
+	Request      *historyCommitmentRequest `json:"request"`
+	PrefixHeight l2stateprovider.Height    `json:"prefixHeight"`
+}
+
+type prefixProofResponse struct {
+	Proof []byte `json:"proof"`
+}
+
+type oneStepProofDataRequest struct {
+	WasmModuleRoot              common.Hash              `json:"wasmModuleRoot"`
+	FromBatch                   l2stateprovider.Batch    `json:"fromBatch"`
+	ToBatch                     l2stateprovider.Batch    `json:"toBatch"`
+	UpperChallengeOriginHeights []l2stateprovider.Height `json:"upperChallengeOriginHeights"`
+	FromHeight                  l2stateprovider.Height   `json:"fromHeight"`
+	UpToHeight                  l2stateprovider.Height   `json:"upToHeight"`
+}
+
+type oneStepProofDataResponse struct {
+	Data                    *protocol.OneStepData `json:"data"`
+	StartLeafInclusionProof []common.Hash         `json:"startLeafInclusionProof"`
+	EndLeafInclusionProof   []common.Hash         `json:"endLeafInclusionProof"`
+}
+
+type agreesWithHistoryCommitmentRequest struct {
+	ChallengeLevel protocol.ChallengeLevel   `json:"challengeLevel"`
+	Request        *historyCommitmentRequest `json:"request"`
+	Commit         l2stateprovider.History   `json:"commit"`
+}
+
+type agreesWithHistoryCommitmentResponse struct {
+	Agrees bool `json:"agrees"`
+}
+
+// A frame of a machine hashes stream. The stream is a sequence of frames carrying
+// hashes, terminated by a frame carrying the total number of hashes sent, so that
+// the client can tell a complete stream from a truncated one. A stream failing once
+// started is terminated by a frame carrying the error instead.
+type machineHashesFrame struct {
+	Hashes []common.Hash  `json:"hashes,omitempty"`
+	Total  *uint64        `json:"total,omitempty"`
+	Error  *errorResponse `json:"error,omitempty"`
+}
@@ -0,0 +1,201 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package remote
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	protocol "github.com/OffchainLabs/bold/chain-abstraction"
+	"github.com/OffchainLabs/bold/containers/option"
+	l2stateprovider "github.com/OffchainLabs/bold/layer2-state-provider"
+	statemanager "github.com/OffchainLabs/bold/testing/mocks/state-provider"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func setupRemote(t *testing.T, cfg *ServerConfig) (*statemanager.L2StateBackend, string) {
+	t.Helper()
+	backend, err := statemanager.NewForSimpleMachine()
+	require.NoError(t, err)
+	if cfg.Provider == nil {
+		cfg.Provider = backend
+	}
+	s, err := NewServer(cfg)
+	require.NoError(t, err)
+	srv := httptest.NewServer(s.srv.Handler)
+	t.Cleanup(srv.Close)
+	return backend, srv.URL
+}
+
+func TestClient_MatchesProvider(t *testing.T) {
+	ctx := context.Background()
+	backend, url := setupRemote(t, &ServerConfig{Token: "secret"})
+	client := NewClient(url, WithToken("secret"))
+
+	wantState, err := backend.ExecutionStateAfterBatchCount(ctx, 1)
+	require.NoError(t, err)
+	gotState, err := client.ExecutionStateAfterBatchCount(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, wantState, gotState)
+	require.NoError(t, client.AgreesWithExecutionState(ctx, gotState))
+
+	for _, upTo := range []option.Option[l2stateprovider.Height]{
+		option.None[l2stateprovider.Height](),
+		option.Some(l2stateprovider.Height(3)),
+	} {
+		req := &l2stateprovider.HistoryCommitmentRequest{
+			FromBatch:                   0,
+			ToBatch:                     1,
+			UpperChallengeOriginHeights: []l2stateprovider.Height{},
+			UpToHeight:                  upTo,
+		}
+		want, err := backend.HistoryCommitment(ctx, req)
+		require.NoError(t, err)
+		got, err := client.HistoryCommitment(ctx, req)
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+
+		agrees, err := client.AgreesWithHistoryCommitment(ctx, protocol.NewBlockChallengeLevel(), req, l2stateprovider.History{
+			Height:     got.Height,
+			MerkleRoot: got.Merkle,
+		})
+		require.NoError(t, err)
+		require.True(t, agrees)
+		agrees, err = client.AgreesWithHistoryCommitment(ctx, protocol.NewBlockChallengeLevel(), req, l2stateprovider.History{
+			Height:     got.Height,
+			MerkleRoot: common.Hash{1},
+		})
+		require.NoError(t, err)
+		require.False(t, agrees)
+	}
+
+	req := &l2stateprovider.HistoryCommitmentRequest{
+		FromBatch:                   0,
+		ToBatch:                     1,
+		UpperChallengeOriginHeights: []l2stateprovider.Height{},
+		UpToHeight:                  option.Some(l2stateprovider.Height(8)),
+	}
+	wantProof, err := backend.PrefixProof(ctx, req, 2)
+	require.NoError(t, err)
+	gotProof, err := client.PrefixProof(ctx, req, 2)
+	require.NoError(t, err)
+	require.Equal(t, wantProof, gotProof)
+
+	heights := []l2stateprovider.Height{0, 0}
+	wantData, wantStart, wantEnd, err := backend.OneStepProofData(ctx, common.Hash{}, 0, 1, heights, 0, 1)
+	require.NoError(t, err)
+	gotData, gotStart, gotEnd, err := client.OneStepProofData(ctx, common.Hash{}, 0, 1, heights, 0, 1)
+	require.NoError(t, err)
+	require.Equal(t, wantData, gotData)
+	require.Equal(t, wantStart, gotStart)
+	require.Equal(t, wantEnd, gotEnd)
+}
+
+func TestClient_Errors(t *testing.T) {
+	ctx := context.Background()
+	_, url := setupRemote(t, &ServerConfig{Token: "secret"})
+
+	err := NewClient(url, WithToken("secret")).AgreesWithExecutionState(ctx, &protocol.ExecutionState{
+		GlobalState: protocol.GoGlobalState{Batch: 100},
+	})
+	require.ErrorIs(t, err, l2stateprovider.ErrNoExecutionState)
+
+	_, err = NewClient(url, WithToken("secret")).ExecutionStateAfterBatchCount(ctx, 100)
+	require.ErrorContains(t, err, "greater than number of execution states")
+
+	_, err = NewClient(url, WithToken("wrong")).ExecutionStateAfterBatchCount(ctx, 1)
+	require.ErrorIs(t, err, ErrUnauthorized)
+
+	// The server was not given a machine hash collector.
+	_, err = NewClient(url, WithToken("secret")).CollectMachineHashes(ctx, &l2stateprovider.HashCollectorConfig{})
+	require.ErrorIs(t, err, ErrUnsupportedVersion)
+
+	_, err = NewServer(&ServerConfig{Provider: &slowProvider{}})
+	require.ErrorIs(t, err, ErrNoToken)
+}
+
+type slowProvider struct {
+	statemanager.L2StateBackend
+}
+
+func (*slowProvider) AgreesWithExecutionState(ctx context.Context, _ *protocol.ExecutionState) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestClient_Timeouts(t *testing.T) {
+	ctx := context.Background()
+	_, url := setupRemote(t, &ServerConfig{
+		Token:          "secret",
+		Provider:       &slowProvider{},
+		RequestTimeout: 50 * time.Millisecond,
+	})
+	err := NewClient(url, WithToken("secret")).AgreesWithExecutionState(ctx, &protocol.ExecutionState{})
+	require.ErrorContains(t, err, "context deadline exceeded")
+
+	_, url = setupRemote(t, &ServerConfig{
+		Token:          "secret",
+		Provider:       &slowProvider{},
+		RequestTimeout: time.Minute,
+	})
+	err = NewClient(url, WithToken("secret"), WithTimeout(50*time.Millisecond)).AgreesWithExecutionState(ctx, &protocol.ExecutionState{})
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+// Hashes the machine index of each step, and fails from a machine index if one is set.
+type countingCollector struct {
+	calls    atomic.Uint64
+	failFrom l2stateprovider.OpcodeIndex
+}
+
+func (c *countingCollector) CollectMachineHashes(_ context.Context, cfg *l2stateprovider.HashCollectorConfig) ([]common.Hash, error) {
+	c.calls.Add(1)
+	if cfg.NumDesiredHashes == 0 {
+		return nil, errors.New("no hashes desired")
+	}
+	if c.failFrom != 0 && cfg.MachineStartIndex >= c.failFrom {
+		return nil, l2stateprovider.ErrChainCatchingUp
+	}
+	hashes := make([]common.Hash, cfg.NumDesiredHashes)
+	for i := range hashes {
+		index := uint64(cfg.MachineStartIndex) + uint64(i)*uint64(cfg.StepSize)
+		hashes[i] = common.BigToHash(new(big.Int).SetUint64(index))
+	}
+	return hashes, nil
+}
+
+func TestClient_StreamsMachineHashes(t *testing.T) {
+	ctx := context.Background()
+	collector := &countingCollector{}
+	_, url := setupRemote(t, &ServerConfig{Token: "secret", MachineHashCollector: collector})
+	client := NewClient(url, WithToken("secret"))
+
+	// Spans several frames, the last one partial, each collected on its own.
+	cfg := &l2stateprovider.HashCollectorConfig{
+		StepHeights:       []l2stateprovider.Height{1},
+		NumDesiredHashes:  3*machineHashesFrameSize + 5,
+		MachineStartIndex: 11,
+		StepSize:          3,
+	}
+	want, err := (&countingCollector{}).CollectMachineHashes(ctx, cfg)
+	require.NoError(t, err)
+	got, err := client.CollectMachineHashes(ctx, cfg)
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+	require.Equal(t, uint64(4), collector.calls.Load())
+
+	_, err = client.CollectMachineHashes(ctx, &l2stateprovider.HashCollectorConfig{})
+	require.ErrorContains(t, err, "no hashes desired")
+
+	// A frame failing once the stream started ends it with the error.
+	collector.failFrom = cfg.MachineStartIndex + l2stateprovider.OpcodeIndex(2*machineHashesFrameSize*cfg.StepSize)
+	_, err = client.CollectMachineHashes(ctx, cfg)
+	require.ErrorIs(t, err, l2stateprovider.ErrChainCatchingUp)
+}
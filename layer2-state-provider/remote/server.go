@@ -0,0 +1,336 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package remote
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	l2stateprovider "github.com/OffchainLabs/bold/layer2-state-provider"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/gorilla/mux"
+)
+
+var srvlog = log.New("service", "remote-state-provider")
+
+var (
+	ErrNoConfig   = errors.New("no config provided")
+	ErrNoProvider = errors.New("no state provider")
+	ErrNoToken    = errors.New("remote state provider requires a token")
+)
+
+const (
+	defaultRequestTimeout = 10 * time.Minute
+	// Number of hashes per frame of a machine hashes stream.
+	machineHashesFrameSize = 4096
+)
+
+type ServerConfig struct {
+	Address string
+	// The provider served to clients.
+	Provider l2stateprovider.Provider
+	// Shared secret clients present as a bearer token.
+	Token string
+	// Bounds how long the provider may take to handle a single request. Defaults to
+	// ten minutes, as history commitments over large ranges are slow to compute.
+	RequestTimeout time.Duration
+	// Optional, enables streaming machine hashes, so the client can collect them for
+	// its own history commitment provider.
+	MachineHashCollector l2stateprovider.MachineHashCollector
+}
+
+// Server serves a state provider to remote clients.
+type Server struct {
+	srv            *http.Server
+	provider       l2stateprovider.Provider
+	collector      l2stateprovider.MachineHashCollector
+	token          string
+	requestTimeout time.Duration
+}
+
+func NewServer(cfg *ServerConfig) (*Server, error) {
+	if cfg == nil {
+		return nil, ErrNoConfig
+	}
+	if cfg.Provider == nil {
+		return nil, ErrNoProvider
+	}
+	if cfg.Token == "" {
+		return nil, ErrNoToken
+	}
+	if cfg.Address == "" {
+		cfg.Address = ":8547"
+	}
+	if cfg.RequestTimeout == 0 {
+		cfg.RequestTimeout = defaultRequestTimeout
+	}
+	s := &Server{
+		provider:       cfg.Provider,
+		collector:      cfg.MachineHashCollector,
+		token:          cfg.Token,
+		requestTimeout: cfg.RequestTimeout,
+	}
+	// No write timeout, as responses take as long as the provider needs, which is
+	// bounded by the request timeout instead.
+	s.srv = &http.Server{
+		Handler:           s.router(),
+		Addr:              cfg.Address,
+		ReadTimeout:       15 * time.Second,
+		ReadHeaderTimeout: 15 * time.Second,
+	}
+	return s, nil
+}
+
+func (s *Server) Start(ctx context.Context) error {
+	srvlog.Info("Serving remote state provider", "address", s.srv.Addr, "version", ProtocolVersion)
+	return s.srv.ListenAndServe()
+}
+
+// Stop shuts down the server, waiting for in-flight requests until the context is done.
+func (s *Server) Stop(ctx context.Context) error {
+	return s.srv.Shutdown(ctx)
+}
+
+func (s *Server) router() *mux.Router {
+	r := mux.NewRouter()
+	v := r.PathPrefix(fmt.Sprintf("/v%d", ProtocolVersion)).Subrouter()
+	v.Use(s.authenticate)
+	v.HandleFunc(executionStateAfterBatchCountRoute, s.executionStateAfterBatchCountHandler).Methods("POST")
+	v.HandleFunc(agreesWithExecutionStateRoute, s.agreesWithExecutionStateHandler).Methods("POST")
+	v.HandleFunc(historyCommitmentRoute, s.historyCommitmentHandler).Methods("POST")
+	v.HandleFunc(prefixProofRoute, s.prefixProofHandler).Methods("POST")
+	v.HandleFunc(oneStepProofDataRoute, s.oneStepProofDataHandler).Methods("POST")
+	v.HandleFunc(agreesWithHistoryCommitmentRoute, s.agreesWithHistoryCommitmentHandler).Methods("POST")
+	if s.collector != nil {
+		v.HandleFunc(machineHashesRoute, s.machineHashesHandler).Methods("POST")
+	}
+	return r
+}
+
+// Only accepts requests bearing the token, and bounds their handling by the request timeout.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			srvlog.Warn("Rejected unauthenticated request", "path", r.URL.Path, "remoteAddr", r.RemoteAddr)
+			writeError(w, http.StatusUnauthorized, codeUnauthorized, errors.New("invalid token"))
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), s.requestTimeout)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (s *Server) executionStateAfterBatchCountHandler(w http.ResponseWriter, r *http.Request) {
+	var req executionStateAfterBatchCountRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	state, err := s.provider.ExecutionStateAfterBatchCount(r.Context(), req.BatchCount)
+	respond(w, state, err)
+}
+
+func (s *Server) agreesWithExecutionStateHandler(w http.ResponseWriter, r *http.Request) {
+	var req agreesWithExecutionStateRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if req.State == nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, errors.New("no execution state"))
+		return
+	}
+	err := s.provider.AgreesWithExecutionState(r.Context(), req.State)
+	respond(w, struct{}{}, err)
+}
+
+func (s *Server) historyCommitmentHandler(w http.ResponseWriter, r *http.Request) {
+	var req historyCommitmentRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	history, err := s.provider.HistoryCommitment(r.Context(), req.request())
+	respond(w, &historyCommitmentResponse{History: history}, err)
+}
+
+func (s *Server) prefixProofHandler(w http.ResponseWriter, r *http.Request) {
+	var req prefixProofRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if req.Request == nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, errors.New("no history commitment request"))
+		return
+	}
+	proof, err := s.provider.PrefixProof(r.Context(), req.Request.request(), req.PrefixHeight)
+	respond(w, &prefixProofResponse{Proof: proof}, err)
+}
+
+func (s *Server) oneStepProofDataHandler(w http.ResponseWriter, r *http.Request) {
+	var req oneStepProofDataRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	data, startProof, endProof, err := s.provider.OneStepProofData(
+		r.Context(),
+		req.WasmModuleRoot,
+		req.FromBatch,
+		req.ToBatch,
+		req.UpperChallengeOriginHeights,
+		req.FromHeight,
+		req.UpToHeight,
+	)
+	respond(w, &oneStepProofDataResponse{
+		Data:                    data,
+		StartLeafInclusionProof: startProof,
+		EndLeafInclusionProof:   endProof,
+	}, err)
+}
+
+func (s *Server) agreesWithHistoryCommitmentHandler(w http.ResponseWriter, r *http.Request) {
+	var req agreesWithHistoryCommitmentRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if req.Request == nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, errors.New("no history commitment request"))
+		return
+	}
+	agrees, err := s.provider.AgreesWithHistoryCommitment(r.Context(), req.ChallengeLevel, req.Request.request(), req.Commit)
+	respond(w, &agreesWithHistoryCommitmentResponse{Agrees: agrees}, err)
+}
+
+// Streams the machine hashes in frames, collecting and flushing each before the next,
+// so that neither the server nor the client holds the whole response in memory.
+func (s *Server) machineHashesHandler(w http.ResponseWriter, r *http.Request) {
+	var cfg l2stateprovider.HashCollectorConfig
+	if !decodeRequest(w, r, &cfg) {
+		return
+	}
+	// The first frame is collected before responding, so that a request the collector
+	// rejects outright fails with the status of its error.
+	hashes, err := s.collectMachineHashesFrame(r.Context(), &cfg, 0)
+	if err != nil {
+		respond(w, nil, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	enc := json.NewEncoder(w)
+	collected := uint64(0)
+	for {
+		if err = enc.Encode(&machineHashesFrame{Hashes: hashes}); err != nil {
+			srvlog.Error("Could not stream machine hashes", "err", err)
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		collected += uint64(len(hashes))
+		if collected >= cfg.NumDesiredHashes {
+			break
+		}
+		hashes, err = s.collectMachineHashesFrame(r.Context(), &cfg, collected)
+		if err != nil {
+			// The status was already sent, so the error ends the stream in a frame of its own.
+			_, code := errorStatus(err)
+			if err = enc.Encode(&machineHashesFrame{Error: &errorResponse{Code: code, Message: err.Error()}}); err != nil {
+				srvlog.Error("Could not stream machine hashes", "err", err)
+			}
+			return
+		}
+	}
+	if err = enc.Encode(&machineHashesFrame{Total: &collected}); err != nil {
+		srvlog.Error("Could not stream machine hashes", "err", err)
+	}
+}
+
+// Collects the machine hashes of a frame, starting after the hashes collected so far.
+func (s *Server) collectMachineHashesFrame(
+	ctx context.Context,
+	cfg *l2stateprovider.HashCollectorConfig,
+	collected uint64,
+) ([]common.Hash, error) {
+	size := cfg.NumDesiredHashes - collected
+	if size > machineHashesFrameSize {
+		size = machineHashesFrameSize
+	}
+	frame := *cfg
+	frame.StepHeights = append([]l2stateprovider.Height(nil), cfg.StepHeights...)
+	frame.MachineStartIndex = cfg.MachineStartIndex + l2stateprovider.OpcodeIndex(collected*uint64(cfg.StepSize))
+	frame.NumDesiredHashes = size
+	hashes, err := s.collector.CollectMachineHashes(ctx, &frame)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(hashes)) != size {
+		return nil, fmt.Errorf(
+			"collector returned %d hashes from machine index %d, expected %d",
+			len(hashes),
+			frame.MachineStartIndex,
+			size,
+		)
+	}
+	return hashes, nil
+}
+
+func decodeRequest(w http.ResponseWriter, r *http.Request, req any) bool {
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, err)
+		return false
+	}
+	return true
+}
+
+// Writes the provider's response, or its error with the code of a known sentinel error.
+func respond(w http.ResponseWriter, resp any, err error) {
+	if err == nil {
+		writeJSONResponse(w, http.StatusOK, resp)
+		return
+	}
+	status, code := errorStatus(err)
+	writeError(w, status, code, err)
+}
+
+// Returns the status and code of a provider error, by the known sentinel error it wraps.
+func errorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, l2stateprovider.ErrNoExecutionState):
+		return http.StatusNotFound, codeNoExecutionState
+	case errors.Is(err, l2stateprovider.ErrChainCatchingUp):
+		return http.StatusServiceUnavailable, codeChainCatchingUp
+	default:
+		return http.StatusInternalServerError, codeInternal
+	}
+}
+
+func writeJSONResponse(w http.ResponseWriter, code int, data any) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, codeInternal, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if _, err = w.Write(body); err != nil {
+		srvlog.Error("Could not write response body", "err", err, "status", code)
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, errCode string, err error) {
+	body, _ := json.Marshal(&errorResponse{Code: errCode, Message: err.Error()})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if _, err2 := w.Write(body); err2 != nil {
+		srvlog.Error("Could not write response body", "err", err2, "status", code)
+	}
+}
@@ -0,0 +1,380 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package l2stateprovider
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/OffchainLabs/bold/containers/option"
+	"github.com/OffchainLabs/bold/math"
+	commitments "github.com/OffchainLabs/bold/state-commitments/history"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	speculativeHitsCounter      = metrics.NewRegisteredCounter("arb/validator/speculative/hits", nil)
+	speculativeMissesCounter    = metrics.NewRegisteredCounter("arb/validator/speculative/misses", nil)
+	speculativeComputedCounter  = metrics.NewRegisteredCounter("arb/validator/speculative/computed", nil)
+	speculativeDiscardedCounter = metrics.NewRegisteredCounter("arb/validator/speculative/discarded", nil)
+)
+
+// SpeculativeEdge describes an edge whose future moves can be precomputed.
+type SpeculativeEdge struct {
+	WasmModuleRoot common.Hash
+	FromBatch      Batch
+	ToBatch        Batch
+	// The challenge origin heights of the edge's history commitments, which are empty
+	// at the block challenge level.
+	UpperChallengeOriginHeights []Height
+	StartHeight                 Height
+	EndHeight                   Height
+	// The height of the level zero edges of the subchallenges opened by one-step edges
+	// at the edge's level, which is zero at the last challenge level.
+	SubchallengeHeight Height
+}
+
+type SpeculativeOpt func(p *SpeculativeProvider)
+
+// WithSpeculativeDepth sets the number of bisection levels below an edge that are
+// precomputed when it is warmed. Defaults to 3.
+func WithSpeculativeDepth(depth uint8) SpeculativeOpt {
+	return func(p *SpeculativeProvider) {
+		p.depth = depth
+	}
+}
+
+// WithSpeculativeWorkers sets the number of computations run concurrently. Defaults
+// to 1, so that precomputation only uses otherwise idle CPU.
+func WithSpeculativeWorkers(n int) SpeculativeOpt {
+	return func(p *SpeculativeProvider) {
+		p.workers = n
+	}
+}
+
+// SpeculativeProvider is a provider which precomputes the history commitments and
+// prefix proofs an edge needs to bisect and to open subchallenges, along both possible
+// bisection paths, before they are requested. Requests it precomputed are served from
+// its cache, while all others are passed through to the wrapped provider.
+//
+// Precomputations are grouped in paths, typically one per tracked edge, and are
+// canceled and dropped once every path that needs them has been discarded.
+type SpeculativeProvider struct {
+	Provider
+	depth   uint8
+	workers int
+	lock    sync.Mutex
+	entries map[string]*speculativeEntry
+	// Keys of the entries needed by each path.
+	paths map[common.Hash][]string
+	queue []*speculativeEntry
+	wake  chan struct{}
+}
+
+type speculativeEntry struct {
+	// Bisection level below the warmed edge, which shallower entries are computed first by.
+	depth   uint8
+	owners  map[common.Hash]bool
+	compute func(ctx context.Context) (commitments.History, []byte, error)
+	running bool
+	cancel  context.CancelFunc
+	done    chan struct{}
+	history commitments.History
+	proof   []byte
+	err     error
+}
+
+// NewSpeculativeProvider wraps a provider with speculative precomputation. Nothing
+// is computed until the provider is started.
+func NewSpeculativeProvider(provider Provider, opts ...SpeculativeOpt) *SpeculativeProvider {
+	p := &SpeculativeProvider{
+		Provider: provider,
+		depth:    3,
+		workers:  1,
+		entries:  make(map[string]*speculativeEntry),
+		paths:    make(map[common.Hash][]string),
+	}
+	for _, o := range opts {
+		o(p)
+	}
+	if p.workers < 1 {
+		p.workers = 1
+	}
+	p.wake = make(chan struct{}, 1)
+	return p
+}
+
+// Start runs the precomputations until the context is done.
+func (p *SpeculativeProvider) Start(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < p.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.work(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+// Warm schedules the precomputation of what an edge needs to bisect, and what its
+// descendants need to bisect or open subchallenges, down to the configured depth. The
+// precomputations are kept under the given path until it is discarded.
+func (p *SpeculativeProvider) Warm(pathId common.Hash, edge *SpeculativeEdge) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if _, ok := p.paths[pathId]; ok {
+		return
+	}
+	p.paths[pathId] = make([]string, 0)
+	p.planLocked(pathId, edge, edge.StartHeight, edge.EndHeight, 0)
+	if len(p.queue) > 0 {
+		p.signal()
+	}
+}
+
+// Discard drops the precomputations of a path, such as once its edge is confirmed or
+// has made its move. Those no other path needs are canceled if running.
+func (p *SpeculativeProvider) Discard(pathId common.Hash) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	keys, ok := p.paths[pathId]
+	if !ok {
+		return
+	}
+	delete(p.paths, pathId)
+	for _, key := range keys {
+		e, ok := p.entries[key]
+		if !ok {
+			continue
+		}
+		delete(e.owners, pathId)
+		if len(e.owners) > 0 {
+			continue
+		}
+		delete(p.entries, key)
+		if e.cancel != nil {
+			e.cancel()
+		}
+		speculativeDiscardedCounter.Inc(1)
+	}
+}
+
+// HistoryCommitment serves a precomputed history commitment, waiting for it if it is
+// being computed, or computes it with the wrapped provider.
+func (p *SpeculativeProvider) HistoryCommitment(ctx context.Context, req *HistoryCommitmentRequest) (commitments.History, error) {
+	if e, ok := p.lookup(commitmentKey(req)); ok {
+		if e.wait(ctx) {
+			return e.history, nil
+		}
+	}
+	speculativeMissesCounter.Inc(1)
+	return p.Provider.HistoryCommitment(ctx, req)
+}
+
+// PrefixProof serves a precomputed prefix proof, waiting for it if it is being
+// computed, or computes it with the wrapped provider.
+func (p *SpeculativeProvider) PrefixProof(ctx context.Context, req *HistoryCommitmentRequest, prefixHeight Height) ([]byte, error) {
+	if e, ok := p.lookup(proofKey(req, prefixHeight)); ok {
+		if e.wait(ctx) {
+			return e.proof, nil
+		}
+	}
+	speculativeMissesCounter.Inc(1)
+	return p.Provider.PrefixProof(ctx, req, prefixHeight)
+}
+
+// Looks up an entry which is being or has been computed. Entries still queued are
+// not waited for, as computing them right away is faster.
+func (p *SpeculativeProvider) lookup(key string) (*speculativeEntry, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	e, ok := p.entries[key]
+	if !ok || !e.running {
+		return nil, false
+	}
+	return e, true
+}
+
+// Waits for an entry to be computed, returning false if it failed, was canceled, or
+// the context is done first.
+func (e *speculativeEntry) wait(ctx context.Context) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-e.done:
+	}
+	if e.err != nil {
+		return false
+	}
+	speculativeHitsCounter.Inc(1)
+	return true
+}
+
+// Schedules the moves of an edge from start to end, and those of its children, at the
+// given bisection level below the warmed edge.
+func (p *SpeculativeProvider) planLocked(pathId common.Hash, edge *SpeculativeEdge, start, end Height, depth uint8) {
+	if depth >= p.depth || end <= start {
+		return
+	}
+	upper := edge.UpperChallengeOriginHeights
+	if end-start == 1 {
+		if edge.SubchallengeHeight == 0 {
+			return
+		}
+		// Opening a subchallenge needs the commitments to the start and end of the level
+		// below, a prefix proof between them, and the commitments to the start and end
+		// of the edge to prove they are its first and last leaves.
+		lower := append(append(make([]Height, 0, len(upper)+1), upper...), start)
+		p.commitmentLocked(pathId, depth, edge.request(lower, option.None[Height]()))
+		p.proofLocked(pathId, depth, edge.request(lower, option.Some(edge.SubchallengeHeight)), 0)
+		p.commitmentLocked(pathId, depth, edge.request(lower, option.Some(Height(0))))
+		p.commitmentLocked(pathId, depth, edge.request(upper, option.Some(end)))
+		p.commitmentLocked(pathId, depth, edge.request(upper, option.Some(start)))
+		return
+	}
+	mid, err := math.Bisect(uint64(start), uint64(end))
+	if err != nil {
+		return
+	}
+	p.commitmentLocked(pathId, depth, edge.request(upper, option.Some(Height(mid))))
+	p.proofLocked(pathId, depth, edge.request(upper, option.Some(end)), Height(mid))
+	p.planLocked(pathId, edge, start, Height(mid), depth+1)
+	p.planLocked(pathId, edge, Height(mid), end, depth+1)
+}
+
+func (e *SpeculativeEdge) request(upper []Height, upTo option.Option[Height]) *HistoryCommitmentRequest {
+	return &HistoryCommitmentRequest{
+		WasmModuleRoot:              e.WasmModuleRoot,
+		FromBatch:                   e.FromBatch,
+		ToBatch:                     e.ToBatch,
+		UpperChallengeOriginHeights: upper,
+		FromHeight:                  0,
+		UpToHeight:                  upTo,
+	}
+}
+
+func (p *SpeculativeProvider) commitmentLocked(pathId common.Hash, depth uint8, req *HistoryCommitmentRequest) {
+	p.scheduleLocked(pathId, depth, commitmentKey(req), func(ctx context.Context) (commitments.History, []byte, error) {
+		history, err := p.Provider.HistoryCommitment(ctx, req)
+		return history, nil, err
+	})
+}
+
+func (p *SpeculativeProvider) proofLocked(pathId common.Hash, depth uint8, req *HistoryCommitmentRequest, prefixHeight Height) {
+	p.scheduleLocked(pathId, depth, proofKey(req, prefixHeight), func(ctx context.Context) (commitments.History, []byte, error) {
+		proof, err := p.Provider.PrefixProof(ctx, req, prefixHeight)
+		return commitments.History{}, proof, err
+	})
+}
+
+func (p *SpeculativeProvider) scheduleLocked(
+	pathId common.Hash,
+	depth uint8,
+	key string,
+	compute func(ctx context.Context) (commitments.History, []byte, error),
+) {
+	if e, ok := p.entries[key]; ok {
+		if !e.owners[pathId] {
+			e.owners[pathId] = true
+			p.paths[pathId] = append(p.paths[pathId], key)
+		}
+		return
+	}
+	e := &speculativeEntry{
+		depth:   depth,
+		owners:  map[common.Hash]bool{pathId: true},
+		compute: compute,
+		done:    make(chan struct{}),
+	}
+	p.entries[key] = e
+	p.paths[pathId] = append(p.paths[pathId], key)
+	p.queue = append(p.queue, e)
+}
+
+func (p *SpeculativeProvider) work(ctx context.Context) {
+	for {
+		e, computeCtx := p.next(ctx)
+		if e == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-p.wake:
+			}
+			continue
+		}
+		history, proof, err := e.compute(computeCtx)
+		p.lock.Lock()
+		e.history, e.proof, e.err = history, proof, err
+		cancel := e.cancel
+		e.cancel = nil
+		p.lock.Unlock()
+		cancel()
+		close(e.done)
+		if err == nil {
+			speculativeComputedCounter.Inc(1)
+		}
+	}
+}
+
+// Takes the shallowest entry off the queue which has not been discarded, and marks it
+// as running. Returns the context its computation is canceled with.
+func (p *SpeculativeProvider) next(ctx context.Context) (*speculativeEntry, context.Context) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for len(p.queue) > 0 {
+		idx := 0
+		for i, e := range p.queue {
+			if e.depth < p.queue[idx].depth {
+				idx = i
+			}
+		}
+		e := p.queue[idx]
+		p.queue = append(p.queue[:idx], p.queue[idx+1:]...)
+		if len(e.owners) == 0 {
+			continue
+		}
+		if len(p.queue) > 0 {
+			p.signal()
+		}
+		computeCtx, cancel := context.WithCancel(ctx)
+		e.running = true
+		e.cancel = cancel
+		return e, computeCtx
+	}
+	return nil, nil
+}
+
+func (p *SpeculativeProvider) signal() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+func commitmentKey(req *HistoryCommitmentRequest) string {
+	return "commitment/" + requestKey(req)
+}
+
+func proofKey(req *HistoryCommitmentRequest, prefixHeight Height) string {
+	return fmt.Sprintf("proof/%d/%s", prefixHeight, requestKey(req))
+}
+
+func requestKey(req *HistoryCommitmentRequest) string {
+	upTo := "all"
+	if req.UpToHeight.IsSome() {
+		upTo = fmt.Sprintf("%d", req.UpToHeight.Unwrap())
+	}
+	return fmt.Sprintf(
+		"%#x/%d/%d/%v/%d/%s",
+		req.WasmModuleRoot,
+		req.FromBatch,
+		req.ToBatch,
+		req.UpperChallengeOriginHeights,
+		req.FromHeight,
+		upTo,
+	)
+}
@@ -0,0 +1,217 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package l2stateprovider
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OffchainLabs/bold/containers/option"
+	commitments "github.com/OffchainLabs/bold/state-commitments/history"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+// Counts the requests it computes, which can be held until released.
+type countingProvider struct {
+	Provider
+	lock     sync.Mutex
+	requests map[string]int
+	started  chan string
+	release  chan struct{}
+}
+
+func newCountingProvider() *countingProvider {
+	return &countingProvider{
+		requests: make(map[string]int),
+		started:  make(chan string, 100),
+	}
+}
+
+func (c *countingProvider) compute(ctx context.Context, key string) error {
+	c.lock.Lock()
+	c.requests[key]++
+	c.lock.Unlock()
+	c.started <- key
+	if c.release == nil {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.release:
+		return nil
+	}
+}
+
+func (c *countingProvider) count(key string) int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.requests[key]
+}
+
+func (c *countingProvider) HistoryCommitment(ctx context.Context, req *HistoryCommitmentRequest) (commitments.History, error) {
+	if err := c.compute(ctx, commitmentKey(req)); err != nil {
+		return commitments.History{}, err
+	}
+	history := commitments.History{Merkle: common.Hash{1}}
+	if req.UpToHeight.IsSome() {
+		history.Height = uint64(req.UpToHeight.Unwrap())
+	}
+	return history, nil
+}
+
+func (c *countingProvider) PrefixProof(ctx context.Context, req *HistoryCommitmentRequest, prefixHeight Height) ([]byte, error) {
+	if err := c.compute(ctx, proofKey(req, prefixHeight)); err != nil {
+		return nil, err
+	}
+	return []byte{byte(prefixHeight)}, nil
+}
+
+func awaitStarted(t *testing.T, c *countingProvider, n int) []string {
+	t.Helper()
+	keys := make([]string, 0, n)
+	for len(keys) < n {
+		select {
+		case key := <-c.started:
+			keys = append(keys, key)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("only %d of %d computations started", len(keys), n)
+		}
+	}
+	return keys
+}
+
+func speculativeRequest(upper []Height, upTo option.Option[Height]) *HistoryCommitmentRequest {
+	return &HistoryCommitmentRequest{
+		WasmModuleRoot:              common.Hash{2},
+		FromBatch:                   1,
+		ToBatch:                     2,
+		UpperChallengeOriginHeights: upper,
+		UpToHeight:                  upTo,
+	}
+}
+
+func TestSpeculativeProvider_Bisections(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	inner := newCountingProvider()
+	p := NewSpeculativeProvider(inner, WithSpeculativeDepth(2))
+	go p.Start(ctx)
+
+	p.Warm(common.Hash{1}, &SpeculativeEdge{
+		WasmModuleRoot:              common.Hash{2},
+		FromBatch:                   1,
+		ToBatch:                     2,
+		UpperChallengeOriginHeights: []Height{},
+		StartHeight:                 0,
+		EndHeight:                   8,
+	})
+	// The edge's bisection is computed first, then those of both its children.
+	keys := awaitStarted(t, inner, 6)
+	require.ElementsMatch(t, []string{
+		commitmentKey(speculativeRequest([]Height{}, option.Some(Height(4)))),
+		proofKey(speculativeRequest([]Height{}, option.Some(Height(8))), 4),
+	}, keys[:2])
+	require.ElementsMatch(t, []string{
+		commitmentKey(speculativeRequest([]Height{}, option.Some(Height(2)))),
+		proofKey(speculativeRequest([]Height{}, option.Some(Height(4))), 2),
+		commitmentKey(speculativeRequest([]Height{}, option.Some(Height(6)))),
+		proofKey(speculativeRequest([]Height{}, option.Some(Height(8))), 6),
+	}, keys[2:])
+
+	// Precomputed requests are served from the cache.
+	req := speculativeRequest([]Height{}, option.Some(Height(4)))
+	history, err := p.HistoryCommitment(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), history.Height)
+	require.Equal(t, 1, inner.count(commitmentKey(req)))
+	proof, err := p.PrefixProof(ctx, speculativeRequest([]Height{}, option.Some(Height(8))), 6)
+	require.NoError(t, err)
+	require.Equal(t, []byte{6}, proof)
+	require.Equal(t, 1, inner.count(proofKey(speculativeRequest([]Height{}, option.Some(Height(8))), 6)))
+
+	// Others are passed through.
+	req = speculativeRequest([]Height{}, option.Some(Height(1)))
+	_, err = p.HistoryCommitment(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, 1, inner.count(commitmentKey(req)))
+
+	// Once discarded, requests are computed again.
+	p.Discard(common.Hash{1})
+	req = speculativeRequest([]Height{}, option.Some(Height(4)))
+	_, err = p.HistoryCommitment(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, 2, inner.count(commitmentKey(req)))
+}
+
+func TestSpeculativeProvider_SubchallengeOpening(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	inner := newCountingProvider()
+	p := NewSpeculativeProvider(inner)
+	go p.Start(ctx)
+
+	p.Warm(common.Hash{1}, &SpeculativeEdge{
+		WasmModuleRoot:              common.Hash{2},
+		FromBatch:                   1,
+		ToBatch:                     2,
+		UpperChallengeOriginHeights: []Height{5},
+		StartHeight:                 3,
+		EndHeight:                   4,
+		SubchallengeHeight:          16,
+	})
+	keys := awaitStarted(t, inner, 5)
+	require.ElementsMatch(t, []string{
+		commitmentKey(speculativeRequest([]Height{5, 3}, option.None[Height]())),
+		proofKey(speculativeRequest([]Height{5, 3}, option.Some(Height(16))), 0),
+		commitmentKey(speculativeRequest([]Height{5, 3}, option.Some(Height(0)))),
+		commitmentKey(speculativeRequest([]Height{5}, option.Some(Height(4)))),
+		commitmentKey(speculativeRequest([]Height{5}, option.Some(Height(3)))),
+	}, keys)
+
+	// One-step edges at the last challenge level open no subchallenges.
+	p.Warm(common.Hash{2}, &SpeculativeEdge{
+		UpperChallengeOriginHeights: []Height{5, 3},
+		StartHeight:                 7,
+		EndHeight:                   8,
+	})
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	require.Empty(t, p.paths[common.Hash{2}])
+}
+
+func TestSpeculativeProvider_DiscardCancels(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	inner := newCountingProvider()
+	inner.release = make(chan struct{})
+	p := NewSpeculativeProvider(inner, WithSpeculativeDepth(1))
+	go p.Start(ctx)
+
+	edge := &SpeculativeEdge{StartHeight: 0, EndHeight: 8}
+	p.Warm(common.Hash{1}, edge)
+	p.Warm(common.Hash{2}, edge)
+	awaitStarted(t, inner, 1)
+
+	// Computations still needed by another path keep running.
+	p.Discard(common.Hash{1})
+	p.lock.Lock()
+	require.Len(t, p.entries, 2)
+	p.lock.Unlock()
+
+	p.Discard(common.Hash{2})
+	p.lock.Lock()
+	require.Empty(t, p.entries)
+	require.Empty(t, p.paths)
+	p.lock.Unlock()
+
+	// The running computation is canceled, and the queued one is skipped, so the
+	// worker is free for the next request.
+	p.Warm(common.Hash{3}, &SpeculativeEdge{StartHeight: 0, EndHeight: 2})
+	next := awaitStarted(t, inner, 1)[0]
+	require.Equal(t, commitmentKey(&HistoryCommitmentRequest{UpToHeight: option.Some(Height(1))}), next)
+}
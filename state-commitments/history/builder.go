@@ -0,0 +1,303 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package history
+
+import (
+	"errors"
+	"fmt"
+
+	prefixproofs "github.com/OffchainLabs/bold/state-commitments/prefix-proofs"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// LeafIterator yields the leaves of a history commitment in order.
+type LeafIterator interface {
+	// Next returns the next leaf, or false once there are no more leaves.
+	Next() (common.Hash, bool, error)
+}
+
+type sliceIterator struct {
+	leaves []common.Hash
+}
+
+// SliceIterator iterates over a slice of leaves.
+func SliceIterator(leaves []common.Hash) LeafIterator {
+	return &sliceIterator{leaves: leaves}
+}
+
+func (it *sliceIterator) Next() (common.Hash, bool, error) {
+	if len(it.leaves) == 0 {
+		return common.Hash{}, false, nil
+	}
+	leaf := it.leaves[0]
+	it.leaves = it.leaves[1:]
+	return leaf, true, nil
+}
+
+// NewFromIterator computes the history commitment over the leaves of an iterator in a
+// single pass.
+func NewFromIterator(it LeafIterator) (History, error) {
+	b := NewBuilder()
+	if err := b.AppendAll(it); err != nil {
+		return emptyCommit, err
+	}
+	return b.History()
+}
+
+// Builder computes a history commitment over leaves appended one at a time. Rather than
+// the leaves, it only keeps the roots of O(log n) subtrees of the Merkle tree over them:
+//
+//   - the Merkle expansion of the leaves so far, whose root is the commitment's root,
+//   - the expansion before the last leaf, whose subtrees are the left siblings on the
+//     path of the last leaf,
+//   - the complete subtrees over leaves [2^l, 2^(l+1)), the siblings on the path of the
+//     first leaf, and the expansion of the leaves since the last power of two, which
+//     is the topmost sibling if it is incomplete,
+//   - if created for a prefix proof, the expansion of the prefix and the complete
+//     subtrees the proof appends to it.
+type Builder struct {
+	size              uint64
+	expansion         prefixproofs.MerkleExpansion
+	previous          prefixproofs.MerkleExpansion
+	tail              prefixproofs.MerkleExpansion
+	firstLeaf         common.Hash
+	lastLeaf          common.Hash
+	firstLeafSiblings []common.Hash
+	prefix            *prefixBuilder
+}
+
+// The complete subtrees a prefix proof appends to the prefix, and their roots once built.
+type prefixBuilder struct {
+	preSize      uint64
+	postSize     uint64
+	preExpansion prefixproofs.MerkleExpansion
+	subtrees     []subtree
+	roots        []common.Hash
+}
+
+// A complete subtree over the 2^level leaves from start.
+type subtree struct {
+	level uint64
+	start uint64
+}
+
+func NewBuilder() *Builder {
+	return &Builder{
+		expansion: prefixproofs.NewEmptyMerkleExpansion(),
+		previous:  prefixproofs.NewEmptyMerkleExpansion(),
+		tail:      prefixproofs.NewEmptyMerkleExpansion(),
+	}
+}
+
+// NewPrefixBuilder creates a builder for a commitment over postSize leaves, which also
+// proves that the commitment over the first preSize leaves is a prefix of it.
+func NewPrefixBuilder(preSize, postSize uint64) (*Builder, error) {
+	subtrees, err := prefixProofSubtrees(preSize, postSize)
+	if err != nil {
+		return nil, err
+	}
+	b := NewBuilder()
+	b.prefix = &prefixBuilder{
+		preSize:  preSize,
+		postSize: postSize,
+		subtrees: subtrees,
+	}
+	return b, nil
+}
+
+// Computes the complete subtrees appended to a tree of preSize leaves to reach postSize
+// leaves, as in prefixproofs.GeneratePrefixProof.
+func prefixProofSubtrees(preSize, postSize uint64) ([]subtree, error) {
+	if preSize == 0 {
+		return nil, fmt.Errorf("%w: prefix size was 0", prefixproofs.ErrCannotBeZero)
+	}
+	if preSize >= postSize {
+		return nil, fmt.Errorf("%w: prefix size %d, size %d", prefixproofs.ErrStartNotLessThanEnd, preSize, postSize)
+	}
+	var subtrees []subtree
+	for size := preSize; size < postSize; {
+		level, err := prefixproofs.MaximumAppendBetween(size, postSize)
+		if err != nil {
+			return nil, err
+		}
+		subtrees = append(subtrees, subtree{level: level, start: size})
+		size += 1 << level
+	}
+	return subtrees, nil
+}
+
+// Size is the number of leaves appended so far.
+func (b *Builder) Size() uint64 {
+	return b.size
+}
+
+// AppendAll appends the leaves of an iterator.
+func (b *Builder) AppendAll(it LeafIterator) error {
+	for {
+		leaf, ok, err := it.Next()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+		if err = b.AppendLeaf(leaf); err != nil {
+			return err
+		}
+	}
+}
+
+func (b *Builder) AppendLeaf(leaf common.Hash) error {
+	if b.prefix != nil && b.size == b.prefix.postSize {
+		return fmt.Errorf("cannot append more than %d leaves to a prefix proof", b.prefix.postSize)
+	}
+	if b.size == 0 {
+		b.firstLeaf = leaf
+	}
+	b.lastLeaf = leaf
+	b.previous = append(b.previous[:0], b.expansion...)
+	// The leaves since the last power of two restart at each power of two.
+	if b.size&(b.size-1) == 0 {
+		b.tail = b.tail[:0]
+	}
+	hashed := crypto.Keccak256Hash(leaf[:])
+	b.tail = appendSubtree(b.tail, hashed, nil)
+	b.expansion = appendSubtree(b.expansion, hashed, b.onComplete)
+	b.size++
+	if b.prefix != nil && b.size == b.prefix.preSize {
+		b.prefix.preExpansion = b.expansion.Clone()
+	}
+	return nil
+}
+
+// Called with each complete subtree as it is built, whose roots are kept if needed for
+// the proofs.
+func (b *Builder) onComplete(level uint64, root common.Hash) {
+	start := b.size + 1 - 1<<level
+	if start == 1<<level && level == uint64(len(b.firstLeafSiblings)) {
+		b.firstLeafSiblings = append(b.firstLeafSiblings, root)
+	}
+	if p := b.prefix; p != nil && len(p.roots) < len(p.subtrees) {
+		if next := p.subtrees[len(p.roots)]; next.level == level && next.start == start {
+			p.roots = append(p.roots, root)
+		}
+	}
+}
+
+// Appends a leaf hash to the expansion in place, reporting each complete subtree built,
+// starting with the leaf itself.
+func appendSubtree(me prefixproofs.MerkleExpansion, hashed common.Hash, onComplete func(level uint64, root common.Hash)) prefixproofs.MerkleExpansion {
+	carry := hashed
+	for level := uint64(0); ; level++ {
+		if onComplete != nil {
+			onComplete(level, carry)
+		}
+		if level == uint64(len(me)) {
+			return append(me, carry)
+		}
+		if me[level] == (common.Hash{}) {
+			me[level] = carry
+			return me
+		}
+		carry = crypto.Keccak256Hash(me[level].Bytes(), carry.Bytes())
+		me[level] = common.Hash{}
+	}
+}
+
+// History is the commitment over the leaves appended so far, with the same leaf proofs
+// as New.
+func (b *Builder) History() (History, error) {
+	if b.size == 0 {
+		return emptyCommit, errors.New("must commit to at least one leaf")
+	}
+	root, err := prefixproofs.Root(b.expansion)
+	if err != nil {
+		return emptyCommit, err
+	}
+	history := History{
+		Height:         b.size - 1,
+		Merkle:         root,
+		FirstLeaf:      b.firstLeaf,
+		LastLeaf:       b.lastLeaf,
+		FirstLeafProof: make([]common.Hash, 0),
+		LastLeafProof:  make([]common.Hash, 0),
+	}
+	if b.size == 1 {
+		return history, nil
+	}
+	maxLevel, err := prefixproofs.MostSignificantBit(b.size - 1)
+	if err != nil {
+		return emptyCommit, err
+	}
+	history.FirstLeafProof, err = b.firstLeafProof(maxLevel)
+	if err != nil {
+		return emptyCommit, err
+	}
+	history.LastLeafProof = make([]common.Hash, maxLevel+1)
+	for level := uint64(0); level <= maxLevel; level++ {
+		// Where the last leaf is a right child, its sibling is a complete subtree to the
+		// left. Otherwise, there are no leaves to its right.
+		if (b.size-1)>>level&1 == 1 {
+			history.LastLeafProof[level] = b.previous[level]
+		}
+	}
+	return history, nil
+}
+
+func (b *Builder) firstLeafProof(maxLevel uint64) ([]common.Hash, error) {
+	proof := make([]common.Hash, maxLevel+1)
+	copy(proof, b.firstLeafSiblings[:maxLevel])
+	// The topmost sibling is complete if the size is a power of two.
+	if b.size&(b.size-1) == 0 {
+		proof[maxLevel] = b.firstLeafSiblings[maxLevel]
+		return proof, nil
+	}
+	// Otherwise it is the tree over the tail, padded with empty subtrees up to its level.
+	top, err := prefixproofs.Root(b.tail)
+	if err != nil {
+		return nil, err
+	}
+	tailSize := b.size - 1<<maxLevel
+	level, err := prefixproofs.MostSignificantBit(tailSize)
+	if err != nil {
+		return nil, err
+	}
+	if tailSize&(tailSize-1) != 0 {
+		level++
+	}
+	for ; level < maxLevel; level++ {
+		top = crypto.Keccak256Hash(top.Bytes(), (common.Hash{}).Bytes())
+	}
+	proof[maxLevel] = top
+	return proof, nil
+}
+
+// PrefixProof proves that the commitment over the prefix is a prefix of the commitment
+// over all leaves, once all of them are appended to a builder created by NewPrefixBuilder.
+func (b *Builder) PrefixProof() (*prefixproofs.VerifyPrefixProofConfig, error) {
+	p := b.prefix
+	if p == nil {
+		return nil, errors.New("builder was not created for a prefix proof")
+	}
+	if b.size != p.postSize || len(p.roots) != len(p.subtrees) {
+		return nil, fmt.Errorf("prefix proof needs %d leaves, got %d", p.postSize, b.size)
+	}
+	preRoot, err := prefixproofs.Root(p.preExpansion)
+	if err != nil {
+		return nil, err
+	}
+	postRoot, err := prefixproofs.Root(b.expansion)
+	if err != nil {
+		return nil, err
+	}
+	return &prefixproofs.VerifyPrefixProofConfig{
+		PreRoot:      preRoot,
+		PreSize:      p.preSize,
+		PostRoot:     postRoot,
+		PostSize:     p.postSize,
+		PreExpansion: p.preExpansion,
+		PrefixProof:  p.roots,
+	}, nil
+}
@@ -0,0 +1,118 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package history
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	inclusionproofs "github.com/OffchainLabs/bold/state-commitments/inclusion-proofs"
+	prefixproofs "github.com/OffchainLabs/bold/state-commitments/prefix-proofs"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func testLeaves(n int) []common.Hash {
+	leaves := make([]common.Hash, n)
+	for i := range leaves {
+		binary.BigEndian.PutUint64(leaves[i][24:], uint64(i)+1)
+	}
+	return leaves
+}
+
+func TestBuilder_MatchesMaterializedTree(t *testing.T) {
+	for n := 1; n <= 70; n++ {
+		leaves := testLeaves(n)
+		history, err := NewFromIterator(SliceIterator(leaves))
+		require.NoError(t, err)
+
+		exp, err := prefixproofs.ExpansionFromLeaves(leaves)
+		require.NoError(t, err)
+		root, err := prefixproofs.Root(exp)
+		require.NoError(t, err)
+		firstLeafProof, err := inclusionproofs.GenerateInclusionProof(leaves, 0)
+		require.NoError(t, err)
+		lastLeafProof, err := inclusionproofs.GenerateInclusionProof(leaves, uint64(n-1))
+		require.NoError(t, err)
+
+		require.Equal(t, uint64(n-1), history.Height)
+		require.Equal(t, root, history.Merkle, "root of %d leaves", n)
+		require.Equal(t, leaves[0], history.FirstLeaf)
+		require.Equal(t, leaves[n-1], history.LastLeaf)
+		require.Equal(t, firstLeafProof, history.FirstLeafProof, "first leaf proof of %d leaves", n)
+		require.Equal(t, lastLeafProof, history.LastLeafProof, "last leaf proof of %d leaves", n)
+	}
+}
+
+func TestBuilder_PrefixProofs(t *testing.T) {
+	for n := 2; n <= 40; n++ {
+		leaves := testLeaves(n)
+		for pre := 1; pre < n; pre++ {
+			b, err := NewPrefixBuilder(uint64(pre), uint64(n))
+			require.NoError(t, err)
+			require.NoError(t, b.AppendAll(SliceIterator(leaves)))
+			proof, err := b.PrefixProof()
+			require.NoError(t, err)
+			require.NoError(t, prefixproofs.VerifyPrefixProof(proof), "prefix %d of %d leaves", pre, n)
+
+			exp, err := prefixproofs.ExpansionFromLeaves(leaves[:pre])
+			require.NoError(t, err)
+			require.Equal(t, exp, prefixproofs.MerkleExpansion(proof.PreExpansion))
+			generated, err := prefixproofs.GeneratePrefixProof(uint64(pre), exp, leaves[pre:], prefixproofs.RootFetcherFromExpansion)
+			require.NoError(t, err)
+			_, numRead := prefixproofs.MerkleExpansionFromCompact(generated, uint64(pre))
+			require.Equal(t, generated[numRead:], proof.PrefixProof, "prefix %d of %d leaves", pre, n)
+		}
+	}
+	b, err := NewPrefixBuilder(2, 3)
+	require.NoError(t, err)
+	require.NoError(t, b.AppendAll(SliceIterator(testLeaves(2))))
+	_, err = b.PrefixProof()
+	require.ErrorContains(t, err, "needs 3 leaves")
+	require.NoError(t, b.AppendLeaf(common.Hash{1}))
+	require.ErrorContains(t, b.AppendLeaf(common.Hash{1}), "more than 3 leaves")
+
+	_, err = NewPrefixBuilder(0, 3)
+	require.ErrorIs(t, err, prefixproofs.ErrCannotBeZero)
+	_, err = NewPrefixBuilder(3, 3)
+	require.ErrorIs(t, err, prefixproofs.ErrStartNotLessThanEnd)
+	_, err = NewBuilder().PrefixProof()
+	require.Error(t, err)
+}
+
+// Generates leaves without materializing them.
+type countingIterator struct {
+	next, end uint64
+	err       error
+}
+
+func (it *countingIterator) Next() (common.Hash, bool, error) {
+	if it.next == it.end {
+		return common.Hash{}, false, it.err
+	}
+	var leaf common.Hash
+	binary.BigEndian.PutUint64(leaf[24:], it.next+1)
+	it.next++
+	return leaf, true, nil
+}
+
+func TestBuilder_StreamsLargeCommitments(t *testing.T) {
+	n := uint64(1<<16 + 3)
+	history, err := NewFromIterator(&countingIterator{end: n})
+	require.NoError(t, err)
+	require.Equal(t, n-1, history.Height)
+
+	root, err := inclusionproofs.CalculateRootFromProof(history.FirstLeafProof, 0, history.FirstLeaf)
+	require.NoError(t, err)
+	require.Equal(t, history.Merkle, root)
+	root, err = inclusionproofs.CalculateRootFromProof(history.LastLeafProof, history.Height, history.LastLeaf)
+	require.NoError(t, err)
+	require.Equal(t, history.Merkle, root)
+
+	_, err = NewFromIterator(&countingIterator{end: 5, err: errors.New("bad leaf")})
+	require.ErrorContains(t, err, "bad leaf")
+	_, err = NewFromIterator(SliceIterator(nil))
+	require.Error(t, err)
+}
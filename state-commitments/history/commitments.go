@@ -6,11 +6,6 @@
 package history
 
 import (
-	"errors"
-	prefixproofs "github.com/OffchainLabs/bold/state-commitments/prefix-proofs"
-	"sync"
-
-	inclusionproofs "github.com/OffchainLabs/bold/state-commitments/inclusion-proofs"
 	"github.com/ethereum/go-ethereum/common"
 )
 
@@ -34,58 +29,8 @@ type History struct {
 	LastLeaf       common.Hash
 }
 
+// New computes the history commitment over a list of leaves. See NewFromIterator to
+// avoid materializing the leaves.
 func New(leaves []common.Hash) (History, error) {
-	if len(leaves) == 0 {
-		return emptyCommit, errors.New("must commit to at least one leaf")
-	}
-	var waitGroup sync.WaitGroup
-	waitGroup.Add(3)
-
-	var firstLeafProof []common.Hash
-	var err1 error
-	go func() {
-		defer waitGroup.Done()
-		firstLeafProof, err1 = inclusionproofs.GenerateInclusionProof(leaves, 0)
-	}()
-
-	var lastLeafProof []common.Hash
-	var err2 error
-	go func() {
-		defer waitGroup.Done()
-		lastLeafProof, err2 = inclusionproofs.GenerateInclusionProof(leaves, uint64(len(leaves))-1)
-	}()
-
-	var root common.Hash
-	var err3 error
-	go func() {
-		defer waitGroup.Done()
-		exp := prefixproofs.NewEmptyMerkleExpansion()
-		for _, r := range leaves {
-			exp, err3 = prefixproofs.AppendLeaf(exp, r)
-			if err3 != nil {
-				return
-			}
-		}
-		root, err3 = prefixproofs.Root(exp)
-	}()
-	waitGroup.Wait()
-
-	if err1 != nil {
-		return emptyCommit, err1
-	}
-	if err2 != nil {
-		return emptyCommit, err2
-	}
-	if err3 != nil {
-		return emptyCommit, err3
-	}
-
-	return History{
-		Merkle:         root,
-		Height:         uint64(len(leaves) - 1),
-		FirstLeaf:      leaves[0],
-		LastLeaf:       leaves[len(leaves)-1],
-		FirstLeafProof: firstLeafProof,
-		LastLeafProof:  lastLeafProof,
-	}, nil
+	return NewFromIterator(SliceIterator(leaves))
 }
@@ -0,0 +1,147 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+// Package adversary contains malicious validator behaviours for testing that
+// an honest challenge manager wins challenges no matter how its opponents play.
+// Adversaries drive the challenge manager contract directly, rather than
+// running edge trackers with a divergent state provider, so they can make
+// moves an honest tracker never would.
+package adversary
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/pkg/errors"
+)
+
+var srvlog = log.New("service", "adversary")
+
+func init() {
+	srvlog.SetHandler(log.StreamHandler(os.Stdout, log.LogfmtFormat()))
+}
+
+// Behaviour is a malicious way of playing challenges, carried out by a set of
+// players controlled by the same adversary.
+type Behaviour interface {
+	// Play makes the moves of a single round of the behaviour. It is called
+	// repeatedly, and must pick up wherever the previous round left off.
+	Play(ctx context.Context, players []*Player) error
+}
+
+// BehaviourFunc adapts a function to a behaviour.
+type BehaviourFunc func(ctx context.Context, players []*Player) error
+
+func (f BehaviourFunc) Play(ctx context.Context, players []*Player) error {
+	return f(ctx, players)
+}
+
+// Stats counts what an adversary has done on chain, so tests can check that a
+// behaviour was actually carried out rather than trivially losing.
+type Stats struct {
+	// Assertions posted by the players.
+	AssertionsPosted uint64
+	// Level zero edges added by the players, at any challenge level.
+	LevelZeroEdgesAdded uint64
+	// Bisections made by the players.
+	Bisections uint64
+	// Invalid moves attempted, which the protocol should reject.
+	InvalidMovesAttempted uint64
+	// Invalid moves the protocol accepted. Should always be zero.
+	InvalidMovesAccepted uint64
+	// Edges confirmed by the players, honest or not.
+	EdgesConfirmed uint64
+	// Assertions confirmed by the players as challenge winners.
+	AssertionsConfirmed uint64
+}
+
+type recorder struct {
+	lock  sync.Mutex
+	stats Stats
+}
+
+func (r *recorder) record(fn func(s *Stats)) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	fn(&r.stats)
+}
+
+// Adversary plays a malicious behaviour with a set of players.
+type Adversary struct {
+	name        string
+	behaviour   Behaviour
+	players     []*Player
+	actInterval time.Duration
+	recorder    *recorder
+}
+
+// Opt to configure the adversary.
+type Opt func(a *Adversary)
+
+// WithName sets the name of the adversary, used only for logging.
+func WithName(name string) Opt {
+	return func(a *Adversary) {
+		a.name = name
+	}
+}
+
+// WithActInterval sets the interval between rounds of the adversary's behaviour.
+func WithActInterval(d time.Duration) Opt {
+	return func(a *Adversary) {
+		a.actInterval = d
+	}
+}
+
+// New creates an adversary playing a behaviour with the given players. All
+// players must challenge the same parent assertion.
+func New(behaviour Behaviour, players []*Player, opts ...Opt) (*Adversary, error) {
+	if behaviour == nil {
+		return nil, errors.New("no behaviour for adversary")
+	}
+	if len(players) == 0 {
+		return nil, errors.New("adversary needs at least one player")
+	}
+	a := &Adversary{
+		name:        "adversary",
+		behaviour:   behaviour,
+		players:     players,
+		actInterval: time.Second,
+		recorder:    &recorder{},
+	}
+	for _, o := range opts {
+		o(a)
+	}
+	for _, p := range players {
+		p.recorder = a.recorder
+	}
+	return a, nil
+}
+
+// Start plays the adversary's behaviour in the background until the context
+// is cancelled. Errors in a round are logged, and the next round is played.
+func (a *Adversary) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(a.actInterval)
+		defer ticker.Stop()
+		for {
+			if err := a.behaviour.Play(ctx, a.players); err != nil && ctx.Err() == nil {
+				srvlog.Warn("Adversary round failed", log.Ctx{"name": a.name, "err": err})
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stats returns a snapshot of what the adversary has done so far.
+func (a *Adversary) Stats() Stats {
+	a.recorder.lock.Lock()
+	defer a.recorder.lock.Unlock()
+	return a.recorder.stats
+}
@@ -0,0 +1,343 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package adversary
+
+import (
+	"context"
+	"fmt"
+
+	protocol "github.com/OffchainLabs/bold/chain-abstraction"
+	edgetracker "github.com/OffchainLabs/bold/challenge-manager/edge-tracker"
+	"github.com/OffchainLabs/bold/containers"
+	"github.com/OffchainLabs/bold/solgen/go/challengeV2gen"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/pkg/errors"
+)
+
+// Makes every move due for every player, consistently with its history, calling
+// before ahead of each move if it is set. A failed move is logged and retried
+// in the next round, so it does not hold up the other moves.
+func playAll(
+	ctx context.Context,
+	players []*Player,
+	before func(ctx context.Context, p *Player, m Move) error,
+) error {
+	for _, p := range players {
+		engaged, err := p.Engage(ctx)
+		if err != nil {
+			return errors.Wrapf(err, "%s could not engage in challenge", p.name)
+		}
+		if !engaged {
+			continue
+		}
+		moves, err := p.Moves(ctx)
+		if err != nil {
+			return errors.Wrapf(err, "%s could not determine moves", p.name)
+		}
+		for _, m := range moves {
+			if before != nil {
+				if err := before(ctx, p, m); err != nil {
+					return err
+				}
+			}
+			if err := p.Make(ctx, m); err != nil {
+				srvlog.Warn("Could not make move", log.Ctx{"player": p.name, "move": m.Kind, "err": err})
+			}
+		}
+	}
+	return nil
+}
+
+// InconsistentBisections tries to bisect every rivaled edge with history roots
+// inconsistent with the edge before bisecting it correctly, so the challenge
+// still plays out once the protocol rejects the invalid attempts.
+func InconsistentBisections() Behaviour {
+	attempted := make(map[protocol.EdgeId]bool)
+	return BehaviourFunc(func(ctx context.Context, players []*Player) error {
+		return playAll(ctx, players, func(ctx context.Context, p *Player, m Move) error {
+			if m.Kind != Bisect || attempted[m.Edge.Id()] {
+				return nil
+			}
+			attempted[m.Edge.Id()] = true
+			_, err := p.AttemptInvalidBisections(ctx, m.Edge)
+			return err
+		})
+	})
+}
+
+// ManyRivals has every player open its own level zero edge in the block
+// challenge, and play out its history from there. Players must claim different
+// histories, so that each of their edges rivals the honest one.
+func ManyRivals() Behaviour {
+	return BehaviourFunc(func(ctx context.Context, players []*Player) error {
+		claimants := make(map[protocol.AssertionHash]string)
+		for _, p := range players {
+			claim, err := p.Claim(ctx)
+			if err != nil {
+				return errors.Wrapf(err, "%s could not claim", p.name)
+			}
+			if other, ok := claimants[claim.Id()]; ok {
+				return fmt.Errorf("players %s and %s claim the same assertion", other, p.name)
+			}
+			claimants[claim.Id()] = p.name
+		}
+		return playAll(ctx, players, nil)
+	})
+}
+
+// SubchallengeSpam has every player open subchallenges at every one step fork
+// it reaches, at every challenge level. Players must claim the same history in
+// the block challenge and diverge within blocks, so that they share their block
+// challenge edges and each opens rival edges in the subchallenges below.
+func SubchallengeSpam() Behaviour {
+	return BehaviourFunc(func(ctx context.Context, players []*Player) error {
+		var claim protocol.AssertionHash
+		for i, p := range players {
+			c, err := p.Claim(ctx)
+			if err != nil {
+				return errors.Wrapf(err, "%s could not claim", p.name)
+			}
+			if i == 0 {
+				claim = c.Id()
+			} else if c.Id() != claim {
+				return fmt.Errorf("player %s does not claim the same assertion as %s", p.name, players[0].name)
+			}
+		}
+		return playAll(ctx, players, nil)
+	})
+}
+
+// WithholdMoves holds off on every move, including opening the block challenge,
+// until the challenge is within the given number of blocks of running out for
+// the first edges added to it, and makes all due moves as soon as possible from
+// then on.
+func WithholdMoves(marginBlocks uint64) Behaviour {
+	return BehaviourFunc(func(ctx context.Context, players []*Player) error {
+		for _, p := range players {
+			if _, err := p.Claim(ctx); err != nil {
+				return errors.Wrapf(err, "%s could not claim", p.name)
+			}
+		}
+		remaining, err := blocksUntilChallengeExpiry(ctx, players[0])
+		if err != nil {
+			return err
+		}
+		if remaining > marginBlocks {
+			return nil
+		}
+		return playAll(ctx, players, nil)
+	})
+}
+
+// Returns the number of blocks left until the edges added at the start of the
+// challenge could be confirmed by time, if unrivaled throughout, or zero if the
+// challenge has not started yet.
+func blocksUntilChallengeExpiry(ctx context.Context, p *Player) (uint64, error) {
+	parent, err := p.chain.GetAssertion(ctx, p.parent.Unwrap())
+	if err != nil {
+		return 0, err
+	}
+	firstChildBlock, err := parent.FirstChildCreationBlock()
+	if err != nil {
+		return 0, err
+	}
+	manager, err := p.chain.SpecChallengeManager(ctx)
+	if err != nil {
+		return 0, err
+	}
+	period, err := manager.ChallengePeriodBlocks(ctx)
+	if err != nil {
+		return 0, err
+	}
+	header, err := p.chain.Backend().HeaderByNumber(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	if !header.Number.IsUint64() {
+		return 0, errors.New("latest block number is not a uint64")
+	}
+	latest := header.Number.Uint64()
+	if firstChildBlock == 0 || latest >= firstChildBlock+period {
+		return 0, nil
+	}
+	return firstChildBlock + period - latest, nil
+}
+
+// FrontRunConfirmations plays out every player's history, and races to confirm
+// any edge in the challenge, honest or not, as soon as it can be confirmed by its
+// children or by a confirmed claiming edge, along with the assertion that wins
+// the block challenge. Honest confirmation transactions that lose the race fail.
+func FrontRunConfirmations() Behaviour {
+	c := &confirmer{edges: make(map[protocol.EdgeId]*challengeV2gen.EdgeChallengeManagerEdgeAdded)}
+	return BehaviourFunc(func(ctx context.Context, players []*Player) error {
+		if err := playAll(ctx, players, nil); err != nil {
+			return err
+		}
+		return c.confirmAll(ctx, players[0])
+	})
+}
+
+// Confirms edges on behalf of a player, keeping track of the edges in the
+// challenge manager.
+type confirmer struct {
+	fromBlock uint64
+	edges     map[protocol.EdgeId]*challengeV2gen.EdgeChallengeManagerEdgeAdded
+}
+
+func (c *confirmer) confirmAll(ctx context.Context, p *Player) error {
+	manager, err := p.chain.SpecChallengeManager(ctx)
+	if err != nil {
+		return err
+	}
+	if err = c.scan(ctx, p, manager); err != nil {
+		return err
+	}
+	for id, added := range c.edges {
+		edgeOpt, err := manager.GetEdge(ctx, id)
+		if err != nil {
+			return err
+		}
+		if edgeOpt.IsNone() {
+			continue
+		}
+		edge := edgeOpt.Unwrap()
+		status, err := edge.Status(ctx)
+		if err != nil {
+			return err
+		}
+		if status == protocol.EdgeConfirmed {
+			done, err := c.confirmClaim(ctx, p, manager, added)
+			if err != nil {
+				srvlog.Debug("Could not confirm claim of edge", log.Ctx{"player": p.name, "err": err})
+			}
+			if done {
+				delete(c.edges, id)
+			}
+			continue
+		}
+		lost, err := edge.HasConfirmedRival(ctx)
+		if err != nil {
+			return err
+		}
+		if lost {
+			delete(c.edges, id)
+			continue
+		}
+		childrenConfirmed, err := edgetracker.ChildrenAreConfirmed(ctx, edge, manager)
+		if err != nil {
+			return err
+		}
+		if !childrenConfirmed {
+			continue
+		}
+		if err := edge.ConfirmByChildren(ctx); err != nil {
+			srvlog.Debug("Could not confirm edge by children", log.Ctx{"player": p.name, "err": err})
+			continue
+		}
+		p.recorder.record(func(s *Stats) { s.EdgesConfirmed++ })
+		srvlog.Info("Front-ran edge confirmation by children", log.Ctx{
+			"player": p.name,
+			"edgeId": containers.Trunc(id.Bytes()),
+		})
+	}
+	return nil
+}
+
+// Confirms what a confirmed level zero edge claims: the edge it claims in the
+// challenge level above, or the assertion it claims in a block challenge.
+// Returns whether there is nothing left to confirm.
+func (c *confirmer) confirmClaim(
+	ctx context.Context,
+	p *Player,
+	manager protocol.SpecChallengeManager,
+	added *challengeV2gen.EdgeChallengeManagerEdgeAdded,
+) (bool, error) {
+	if !added.IsLayerZero {
+		return true, nil
+	}
+	if added.Level == protocol.NewBlockChallengeLevel().Uint8() {
+		assertionHash := protocol.AssertionHash{Hash: added.ClaimId}
+		status, err := p.chain.AssertionStatus(ctx, assertionHash)
+		if err != nil {
+			return false, err
+		}
+		if status == protocol.AssertionConfirmed {
+			return true, nil
+		}
+		if err := p.chain.ConfirmAssertionByChallengeWinner(ctx, assertionHash, protocol.EdgeId{Hash: added.EdgeId}); err != nil {
+			return false, err
+		}
+		p.recorder.record(func(s *Stats) { s.AssertionsConfirmed++ })
+		srvlog.Info("Front-ran assertion confirmation", log.Ctx{
+			"player":        p.name,
+			"assertionHash": containers.Trunc(added.ClaimId[:]),
+		})
+		return true, nil
+	}
+	claimedOpt, err := manager.GetEdge(ctx, protocol.EdgeId{Hash: added.ClaimId})
+	if err != nil {
+		return false, err
+	}
+	if claimedOpt.IsNone() {
+		return true, nil
+	}
+	claimed := claimedOpt.Unwrap()
+	status, err := claimed.Status(ctx)
+	if err != nil {
+		return false, err
+	}
+	if status == protocol.EdgeConfirmed {
+		return true, nil
+	}
+	if err := claimed.ConfirmByClaim(ctx, protocol.ClaimId(added.EdgeId)); err != nil {
+		return false, err
+	}
+	p.recorder.record(func(s *Stats) { s.EdgesConfirmed++ })
+	srvlog.Info("Front-ran edge confirmation by claim", log.Ctx{
+		"player": p.name,
+		"edgeId": containers.Trunc(added.ClaimId[:]),
+	})
+	return true, nil
+}
+
+// Picks up the edges added to the challenge manager since the last scan.
+func (c *confirmer) scan(ctx context.Context, p *Player, manager protocol.SpecChallengeManager) error {
+	header, err := p.chain.Backend().HeaderByNumber(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if !header.Number.IsUint64() {
+		return errors.New("latest block number is not a uint64")
+	}
+	latest := header.Number.Uint64()
+	if latest < c.fromBlock {
+		return nil
+	}
+	filterer, err := challengeV2gen.NewEdgeChallengeManagerFilterer(manager.Address(), p.chain.Backend())
+	if err != nil {
+		return err
+	}
+	it, err := filterer.FilterEdgeAdded(&bind.FilterOpts{
+		Start:   c.fromBlock,
+		End:     &latest,
+		Context: ctx,
+	}, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err = it.Close(); err != nil {
+			srvlog.Error("Could not close filter iterator", log.Ctx{"err": err})
+		}
+	}()
+	for it.Next() {
+		c.edges[protocol.EdgeId{Hash: it.Event.EdgeId}] = it.Event
+	}
+	if it.Error() != nil {
+		return it.Error()
+	}
+	c.fromBlock = latest + 1
+	return nil
+}
@@ -0,0 +1,545 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/offchainlabs/bold/blob/main/LICENSE
+
+package adversary
+
+import (
+	"context"
+	"fmt"
+
+	protocol "github.com/OffchainLabs/bold/chain-abstraction"
+	edgetracker "github.com/OffchainLabs/bold/challenge-manager/edge-tracker"
+	"github.com/OffchainLabs/bold/containers"
+	"github.com/OffchainLabs/bold/containers/option"
+	l2stateprovider "github.com/OffchainLabs/bold/layer2-state-provider"
+	"github.com/OffchainLabs/bold/math"
+	commitments "github.com/OffchainLabs/bold/state-commitments/history"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/pkg/errors"
+)
+
+// MoveKind is a kind of move a player makes on one of its edges.
+type MoveKind uint8
+
+const (
+	// Bisect bisects a rivaled edge.
+	Bisect MoveKind = iota + 1
+	// OpenSubchallengeLeaf adds a level zero edge in the subchallenge of an edge
+	// with a length one rival.
+	OpenSubchallengeLeaf
+)
+
+func (k MoveKind) String() string {
+	switch k {
+	case Bisect:
+		return "bisect"
+	case OpenSubchallengeLeaf:
+		return "open_subchallenge_leaf"
+	default:
+		return fmt.Sprintf("move(%d)", uint8(k))
+	}
+}
+
+// Move is a move due on one of a player's edges.
+type Move struct {
+	Kind MoveKind
+	Edge protocol.SpecEdge
+}
+
+// Player is a malicious identity, staking with its own key and claiming the
+// history of its state provider. A player keeps track of its own edges, and
+// plays them consistently with its history unless told otherwise. Players with
+// the same history share their edges, adopting those another player created.
+type Player struct {
+	name     string
+	chain    protocol.Protocol
+	provider l2stateprovider.Provider
+	recorder *recorder
+
+	parent   option.Option[protocol.AssertionHash]
+	claim    option.Option[protocol.Assertion]
+	metadata *edgetracker.AssociatedAssertionMetadata
+	engaged  bool
+	edges    map[protocol.EdgeId]protocol.SpecEdge
+}
+
+// NewPlayer creates a player acting on chain with the key of the given chain,
+// and claiming the history of the given state provider.
+func NewPlayer(name string, chain protocol.Protocol, provider l2stateprovider.Provider) *Player {
+	return &Player{
+		name:     name,
+		chain:    chain,
+		provider: provider,
+		recorder: &recorder{},
+		edges:    make(map[protocol.EdgeId]protocol.SpecEdge),
+	}
+}
+
+// Name of the player.
+func (p *Player) Name() string {
+	return p.name
+}
+
+// Chain the player acts on.
+func (p *Player) Chain() protocol.Protocol {
+	return p.chain
+}
+
+// Claim makes sure an assertion claiming the player's history exists as a
+// child of the latest confirmed assertion, posting and staking on it if no
+// other player has yet.
+func (p *Player) Claim(ctx context.Context) (protocol.Assertion, error) {
+	if p.claim.IsSome() {
+		return p.claim.Unwrap(), nil
+	}
+	parent, err := p.chain.LatestConfirmed(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get latest confirmed assertion")
+	}
+	parentInfo, err := p.chain.ReadAssertionCreationInfo(ctx, parent.Id())
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read parent assertion creation info")
+	}
+	if !parentInfo.InboxMaxCount.IsUint64() {
+		return nil, errors.New("inbox max count not a uint64")
+	}
+	state, err := p.provider.ExecutionStateAfterBatchCount(ctx, parentInfo.InboxMaxCount.Uint64())
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get execution state to claim")
+	}
+	assertion, err := p.findClaim(ctx, parent.Id(), state)
+	if err != nil {
+		return nil, err
+	}
+	if assertion.IsNone() {
+		staked, stakedErr := p.chain.IsStaked(ctx)
+		if stakedErr != nil {
+			return nil, stakedErr
+		}
+		post := p.chain.NewStakeOnNewAssertion
+		if staked {
+			post = p.chain.StakeOnNewAssertion
+		}
+		posted, postErr := post(ctx, parentInfo, state)
+		if postErr != nil {
+			return nil, errors.Wrap(postErr, "could not post assertion")
+		}
+		p.recorder.record(func(s *Stats) { s.AssertionsPosted++ })
+		srvlog.Info("Posted malicious assertion", log.Ctx{
+			"player":        p.name,
+			"assertionHash": containers.Trunc(posted.Id().Bytes()),
+		})
+		assertion = option.Some(posted)
+	}
+	creationInfo, err := p.chain.ReadAssertionCreationInfo(ctx, assertion.Unwrap().Id())
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read claimed assertion creation info")
+	}
+	p.metadata = &edgetracker.AssociatedAssertionMetadata{
+		FromBatch:      l2stateprovider.Batch(protocol.GoGlobalStateFromSolidity(creationInfo.BeforeState.GlobalState).Batch),
+		ToBatch:        l2stateprovider.Batch(protocol.GoGlobalStateFromSolidity(creationInfo.AfterState.GlobalState).Batch),
+		WasmModuleRoot: parentInfo.WasmModuleRoot,
+	}
+	p.parent = option.Some(parent.Id())
+	p.claim = assertion
+	return assertion.Unwrap(), nil
+}
+
+func (p *Player) findClaim(
+	ctx context.Context,
+	parent protocol.AssertionHash,
+	state *protocol.ExecutionState,
+) (option.Option[protocol.Assertion], error) {
+	hashes, err := p.chain.LatestCreatedAssertionHashes(ctx)
+	if err != nil {
+		return option.None[protocol.Assertion](), errors.Wrap(err, "could not get latest created assertions")
+	}
+	for _, h := range hashes {
+		info, infoErr := p.chain.ReadAssertionCreationInfo(ctx, h)
+		if infoErr != nil {
+			return option.None[protocol.Assertion](), infoErr
+		}
+		if info.ParentAssertionHash != parent.Hash {
+			continue
+		}
+		if !protocol.GoExecutionStateFromSolidity(info.AfterState).Equals(state) {
+			continue
+		}
+		assertion, getErr := p.chain.GetAssertion(ctx, h)
+		if getErr != nil {
+			return option.None[protocol.Assertion](), getErr
+		}
+		return option.Some(assertion), nil
+	}
+	return option.None[protocol.Assertion](), nil
+}
+
+// Engage adds the player's level zero edge to the block challenge on its claim,
+// once the claim has been challenged. It returns whether the player is engaged
+// in the challenge.
+func (p *Player) Engage(ctx context.Context) (bool, error) {
+	if p.engaged {
+		return true, nil
+	}
+	claim, err := p.Claim(ctx)
+	if err != nil {
+		return false, err
+	}
+	parent, err := p.chain.GetAssertion(ctx, p.parent.Unwrap())
+	if err != nil {
+		return false, err
+	}
+	challenged, err := parent.HasSecondChild()
+	if err != nil {
+		return false, err
+	}
+	if !challenged {
+		return false, nil
+	}
+	manager, err := p.chain.SpecChallengeManager(ctx)
+	if err != nil {
+		return false, err
+	}
+	heights, err := manager.LayerZeroHeights(ctx)
+	if err != nil {
+		return false, err
+	}
+	startCommit, err := p.provider.HistoryCommitment(ctx, p.request(nil, option.Some(l2stateprovider.Height(0))))
+	if err != nil {
+		return false, err
+	}
+	endRequest := p.request(nil, option.Some(l2stateprovider.Height(heights.BlockChallengeHeight)))
+	endCommit, err := p.provider.HistoryCommitment(ctx, endRequest)
+	if err != nil {
+		return false, err
+	}
+	edgeId, err := manager.CalculateEdgeId(
+		ctx,
+		protocol.NewBlockChallengeLevel(),
+		protocol.OriginId(p.parent.Unwrap().Hash),
+		protocol.Height(startCommit.Height),
+		startCommit.Merkle,
+		protocol.Height(endCommit.Height),
+		endCommit.Merkle,
+	)
+	if err != nil {
+		return false, errors.Wrap(err, "could not calculate edge id")
+	}
+	edge, err := p.adoptOr(ctx, edgeId, func() (protocol.SpecEdge, error) {
+		proof, proofErr := p.provider.PrefixProof(ctx, endRequest, l2stateprovider.Height(0))
+		if proofErr != nil {
+			return nil, proofErr
+		}
+		return manager.AddBlockChallengeLevelZeroEdge(ctx, claim, startCommit, endCommit, proof)
+	})
+	if err != nil {
+		return false, errors.Wrap(err, "could not add block challenge level zero edge")
+	}
+	p.edges[edge.Id()] = edge
+	p.engaged = true
+	return true, nil
+}
+
+// Moves returns the moves due on the player's edges: bisecting rivaled edges,
+// and opening subchallenges on edges with length one rivals. Edges that were
+// confirmed, lost, or already played by another player sharing them are no
+// longer tracked.
+func (p *Player) Moves(ctx context.Context) ([]Move, error) {
+	manager, err := p.chain.SpecChallengeManager(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var moves []Move
+	for id, edge := range p.edges {
+		status, err := edge.Status(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if status == protocol.EdgeConfirmed {
+			delete(p.edges, id)
+			continue
+		}
+		lost, err := edge.HasConfirmedRival(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if lost {
+			delete(p.edges, id)
+			continue
+		}
+		hasChildren, err := edge.HasChildren(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if hasChildren {
+			if err := p.adoptChildren(ctx, manager, edge); err != nil {
+				return nil, err
+			}
+			delete(p.edges, id)
+			continue
+		}
+		hasRival, err := edge.HasRival(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !hasRival {
+			continue
+		}
+		atOneStepFork, err := edge.HasLengthOneRival(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !atOneStepFork {
+			moves = append(moves, Move{Kind: Bisect, Edge: edge})
+			continue
+		}
+		// A one step fork at the lowest level is settled by a one step proof,
+		// which the adversary cannot make for its history.
+		if edge.GetChallengeLevel().Uint8()+1 < edge.GetTotalChallengeLevels(ctx) {
+			moves = append(moves, Move{Kind: OpenSubchallengeLeaf, Edge: edge})
+		}
+	}
+	return moves, nil
+}
+
+// Make makes a move consistent with the player's history, and tracks the edges
+// it creates.
+func (p *Player) Make(ctx context.Context, m Move) error {
+	switch m.Kind {
+	case Bisect:
+		commit, proof, err := p.bisectionCommitment(ctx, m.Edge)
+		if err != nil {
+			return err
+		}
+		lower, upper, err := m.Edge.Bisect(ctx, commit.Merkle, proof)
+		if err != nil {
+			return errors.Wrapf(err, "%s could not bisect edge %#x", p.name, m.Edge.Id().Hash)
+		}
+		p.recorder.record(func(s *Stats) { s.Bisections++ })
+		p.edges[lower.Id()] = lower
+		p.edges[upper.Id()] = upper
+	case OpenSubchallengeLeaf:
+		leaf, err := p.openSubchallengeLeaf(ctx, m.Edge)
+		if err != nil {
+			return errors.Wrapf(err, "%s could not open subchallenge on edge %#x", p.name, m.Edge.Id().Hash)
+		}
+		p.edges[leaf.Id()] = leaf
+	default:
+		return fmt.Errorf("unknown move %s", m.Kind)
+	}
+	delete(p.edges, m.Edge.Id())
+	return nil
+}
+
+// AttemptInvalidBisections tries to bisect an edge with history roots that are
+// inconsistent with the edge: a tampered version of the correct root, and a root
+// at the wrong height, both with the prefix proof for the correct root. It
+// returns the number of attempts the protocol accepted, which should be zero.
+func (p *Player) AttemptInvalidBisections(ctx context.Context, edge protocol.SpecEdge) (uint64, error) {
+	commit, proof, err := p.bisectionCommitment(ctx, edge)
+	if err != nil {
+		return 0, err
+	}
+	heights, err := p.originHeights(ctx, edge)
+	if err != nil {
+		return 0, err
+	}
+	wrongHeight, err := p.provider.HistoryCommitment(ctx, p.request(heights, option.Some(l2stateprovider.Height(commit.Height-1))))
+	if err != nil {
+		return 0, err
+	}
+	tampered := commit.Merkle
+	tampered[0] ^= 0xff
+	var accepted uint64
+	for _, root := range []common.Hash{tampered, wrongHeight.Merkle} {
+		lower, upper, bisectErr := edge.Bisect(ctx, root, proof)
+		p.recorder.record(func(s *Stats) { s.InvalidMovesAttempted++ })
+		if bisectErr != nil {
+			continue
+		}
+		srvlog.Error("Invalid bisection was accepted", log.Ctx{
+			"player": p.name,
+			"edgeId": containers.Trunc(edge.Id().Bytes()),
+		})
+		p.recorder.record(func(s *Stats) { s.InvalidMovesAccepted++ })
+		p.edges[lower.Id()] = lower
+		p.edges[upper.Id()] = upper
+		accepted++
+	}
+	return accepted, nil
+}
+
+func (p *Player) adoptChildren(ctx context.Context, manager protocol.SpecChallengeManager, edge protocol.SpecEdge) error {
+	lower, err := edge.LowerChild(ctx)
+	if err != nil {
+		return err
+	}
+	upper, err := edge.UpperChild(ctx)
+	if err != nil {
+		return err
+	}
+	for _, childId := range []option.Option[protocol.EdgeId]{lower, upper} {
+		if childId.IsNone() {
+			continue
+		}
+		child, err := manager.GetEdge(ctx, childId.Unwrap())
+		if err != nil {
+			return err
+		}
+		if child.IsSome() {
+			p.edges[childId.Unwrap()] = child.Unwrap()
+		}
+	}
+	return nil
+}
+
+// Returns the edge with the given id if it already exists, or adds it otherwise.
+func (p *Player) adoptOr(
+	ctx context.Context,
+	id protocol.EdgeId,
+	add func() (protocol.SpecEdge, error),
+) (protocol.SpecEdge, error) {
+	manager, err := p.chain.SpecChallengeManager(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Getting an edge that does not exist errors.
+	existing, err := manager.GetEdge(ctx, id)
+	if err == nil && existing.IsSome() {
+		return existing.Unwrap(), nil
+	}
+	edge, err := add()
+	if err != nil {
+		return nil, err
+	}
+	p.recorder.record(func(s *Stats) { s.LevelZeroEdgesAdded++ })
+	srvlog.Info("Added malicious level zero edge", log.Ctx{
+		"player":         p.name,
+		"edgeId":         containers.Trunc(edge.Id().Bytes()),
+		"challengeLevel": edge.GetChallengeLevel(),
+	})
+	return edge, nil
+}
+
+func (p *Player) bisectionCommitment(ctx context.Context, edge protocol.SpecEdge) (commitments.History, []byte, error) {
+	startHeight, _ := edge.StartCommitment()
+	endHeight, _ := edge.EndCommitment()
+	bisectTo, err := math.Bisect(uint64(startHeight), uint64(endHeight))
+	if err != nil {
+		return commitments.History{}, nil, errors.Wrapf(err, "determining bisection point errored for %d and %d", startHeight, endHeight)
+	}
+	heights, err := p.originHeights(ctx, edge)
+	if err != nil {
+		return commitments.History{}, nil, err
+	}
+	commit, err := p.provider.HistoryCommitment(ctx, p.request(heights, option.Some(l2stateprovider.Height(bisectTo))))
+	if err != nil {
+		return commitments.History{}, nil, errors.Wrap(err, "could not produce history commitment")
+	}
+	proof, err := p.provider.PrefixProof(
+		ctx,
+		p.request(heights, option.Some(l2stateprovider.Height(endHeight))),
+		l2stateprovider.Height(bisectTo),
+	)
+	if err != nil {
+		return commitments.History{}, nil, errors.Wrap(err, "could not produce prefix proof")
+	}
+	return commit, proof, nil
+}
+
+func (p *Player) openSubchallengeLeaf(ctx context.Context, edge protocol.SpecEdge) (protocol.SpecEdge, error) {
+	manager, err := p.chain.SpecChallengeManager(ctx)
+	if err != nil {
+		return nil, err
+	}
+	parentHeights, err := p.originHeights(ctx, edge)
+	if err != nil {
+		return nil, err
+	}
+	startHeight, _ := edge.StartCommitment()
+	endHeight, _ := edge.EndCommitment()
+	heights := append(append([]l2stateprovider.Height{}, parentHeights...), l2stateprovider.Height(startHeight))
+
+	endHistory, err := p.provider.HistoryCommitment(ctx, p.request(heights, option.None[l2stateprovider.Height]()))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not compute end history commitment")
+	}
+	startHistory, err := p.provider.HistoryCommitment(ctx, p.request(heights, option.Some(l2stateprovider.Height(0))))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not compute start history commitment")
+	}
+	leafId, err := manager.CalculateEdgeId(
+		ctx,
+		edge.GetChallengeLevel().Next(),
+		protocol.OriginId(edge.MutualId()),
+		protocol.Height(startHistory.Height),
+		startHistory.Merkle,
+		protocol.Height(endHistory.Height),
+		endHistory.Merkle,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not calculate edge id")
+	}
+	return p.adoptOr(ctx, leafId, func() (protocol.SpecEdge, error) {
+		proof, proofErr := p.provider.PrefixProof(
+			ctx,
+			p.request(heights, option.Some(l2stateprovider.Height(endHistory.Height))),
+			l2stateprovider.Height(0),
+		)
+		if proofErr != nil {
+			return nil, errors.Wrap(proofErr, "could not compute prefix proof")
+		}
+		startParent, commitErr := p.provider.HistoryCommitment(ctx, p.request(parentHeights, option.Some(l2stateprovider.Height(startHeight))))
+		if commitErr != nil {
+			return nil, errors.Wrap(commitErr, "could not compute start parent commitment")
+		}
+		endParent, commitErr := p.provider.HistoryCommitment(ctx, p.request(parentHeights, option.Some(l2stateprovider.Height(endHeight))))
+		if commitErr != nil {
+			return nil, errors.Wrap(commitErr, "could not compute end parent commitment")
+		}
+		return manager.AddSubChallengeLevelZeroEdge(
+			ctx,
+			edge,
+			startHistory,
+			endHistory,
+			startParent.LastLeafProof,
+			endParent.LastLeafProof,
+			proof,
+		)
+	})
+}
+
+// Heights at which the challenges above the edge's challenge originate, as
+// used in history commitment requests for the edge.
+func (p *Player) originHeights(ctx context.Context, edge protocol.SpecEdge) ([]l2stateprovider.Height, error) {
+	if edge.GetChallengeLevel().IsBlockChallengeLevel() {
+		return nil, nil
+	}
+	origin, err := edge.TopLevelClaimHeight(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get top level claim height")
+	}
+	heights := make([]l2stateprovider.Height, len(origin.ChallengeOriginHeights))
+	for i, h := range origin.ChallengeOriginHeights {
+		heights[i] = l2stateprovider.Height(h)
+	}
+	return heights, nil
+}
+
+func (p *Player) request(
+	heights []l2stateprovider.Height,
+	upTo option.Option[l2stateprovider.Height],
+) *l2stateprovider.HistoryCommitmentRequest {
+	if heights == nil {
+		heights = []l2stateprovider.Height{}
+	}
+	return &l2stateprovider.HistoryCommitmentRequest{
+		WasmModuleRoot:              p.metadata.WasmModuleRoot,
+		FromBatch:                   p.metadata.FromBatch,
+		ToBatch:                     p.metadata.ToBatch,
+		UpperChallengeOriginHeights: heights,
+		FromHeight:                  0,
+		UpToHeight:                  upTo,
+	}
+}
@@ -0,0 +1,174 @@
+package endtoend
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	solimpl "github.com/OffchainLabs/bold/chain-abstraction/sol-implementation"
+	challengemanager "github.com/OffchainLabs/bold/challenge-manager"
+	"github.com/OffchainLabs/bold/testing/adversary"
+	statemanager "github.com/OffchainLabs/bold/testing/mocks/state-provider"
+	"github.com/stretchr/testify/require"
+)
+
+// Defines an end-to-end test of the honest validator against an adversary
+// driving the challenge manager contract directly.
+type adversaryConfig struct {
+	protocol  protocolParams
+	timings   timeParams
+	inbox     inboxParams
+	behaviour adversary.Behaviour
+	// State provider options for each of the adversary's players, on top of
+	// the options shared by all validators.
+	players [][]statemanager.Opt
+	// Checks what the adversary did once the honest validator has won.
+	expectStats func(t *testing.T, stats adversary.Stats)
+}
+
+func defaultAdversaryConfig(behaviour adversary.Behaviour) *adversaryConfig {
+	protocolCfg := defaultProtocolParams()
+	totalOpcodes := totalWasmOpcodes(&protocolCfg.layerZeroHeights, protocolCfg.numBigStepLevels)
+	return &adversaryConfig{
+		protocol:  protocolCfg,
+		timings:   defaultTimeParams(),
+		inbox:     defaultInboxParams(),
+		behaviour: behaviour,
+		players: [][]statemanager.Opt{
+			divergentPlayer(0, randDivergenceStep(&protocolCfg, totalOpcodes)),
+		},
+	}
+}
+
+// Picks a random machine step to diverge at. Steps right after the start of a
+// small step, which would fork at the first step of a small step challenge, are
+// avoided, as one step proofs of that step fail against the mock state provider.
+func randDivergenceStep(protocolCfg *protocolParams, totalOpcodes uint64) uint64 {
+	smallStep := protocolCfg.layerZeroHeights.SmallStepChallengeHeight
+	for {
+		step := 1 + randUint64(totalOpcodes-1)
+		if step%smallStep != 1 {
+			return step
+		}
+	}
+}
+
+// State provider options for a player diverging from the honest history in the
+// first block, at the given machine step, with a block hash depending on its index.
+func divergentPlayer(index uint64, machineDivergenceStep uint64) []statemanager.Opt {
+	return []statemanager.Opt{
+		statemanager.WithMachineDivergenceStep(machineDivergenceStep),
+		statemanager.WithBlockDivergenceHeight(1),
+		statemanager.WithDivergentBlockHeightOffset(1),
+		statemanager.WithMaliciousMachineIndex(index),
+	}
+}
+
+func TestEndToEnd_Adversary_InconsistentBisections(t *testing.T) {
+	cfg := defaultAdversaryConfig(adversary.InconsistentBisections())
+	cfg.expectStats = func(t *testing.T, stats adversary.Stats) {
+		require.NotZero(t, stats.InvalidMovesAttempted)
+		require.Zero(t, stats.InvalidMovesAccepted)
+	}
+	runAdversaryTest(t, cfg)
+}
+
+func TestEndToEnd_Adversary_ManyRivals(t *testing.T) {
+	cfg := defaultAdversaryConfig(adversary.ManyRivals())
+	totalOpcodes := totalWasmOpcodes(&cfg.protocol.layerZeroHeights, cfg.protocol.numBigStepLevels)
+	numRivals := uint64(4)
+	cfg.players = nil
+	for i := uint64(0); i < numRivals; i++ {
+		cfg.players = append(cfg.players, divergentPlayer(i, randDivergenceStep(&cfg.protocol, totalOpcodes)))
+	}
+	cfg.expectStats = func(t *testing.T, stats adversary.Stats) {
+		require.Equal(t, numRivals, stats.AssertionsPosted)
+		require.GreaterOrEqual(t, stats.LevelZeroEdgesAdded, numRivals)
+	}
+	runAdversaryTest(t, cfg)
+}
+
+func TestEndToEnd_Adversary_FrontRunConfirmations(t *testing.T) {
+	cfg := defaultAdversaryConfig(adversary.FrontRunConfirmations())
+	cfg.expectStats = func(t *testing.T, stats adversary.Stats) {
+		require.NotZero(t, stats.EdgesConfirmed+stats.AssertionsConfirmed)
+	}
+	runAdversaryTest(t, cfg)
+}
+
+func TestEndToEnd_Adversary_WithholdMoves(t *testing.T) {
+	cfg := defaultAdversaryConfig(adversary.WithholdMoves(defaultProtocolParams().challengePeriodBlocks / 3))
+	cfg.expectStats = func(t *testing.T, stats adversary.Stats) {
+		require.NotZero(t, stats.LevelZeroEdgesAdded)
+	}
+	runAdversaryTest(t, cfg)
+}
+
+func TestEndToEnd_Adversary_SubchallengeSpam(t *testing.T) {
+	cfg := defaultAdversaryConfig(adversary.SubchallengeSpam())
+	bigStep := cfg.protocol.layerZeroHeights.SmallStepChallengeHeight
+	// The first two players diverge within the same big step, so they share their
+	// big step edges and rival each other in the small step challenge. The third
+	// diverges in a different big step, and rivals them in the big step challenge.
+	cfg.players = [][]statemanager.Opt{
+		divergentPlayer(0, 3*bigStep+2),
+		divergentPlayer(0, 3*bigStep+3),
+		divergentPlayer(0, 20*bigStep+2),
+	}
+	cfg.expectStats = func(t *testing.T, stats adversary.Stats) {
+		// One shared block challenge edge, plus at least a big step and small step
+		// edge for each distinct history within the block.
+		require.GreaterOrEqual(t, stats.LevelZeroEdgesAdded, uint64(5))
+	}
+	runAdversaryTest(t, cfg)
+}
+
+func runAdversaryTest(t *testing.T, cfg *adversaryConfig) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Accounts include a chain admin, a single honest validator, and one account per player.
+	numPlayers := uint64(len(cfg.players))
+	bk, rollupAddr := setupBackend(t, ctx, simulated, &cfg.protocol, &cfg.timings, numPlayers+2)
+	accounts := bk.Accounts()
+
+	baseStateManagerOpts := defaultStateManagerOpts(&cfg.protocol, &cfg.inbox)
+	honestStateManager, err := statemanager.NewForSimpleMachine(baseStateManagerOpts...)
+	require.NoError(t, err)
+
+	name := "honest"
+	honestOpts := append(
+		defaultChallengeManagerOpts(&cfg.timings),
+		challengemanager.WithAddress(accounts[1].From),
+		challengemanager.WithName(name),
+	)
+	honestManager := setupChallengeManager(
+		t, ctx, bk.Client(), rollupAddr, honestStateManager, accounts[1], name, honestOpts...,
+	)
+
+	players := make([]*adversary.Player, numPlayers)
+	for i, playerOpts := range cfg.players {
+		stateManager, err := statemanager.NewForSimpleMachine(append(baseStateManagerOpts, playerOpts...)...)
+		require.NoError(t, err)
+		chain, err := solimpl.NewAssertionChain(ctx, rollupAddr, accounts[2+i], bk.Client())
+		require.NoError(t, err)
+		players[i] = adversary.NewPlayer(fmt.Sprintf("adversary-%d", i), chain, stateManager)
+	}
+	adv, err := adversary.New(
+		cfg.behaviour,
+		players,
+		adversary.WithActInterval(cfg.timings.challengeMoveInterval),
+	)
+	require.NoError(t, err)
+
+	honestManager.Start(ctx)
+	adv.Start(ctx)
+
+	expectWin := expectHonestAssertionConfirmed(honestStateManager)
+	require.NoError(t, expectWin(t, ctx, bk.ContractAddresses(), bk.Client()))
+	stats := adv.Stats()
+	t.Logf("Adversary stats: %+v", stats)
+	if cfg.expectStats != nil {
+		cfg.expectStats(t, stats)
+	}
+}
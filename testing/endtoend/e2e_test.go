@@ -15,6 +15,8 @@ import (
 	"github.com/OffchainLabs/bold/testing/endtoend/backend"
 	statemanager "github.com/OffchainLabs/bold/testing/mocks/state-provider"
 	"github.com/OffchainLabs/bold/testing/setup"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/sync/errgroup"
 )
@@ -54,6 +56,8 @@ type e2eConfig struct {
 // Defines parameters related to the actors participating in the test.
 type actorParams struct {
 	numEvilValidators uint64
+	// The module roots validators have machines for, or any root if empty.
+	wasmModuleRoots []common.Hash
 }
 
 // Configures intervals related to timings in the system.
@@ -97,6 +101,10 @@ type protocolParams struct {
 	numBigStepLevels      uint8
 	challengePeriodBlocks uint64
 	layerZeroHeights      protocol.LayerZeroHeights
+	// If set, the chain's module root is upgraded to it once an assertion was posted on
+	// top of the latest confirmed one, so the challenge on that assertion runs on a chain
+	// whose module root differs from the one the assertion is executed by.
+	upgradedWasmModuleRoot common.Hash
 }
 
 func defaultProtocolParams() protocolParams {
@@ -173,6 +181,27 @@ func TestEndToEnd_MultipleEvilValidators(t *testing.T) {
 	})
 }
 
+func TestEndToEnd_WasmModuleRootUpgrade(t *testing.T) {
+	protocolCfg := defaultProtocolParams()
+	protocolCfg.upgradedWasmModuleRoot = common.BytesToHash([]byte("upgraded"))
+	runEndToEndTest(t, &e2eConfig{
+		backend:  simulated,
+		protocol: protocolCfg,
+		inbox:    defaultInboxParams(),
+		actors: actorParams{
+			numEvilValidators: 1,
+			// Validators have machines for the roots before and after the upgrade, and
+			// must pick the one the challenged assertion is executed by.
+			wasmModuleRoots: []common.Hash{{}, protocolCfg.upgradedWasmModuleRoot},
+		},
+		timings: defaultTimeParams(),
+		expectations: []expect{
+			// Expect one assertion is confirmed by challenge win.
+			expectAssertionConfirmedByChallengeWin,
+		},
+	})
+}
+
 func runEndToEndTest(t *testing.T, cfg *e2eConfig) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -180,59 +209,17 @@ func runEndToEndTest(t *testing.T, cfg *e2eConfig) {
 	// Validators include a chain admin, a single honest validators, and any number of evil entities.
 	totalValidators := cfg.actors.numEvilValidators + 2
 
-	challengeTestingOpts := []challenge_testing.Opt{
-		challenge_testing.WithConfirmPeriodBlocks(cfg.protocol.challengePeriodBlocks),
-		challenge_testing.WithLayerZeroHeights(&cfg.protocol.layerZeroHeights),
-		challenge_testing.WithNumBigStepLevels(cfg.protocol.numBigStepLevels),
-	}
-	deployOpts := []setup.Opt{
-		setup.WithMockBridge(),
-		setup.WithMockOneStepProver(),
-		setup.WithNumAccounts(totalValidators),
-		setup.WithChallengeTestingOpts(challengeTestingOpts...),
-	}
-
-	var bk backend.Backend
-	switch cfg.backend {
-	case simulated:
-		simBackend, err := backend.NewSimulated(cfg.timings.blockTime, deployOpts...)
-		require.NoError(t, err)
-		bk = simBackend
-	case anvil:
-		anvilBackend, err := backend.NewAnvilLocal(ctx)
-		require.NoError(t, err)
-		bk = anvilBackend
-	default:
-		t.Fatalf("Backend kind for e2e test not supported: %s", cfg.backend)
-	}
-
-	rollupAddr, err := bk.DeployRollup(ctx, challengeTestingOpts...)
-	require.NoError(t, err)
-
-	require.NoError(t, bk.Start(ctx))
-
-	rollupAdminBindings, err := rollupgen.NewRollupAdminLogic(rollupAddr, bk.Client())
-	require.NoError(t, err)
+	bk, rollupAddr := setupBackend(t, ctx, cfg.backend, &cfg.protocol, &cfg.timings, totalValidators)
 	accounts := bk.Accounts()
-	_, err = rollupAdminBindings.SetMinimumAssertionPeriod(accounts[0], big.NewInt(1))
-	require.NoError(t, err)
 
-	bk.Commit()
-
-	baseStateManagerOpts := []statemanager.Opt{
-		statemanager.WithNumBatchesRead(cfg.inbox.numBatchesPosted),
-		statemanager.WithLayerZeroHeights(&cfg.protocol.layerZeroHeights, cfg.protocol.numBigStepLevels),
+	baseStateManagerOpts := defaultStateManagerOpts(&cfg.protocol, &cfg.inbox)
+	if len(cfg.actors.wasmModuleRoots) > 0 {
+		baseStateManagerOpts = append(baseStateManagerOpts, statemanager.WithWasmModuleRoots(cfg.actors.wasmModuleRoots...))
 	}
 	honestStateManager, err := statemanager.NewForSimpleMachine(baseStateManagerOpts...)
 	require.NoError(t, err)
 
-	baseChallengeManagerOpts := []challengemanager.Opt{
-		challengemanager.WithEdgeTrackerWakeInterval(cfg.timings.challengeMoveInterval),
-		challengemanager.WithMode(types.MakeMode),
-		challengemanager.WithAssertionPostingInterval(cfg.timings.assertionPostingInterval),
-		challengemanager.WithAssertionScanningInterval(cfg.timings.assertionScanningInterval),
-		challengemanager.WithAssertionConfirmingInterval(cfg.timings.assertionConfirmationAttemptInterval),
-	}
+	baseChallengeManagerOpts := defaultChallengeManagerOpts(&cfg.timings)
 
 	name := "honest"
 	txOpts := accounts[1]
@@ -278,6 +265,20 @@ func runEndToEndTest(t *testing.T, cfg *e2eConfig) {
 		evilChallengeManagers[i] = evilManager
 	}
 
+	expectations := cfg.expectations
+	if cfg.protocol.upgradedWasmModuleRoot != (common.Hash{}) {
+		rollup, err := rollupgen.NewRollupCore(rollupAddr, bk.Client())
+		require.NoError(t, err)
+		challengedRoot, err := rollup.WasmModuleRoot(&bind.CallOpts{Context: ctx})
+		require.NoError(t, err)
+		// The challenged assertions are children of the latest confirmed one, so their
+		// one-step proofs are of its module root even once the chain was upgraded.
+		expectations = append(
+			expectations,
+			expectOneStepProofsOfModuleRoot(honestStateManager, challengedRoot, cfg.protocol.upgradedWasmModuleRoot),
+		)
+	}
+
 	honestManager.Start(ctx)
 
 	for _, evilManager := range evilChallengeManagers {
@@ -285,7 +286,12 @@ func runEndToEndTest(t *testing.T, cfg *e2eConfig) {
 	}
 
 	g, ctx := errgroup.WithContext(ctx)
-	for _, e := range cfg.expectations {
+	if cfg.protocol.upgradedWasmModuleRoot != (common.Hash{}) {
+		g.Go(func() error {
+			return upgradeWasmModuleRootAfterAssertion(ctx, bk, rollupAddr, cfg.protocol.upgradedWasmModuleRoot)
+		})
+	}
+	for _, e := range expectations {
 		fn := e // loop closure
 		g.Go(func() error {
 			return fn(t, ctx, bk.ContractAddresses(), bk.Client())
@@ -293,3 +299,109 @@ func runEndToEndTest(t *testing.T, cfg *e2eConfig) {
 	}
 	require.NoError(t, g.Wait())
 }
+
+// Starts a backend of the given kind with a rollup deployed on it, and the given
+// number of accounts, the first of which is the chain admin.
+func setupBackend(
+	t *testing.T,
+	ctx context.Context,
+	kind backendKind,
+	protocolCfg *protocolParams,
+	timings *timeParams,
+	numAccounts uint64,
+) (backend.Backend, common.Address) {
+	challengeTestingOpts := []challenge_testing.Opt{
+		challenge_testing.WithConfirmPeriodBlocks(protocolCfg.challengePeriodBlocks),
+		challenge_testing.WithLayerZeroHeights(&protocolCfg.layerZeroHeights),
+		challenge_testing.WithNumBigStepLevels(protocolCfg.numBigStepLevels),
+	}
+	deployOpts := []setup.Opt{
+		setup.WithMockBridge(),
+		setup.WithMockOneStepProver(),
+		setup.WithNumAccounts(numAccounts),
+		setup.WithChallengeTestingOpts(challengeTestingOpts...),
+	}
+
+	var bk backend.Backend
+	switch kind {
+	case simulated:
+		simBackend, err := backend.NewSimulated(timings.blockTime, deployOpts...)
+		require.NoError(t, err)
+		bk = simBackend
+	case anvil:
+		anvilBackend, err := backend.NewAnvilLocal(ctx)
+		require.NoError(t, err)
+		bk = anvilBackend
+	default:
+		t.Fatalf("Backend kind for e2e test not supported: %s", kind)
+	}
+
+	rollupAddr, err := bk.DeployRollup(ctx, challengeTestingOpts...)
+	require.NoError(t, err)
+
+	require.NoError(t, bk.Start(ctx))
+
+	rollupAdminBindings, err := rollupgen.NewRollupAdminLogic(rollupAddr, bk.Client())
+	require.NoError(t, err)
+	_, err = rollupAdminBindings.SetMinimumAssertionPeriod(bk.Accounts()[0], big.NewInt(1))
+	require.NoError(t, err)
+	bk.Commit()
+	return bk, rollupAddr
+}
+
+// Upgrades the module root of the chain once an assertion was posted on top of the latest
+// confirmed one, which remains executed by the module root from before the upgrade.
+func upgradeWasmModuleRootAfterAssertion(
+	ctx context.Context,
+	bk backend.Backend,
+	rollupAddr common.Address,
+	wasmModuleRoot common.Hash,
+) error {
+	rollup, err := rollupgen.NewRollupCore(rollupAddr, bk.Client())
+	if err != nil {
+		return err
+	}
+	for {
+		latestConfirmed, err := rollup.LatestConfirmed(&bind.CallOpts{Context: ctx})
+		if err != nil {
+			return err
+		}
+		created, err := rollup.FilterAssertionCreated(nil, nil, [][32]byte{latestConfirmed})
+		if err != nil {
+			return err
+		}
+		if created.Next() {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(500 * time.Millisecond): // Don't spam the backend.
+		}
+	}
+	rollupAdminBindings, err := rollupgen.NewRollupAdminLogic(rollupAddr, bk.Client())
+	if err != nil {
+		return err
+	}
+	_, err = rollupAdminBindings.SetWasmModuleRoot(bk.Accounts()[0], wasmModuleRoot)
+	return err
+}
+
+// State provider options shared by all validators in a test.
+func defaultStateManagerOpts(protocolCfg *protocolParams, inbox *inboxParams) []statemanager.Opt {
+	return []statemanager.Opt{
+		statemanager.WithNumBatchesRead(inbox.numBatchesPosted),
+		statemanager.WithLayerZeroHeights(&protocolCfg.layerZeroHeights, protocolCfg.numBigStepLevels),
+	}
+}
+
+// Challenge manager options shared by all validators in a test.
+func defaultChallengeManagerOpts(timings *timeParams) []challengemanager.Opt {
+	return []challengemanager.Opt{
+		challengemanager.WithEdgeTrackerWakeInterval(timings.challengeMoveInterval),
+		challengemanager.WithMode(types.MakeMode),
+		challengemanager.WithAssertionPostingInterval(timings.assertionPostingInterval),
+		challengemanager.WithAssertionScanningInterval(timings.assertionScanningInterval),
+		challengemanager.WithAssertionConfirmingInterval(timings.assertionConfirmationAttemptInterval),
+	}
+}
@@ -6,10 +6,12 @@ import (
 	"time"
 
 	protocol "github.com/OffchainLabs/bold/chain-abstraction"
+	l2stateprovider "github.com/OffchainLabs/bold/layer2-state-provider"
 	retry "github.com/OffchainLabs/bold/runtime"
 	"github.com/OffchainLabs/bold/solgen/go/rollupgen"
 	"github.com/OffchainLabs/bold/testing/setup"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/stretchr/testify/require"
 )
 
@@ -54,3 +56,75 @@ func expectAssertionConfirmedByChallengeWin(
 	})
 	return nil
 }
+
+// Expects that an assertion is confirmed, and that the confirmed assertion is one the
+// honest state provider agrees with.
+func expectHonestAssertionConfirmed(honest l2stateprovider.ExecutionStateAgreementChecker) expect {
+	return func(t *testing.T, ctx context.Context, addresses *setup.RollupAddresses, backend protocol.ChainBackend) error {
+		t.Run("honest assertion confirmed", func(t *testing.T) {
+			rc, err := rollupgen.NewRollupCore(addresses.Rollup, backend)
+			require.NoError(t, err)
+
+			var confirmed bool
+			for ctx.Err() == nil && !confirmed {
+				i, err := retry.UntilSucceeds(ctx, func() (*rollupgen.RollupCoreAssertionConfirmedIterator, error) {
+					return rc.FilterAssertionConfirmed(nil, nil)
+				})
+				require.NoError(t, err)
+				for i.Next() {
+					created, err := retry.UntilSucceeds(ctx, func() (*rollupgen.RollupCoreAssertionCreatedIterator, error) {
+						return rc.FilterAssertionCreated(nil, [][32]byte{i.Event.AssertionHash}, nil)
+					})
+					require.NoError(t, err)
+					require.True(t, created.Next(), "no creation event for confirmed assertion")
+					afterState := protocol.GoExecutionStateFromSolidity(created.Event.Assertion.AfterState)
+					if agreeErr := honest.AgreesWithExecutionState(ctx, afterState); agreeErr != nil {
+						t.Fatalf("Confirmed assertion %#x the honest validator disagrees with", i.Event.AssertionHash)
+					}
+					confirmed = true
+					break
+				}
+				time.Sleep(500 * time.Millisecond) // Don't spam the backend.
+			}
+
+			if !confirmed {
+				t.Fatal("assertion was not confirmed")
+			}
+		})
+		return nil
+	}
+}
+
+// oneStepProofCollector reports the one-step proofs collected per WASM module root.
+type oneStepProofCollector interface {
+	OneStepProofsCollected(wasmModuleRoot common.Hash) uint64
+}
+
+// Expects that one-step proofs are collected for the given module root while the chain
+// is on the upgraded one, and that none are collected for the upgraded root.
+func expectOneStepProofsOfModuleRoot(
+	collector oneStepProofCollector,
+	wasmModuleRoot,
+	upgradedWasmModuleRoot common.Hash,
+) expect {
+	return func(t *testing.T, ctx context.Context, addresses *setup.RollupAddresses, backend protocol.ChainBackend) error {
+		t.Run("one-step proofs of challenged module root", func(t *testing.T) {
+			rc, err := rollupgen.NewRollupCore(addresses.Rollup, backend)
+			require.NoError(t, err)
+
+			for ctx.Err() == nil && collector.OneStepProofsCollected(wasmModuleRoot) == 0 {
+				time.Sleep(500 * time.Millisecond) // Don't spam the backend.
+			}
+			if ctx.Err() != nil {
+				t.Fatal("no one-step proof was collected")
+			}
+			chainRoot, err := retry.UntilSucceeds(ctx, func() ([32]byte, error) {
+				return rc.WasmModuleRoot(&bind.CallOpts{Context: ctx})
+			})
+			require.NoError(t, err)
+			require.Equal(t, upgradedWasmModuleRoot, common.Hash(chainRoot), "one-step proof collected before the upgrade")
+			require.Zero(t, collector.OneStepProofsCollected(upgradedWasmModuleRoot))
+		})
+		return nil
+	}
+}
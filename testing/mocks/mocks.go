@@ -32,6 +32,7 @@ type MockAssertion struct {
 	MockCreatedAtBlock    uint64
 	MockHasSecondChild    bool
 	CreatedAt             uint64
+	MockFirstChildBlock   uint64
 }
 
 func (m *MockAssertion) Id() protocol.AssertionHash {
@@ -57,6 +58,10 @@ func (m *MockAssertion) CreatedAtBlock() (uint64, error) {
 	return m.CreatedAt, nil
 }
 
+func (m *MockAssertion) FirstChildCreationBlock() (uint64, error) {
+	return m.MockFirstChildBlock, nil
+}
+
 type MockStateManager struct {
 	mock.Mock
 	Agrees   bool
@@ -10,6 +10,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"sync/atomic"
 
 	protocol "github.com/OffchainLabs/bold/chain-abstraction"
 	l2stateprovider "github.com/OffchainLabs/bold/layer2-state-provider"
@@ -44,6 +45,8 @@ type L2StateBackend struct {
 	numBigSteps             uint64
 	numBatches              uint64
 	challengeLeafHeights    []l2stateprovider.Height
+	wasmModuleRoots         []common.Hash
+	moduleRootBackends      map[common.Hash]*moduleRootBackend
 }
 
 // NewWithMockedStateRoots initialize with a list of predefined state roots, useful for tests and simulations.
@@ -66,7 +69,11 @@ func NewWithMockedStateRoots(stateRoots []common.Hash, opts ...Opt) (*L2StateBac
 	for _, o := range opts {
 		o(s)
 	}
-	commitmentProvider := l2stateprovider.NewHistoryCommitmentProvider(s, s, s, s.challengeLeafHeights, s)
+	providerOpts, err := s.historyCommitmentProviderOpts()
+	if err != nil {
+		return nil, err
+	}
+	commitmentProvider := l2stateprovider.NewHistoryCommitmentProvider(s, s, s, s.challengeLeafHeights, s, providerOpts...)
 	s.HistoryCommitmentProvider = *commitmentProvider
 	return s, nil
 }
@@ -134,6 +141,77 @@ func WithNumBatchesRead(n uint64) Opt {
 	}
 }
 
+// WithWasmModuleRoots only serves requests about machines of the given WASM module roots,
+// each of which gets a backend of its own executing the mock machines, and fails others
+// with l2stateprovider.ErrUnknownWasmModuleRoot.
+func WithWasmModuleRoots(roots ...common.Hash) Opt {
+	return func(s *L2StateBackend) {
+		s.wasmModuleRoots = roots
+	}
+}
+
+// OneStepProofsCollected by the backend of a WASM module root, which is zero for
+// roots that have no backend.
+func (s *L2StateBackend) OneStepProofsCollected(wasmModuleRoot common.Hash) uint64 {
+	backend, ok := s.moduleRootBackends[wasmModuleRoot]
+	if !ok {
+		return 0
+	}
+	return backend.numProofs.Load()
+}
+
+// A machine backend of a single WASM module root, which counts the proofs it collects
+// so tests can tell which root a one-step proof was made for.
+type moduleRootBackend struct {
+	wasmModuleRoot common.Hash
+	machines       *L2StateBackend
+	numProofs      atomic.Uint64
+}
+
+func (b *moduleRootBackend) CollectMachineHashes(
+	ctx context.Context,
+	cfg *l2stateprovider.HashCollectorConfig,
+) ([]common.Hash, error) {
+	if cfg.WasmModuleRoot != b.wasmModuleRoot {
+		return nil, fmt.Errorf("backend of wasm module root %#x asked for machines of %#x", b.wasmModuleRoot, cfg.WasmModuleRoot)
+	}
+	return b.machines.CollectMachineHashes(ctx, cfg)
+}
+
+func (b *moduleRootBackend) CollectProof(
+	ctx context.Context,
+	wasmModuleRoot common.Hash,
+	fromBatch l2stateprovider.Batch,
+	blockChallengeHeight l2stateprovider.Height,
+	machineIndex l2stateprovider.OpcodeIndex,
+) ([]byte, error) {
+	if wasmModuleRoot != b.wasmModuleRoot {
+		return nil, fmt.Errorf("backend of wasm module root %#x asked for a proof of %#x", b.wasmModuleRoot, wasmModuleRoot)
+	}
+	proof, err := b.machines.CollectProof(ctx, wasmModuleRoot, fromBatch, blockChallengeHeight, machineIndex)
+	if err != nil {
+		return nil, err
+	}
+	b.numProofs.Add(1)
+	return proof, nil
+}
+
+func (s *L2StateBackend) historyCommitmentProviderOpts() ([]l2stateprovider.HistoryCommitmentProviderOpt, error) {
+	if len(s.wasmModuleRoots) == 0 {
+		return nil, nil
+	}
+	registry := l2stateprovider.NewModuleRootRegistry()
+	s.moduleRootBackends = make(map[common.Hash]*moduleRootBackend, len(s.wasmModuleRoots))
+	for _, root := range s.wasmModuleRoots {
+		backend := &moduleRootBackend{wasmModuleRoot: root, machines: s}
+		if err := registry.Register(root, &l2stateprovider.MachineBackend{HashCollector: backend, ProofCollector: backend}); err != nil {
+			return nil, err
+		}
+		s.moduleRootBackends[root] = backend
+	}
+	return []l2stateprovider.HistoryCommitmentProviderOpt{l2stateprovider.WithModuleRootRegistry(registry)}, nil
+}
+
 func NewForSimpleMachine(
 	opts ...Opt,
 ) (*L2StateBackend, error) {
@@ -149,7 +227,11 @@ func NewForSimpleMachine(
 	for _, o := range opts {
 		o(s)
 	}
-	commitmentProvider := l2stateprovider.NewHistoryCommitmentProvider(s, s, s, s.challengeLeafHeights, s)
+	providerOpts, err := s.historyCommitmentProviderOpts()
+	if err != nil {
+		return nil, err
+	}
+	commitmentProvider := l2stateprovider.NewHistoryCommitmentProvider(s, s, s, s.challengeLeafHeights, s, providerOpts...)
 	s.HistoryCommitmentProvider = *commitmentProvider
 	totalWavmOpcodes := uint64(1)
 	for _, h := range s.challengeLeafHeights[1:] {